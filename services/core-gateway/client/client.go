@@ -0,0 +1,133 @@
+// Package client is a programmatic Go client for the Woorung-Gaksi Core Gateway.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Client calls the gateway API, refreshing the access token once on 401
+type Client struct {
+	baseURL    string
+	tokens     TokenSource
+	httpClient *http.Client
+}
+
+// New creates a client for baseURL, which may be a unix:// socket URL.
+// A nil httpClient uses http.DefaultClient (or a socket-dialing client).
+func New(baseURL string, tokens TokenSource, httpClient *http.Client) *Client {
+	baseURL, httpClient = resolve(baseURL, httpClient)
+	return &Client{
+		baseURL:    baseURL,
+		tokens:     tokens,
+		httpClient: httpClient,
+	}
+}
+
+type AskRequest struct {
+	Message  string `json:"message"`
+	Source   string `json:"source,omitempty"`
+	ThreadID string `json:"thread_id,omitempty"`
+}
+
+type AskResponse struct {
+	Reply    string `json:"reply"`
+	ThreadID string `json:"thread_id"`
+}
+
+// APIError is returned when the gateway responds with a non-2xx status
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("gateway returned %d: %s", e.StatusCode, e.Message)
+}
+
+func (c *Client) Ask(ctx context.Context, req AskRequest) (*AskResponse, error) {
+	var resp AskResponse
+	if err := c.do(ctx, http.MethodPost, "/api/v1/ask", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+
+	token, err := c.tokens.Token(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to obtain token: %w", err)
+	}
+
+	resp, err := c.send(ctx, method, path, body, token)
+	if err != nil {
+		return err
+	}
+
+	// Retry once with a fresh token if the current one was rejected
+	if resp.StatusCode == http.StatusUnauthorized {
+		resp.Body.Close()
+
+		token, err = c.tokens.Refresh(ctx, token)
+		if errors.Is(err, ErrRefreshUnsupported) {
+			return &APIError{StatusCode: http.StatusUnauthorized, Message: "token rejected"}
+		}
+		if err != nil {
+			return err
+		}
+
+		if resp, err = c.send(ctx, method, path, body, token); err != nil {
+			return err
+		}
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		message := strings.TrimSpace(string(respBody))
+		if json.Unmarshal(respBody, &apiErr) == nil && apiErr.Error != "" {
+			message = apiErr.Error
+		}
+		return &APIError{StatusCode: resp.StatusCode, Message: message}
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) send(ctx context.Context, method, path string, body []byte, token string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to contact gateway: %w", err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,170 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_RefreshesTokenOn401(t *testing.T) {
+	// Arrange: the gateway only accepts "fresh_token", which /auth/refresh hands out
+	var askCalls, refreshCalls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/refresh":
+			refreshCalls++
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			assert.Equal(t, "refresh_123", body["refresh_token"])
+			json.NewEncoder(w).Encode(map[string]string{"access_token": "fresh_token"})
+		case "/api/v1/ask":
+			askCalls++
+			if r.Header.Get("Authorization") != "Bearer fresh_token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				json.NewEncoder(w).Encode(map[string]string{"error": "Invalid or expired token"})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"reply": "hello", "thread_id": "t1"})
+		}
+	}))
+	defer server.Close()
+
+	tokens := client.NewRefreshTokenSource(server.URL, "expired_token", "refresh_123", nil)
+	c := client.New(server.URL, tokens, nil)
+
+	// Act
+	resp, err := c.Ask(context.Background(), client.AskRequest{Message: "hi"})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "hello", resp.Reply)
+	assert.Equal(t, "t1", resp.ThreadID)
+	assert.Equal(t, 2, askCalls, "Should retry exactly once after refreshing")
+	assert.Equal(t, 1, refreshCalls)
+
+	// Subsequent calls reuse the refreshed token
+	_, err = c.Ask(context.Background(), client.AskRequest{Message: "again"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, askCalls)
+	assert.Equal(t, 1, refreshCalls)
+}
+
+func TestClient_StoresRotatedRefreshToken(t *testing.T) {
+	// Arrange: every refresh hands out the next pair; each access token works once
+	var presented []string
+	current := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/auth/refresh" {
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			presented = append(presented, body["refresh_token"])
+			current = fmt.Sprintf("access_%d", len(presented))
+			json.NewEncoder(w).Encode(map[string]string{
+				"access_token":  current,
+				"refresh_token": fmt.Sprintf("refresh_%d", len(presented)),
+			})
+			return
+		}
+		if current == "" || r.Header.Get("Authorization") != "Bearer "+current {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		current = ""
+		json.NewEncoder(w).Encode(map[string]string{"reply": "ok"})
+	}))
+	defer server.Close()
+	c := client.New(server.URL, client.NewRefreshTokenSource(server.URL, "expired", "refresh_0", nil), nil)
+
+	// Act
+	_, firstErr := c.Ask(context.Background(), client.AskRequest{Message: "one"})
+	_, secondErr := c.Ask(context.Background(), client.AskRequest{Message: "two"})
+
+	// Assert: the second refresh presented the token the first one returned
+	require.NoError(t, firstErr)
+	require.NoError(t, secondErr)
+	assert.Equal(t, []string{"refresh_0", "refresh_1"}, presented)
+}
+
+func TestClient_ConcurrentRejectionsRefreshOnce(t *testing.T) {
+	// Arrange
+	var refreshCalls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/auth/refresh" {
+			refreshCalls.Add(1)
+			json.NewEncoder(w).Encode(map[string]string{"access_token": "fresh_token", "refresh_token": "refresh_next"})
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer fresh_token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"reply": "ok"})
+	}))
+	defer server.Close()
+	c := client.New(server.URL, client.NewRefreshTokenSource(server.URL, "expired", "refresh_123", nil), nil)
+
+	// Act: every ask is rejected with the same expired token
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = c.Ask(context.Background(), client.AskRequest{Message: "hi"})
+		}(i)
+	}
+	wg.Wait()
+
+	// Assert: the first refresh serves every waiting caller
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), refreshCalls.Load())
+}
+
+func TestClient_StaticTokenDoesNotRetry(t *testing.T) {
+	var askCalls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		askCalls++
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	c := client.New(server.URL, client.StaticTokenSource("bad"), nil)
+	_, err := c.Ask(context.Background(), client.AskRequest{Message: "hi"})
+
+	var apiErr *client.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+	assert.Equal(t, 1, askCalls)
+}
+
+func TestClient_RefreshFailureIsReturned(t *testing.T) {
+	var askCalls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/auth/refresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		askCalls++
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	tokens := client.NewRefreshTokenSource(server.URL, "expired", "revoked_refresh", nil)
+	c := client.New(server.URL, tokens, nil)
+	_, err := c.Ask(context.Background(), client.AskRequest{Message: "hi"})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "token refresh rejected")
+	assert.Equal(t, 1, askCalls)
+}
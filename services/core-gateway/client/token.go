@@ -0,0 +1,111 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// ErrRefreshUnsupported is returned by token sources that cannot mint a new token
+var ErrRefreshUnsupported = errors.New("token refresh not supported")
+
+// TokenSource provides access tokens for gateway requests.
+// Refresh is called once when the gateway rejects the current token with 401,
+// passing the rejected token so concurrent callers share a single refresh.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+	Refresh(ctx context.Context, rejected string) (string, error)
+}
+
+// StaticTokenSource always returns the same token and never refreshes
+type StaticTokenSource string
+
+func (s StaticTokenSource) Token(ctx context.Context) (string, error) {
+	return string(s), nil
+}
+
+func (s StaticTokenSource) Refresh(ctx context.Context, rejected string) (string, error) {
+	return "", ErrRefreshUnsupported
+}
+
+// RefreshTokenSource exchanges a stored refresh token for new access tokens
+// via the gateway's /auth/refresh endpoint. The gateway rotates the refresh
+// token on every exchange, so the replacement is stored for the next one.
+type RefreshTokenSource struct {
+	baseURL    string
+	httpClient *http.Client
+
+	mu           sync.Mutex
+	accessToken  string
+	refreshToken string
+}
+
+func NewRefreshTokenSource(baseURL, accessToken, refreshToken string, httpClient *http.Client) *RefreshTokenSource {
+	baseURL, httpClient = resolve(baseURL, httpClient)
+	return &RefreshTokenSource{
+		baseURL:      baseURL,
+		refreshToken: refreshToken,
+		httpClient:   httpClient,
+		accessToken:  accessToken,
+	}
+}
+
+func (s *RefreshTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	token := s.accessToken
+	s.mu.Unlock()
+
+	if token == "" {
+		return s.Refresh(ctx, "")
+	}
+	return token, nil
+}
+
+// Refresh returns the current access token if another caller already replaced
+// rejected; otherwise it exchanges the refresh token for a new pair
+func (s *RefreshTokenSource) Refresh(ctx context.Context, rejected string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.accessToken != "" && s.accessToken != rejected {
+		return s.accessToken, nil
+	}
+
+	jsonData, _ := json.Marshal(map[string]string{"refresh_token": s.refreshToken})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/auth/refresh", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to refresh token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token refresh rejected: %d", resp.StatusCode)
+	}
+
+	var result struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to parse refresh response: %w", err)
+	}
+	if result.AccessToken == "" {
+		return "", errors.New("refresh response did not contain an access token")
+	}
+
+	s.accessToken = result.AccessToken
+	if result.RefreshToken != "" {
+		s.refreshToken = result.RefreshToken
+	}
+	return s.accessToken, nil
+}
@@ -0,0 +1,40 @@
+package client
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// UnixScheme prefixes gateway URLs served on a Unix domain socket,
+// e.g. "unix:///run/woorung/gateway.sock"
+const UnixScheme = "unix://"
+
+// unixBaseURL stands in for the host when requests travel over a socket
+const unixBaseURL = "http://unix"
+
+// ForURL resolves a gateway URL to the base URL and HTTP client to use.
+// unix:// URLs get a client that dials the socket; other URLs are returned
+// as-is with http.DefaultClient.
+func ForURL(gatewayURL string) (string, *http.Client) {
+	socketPath, ok := strings.CutPrefix(gatewayURL, UnixScheme)
+	if !ok {
+		return strings.TrimRight(gatewayURL, "/"), http.DefaultClient
+	}
+	return unixBaseURL, &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socketPath)
+		},
+	}}
+}
+
+// resolve applies ForURL unless the caller supplied its own client
+func resolve(baseURL string, httpClient *http.Client) (string, *http.Client) {
+	resolved, socketClient := ForURL(baseURL)
+	if httpClient == nil {
+		httpClient = socketClient
+	}
+	return resolved, httpClient
+}
@@ -1,46 +1,98 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"log"
+	"net/http"
 	"os"
-	"strconv"
-
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/nookcoder/woorung-gaksi/services/core-gateway/config"
 	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/agent"
 	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/auth"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/conversation"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/gateway"
 	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/health"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/idempotency"
 	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/infrastructure/database"
-	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/middleware"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/listener"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/notify"
 	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/telegram"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/telegramlink"
 )
 
 func main() {
 	// 0. Load Config
+	strictConfig := flag.Bool("strict-config", false, "fail on unknown keys in the config file")
+	flag.Parse()
+
 	env := os.Getenv("APP_ENV")
-	cfg, err := config.Load(env)
+	if env == "" {
+		env = "local"
+	}
+	loadOpts := config.LoadOptions{Strict: *strictConfig}
+	cfg, err := config.LoadWithOptions(env, loadOpts)
 	if err != nil {
+		if *strictConfig {
+			for _, f := range config.Schema() {
+				log.Printf("  valid key: %s (%s)", f.Key, f.Type)
+			}
+		}
 		log.Fatalf("Failed to load config: %v", err)
 	}
+	config.Store(cfg)
+
+	if !cfg.Server.HideBanner {
+		printBanner(os.Stdout, env, cfg)
+	}
+	for _, finding := range cfg.InsecureDefaults(env) {
+		log.Printf("⚠️ INSECURE (%s): %s", env, finding)
+	}
+	if err := cfg.CheckStrictProd(env); err != nil {
+		log.Fatal(err)
+	}
+
+	// Reload on SIGHUP. Handlers read config.Current(), so swaps are race-free.
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			if _, err := config.Reload(env, loadOpts); err != nil {
+				log.Printf("⚠️ Config reload failed, keeping current config: %v", err)
+			}
+		}
+	}()
 
 	// 1. Setup
 	if cfg.Server.Mode == "release" {
 		gin.SetMode(gin.ReleaseMode)
 	}
-	r := gin.Default()
 
-	// 1.5 Database
-	_, err = database.NewPostgresDB(*cfg)
+	// 1.5 Storage (db.driver: postgres, sqlite or memory)
+	storage, err := database.Open(*cfg)
 	if err != nil {
-		log.Printf("⚠️ Failed to connect to database: %v", err)
+		log.Printf("⚠️ Failed to open %s storage: %v", cfg.DB.Driver, err)
+	}
+
+	// 2. Services
+	jwtKeys, err := loadJWTKeys(cfg)
+	if err != nil {
+		log.Fatalf("Failed to load JWT keys: %v", err)
+	}
+	jwtService, err := auth.NewJWTServiceFromKeys(jwtKeys, time.Duration(cfg.JWT.ExpiryMinutes)*time.Minute)
+	if err != nil {
+		log.Fatalf("Failed to configure JWT signing: %v", err)
 	}
 
-	// 2. Services & Middleware
-	jwtService := auth.NewJWTService(cfg.JWT.Secret, 24*time.Hour)
-	authMiddleware := middleware.AuthMiddleware(jwtService)
-	
 	// Dev UX: Print a valid token for testing
 	if cfg.Server.Mode == "debug" {
 		devToken, _ := jwtService.GenerateToken("dev_admin", "admin")
@@ -48,61 +100,338 @@ func main() {
 	}
 
 	// 3. Shared Agent Service (Client)
-	agentClient := agent.NewAgentClient(cfg.PMAgent.URL)
+	var agentClient agent.Service
+	if cfg.PMAgent.Mode == "echo" {
+		log.Println("⚠️ PM Agent in echo mode: replies are not generated by the agent")
+		agentClient = agent.NewEchoService()
+	} else {
+		if err := agent.ValidateURL(cfg.PMAgent.URL); err != nil {
+			if cfg.PMAgent.Required {
+				log.Fatalf("PM Agent is required: %v", err)
+			}
+			log.Printf("⚠️ PM Agent unavailable (%v); /ask will return 503", err)
+		}
+		agentTransport, err := agent.NewTransport(agent.TransportOptions{
+			ProxyURL:           cfg.PMAgent.ProxyURL,
+			DisableEnvProxy:    cfg.PMAgent.DisableEnvProxy,
+			InsecureSkipVerify: cfg.PMAgent.TLS.InsecureSkipVerify,
+			CAFile:             cfg.PMAgent.TLS.CAFile,
+			ConnectTimeout:     time.Duration(cfg.PMAgent.ConnectTimeoutSeconds) * time.Second,
+			ResponseTimeout:    time.Duration(cfg.PMAgent.ResponseTimeoutSeconds) * time.Second,
+		})
+		if err != nil {
+			log.Fatalf("Invalid PM Agent transport config: %v", err)
+		}
+		if cfg.PMAgent.TLS.InsecureSkipVerify {
+			log.Println("⚠️ PM Agent TLS verification is disabled")
+		}
+		agentClient = agent.NewAgentClient(cfg.PMAgent.URL, agent.ClientOptions{
+			Transport:         agentTransport,
+			StreamIdleTimeout: time.Duration(cfg.PMAgent.StreamIdleTimeoutSeconds) * time.Second,
+			RequestDeadline:   time.Duration(cfg.PMAgent.RequestDeadlineSeconds) * time.Second,
+			MaxRetries:        cfg.PMAgent.MaxRetries,
+			RetryBackoff:      time.Duration(cfg.PMAgent.RetryBackoffMs) * time.Millisecond,
+			SecondaryURL:      cfg.PMAgent.SecondaryURL,
+			FailoverCooldown:  time.Duration(cfg.PMAgent.FailoverCooldownSeconds) * time.Second,
+			StrictDecoding:    cfg.PMAgent.StrictResponseDecoding,
+		})
+		if cfg.PMAgent.SecondaryURL != "" {
+			if err := agent.ValidateURL(cfg.PMAgent.SecondaryURL); err != nil {
+				log.Printf("⚠️ Ignoring PM Agent secondary URL: %v", err)
+			}
+		}
+	}
+
+	// 3.0.0 Optional worker pool: bounds concurrent agent calls, interactive first
+	var agentPool *agent.Pool
+	botService, wsService := agentClient, agentClient
+	if cfg.PMAgent.Pool.Workers > 0 {
+		agentPool = agent.NewPool(agent.PoolOptions{
+			Workers:   cfg.PMAgent.Pool.Workers,
+			QueueSize: cfg.PMAgent.Pool.QueueSize,
+		})
+		botService = agentPool.Service(agentClient, agent.PriorityHigh)
+		wsService = agentPool.Service(agentClient, agent.PriorityNormal)
+	}
+
+	// 3.0 Conversation history (optional, requires DB)
+	var messageRepo conversation.Repository
+	if storage != nil {
+		messageRepo = storage.Messages
+	}
+
+	// Background jobs (retention, health probes) stop on shutdown
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	// 3.0 (cont.) Retention: prune old history in the background (opt-in)
+	if prunable, ok := messageRepo.(conversation.Prunable); ok {
+		retention := cfg.History.Retention
+		policy := conversation.RetentionPolicy{
+			MaxAge:            time.Duration(retention.MaxAgeDays) * 24 * time.Hour,
+			MaxThreadsPerUser: retention.MaxThreadsPerUser,
+			BatchSize:         retention.BatchSize,
+		}
+		if policy.Enabled() {
+			log.Printf("History retention enabled: max age %s, max %d threads per user", policy.MaxAge, policy.MaxThreadsPerUser)
+			go conversation.NewPruner(prunable, policy).Run(backgroundCtx, time.Duration(retention.IntervalMinutes)*time.Minute)
+		}
+	}
+
+	// 3.0 (cont.) Idempotency keys in the database are swept once expired; the
+	// in-memory store sweeps itself as keys are claimed
+	if storage != nil {
+		go idempotency.RunSweeper(backgroundCtx, storage.IdempotencyKeys, idempotency.DefaultSweepInterval)
+	}
+
+	// 3.0.3 Dependency health, probed in the background
+	healthRegistry := health.NewRegistry()
+
+	// Channels that can receive async agent results (POST /internal/agent/callback)
+	var callbackNotifiers []agent.Notifier
+
+	// Telegram account linking (/link <code>) needs the bot and a database
+	var telegramLinks *telegramlink.Service
+	if cfg.Telegram.Token != "" && storage != nil {
+		telegramLinks = telegramlink.NewService(storage.TelegramLinks, telegramlink.Options{})
+	}
 
 	// 3.1 Telegram Bot
+	var telegramBot *telegram.Bot
 	if cfg.Telegram.Token != "" {
-		// Get Allowed Chat ID from Env for Security
-		var allowedID int64 = 0
-		if idStr := os.Getenv("TELEGRAM_ALLOWED_ID"); idStr != "" {
-			parsed, err := strconv.ParseInt(idStr, 10, 64)
-			if err == nil {
-				allowedID = parsed
+		// Security: an empty whitelist means "allow all", which is refused outside local/debug
+		allowedIDs := telegram.Whitelist(cfg.AllowedTelegramIDs())
+		if err := telegram.CheckWhitelist(env, cfg.Server.Mode, allowedIDs, cfg.Telegram.AllowOpenAccess); err != nil {
+			log.Fatalf("Refusing to start Telegram Bot: %v", err)
+		}
+
+		botOpts := telegram.Options{
+			PublicURL:          cfg.Server.PublicURL,
+			EmptyReplyFallback: cfg.PMAgent.EmptyReplyFallback,
+			Links:              telegramLinks,
+			StreamReplies:      cfg.Telegram.StreamReplies,
+			EditInterval:       time.Duration(cfg.Telegram.StreamEditIntervalMs) * time.Millisecond,
+			PollTimeout:        cfg.Telegram.PollTimeoutSeconds,
+			CoalesceWindow:     time.Duration(cfg.Telegram.CoalesceWindowMs) * time.Millisecond,
+			ReplyAccessDenied:  cfg.Telegram.ReplyAccessDenied,
+		}
+		if pinger, ok := agentClient.(*agent.AgentClient); ok && cfg.Telegram.PauseOnAgentOutage {
+			interval := time.Duration(cfg.PMAgent.HealthCheckIntervalSeconds) * time.Second
+			if interval <= 0 {
+				interval = defaultHealthCheckInterval
 			}
+			go healthRegistry.Watch(backgroundCtx, agentHealthName, interval, pinger.Ping)
+			botOpts.AgentHealthy = func() bool { return healthRegistry.Healthy(agentHealthName) }
+		}
+		if cfg.Telegram.TruncateLongReplies && messageRepo != nil {
+			botOpts.TruncateThreshold = cfg.Telegram.TruncateThreshold
+			if botOpts.TruncateThreshold <= 0 {
+				botOpts.TruncateThreshold = telegram.DefaultTruncateThreshold
+			}
+			botOpts.Replies = messageRepo
+		}
+		if messageRepo != nil {
+			botOpts.History = messageRepo
 		}
 
-		bot, err := telegram.NewBot(cfg.Telegram.Token, allowedID, agentClient)
+		bot, err := telegram.NewBot(cfg.Telegram.Token, allowedIDs, botService, botOpts)
 		if err != nil {
 			log.Printf("Failed to init Telegram Bot: %v", err)
 		} else {
 			log.Println("Starting Telegram Bot...")
 			bot.Start()
+			telegramBot = bot
+			callbackNotifiers = append(callbackNotifiers, bot)
 		}
 	} else {
 		log.Println("Telegram Token not found, skipping bot init.")
 	}
 
-	// 4. Handlers
-	healthHandler := health.NewHealthHandler()
-	agentHandler := agent.NewHandler(agentClient)
-
-	// 5. Routes
-	// Public
-	r.GET("/health", healthHandler.Check)
-	r.GET("/", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"service": "Woorung-Gaksi Core Gateway",
-			"env":     env,
-			"status":  "running",
+	// 3.2 Notifications (quota reached, async task done) over the configured channel
+	notifyChannels := map[string]notify.Notifier{notify.ChannelNone: notify.Noop{}}
+	if telegramBot != nil {
+		notifyChannels[notify.ChannelTelegram] = telegramBot.UserNotifier()
+	}
+	if email := cfg.Notifications.Email; email.SMTPAddr != "" {
+		notifyChannels[notify.ChannelEmail] = notify.NewEmail(notify.EmailOptions{
+			SMTPAddr:   email.SMTPAddr,
+			From:       email.From,
+			Username:   email.Username,
+			Password:   email.Password,
+			Recipients: func() map[string]string { return config.Current().Notifications.Email.Recipients },
 		})
+	}
+	notifications := notify.NewRouter(notifyChannels, notify.Options{
+		Routes: func() notify.Routes {
+			n := config.Current().Notifications
+			return notify.Routes{Default: n.Default, Events: n.Events, Users: n.Users}
+		},
+		Cooldown: time.Duration(cfg.Notifications.CooldownMinutes) * time.Minute,
 	})
 
-	// Protected API
-	api := r.Group("/api/v1")
-	api.Use(authMiddleware)
-	{
-		api.GET("/me", func(c *gin.Context) {
-			userID, _ := c.Get("userID")
-			role, _ := c.Get("role")
-			c.JSON(200, gin.H{"user_id": userID, "role": role})
-		})
-		api.POST("/ask", agentHandler.Ask)
+	// 4. Handlers & routes
+	gw, err := gateway.New(gateway.Deps{
+		Env:               env,
+		Config:            cfg,
+		Storage:           storage,
+		JWT:               jwtService,
+		Agent:             agentClient,
+		Pool:              agentPool,
+		WSAgent:           wsService,
+		TelegramLinks:     telegramLinks,
+		CallbackNotifiers: callbackNotifiers,
+		Notifications:     notifications,
+		AccessLog:         os.Stdout,
+	})
+	if err != nil {
+		log.Fatal(err)
 	}
 
 	// 6. Run
-	addr := ":" + cfg.Server.Port
-	log.Printf("Starting Core Gateway on %s (env: %s)", addr, env)
-	if err := r.Run(addr); err != nil {
-		log.Fatalf("Failed to run server: %v", err)
+	// server.socket_path (e.g. for a sidecar) takes precedence over the port
+	ln, err := listener.Listen(cfg.Server.SocketPath, ":"+cfg.Server.Port)
+	if err != nil {
+		log.Fatalf("Failed to listen: %v", err)
+	}
+	// server.tls serves HTTPS directly when there is no TLS-terminating proxy
+	tlsOpts := listener.TLSOptions{CertFile: cfg.Server.TLS.CertFile, KeyFile: cfg.Server.TLS.KeyFile}
+	srv := &http.Server{Handler: gw.Router}
+	go func() {
+		scheme := "http"
+		if tlsOpts.Enabled() {
+			scheme = "https"
+		}
+		log.Printf("Starting Core Gateway on %s (%s, env: %s)", ln.Addr(), scheme, env)
+		if err := listener.Serve(srv, ln, tlsOpts); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to run server: %v", err)
+		}
+	}()
+
+	var redirectSrv *http.Server
+	if tlsOpts.Enabled() && cfg.Server.TLS.RedirectHTTPPort != "" {
+		redirectSrv = &http.Server{Addr: ":" + cfg.Server.TLS.RedirectHTTPPort, Handler: listener.RedirectToHTTPS(cfg.Server.Port)}
+		go func() {
+			log.Printf("Redirecting HTTP on %s to HTTPS", redirectSrv.Addr)
+			if err := redirectSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("⚠️ HTTP redirect server: %v", err)
+			}
+		}()
+	}
+
+	// 7. Graceful shutdown: let in-flight streams finish (or close them cleanly), then stop the server
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	<-stop
+
+	grace := time.Duration(cfg.Server.ShutdownGraceSeconds) * time.Second
+	if grace <= 0 {
+		grace = defaultShutdownGrace
+	}
+	log.Printf("Shutting down (stream grace %s)...", grace)
+	ctx, cancel := context.WithTimeout(context.Background(), grace+5*time.Second)
+	defer cancel()
+
+	// Stop polling Telegram and let its replies finish alongside the drain; neither can hold shutdown past the grace
+	botStopped := make(chan error, 1)
+	go func() {
+		botCtx, cancelBot := context.WithTimeout(ctx, grace)
+		defer cancelBot()
+		botStopped <- telegramBot.Stop(botCtx)
+	}()
+
+	if err := gw.Drainer.Shutdown(ctx, grace); err != nil {
+		log.Printf("⚠️ Streams did not drain: %v", err)
+	}
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("⚠️ Server shutdown: %v", err)
+	}
+	if redirectSrv != nil {
+		redirectSrv.Shutdown(ctx)
 	}
+	if err := <-botStopped; err != nil {
+		log.Printf("⚠️ Telegram bot: %v", err)
+	}
+	if agentPool != nil {
+		agentPool.Close()
+	}
+	stopBackground()
+	log.Println("Core Gateway stopped")
 }
+
+// version is stamped at build time: -ldflags "-X main.version=v1.2.3"
+var version = "dev"
+
+// printBanner writes a short summary of what this instance runs with
+func printBanner(w io.Writer, env string, cfg *config.Config) {
+	bind := ":" + cfg.Server.Port
+	if cfg.Server.SocketPath != "" {
+		bind = "unix://" + cfg.Server.SocketPath
+	}
+	scheme := "http"
+	if cfg.Server.TLS.CertFile != "" && cfg.Server.TLS.KeyFile != "" {
+		scheme = "https"
+	}
+	driver := cfg.DB.Driver
+	if driver == "" {
+		driver = database.DriverPostgres
+	}
+
+	var features []string
+	for name, on := range map[string]bool{
+		"telegram":       cfg.Telegram.Token != "",
+		"tenancy":        cfg.Tenancy.Enabled,
+		"agent-failover": cfg.PMAgent.SecondaryURL != "",
+		"agent-pool":     cfg.PMAgent.Pool.Workers > 0,
+		"agent-callback": cfg.PMAgent.CallbackSecret != "",
+		"retention":      cfg.History.Retention.MaxAgeDays > 0 || cfg.History.Retention.MaxThreadsPerUser > 0,
+		"echo-mode":      cfg.PMAgent.Mode == "echo",
+	} {
+		if on {
+			features = append(features, name)
+		}
+	}
+	sort.Strings(features)
+	if len(features) == 0 {
+		features = []string{"none"}
+	}
+
+	fmt.Fprintf(w, "Woorung-Gaksi Core Gateway %s\n", version)
+	fmt.Fprintf(w, "  env:      %s (mode: %s)\n", env, cfg.Server.Mode)
+	fmt.Fprintf(w, "  listen:   %s (%s)\n", bind, scheme)
+	fmt.Fprintf(w, "  storage:  %s\n", driver)
+	fmt.Fprintf(w, "  features: %s\n", strings.Join(features, ", "))
+}
+
+// loadJWTKeys builds the signing keys from config, reading the rs256 PEM files
+func loadJWTKeys(cfg *config.Config) (auth.Keys, error) {
+	keys := auth.Keys{
+		Algorithm:      cfg.JWT.Algorithm,
+		KID:            cfg.JWT.KID,
+		Secret:         cfg.JWT.Secret,
+		Previous:       cfg.JWT.PreviousKeys,
+		PreviousSecret: cfg.JWT.PreviousSecret,
+		MaxExpiry:      time.Duration(cfg.JWT.MaxExpiryMinutes) * time.Minute,
+		RefreshExpiry:  time.Duration(cfg.JWT.RefreshExpiryHours) * time.Hour,
+	}
+	var err error
+	if path := cfg.JWT.PrivateKeyFile; path != "" {
+		if keys.PrivateKeyPEM, err = os.ReadFile(path); err != nil {
+			return keys, err
+		}
+	}
+	if path := cfg.JWT.PublicKeyFile; path != "" {
+		if keys.PublicKeyPEM, err = os.ReadFile(path); err != nil {
+			return keys, err
+		}
+	}
+	return keys, nil
+}
+
+// agentHealthName is the PM Agent's entry in the health registry
+const agentHealthName = "pm_agent"
+
+// defaultHealthCheckInterval applies when pm_agent.health_check_interval_seconds is unset
+const defaultHealthCheckInterval = 15 * time.Second
+
+// defaultShutdownGrace is how long in-flight streams may run after a shutdown signal
+const defaultShutdownGrace = 10 * time.Second
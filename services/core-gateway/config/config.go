@@ -1,89 +1,442 @@
 package config
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/goccy/go-yaml"
 )
 
+// CharBudget limits prompt characters per request and total characters per day (0 = unlimited)
+type CharBudget struct {
+	MaxRequestChars int `yaml:"max_request_chars"`
+	DailyChars      int `yaml:"daily_chars"`
+}
+
+// RouteLimit caps a route's body size and request rate per client IP (0 = unlimited)
+type RouteLimit struct {
+	MaxBodyBytes      int64 `yaml:"max_body_bytes"`
+	RequestsPerMinute int   `yaml:"requests_per_minute"`
+	// Burst is how many requests may arrive at once (default requests_per_minute)
+	Burst int `yaml:"burst"`
+}
+
 type Config struct {
 	Server struct {
 		Port string `yaml:"port"`
-		Mode string `yaml:"mode"`
+		// SocketPath listens on a Unix domain socket instead of Port (clients use unix://<path>)
+		SocketPath string `yaml:"socket_path"`
+		Mode       string `yaml:"mode"`
+		// TLS serves HTTPS directly when cert_file and key_file are set (TLS 1.2+)
+		TLS struct {
+			CertFile string `yaml:"cert_file"`
+			KeyFile  string `yaml:"key_file"`
+			// RedirectHTTPPort, when set, answers plain HTTP on this port with a redirect to HTTPS
+			RedirectHTTPPort string `yaml:"redirect_http_port"`
+		} `yaml:"tls"`
+		// PublicURL is the externally reachable base URL (used to build links in bot replies)
+		PublicURL string `yaml:"public_url"`
+		// CORSOrigins are the browser origins allowed to call the API ("*" allows any, without credentials)
+		CORSOrigins []string `yaml:"cors_origins"`
+		// CORSExposeHeaders are the response headers browsers may read (defaults include X-Request-ID and rate-limit headers)
+		CORSExposeHeaders []string `yaml:"cors_expose_headers"`
+		// CORSMaxAgeSeconds caches preflight responses (default 600, negative disables)
+		CORSMaxAgeSeconds int `yaml:"cors_max_age_seconds"`
+		// WebSocketOrigins are the origins allowed to open /api/v1/ws. Defaults to CORSOrigins.
+		WebSocketOrigins []string `yaml:"websocket_origins"`
+		// AccessLogFormat is json (default), combined (NCSA; "text" is an alias) or both
+		AccessLogFormat string `yaml:"access_log_format"`
+		// LogSampleRate logs 1 in N successful requests (0 or 1 = all); failed requests
+		// and those slower than LogSlowMS (default 1000) are always logged
+		LogSampleRate int `yaml:"log_sample_rate"`
+		LogSlowMS     int `yaml:"log_slow_ms"`
+		// SecurityHeaders are added to every response (empty values use safe defaults)
+		SecurityHeaders struct {
+			FrameOptions   string `yaml:"frame_options"`
+			ReferrerPolicy string `yaml:"referrer_policy"`
+			// HSTSMaxAgeSeconds enables HSTS on TLS requests (0 = off)
+			HSTSMaxAgeSeconds     int  `yaml:"hsts_max_age_seconds"`
+			HSTSIncludeSubdomains bool `yaml:"hsts_include_subdomains"`
+		} `yaml:"security_headers"`
+		// Limits apply per route pattern (e.g. "/api/v1/ask"), falling back to default.
+		// Patterns that match no route are logged at startup.
+		Limits struct {
+			Default RouteLimit            `yaml:"default"`
+			Routes  map[string]RouteLimit `yaml:"routes"`
+		} `yaml:"limits"`
+		// MaxJSONBytes and MaxMultipartBytes cap request bodies by content type on every
+		// route, on top of limits (0 = unlimited)
+		MaxJSONBytes      int64 `yaml:"max_json_bytes"`
+		MaxMultipartBytes int64 `yaml:"max_multipart_bytes"`
+		// RequireHTTPS redirects (GET/HEAD) or rejects plain HTTP requests; /health is exempt
+		RequireHTTPS bool `yaml:"require_https"`
+		// TrustedProxies are the IPs/CIDRs whose X-Forwarded-Proto (require_https) and
+		// X-Forwarded-For (client IP) are believed. Empty trusts no proxy; the client
+		// IP list requires a restart.
+		TrustedProxies []string `yaml:"trusted_proxies"`
+		// MetricsAuth protects /metrics with a bearer token and/or an IP allow-list.
+		// Unset means open; /health always stays open for orchestrator probes.
+		MetricsAuth struct {
+			Token      string   `yaml:"token" secret:"true"`
+			AllowedIPs []string `yaml:"allowed_ips"`
+		} `yaml:"metrics_auth"`
+		// DefaultAPIVersion is the response shape for clients that send no Accept-Version
+		// (default: the latest); pin it to "1" while old clients migrate
+		DefaultAPIVersion string `yaml:"default_api_version"`
+		// HideBanner skips the startup banner (insecure-default warnings are always logged)
+		HideBanner bool `yaml:"hide_banner"`
+		// StrictProd refuses to start in the prod env while any insecure default is set
+		// (placeholder JWT secret, sslmode=disable, open Telegram access, debug mode)
+		StrictProd bool `yaml:"strict_prod"`
+		// ShutdownGraceSeconds lets in-flight streams finish before they are closed (default 10)
+		ShutdownGraceSeconds int `yaml:"shutdown_grace_seconds"`
+		// RequestTimeoutSeconds is a hard deadline for every request, on top of the agent's
+		// own timeouts; it answers 504 when it passes (0 = none). Streams and /ws are exempt.
+		RequestTimeoutSeconds int `yaml:"request_timeout_seconds"`
 	} `yaml:"server"`
 	DB struct {
+		// Driver is postgres (default), sqlite or memory (env: DB_DRIVER)
+		Driver string `yaml:"driver"`
+		// Path is the SQLite database file (default woorung.db)
+		Path     string `yaml:"path"`
 		Host     string `yaml:"host"`
 		Port     string `yaml:"port"`
 		User     string `yaml:"user"`
-		Password string `yaml:"password"`
+		Password string `yaml:"password" secret:"true"`
 		Name     string `yaml:"name"`
+		// SSLMode is the Postgres sslmode (default disable; use require or verify-full outside local, env: DB_SSLMODE)
+		SSLMode string `yaml:"ssl_mode"`
 	} `yaml:"db"`
 	JWT struct {
-		Secret string `yaml:"secret"`
+		// Algorithm is hs256 (default, signs with Secret) or rs256 (signs with
+		// PrivateKeyFile so other services can verify with only PublicKeyFile)
+		Algorithm string `yaml:"algorithm"`
+		// Secret signs new tokens; KID is stamped on them to allow rotation
+		Secret string `yaml:"secret" secret:"true"`
+		KID    string `yaml:"kid"`
+		// PrivateKeyFile and PublicKeyFile are PEM files used by rs256. The public
+		// key is derived from the private key when PublicKeyFile is empty.
+		PrivateKeyFile string `yaml:"private_key_file"`
+		PublicKeyFile  string `yaml:"public_key_file"`
+		// PreviousKeys maps retired kids to secrets (PEM public keys for rs256) that
+		// still verify existing tokens. Use the "" kid for tokens issued before kids were configured.
+		PreviousKeys map[string]string `yaml:"previous_keys" secret:"true"`
+		// PreviousSecret still verifies tokens signed before Secret was changed in place
+		// (env: API_PREVIOUS_SECRET). Remove it once those tokens have expired.
+		PreviousSecret string `yaml:"previous_secret" secret:"true"`
+		// ExpiryMinutes is the default access token lifetime (default 1440, i.e. 24h)
+		ExpiryMinutes int `yaml:"expiry_minutes"`
+		// MaxExpiryMinutes caps the lifetime callers may request for any token (default 1440)
+		MaxExpiryMinutes int `yaml:"max_expiry_minutes"`
+		// RefreshExpiryHours is the refresh token lifetime (default 720, i.e. 30 days)
+		RefreshExpiryHours int `yaml:"refresh_expiry_hours"`
 	} `yaml:"jwt"`
+	Auth struct {
+		// OptionalRoutes serve anonymous callers when no token is sent (e.g. "/api/v1/ask").
+		// Anonymous usage is budgeted under usage.roles.anonymous.
+		OptionalRoutes []string `yaml:"optional_routes"`
+		// Lockout refuses logins after repeated failures per username / client IP (0 = default)
+		Lockout struct {
+			MaxAttempts     int `yaml:"max_attempts"`
+			WindowSeconds   int `yaml:"window_seconds"`
+			CooldownSeconds int `yaml:"cooldown_seconds"`
+		} `yaml:"lockout"`
+	} `yaml:"auth"`
 	Telegram struct {
-		Token string `yaml:"token"`
+		Token string `yaml:"token" secret:"true"`
+		// AllowedID is the chat the bot answers and sends unlinked notifications to.
+		// AllowedIDs are further chats it answers. Both come from TELEGRAM_ALLOWED_ID,
+		// a comma-separated list whose first entry is AllowedID.
+		AllowedID  int64   `yaml:"allowed_id"`
+		AllowedIDs []int64 `yaml:"allowed_ids"`
+		// AllowOpenAccess lets the bot answer any chat when no allowed ID is set.
+		// Only honored in the local env or debug mode; elsewhere startup fails.
+		AllowOpenAccess bool `yaml:"allow_open_access"`
+		// ReplyAccessDenied answers chats outside the whitelist with AccessDeniedMessage
+		// instead of ignoring them silently (which hides that the bot exists)
+		ReplyAccessDenied bool `yaml:"reply_access_denied"`
+		// PauseOnAgentOutage answers prompts with one "temporarily unavailable"
+		// message while the PM Agent health check fails, instead of an error per prompt
+		PauseOnAgentOutage  bool `yaml:"pause_on_agent_outage"`
+		TruncateLongReplies bool `yaml:"truncate_long_replies"`
+		TruncateThreshold   int  `yaml:"truncate_threshold"`
+		// StreamReplies edits the reply into one message as the agent streams it
+		StreamReplies bool `yaml:"stream_replies"`
+		// StreamEditIntervalMs throttles those edits (default 1500, Telegram allows ~1/s)
+		StreamEditIntervalMs int `yaml:"stream_edit_interval_ms"`
+		// PollTimeoutSeconds is the long-poll timeout (default 10); shutdown may wait this
+		// long for Telegram, bounded by server.shutdown_grace_seconds
+		PollTimeoutSeconds int `yaml:"poll_timeout_seconds"`
+		// CoalesceWindowMs joins messages one sender sends to a chat this close
+		// together into one prompt (e.g. 1500; 0 = off)
+		CoalesceWindowMs int `yaml:"coalesce_window_ms"`
 	} `yaml:"telegram"`
 	PMAgent struct {
 		URL string `yaml:"url"`
+		// SecondaryURL takes over for FailoverCooldownSeconds (default 30) when URL fails (env: PM_AGENT_SECONDARY_URL)
+		SecondaryURL            string `yaml:"secondary_url"`
+		FailoverCooldownSeconds int    `yaml:"failover_cooldown_seconds"`
+		// Mode "echo" replies locally without calling the agent (dry run for local dev)
+		Mode string `yaml:"mode"`
+		// Required fails startup when URL is unset; otherwise /ask returns 503
+		Required bool `yaml:"required"`
+		// EmptyReplyFallback replaces empty agent replies (default: a "please rephrase" notice)
+		EmptyReplyFallback string `yaml:"empty_reply_fallback"`
+		// DefaultSource is assumed for asks that name no source (default "api")
+		DefaultSource string `yaml:"default_source"`
+		// ForwardHeaders lists inbound headers passed to the agent (default: X-Request-ID,
+		// Traceparent, Tracestate); Authorization and Cookie are always stripped
+		ForwardHeaders []string `yaml:"forward_headers"`
+		// UserURLAllowList lets users point their requests at their own agent, limited to these
+		// origins (e.g. "https://*.agents.example.com"); empty disables custom agent URLs.
+		// Private, loopback and link-local addresses are refused even when listed.
+		UserURLAllowList []string `yaml:"user_url_allow_list"`
+		// CallbackSecret authenticates POST /internal/agent/callback (env: PM_AGENT_CALLBACK_SECRET; empty disables it)
+		CallbackSecret string `yaml:"callback_secret" secret:"true"`
+		// HealthCheckIntervalSeconds between GET /health probes of the agent (default 15)
+		HealthCheckIntervalSeconds int `yaml:"health_check_interval_seconds"`
+		// StreamIdleTimeoutSeconds cuts a streaming reply after this long without data (default 60)
+		StreamIdleTimeoutSeconds int `yaml:"stream_idle_timeout_seconds"`
+		// RequestDeadlineSeconds bounds one ask including all retries (default 120)
+		RequestDeadlineSeconds int `yaml:"request_deadline_seconds"`
+		// ConnectTimeoutSeconds bounds connecting to the agent and the TLS handshake (default 5)
+		ConnectTimeoutSeconds int `yaml:"connect_timeout_seconds"`
+		// ResponseTimeoutSeconds bounds waiting for the agent's reply headers, i.e. generation
+		// time for non-streaming asks (0 = only request_deadline_seconds applies)
+		ResponseTimeoutSeconds int `yaml:"response_timeout_seconds"`
+		// RawDebug returns the agent's unparsed body to admins on /ask?debug=raw
+		// (always on in the local env)
+		RawDebug bool `yaml:"raw_debug"`
+		// StrictResponseDecoding rejects agent replies carrying unknown fields and logs them,
+		// for integration debugging (default: unknown fields are ignored)
+		StrictResponseDecoding bool `yaml:"strict_response_decoding"`
+		// MaxRetries for transient agent failures (default 2, negative disables)
+		MaxRetries int `yaml:"max_retries"`
+		// RetryBackoffMs is the first retry delay, doubled on every retry (default 200)
+		RetryBackoffMs int `yaml:"retry_backoff_ms"`
+		// ProxyURL routes agent traffic through a proxy; otherwise HTTP(S)_PROXY env vars apply
+		ProxyURL        string `yaml:"proxy_url"`
+		DisableEnvProxy bool   `yaml:"disable_env_proxy"`
+		// Pool bounds concurrent agent calls (0 workers = unbounded); CLI/Telegram are served before batch
+		Pool struct {
+			Workers   int `yaml:"workers"`
+			QueueSize int `yaml:"queue_size"`
+		} `yaml:"pool"`
+		TLS struct {
+			// InsecureSkipVerify is for internal agents with self-signed certs only
+			InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+			CAFile             string `yaml:"ca_file"`
+		} `yaml:"tls"`
 	} `yaml:"pm_agent"`
+	Tenancy struct {
+		// Enabled requires a tenant_id claim on every API token and scopes data by tenant.
+		// Login, refresh and impersonation take the claim from the user's tenant_id column.
+		Enabled bool `yaml:"enabled"`
+	} `yaml:"tenancy"`
+	History struct {
+		// MaxThreadsPerUser caps threads per user when a new one starts (default 100, negative disables)
+		MaxThreadsPerUser int `yaml:"max_threads_per_user"`
+		// ThreadLimitPolicy at the cap: reject (409, default) or evict the least recently active thread
+		ThreadLimitPolicy string `yaml:"thread_limit_policy"`
+		// Retention is opt-in: set max_age_days and/or max_threads_per_user
+		Retention struct {
+			MaxAgeDays        int `yaml:"max_age_days"`
+			MaxThreadsPerUser int `yaml:"max_threads_per_user"`
+			// IntervalMinutes between prune runs (default 60)
+			IntervalMinutes int `yaml:"interval_minutes"`
+			// BatchSize caps rows per delete (default 500)
+			BatchSize int `yaml:"batch_size"`
+		} `yaml:"retention"`
+	} `yaml:"history"`
+	Usage struct {
+		CharBudget `yaml:",inline"`
+		// Roles overrides the default budget per token role
+		Roles map[string]CharBudget `yaml:"roles"`
+	} `yaml:"usage"`
+	// Notifications reach users about quota_exceeded and task_done events over
+	// none (default), telegram or email: the user's channel, else the event's, else default
+	Notifications struct {
+		Default string            `yaml:"default"`
+		Events  map[string]string `yaml:"events"`
+		Users   map[string]string `yaml:"users"`
+		// CooldownMinutes keeps one event from repeating to a user (default 60)
+		CooldownMinutes int `yaml:"cooldown_minutes"`
+		// Email is the email channel
+		Email struct {
+			// SMTPAddr is host:port; empty disables the email channel
+			SMTPAddr string `yaml:"smtp_addr"`
+			From     string `yaml:"from"`
+			Username string `yaml:"username"`
+			// Password for SMTP auth (env: SMTP_PASSWORD)
+			Password string `yaml:"password" secret:"true"`
+			// Recipients maps user IDs to email addresses
+			Recipients map[string]string `yaml:"recipients"`
+		} `yaml:"email"`
+	} `yaml:"notifications"`
+
+	// sources maps keys set by the config file or the environment to SourceFile
+	// or SourceEnv (see Describe); absent keys keep their default
+	sources map[string]string
+}
+
+// AllowedTelegramIDs lists the chats the bot answers, AllowedID first and
+// without duplicates or zeros (empty = no whitelist)
+func (c *Config) AllowedTelegramIDs() []int64 {
+	var ids []int64
+	seen := map[int64]bool{0: true}
+	for _, id := range append([]int64{c.Telegram.AllowedID}, c.Telegram.AllowedIDs...) {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// LoadOptions controls how the config file is decoded
+type LoadOptions struct {
+	// Strict rejects unknown keys (typos) instead of silently ignoring them
+	Strict bool
 }
 
 func Load(env string) (*Config, error) {
+	return LoadWithOptions(env, LoadOptions{})
+}
+
+func LoadWithOptions(env string, opts LoadOptions) (*Config, error) {
 	if env == "" {
 		env = "local"
 	}
 
 	configPath := filepath.Join("config", "envs", env+".yaml")
-	
-	// Open file
-	f, err := os.Open(configPath)
+
+	data, err := os.ReadFile(configPath)
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
 
 	// Decode YAML
-	var cfg Config
-	decoder := yaml.NewDecoder(f)
-	if err := decoder.Decode(&cfg); err != nil {
-		return nil, err
+	cfgPtr, err := Decode(bytes.NewReader(data), opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", configPath, err)
 	}
-	
-	// Override with Environment Variables (Docker Support)
+	cfg := *cfgPtr
+
+	// Override with Environment Variables (Docker Support).
+	// fromEnv records the overridden keys for Describe.
+	var fromEnv []string
 	if url := os.Getenv("PM_AGENT_URL"); url != "" {
 		cfg.PMAgent.URL = url
+		fromEnv = append(fromEnv, "pm_agent.url")
+	}
+	if url := os.Getenv("PM_AGENT_SECONDARY_URL"); url != "" {
+		cfg.PMAgent.SecondaryURL = url
+		fromEnv = append(fromEnv, "pm_agent.secondary_url")
+	}
+	if secret := os.Getenv("PM_AGENT_CALLBACK_SECRET"); secret != "" {
+		cfg.PMAgent.CallbackSecret = secret
+		fromEnv = append(fromEnv, "pm_agent.callback_secret")
 	}
 	if secret := os.Getenv("API_SECRET"); secret != "" {
 		cfg.JWT.Secret = secret
+		fromEnv = append(fromEnv, "jwt.secret")
+	}
+	if secret := os.Getenv("API_PREVIOUS_SECRET"); secret != "" {
+		cfg.JWT.PreviousSecret = secret
+		fromEnv = append(fromEnv, "jwt.previous_secret")
 	}
 	if token := os.Getenv("TELEGRAM_TOKEN"); token != "" {
 		cfg.Telegram.Token = token
+		fromEnv = append(fromEnv, "telegram.token")
+	}
+	if token := os.Getenv("METRICS_TOKEN"); token != "" {
+		cfg.Server.MetricsAuth.Token = token
+		fromEnv = append(fromEnv, "server.metrics_auth.token")
+	}
+	if password := os.Getenv("SMTP_PASSWORD"); password != "" {
+		cfg.Notifications.Email.Password = password
+		fromEnv = append(fromEnv, "notifications.email.password")
+	}
+	if publicURL := os.Getenv("PUBLIC_URL"); publicURL != "" {
+		cfg.Server.PublicURL = publicURL
+		fromEnv = append(fromEnv, "server.public_url")
+	}
+	if origins := os.Getenv("CORS_ORIGINS"); origins != "" {
+		cfg.Server.CORSOrigins = strings.Split(origins, ",")
+		fromEnv = append(fromEnv, "server.cors_origins")
+	}
+	if len(cfg.Server.WebSocketOrigins) == 0 {
+		cfg.Server.WebSocketOrigins = cfg.Server.CORSOrigins
 	}
 	if allowed := os.Getenv("TELEGRAM_ALLOWED_ID"); allowed != "" {
-		// Just for consistency, though main.go handles this separately
-		// cfg.Telegram.AllowedID = ... (struct doesn't have it yet, skip)
+		var ids []int64
+		for _, field := range strings.Split(allowed, ",") {
+			id, err := strconv.ParseInt(strings.TrimSpace(field), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid TELEGRAM_ALLOWED_ID %q: %w", allowed, err)
+			}
+			ids = append(ids, id)
+		}
+		cfg.Telegram.AllowedID, cfg.Telegram.AllowedIDs = ids[0], ids[1:]
+		fromEnv = append(fromEnv, "telegram.allowed_id", "telegram.allowed_ids")
 	}
 
 	// Database Overrides
+	if driver := os.Getenv("DB_DRIVER"); driver != "" {
+		cfg.DB.Driver = driver
+		fromEnv = append(fromEnv, "db.driver")
+	}
 	if host := os.Getenv("DB_HOST"); host != "" {
 		cfg.DB.Host = host
+		fromEnv = append(fromEnv, "db.host")
 	}
 	if port := os.Getenv("DB_PORT"); port != "" {
 		cfg.DB.Port = port
+		fromEnv = append(fromEnv, "db.port")
 	}
 	if user := os.Getenv("DB_USER"); user != "" {
 		cfg.DB.User = user
+		fromEnv = append(fromEnv, "db.user")
 	}
 	if password := os.Getenv("DB_PASSWORD"); password != "" {
 		cfg.DB.Password = password
+		fromEnv = append(fromEnv, "db.password")
 	}
 	if name := os.Getenv("DB_NAME"); name != "" {
 		cfg.DB.Name = name
+		fromEnv = append(fromEnv, "db.name")
+	}
+	if sslMode := os.Getenv("DB_SSLMODE"); sslMode != "" {
+		cfg.DB.SSLMode = sslMode
+		fromEnv = append(fromEnv, "db.ssl_mode")
 	}
 
+	cfg.sources = sources(data, fromEnv)
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", configPath, err)
+	}
 	log.Printf("Loaded configuration for env: %s", env)
 	return &cfg, nil
 }
+
+// Decode parses YAML config from r. In strict mode an unknown key fails with
+// its line and column, e.g. `[3:3] unknown field "prot"`.
+func Decode(r io.Reader, opts LoadOptions) (*Config, error) {
+	var decodeOpts []yaml.DecodeOption
+	if opts.Strict {
+		decodeOpts = append(decodeOpts, yaml.DisallowUnknownField())
+	}
+
+	var cfg Config
+	if err := yaml.NewDecoder(r, decodeOpts...).Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return &cfg, nil
+}
@@ -0,0 +1,98 @@
+package config_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const typoConfig = `server:
+  port: "8080"
+  mdoe: "release"
+`
+
+func TestDecode_StrictRejectsUnknownKey(t *testing.T) {
+	// Act
+	_, err := config.Decode(strings.NewReader(typoConfig), config.LoadOptions{Strict: true})
+
+	// Assert: error names the key and its line
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "mdoe")
+	assert.Contains(t, err.Error(), "[3:3]")
+}
+
+func TestDecode_LenientIgnoresUnknownKey(t *testing.T) {
+	cfg, err := config.Decode(strings.NewReader(typoConfig), config.LoadOptions{})
+
+	require.NoError(t, err)
+	assert.Equal(t, "8080", cfg.Server.Port)
+	assert.Empty(t, cfg.Server.Mode)
+}
+
+func TestDecode_StrictAcceptsValidConfig(t *testing.T) {
+	valid := `server:
+  port: "8080"
+usage:
+  daily_chars: 100
+  roles:
+    admin:
+      daily_chars: 0
+`
+	cfg, err := config.Decode(strings.NewReader(valid), config.LoadOptions{Strict: true})
+
+	require.NoError(t, err)
+	assert.Equal(t, 100, cfg.Usage.DailyChars)
+}
+
+func TestSchema(t *testing.T) {
+	keys := map[string]string{}
+	for _, f := range config.Schema() {
+		keys[f.Key] = f.Type
+	}
+
+	assert.Equal(t, "string", keys["server.port"])
+	assert.Equal(t, "[]string", keys["server.cors_origins"])
+	assert.Equal(t, "int", keys["usage.daily_chars"], "Inline fields appear at the parent level")
+	assert.Equal(t, "int", keys["usage.roles.<name>.max_request_chars"])
+	assert.NotContains(t, keys, "server")
+}
+
+func TestLoad_TelegramAllowedIDFromEnv(t *testing.T) {
+	// Arrange: Load reads config/envs relative to the working directory
+	t.Chdir("..")
+	t.Setenv("TELEGRAM_ALLOWED_ID", "123456")
+
+	// Act
+	cfg, err := config.Load("local")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, int64(123456), cfg.Telegram.AllowedID)
+	assert.True(t, cfg.Telegram.AllowOpenAccess)
+}
+
+func TestLoad_TelegramAllowedIDListFromEnv(t *testing.T) {
+	// Arrange
+	t.Chdir("..")
+	t.Setenv("TELEGRAM_ALLOWED_ID", "123456, -100987,123456")
+
+	// Act
+	cfg, err := config.Load("local")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, int64(123456), cfg.Telegram.AllowedID)
+	assert.Equal(t, []int64{123456, -100987}, cfg.AllowedTelegramIDs())
+}
+
+func TestLoad_InvalidTelegramAllowedID(t *testing.T) {
+	t.Chdir("..")
+	t.Setenv("TELEGRAM_ALLOWED_ID", "not-a-number")
+
+	_, err := config.Load("local")
+
+	assert.ErrorContains(t, err, "TELEGRAM_ALLOWED_ID")
+}
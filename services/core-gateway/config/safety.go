@@ -0,0 +1,59 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// minJWTSecretLen is the shortest JWT secret accepted outside the local env
+const minJWTSecretLen = 32
+
+// placeholderSecrets are the JWT secrets shipped in the sample env files
+var placeholderSecrets = map[string]bool{
+	"":                 true,
+	"secret":           true,
+	"local_secret_key": true,
+	"dev_secret_key":   true,
+	"prod_secret_key":  true,
+}
+
+// ErrInsecureProd is returned by CheckStrictProd when prod runs with insecure defaults
+var ErrInsecureProd = errors.New("refusing to start in prod with insecure defaults (server.strict_prod)")
+
+// InsecureDefaults lists settings that are fine for local development but
+// unsafe in env. The local env never reports anything.
+func (c *Config) InsecureDefaults(env string) []string {
+	if env == "" || env == "local" {
+		return nil
+	}
+
+	var findings []string
+	hmac := !strings.EqualFold(c.JWT.Algorithm, "rs256")
+	if hmac && (placeholderSecrets[c.JWT.Secret] || len(c.JWT.Secret) < minJWTSecretLen) {
+		findings = append(findings, fmt.Sprintf("jwt.secret is a placeholder or shorter than %d characters (set API_SECRET)", minJWTSecretLen))
+	}
+	postgres := c.DB.Driver == "" || c.DB.Driver == "postgres"
+	if postgres && (c.DB.SSLMode == "" || c.DB.SSLMode == "disable") {
+		findings = append(findings, "db.ssl_mode is disable: database traffic is unencrypted")
+	}
+	if c.Telegram.Token != "" && len(c.AllowedTelegramIDs()) == 0 {
+		findings = append(findings, "telegram.allowed_id is unset: any chat may talk to the agent")
+	}
+	if c.Server.Mode != "release" {
+		findings = append(findings, fmt.Sprintf("server.mode is %q, not release", c.Server.Mode))
+	}
+	return findings
+}
+
+// CheckStrictProd fails when server.strict_prod is set, env is prod and
+// InsecureDefaults reports anything
+func (c *Config) CheckStrictProd(env string) error {
+	if !c.Server.StrictProd || env != "prod" {
+		return nil
+	}
+	if findings := c.InsecureDefaults(env); len(findings) > 0 {
+		return fmt.Errorf("%w: %s", ErrInsecureProd, strings.Join(findings, "; "))
+	}
+	return nil
+}
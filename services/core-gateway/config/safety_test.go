@@ -0,0 +1,91 @@
+package config_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// insecureConfig mirrors the sample env files: placeholder secret, no sslmode,
+// open Telegram access and debug mode
+func insecureConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.Server.Mode = "debug"
+	cfg.JWT.Secret = "prod_secret_key"
+	cfg.Telegram.Token = "123:abc"
+	return cfg
+}
+
+func secureConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.Server.Mode = "release"
+	cfg.JWT.Secret = strings.Repeat("k", 48)
+	cfg.DB.SSLMode = "verify-full"
+	cfg.Telegram.Token = "123:abc"
+	cfg.Telegram.AllowedID = 42
+	return cfg
+}
+
+func TestInsecureDefaults_PerEnv(t *testing.T) {
+	tests := []struct {
+		name string
+		env  string
+		cfg  *config.Config
+		want []string
+	}{
+		{"local never warns", "local", insecureConfig(), nil},
+		{"dev reports every insecure default", "dev", insecureConfig(), []string{"jwt.secret", "db.ssl_mode", "telegram.allowed_id", "server.mode"}},
+		{"prod reports every insecure default", "prod", insecureConfig(), []string{"jwt.secret", "db.ssl_mode", "telegram.allowed_id", "server.mode"}},
+		{"prod with hardened config is clean", "prod", secureConfig(), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			findings := tt.cfg.InsecureDefaults(tt.env)
+
+			// Assert
+			require.Len(t, findings, len(tt.want))
+			for i, key := range tt.want {
+				assert.True(t, strings.HasPrefix(findings[i], key), "finding %q should be about %s", findings[i], key)
+			}
+		})
+	}
+}
+
+func TestInsecureDefaults_SQLiteSkipsSSLMode(t *testing.T) {
+	// Arrange
+	cfg := secureConfig()
+	cfg.DB.Driver = "sqlite"
+	cfg.DB.SSLMode = ""
+
+	// Act & Assert
+	assert.Empty(t, cfg.InsecureDefaults("prod"))
+}
+
+func TestInsecureDefaults_RS256IgnoresSecret(t *testing.T) {
+	// Arrange: rs256 signs with a key file, so the HMAC secret is unused
+	cfg := secureConfig()
+	cfg.JWT.Algorithm = "rs256"
+	cfg.JWT.Secret = ""
+
+	// Act & Assert
+	assert.Empty(t, cfg.InsecureDefaults("prod"))
+}
+
+func TestCheckStrictProd(t *testing.T) {
+	// Arrange
+	strict := insecureConfig()
+	strict.Server.StrictProd = true
+
+	// Act & Assert: only prod refuses, and only when strict_prod is set
+	assert.ErrorIs(t, strict.CheckStrictProd("prod"), config.ErrInsecureProd)
+	assert.NoError(t, strict.CheckStrictProd("dev"))
+	assert.NoError(t, insecureConfig().CheckStrictProd("prod"))
+
+	hardened := secureConfig()
+	hardened.Server.StrictProd = true
+	assert.NoError(t, hardened.CheckStrictProd("prod"))
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"bytes"
+	"crypto/rand"
+	_ "embed"
+	"encoding/hex"
+	"text/template"
+)
+
+//go:embed sample.yaml.tmpl
+var sampleTemplate string
+
+var sample = template.Must(template.New("sample").Parse(sampleTemplate))
+
+// Sample renders a commented config for env with safe defaults: SQLite
+// storage, a freshly generated JWT secret, and release mode outside local.
+// It decodes cleanly in strict mode.
+func Sample(env string) ([]byte, error) {
+	if env == "" {
+		env = "local"
+	}
+	secret := make([]byte, minJWTSecretLen)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	data := struct{ Env, Mode, SSLMode, Secret string }{
+		Env:     env,
+		Mode:    "release",
+		SSLMode: "require",
+		Secret:  hex.EncodeToString(secret),
+	}
+	if env == "local" {
+		data.Mode, data.SSLMode = "debug", "disable"
+	}
+
+	var buf bytes.Buffer
+	if err := sample.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// SchemaField documents a single valid config key
+type SchemaField struct {
+	Key  string // dotted path, e.g. "server.port"; map keys appear as "<name>"
+	Type string // Go kind of the value, e.g. "string", "int", "[]string"
+}
+
+// Schema lists every key accepted in the config file, derived from the Config struct tags
+func Schema() []SchemaField {
+	var fields []SchemaField
+	walkSchema(reflect.TypeOf(Config{}), "", &fields)
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Key < fields[j].Key })
+	return fields
+}
+
+func walkSchema(t reflect.Type, prefix string, out *[]SchemaField) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if !f.IsExported() || name == "-" {
+			continue
+		}
+
+		// Inlined structs contribute their fields at the current level
+		if strings.Contains(opts, "inline") {
+			walkSchema(f.Type, prefix, out)
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		key := prefix + name
+
+		switch {
+		case f.Type.Kind() == reflect.Struct:
+			walkSchema(f.Type, key+".", out)
+		case f.Type.Kind() == reflect.Map && f.Type.Elem().Kind() == reflect.Struct:
+			walkSchema(f.Type.Elem(), key+".<name>.", out)
+		default:
+			*out = append(*out, SchemaField{Key: key, Type: typeName(f.Type)})
+		}
+	}
+}
+
+func typeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Slice:
+		return "[]" + typeName(t.Elem())
+	case reflect.Map:
+		return "map[" + typeName(t.Key()) + "]" + typeName(t.Elem())
+	default:
+		return t.Kind().String()
+	}
+}
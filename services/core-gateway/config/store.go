@@ -0,0 +1,78 @@
+package config
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	current atomic.Pointer[Config]
+
+	hooksMu  sync.Mutex
+	hooks    []hook
+	nextHook int
+)
+
+type hook struct {
+	id int
+	fn func(*Config)
+}
+
+// Current returns the active config. Always read through Current() at request
+// time rather than capturing a *Config, so SIGHUP reloads are picked up race-free.
+// The returned value must be treated as read-only.
+func Current() *Config {
+	return current.Load()
+}
+
+// Store atomically replaces the active config and notifies reload hooks
+func Store(cfg *Config) {
+	current.Store(cfg)
+
+	hooksMu.Lock()
+	fns := make([]func(*Config), 0, len(hooks))
+	for _, h := range hooks {
+		fns = append(fns, h.fn)
+	}
+	hooksMu.Unlock()
+
+	for _, fn := range fns {
+		fn(cfg)
+	}
+}
+
+// OnReload registers fn to run after every Store. Use it for stateful
+// components (e.g. usage budgets) that cannot read Current() per request.
+// The returned func removes fn again; components that outlive the process's
+// config, such as gateways built in tests, must call it when they shut down.
+func OnReload(fn func(*Config)) (unregister func()) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	nextHook++
+	id := nextHook
+	hooks = append(hooks, hook{id: id, fn: fn})
+	return func() {
+		hooksMu.Lock()
+		defer hooksMu.Unlock()
+		for i, h := range hooks {
+			if h.id == id {
+				hooks = append(hooks[:i], hooks[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Reload re-reads the config file for env and swaps it in. On error the active
+// config is left untouched. Listener, database and Telegram settings still
+// require a restart.
+func Reload(env string, opts LoadOptions) (*Config, error) {
+	cfg, err := LoadWithOptions(env, opts)
+	if err != nil {
+		return nil, err
+	}
+	Store(cfg)
+	log.Printf("Reloaded configuration for env: %s", env)
+	return cfg, nil
+}
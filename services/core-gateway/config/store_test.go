@@ -0,0 +1,81 @@
+package config_test
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// Run with -race: readers must never observe a torn or partially written config.
+func TestCurrent_ConcurrentReloads(t *testing.T) {
+	// Arrange
+	initial := &config.Config{}
+	initial.Server.Port = "0"
+	config.Store(initial)
+
+	var hookCalls int
+	var hookMu sync.Mutex
+	t.Cleanup(config.OnReload(func(cfg *config.Config) {
+		hookMu.Lock()
+		hookCalls++
+		hookMu.Unlock()
+	}))
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+
+	// Act: readers hammer Current() while a writer swaps configs
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				cfg := config.Current()
+				// Port and Mode are written together, so they must always agree
+				if cfg.Server.Mode != "" {
+					assert.Equal(t, "mode-"+cfg.Server.Port, cfg.Server.Mode)
+				}
+				_ = len(cfg.Server.CORSOrigins)
+			}
+		}()
+	}
+
+	for i := 1; i <= 500; i++ {
+		next := &config.Config{}
+		next.Server.Port = strconv.Itoa(i)
+		next.Server.Mode = "mode-" + next.Server.Port
+		next.Server.CORSOrigins = []string{"https://" + next.Server.Port + ".example.com"}
+		config.Store(next)
+	}
+	close(stop)
+	wg.Wait()
+
+	// Assert
+	assert.Equal(t, "500", config.Current().Server.Port)
+	assert.Equal(t, 500, hookCalls)
+}
+
+func TestOnReload_Unregister(t *testing.T) {
+	// Arrange: two hooks, the first of which is removed
+	var first, second int
+	unregister := config.OnReload(func(*config.Config) { first++ })
+	t.Cleanup(config.OnReload(func(*config.Config) { second++ }))
+
+	// Act
+	config.Store(&config.Config{})
+	unregister()
+	unregister()
+	config.Store(&config.Config{})
+
+	// Assert: removing is idempotent and leaves other hooks registered
+	assert.Equal(t, 1, first)
+	assert.Equal(t, 2, second)
+}
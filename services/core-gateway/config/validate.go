@@ -0,0 +1,72 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ErrInvalid is returned by Validate (and so by Load) for unusable configs
+var ErrInvalid = errors.New("invalid config")
+
+// Validate reports every missing or malformed setting at once, so startup fails
+// with the whole list instead of a confusing error much later
+func (c *Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if c.Server.Port == "" && c.Server.SocketPath == "" {
+		add("server.port is required (or set server.socket_path)")
+	}
+	switch c.Server.Mode {
+	case "", "debug", "release", "test":
+	default:
+		add("server.mode %q must be debug, release or test", c.Server.Mode)
+	}
+
+	switch strings.ToLower(c.JWT.Algorithm) {
+	case "", "hs256":
+		if c.JWT.Secret == "" {
+			add("jwt.secret is required (or set API_SECRET)")
+		}
+	case "rs256":
+		if c.JWT.PrivateKeyFile == "" {
+			add("jwt.private_key_file is required for rs256")
+		}
+	default:
+		add("jwt.algorithm %q must be hs256 or rs256", c.JWT.Algorithm)
+	}
+
+	switch c.DB.Driver {
+	case "", "postgres", "sqlite", "memory":
+	default:
+		add("db.driver %q must be postgres, sqlite or memory", c.DB.Driver)
+	}
+
+	switch c.PMAgent.Mode {
+	case "":
+		if c.PMAgent.URL == "" && c.PMAgent.Required {
+			add("pm_agent.url is required when pm_agent.required is set (or set PM_AGENT_URL)")
+		}
+	case "echo":
+	default:
+		add("pm_agent.mode %q must be empty or echo", c.PMAgent.Mode)
+	}
+	for _, setting := range [][2]string{{"pm_agent.url", c.PMAgent.URL}, {"pm_agent.secondary_url", c.PMAgent.SecondaryURL}} {
+		key, raw := setting[0], setting[1]
+		if raw == "" {
+			continue
+		}
+		if u, err := url.Parse(raw); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add("%s %q is not an http(s) URL", key, raw)
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w:\n  - %s", ErrInvalid, strings.Join(problems, "\n  - "))
+}
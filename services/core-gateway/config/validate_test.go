@@ -0,0 +1,60 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate_ListsEveryProblem(t *testing.T) {
+	// Arrange
+	cfg := &config.Config{}
+	cfg.Server.Mode = "prod"
+	cfg.PMAgent.URL = "localhost:8000"
+	cfg.PMAgent.SecondaryURL = "http://backup:8000"
+
+	// Act
+	err := cfg.Validate()
+
+	// Assert
+	require.ErrorIs(t, err, config.ErrInvalid)
+	assert.Equal(t, `invalid config:
+  - server.port is required (or set server.socket_path)
+  - server.mode "prod" must be debug, release or test
+  - jwt.secret is required (or set API_SECRET)
+  - pm_agent.url "localhost:8000" is not an http(s) URL`, err.Error())
+}
+
+func TestValidate_AcceptsMinimalConfig(t *testing.T) {
+	tests := map[string]func(*config.Config){
+		"hs256": func(c *config.Config) { c.JWT.Secret = "secret" },
+		"rs256": func(c *config.Config) { c.JWT.Algorithm = "rs256"; c.JWT.PrivateKeyFile = "jwt.pem" },
+		"unix socket": func(c *config.Config) {
+			c.JWT.Secret = "secret"
+			c.Server.Port = ""
+			c.Server.SocketPath = "/run/woorung.sock"
+		},
+		"echo without url":  func(c *config.Config) { c.JWT.Secret = "secret"; c.PMAgent.Mode = "echo"; c.PMAgent.Required = true },
+		"agent url present": func(c *config.Config) { c.JWT.Secret = "secret"; c.PMAgent.URL = "https://agent.internal" },
+	}
+	for name, configure := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Server.Port = "8080"
+			configure(cfg)
+
+			assert.NoError(t, cfg.Validate())
+		})
+	}
+}
+
+func TestValidate_RequiredAgentURL(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Server.Port = "8080"
+	cfg.JWT.Secret = "secret"
+	cfg.PMAgent.Required = true
+
+	assert.ErrorContains(t, cfg.Validate(), "pm_agent.url is required")
+}
@@ -0,0 +1,101 @@
+// Package account serves a user's own data export and account deletion
+// (GET /api/v1/me/export, DELETE /api/v1/me).
+package account
+
+import (
+	"context"
+	"errors"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/agenturl"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/conversation"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/idempotency"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/limits"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/telegramlink"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/user"
+)
+
+// ErrNotErasable is returned when the storage cannot delete history
+var ErrNotErasable = errors.New("account deletion is not supported by this storage")
+
+// Erased counts what an erasure removed
+type Erased struct {
+	Messages int64 `json:"messages"`
+	Threads  int   `json:"threads"`
+}
+
+// Eraser deletes everything stored for a user and replaces the account with
+// a disabled user.Tombstone, so existing tokens stop working
+type Eraser interface {
+	EraseUser(ctx context.Context, scope conversation.Scope) (Erased, error)
+}
+
+// Stores are what a repository eraser erases from; History and Users are
+// required, nil stores are skipped
+type Stores struct {
+	History       conversation.Repository
+	Users         user.Repository
+	Keys          idempotency.Store
+	TelegramLinks telegramlink.Store
+	AgentURLs     agenturl.Store
+	Limits        limits.Store
+}
+
+type repositoryEraser struct {
+	Stores
+}
+
+// NewRepositoryEraser erases through the repository interfaces, one store at a
+// time. It is not transactional, so it is meant for the in-memory backend;
+// database backends provide a transactional Eraser instead.
+func NewRepositoryEraser(stores Stores) Eraser {
+	return &repositoryEraser{Stores: stores}
+}
+
+func (e *repositoryEraser) EraseUser(ctx context.Context, scope conversation.Scope) (Erased, error) {
+	var erased Erased
+	store, ok := e.History.(conversation.Prunable)
+	if !ok {
+		return erased, ErrNotErasable
+	}
+
+	threads, err := e.History.ListThreads(ctx, scope)
+	if err != nil {
+		return erased, err
+	}
+	for _, t := range threads {
+		if err := conversation.DeleteThread(ctx, store, conversation.OwnedThread{Scope: scope, ThreadID: t.ID}); err != nil {
+			return erased, err
+		}
+		if e.Keys != nil {
+			if err := e.Keys.ForgetThread(ctx, idempotency.Owner(scope.TenantID, scope.UserID), t.ID); err != nil {
+				return erased, err
+			}
+		}
+		erased.Threads++
+		erased.Messages += int64(t.MessageCount)
+	}
+
+	// Linked chats would otherwise keep being attributed to the tombstone
+	if e.TelegramLinks != nil {
+		if err := e.TelegramLinks.DeleteUser(ctx, scope.UserID); err != nil {
+			return erased, err
+		}
+	}
+	if e.AgentURLs != nil {
+		if err := e.AgentURLs.Delete(ctx, scope.UserID); err != nil {
+			return erased, err
+		}
+	}
+	if e.Limits != nil {
+		if err := e.Limits.Delete(ctx, limits.SubjectUser, scope.UserID); err != nil {
+			return erased, err
+		}
+	}
+
+	if users, ok := e.Users.(user.Erasable); ok {
+		if err := users.Erase(ctx, scope.UserID); err != nil {
+			return erased, err
+		}
+	}
+	return erased, nil
+}
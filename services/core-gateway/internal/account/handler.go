@@ -0,0 +1,171 @@
+package account
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/conversation"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/ctxutil"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/httperr"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/usage"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/user"
+)
+
+// Options configures the account handler; nil sources are left out of the export
+type Options struct {
+	History conversation.Repository
+	Users   user.Repository
+	Usage   *usage.Tracker
+	// Eraser powers DELETE /api/v1/me (503 when nil)
+	Eraser Eraser
+}
+
+type Handler struct {
+	opts Options
+	now  func() time.Time
+}
+
+func NewHandler(opts Options) *Handler {
+	return &Handler{opts: opts, now: time.Now}
+}
+
+// Export is the body of GET /api/v1/me/export
+type Export struct {
+	ExportedAt    time.Time      `json:"exported_at"`
+	User          ExportUser     `json:"user"`
+	Conversations []Conversation `json:"conversations"`
+	// Usage is today's character usage (older days are not kept)
+	Usage *ExportUsage `json:"usage,omitempty"`
+}
+
+// ExportUser is the account record; Username and CreatedAt are omitted for
+// users that only exist in tokens
+type ExportUser struct {
+	ID        string     `json:"id"`
+	TenantID  string     `json:"tenant_id,omitempty"`
+	Username  string     `json:"username,omitempty"`
+	Role      string     `json:"role,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// Conversation is one thread with all of its messages, oldest first
+type Conversation struct {
+	ThreadID string    `json:"thread_id"`
+	Messages []Message `json:"messages"`
+}
+
+type Message struct {
+	ID         string          `json:"id"`
+	Role       string          `json:"role"`
+	Content    string          `json:"content"`
+	Incomplete bool            `json:"incomplete,omitempty"`
+	Metadata   json.RawMessage `json:"metadata,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+type ExportUsage struct {
+	Chars         int       `json:"chars"`
+	PromptChars   int       `json:"prompt_chars"`
+	ResponseChars int       `json:"response_chars"`
+	ResetsAt      time.Time `json:"resets_at"`
+}
+
+// Export returns everything stored for the caller as one JSON bundle
+func (h *Handler) Export(c *gin.Context) {
+	ctx := c.Request.Context()
+	scope := conversation.ScopeFrom(c)
+	export := Export{
+		ExportedAt:    h.now().UTC(),
+		User:          ExportUser{ID: scope.UserID, TenantID: scope.TenantID},
+		Conversations: []Conversation{},
+	}
+
+	if h.opts.Users != nil {
+		u, err := h.opts.Users.FindByID(ctx, scope.UserID)
+		switch {
+		case err == nil:
+			created := u.CreatedAt
+			export.User.Username, export.User.Role, export.User.CreatedAt = u.Username, u.Role, &created
+		case !errors.Is(err, user.ErrNotFound):
+			h.fail(c, "load account", scope, err)
+			return
+		}
+	}
+
+	if h.opts.History != nil {
+		threads, err := h.opts.History.ListThreads(ctx, scope)
+		if err != nil {
+			h.fail(c, "list conversations", scope, err)
+			return
+		}
+		for _, t := range threads {
+			messages, err := h.opts.History.ListMessages(ctx, scope, t.ID)
+			if err != nil {
+				h.fail(c, "list messages", scope, err)
+				return
+			}
+			conv := Conversation{ThreadID: t.ID, Messages: make([]Message, 0, len(messages))}
+			for _, m := range messages {
+				msg := Message{ID: m.ID, Role: m.Role, Content: m.Content, Incomplete: m.Incomplete, CreatedAt: m.CreatedAt}
+				if m.Metadata != "" {
+					msg.Metadata = json.RawMessage(m.Metadata)
+				}
+				conv.Messages = append(conv.Messages, msg)
+			}
+			export.Conversations = append(export.Conversations, conv)
+		}
+	}
+
+	if h.opts.Usage != nil {
+		snap := h.opts.Usage.Usage(usage.Key(scope.TenantID, scope.UserID))
+		export.Usage = &ExportUsage{Chars: snap.Chars, PromptChars: snap.PromptChars, ResponseChars: snap.ResponseChars, ResetsAt: snap.ResetsAt}
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="woorung-export.json"`)
+	c.JSON(http.StatusOK, export)
+}
+
+// Delete erases all of the caller's data and disables the account. Tokens
+// already issued to the user are refused from then on.
+func (h *Handler) Delete(c *gin.Context) {
+	if h.opts.Eraser == nil {
+		httperr.Respond(c, http.StatusServiceUnavailable, "Account deletion is not available")
+		return
+	}
+
+	// Deleting is the user's own decision, never a support session's
+	if _, ok := ctxutil.ImpersonatedBy(c); ok {
+		httperr.Respond(c, http.StatusForbidden, "Accounts cannot be deleted while impersonating")
+		return
+	}
+
+	scope := conversation.ScopeFrom(c)
+	erased, err := h.opts.Eraser.EraseUser(c.Request.Context(), scope)
+	if errors.Is(err, ErrNotErasable) {
+		httperr.Respond(c, http.StatusServiceUnavailable, "Account deletion is not available")
+		return
+	}
+	if err != nil {
+		h.fail(c, "erase account", scope, err)
+		return
+	}
+	if h.opts.Usage != nil {
+		h.opts.Usage.Forget(usage.Key(scope.TenantID, scope.UserID))
+	}
+	// The eraser tombstones the account directly; cached copies must not keep its tokens alive
+	if cache, ok := h.opts.Users.(user.Invalidator); ok {
+		cache.Invalidate(scope.UserID)
+	}
+
+	log.Printf("[Account] Erased %s (tenant %q): %d messages in %d threads", scope.UserID, scope.TenantID, erased.Messages, erased.Threads)
+	c.JSON(http.StatusOK, gin.H{"deleted": erased})
+}
+
+func (h *Handler) fail(c *gin.Context, action string, scope conversation.Scope, err error) {
+	log.Printf("[Account] Failed to %s for %s: %v", action, scope.UserID, err)
+	httperr.Respond(c, http.StatusInternalServerError, "Failed to "+action)
+}
@@ -0,0 +1,114 @@
+package account_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/account"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/auth"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/conversation"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/infrastructure/database"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/middleware"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/usage"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fixture struct {
+	router  *gin.Engine
+	storage *database.Backend
+	usage   *usage.Tracker
+	alice   string
+	bob     string
+}
+
+// setup stores a two-message thread for alice, one for bob, and some usage
+func setup(t *testing.T) fixture {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	storage := database.NewMemoryBackend()
+	jwtService := auth.NewJWTService("secret", time.Hour)
+
+	alice := &user.User{Username: "alice", PasswordHash: "x"}
+	require.NoError(t, storage.Users.Create(ctx, alice))
+	for _, m := range []conversation.Message{
+		{UserID: alice.ID, ThreadID: "t1", Role: conversation.RoleUser, Content: "plan the release"},
+		{UserID: alice.ID, ThreadID: "t1", Role: conversation.RoleAssistant, Content: "done", Metadata: `{"usage":{"tokens":3}}`},
+		{UserID: "bob", ThreadID: "t2", Role: conversation.RoleUser, Content: "bob's secret"},
+	} {
+		require.NoError(t, storage.Messages.SaveMessage(ctx, &m))
+	}
+	tracker := usage.NewTracker(usage.Budget{}, nil)
+	tracker.Record(alice.ID, "user", "plan the release", "done")
+
+	h := account.NewHandler(account.Options{History: storage.Messages, Users: storage.Users, Usage: tracker, Eraser: storage.Accounts})
+	r := gin.New()
+	api := r.Group("/api/v1", middleware.AuthMiddleware(jwtService), middleware.RejectDisabledUsers(storage.Users))
+	api.GET("/me/export", h.Export)
+	api.DELETE("/me", h.Delete)
+
+	aliceToken, _ := jwtService.GenerateToken(alice.ID, "user")
+	bobToken, _ := jwtService.GenerateToken("bob", "user")
+	return fixture{router: r, storage: storage, usage: tracker, alice: aliceToken, bob: bobToken}
+}
+
+func (f fixture) do(method, path, token string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	f.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestExport_ContainsOnlyCallersData(t *testing.T) {
+	// Arrange
+	f := setup(t)
+
+	// Act
+	w := f.do(http.MethodGet, "/api/v1/me/export", f.alice)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	var export account.Export
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &export))
+	assert.Equal(t, "alice", export.User.Username)
+	require.Len(t, export.Conversations, 1)
+	conv := export.Conversations[0]
+	assert.Equal(t, "t1", conv.ThreadID)
+	require.Len(t, conv.Messages, 2)
+	assert.Equal(t, "plan the release", conv.Messages[0].Content)
+	assert.JSONEq(t, `{"usage":{"tokens":3}}`, string(conv.Messages[1].Metadata))
+	require.NotNil(t, export.Usage)
+	assert.Equal(t, len("plan the release")+len("done"), export.Usage.Chars)
+	assert.NotContains(t, w.Body.String(), "bob's secret")
+}
+
+func TestDelete_ErasesDataAndInvalidatesTokens(t *testing.T) {
+	// Arrange
+	f := setup(t)
+	ctx := context.Background()
+
+	// Act
+	w := f.do(http.MethodDelete, "/api/v1/me", f.alice)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"deleted":{"messages":2,"threads":1}}`, w.Body.String())
+
+	threads, err := f.storage.Messages.ListThreads(ctx, conversation.Scope{UserID: "bob"})
+	require.NoError(t, err)
+	assert.Len(t, threads, 1, "other users keep their data")
+
+	u, err := f.storage.Users.FindByUsername(ctx, "alice")
+	assert.ErrorIs(t, err, user.ErrNotFound, "username is scrubbed")
+	assert.Nil(t, u)
+
+	assert.Equal(t, http.StatusUnauthorized, f.do(http.MethodGet, "/api/v1/me/export", f.alice).Code, "token no longer works")
+	assert.Equal(t, http.StatusOK, f.do(http.MethodGet, "/api/v1/me/export", f.bob).Code)
+}
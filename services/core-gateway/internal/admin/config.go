@@ -0,0 +1,24 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/config"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/httperr"
+)
+
+// Config serves the effective config (after env overrides and reloads) with
+// secrets redacted and each key's source, to debug drift between environments.
+// Routes must be guarded by RequireRole("admin").
+func Config(current func() *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cfg := current()
+		if cfg == nil {
+			httperr.Respond(c, http.StatusServiceUnavailable, "Config is not loaded")
+			return
+		}
+		c.Header("Cache-Control", "no-store")
+		c.JSON(http.StatusOK, gin.H{"config": config.Describe(cfg)})
+	}
+}
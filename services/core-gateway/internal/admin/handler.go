@@ -0,0 +1,139 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/audit"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/auth"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/ctxutil"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/httperr"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/user"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/validation"
+)
+
+// ImpersonationTTL keeps support sessions short-lived
+const ImpersonationTTL = 15 * time.Minute
+
+// Handler serves admin-only endpoints. Routes must be guarded by RequireRole("admin").
+type Handler struct {
+	users      user.Repository
+	jwtService auth.Service
+	audit      audit.Logger
+}
+
+func NewHandler(users user.Repository, jwtService auth.Service, auditLogger audit.Logger) *Handler {
+	return &Handler{users: users, jwtService: jwtService, audit: auditLogger}
+}
+
+// Impersonate issues a short-lived token scoped as :user_id with an impersonated_by claim
+func (h *Handler) Impersonate(c *gin.Context) {
+	adminID, _ := ctxutil.UserID(c)
+	targetID := c.Param("user_id")
+
+	target, err := h.users.FindByID(c.Request.Context(), targetID)
+	if errors.Is(err, user.ErrNotFound) {
+		httperr.Respond(c, http.StatusNotFound, "User not found")
+		return
+	}
+	if err != nil {
+		httperr.Respond(c, http.StatusInternalServerError, "Failed to look up user")
+		return
+	}
+
+	if target.Role == "admin" {
+		h.audit.Log(c.Request.Context(), audit.Entry{
+			Action:   "admin.impersonate.denied",
+			ActorID:  adminID,
+			TargetID: target.ID,
+			Fields:   gin.H{"reason": "target is admin", "client_ip": c.ClientIP()},
+		})
+		httperr.Respond(c, http.StatusForbidden, "Cannot impersonate another admin")
+		return
+	}
+
+	token, err := h.jwtService.GenerateImpersonationToken(target.ID, target.Role, target.TenantID, adminID, ImpersonationTTL)
+	if err != nil {
+		httperr.Respond(c, http.StatusInternalServerError, "Failed to issue token")
+		return
+	}
+
+	expiresAt := time.Now().Add(ImpersonationTTL)
+	h.audit.Log(c.Request.Context(), audit.Entry{
+		Action:   "admin.impersonate",
+		ActorID:  adminID,
+		TargetID: target.ID,
+		Fields: gin.H{
+			"target_role": target.Role,
+			"expires_at":  expiresAt.UTC().Format(time.RFC3339),
+			"client_ip":   c.ClientIP(),
+			"user_agent":  c.Request.UserAgent(),
+		},
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":           token,
+		"user_id":         target.ID,
+		"impersonated_by": adminID,
+		"expires_at":      expiresAt.UTC().Format(time.RFC3339),
+	})
+}
+
+// UpdateUserRequest changes an account; omitted fields are kept
+type UpdateUserRequest struct {
+	Role     *string `json:"role" binding:"omitempty,min=1,max=32"`
+	Disabled *bool   `json:"disabled"`
+}
+
+// UpdateUser changes :user_id's role or disables the account. Its tokens are
+// refused from the next request on (see middleware.RejectDisabledUsers).
+func (h *Handler) UpdateUser(c *gin.Context) {
+	adminID, _ := ctxutil.UserID(c)
+	var req UpdateUserRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+	users, ok := h.users.(user.Updatable)
+	if !ok {
+		httperr.Respond(c, http.StatusServiceUnavailable, "Updating users is not available")
+		return
+	}
+
+	target, err := h.users.FindByID(c.Request.Context(), c.Param("user_id"))
+	if errors.Is(err, user.ErrNotFound) {
+		httperr.Respond(c, http.StatusNotFound, "User not found")
+		return
+	}
+	if err != nil {
+		httperr.Respond(c, http.StatusInternalServerError, "Failed to look up user")
+		return
+	}
+	if target.ID == adminID && req.Disabled != nil && *req.Disabled {
+		httperr.Respond(c, http.StatusBadRequest, "Cannot disable your own account")
+		return
+	}
+
+	changes := gin.H{"client_ip": c.ClientIP()}
+	if req.Role != nil && *req.Role != target.Role {
+		changes["role"] = gin.H{"from": target.Role, "to": *req.Role}
+		target.Role = *req.Role
+	}
+	if req.Disabled != nil && *req.Disabled != target.Disabled {
+		changes["disabled"] = *req.Disabled
+		target.Disabled = *req.Disabled
+	}
+	if err := users.Update(c.Request.Context(), target); err != nil {
+		httperr.Respond(c, http.StatusInternalServerError, "Failed to update user")
+		return
+	}
+
+	h.audit.Log(c.Request.Context(), audit.Entry{
+		Action:   "admin.user.update",
+		ActorID:  adminID,
+		TargetID: target.ID,
+		Fields:   changes,
+	})
+	c.JSON(http.StatusOK, gin.H{"user_id": target.ID, "username": target.Username, "role": target.Role, "disabled": target.Disabled})
+}
@@ -0,0 +1,176 @@
+package admin_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/admin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/audit"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/auth"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/ctxutil"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/middleware"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/testutil"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeUsers map[string]*user.User
+
+func (f fakeUsers) FindByID(ctx context.Context, id string) (*user.User, error) {
+	if u, ok := f[id]; ok {
+		return u, nil
+	}
+	return nil, user.ErrNotFound
+}
+
+func (f fakeUsers) FindByUsername(ctx context.Context, username string) (*user.User, error) {
+	return nil, user.ErrNotFound
+}
+
+func (f fakeUsers) Create(ctx context.Context, u *user.User) error {
+	f[u.ID] = u
+	return nil
+}
+
+type recordingAudit struct {
+	entries []audit.Entry
+}
+
+func (r *recordingAudit) Log(ctx context.Context, entry audit.Entry) {
+	r.entries = append(r.entries, entry)
+}
+
+func setupRouter(jwtService auth.Service, auditLog audit.Logger) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	users := fakeUsers{
+		"user_1":  {ID: "user_1", Role: "user"},
+		"admin_2": {ID: "admin_2", Role: "admin"},
+	}
+	h := admin.NewHandler(users, jwtService, auditLog)
+
+	r := gin.New()
+	api := r.Group("/api/v1")
+	api.Use(middleware.AuthMiddleware(jwtService))
+	api.GET("/me", func(c *gin.Context) {
+		userID, _ := ctxutil.UserID(c)
+		impersonatedBy, _ := ctxutil.ImpersonatedBy(c)
+		c.JSON(200, gin.H{"user_id": userID, "impersonated_by": impersonatedBy})
+	})
+	adminGroup := api.Group("/admin", middleware.RequireRole("admin"))
+	adminGroup.POST("/impersonate/:user_id", h.Impersonate)
+	return r
+}
+
+func TestImpersonate_IssuesScopedToken(t *testing.T) {
+	// Arrange
+	jwtService := auth.NewJWTService("secret", time.Hour)
+	auditLog := &recordingAudit{}
+	r := setupRouter(jwtService, auditLog)
+	adminToken, _ := jwtService.GenerateToken("admin_1", "admin")
+
+	// Act
+	req, _ := http.NewRequest("POST", "/api/v1/admin/impersonate/user_1", nil)
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	// Assert: token carries the target identity and the impersonator
+	require.Equal(t, http.StatusOK, w.Code)
+	var body map[string]string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+
+	claims, err := jwtService.ValidateToken(body["token"])
+	require.NoError(t, err)
+	assert.Equal(t, "user_1", claims.UserID)
+	assert.Equal(t, "user", claims.Role)
+	assert.Equal(t, "admin_1", claims.ImpersonatedBy)
+	assert.WithinDuration(t, time.Now().Add(admin.ImpersonationTTL), claims.ExpiresAt.Time, 5*time.Second)
+
+	// Assert: audit entry recorded
+	require.Len(t, auditLog.entries, 1)
+	assert.Equal(t, "admin.impersonate", auditLog.entries[0].Action)
+	assert.Equal(t, "admin_1", auditLog.entries[0].ActorID)
+	assert.Equal(t, "user_1", auditLog.entries[0].TargetID)
+
+	// Assert: /me reveals the impersonation
+	meReq, _ := http.NewRequest("GET", "/api/v1/me", nil)
+	meReq.Header.Set("Authorization", "Bearer "+body["token"])
+	meW := httptest.NewRecorder()
+	r.ServeHTTP(meW, meReq)
+	assert.JSONEq(t, `{"user_id":"user_1","impersonated_by":"admin_1"}`, meW.Body.String())
+}
+
+func TestImpersonate_Rejections(t *testing.T) {
+	jwtService := auth.NewJWTService("secret", time.Hour)
+	auditLog := &recordingAudit{}
+	r := setupRouter(jwtService, auditLog)
+	adminToken, _ := jwtService.GenerateToken("admin_1", "admin")
+	userToken, _ := jwtService.GenerateToken("user_1", "user")
+
+	cases := []struct {
+		name   string
+		token  string
+		target string
+		status int
+	}{
+		{"non-admin caller", userToken, "user_1", http.StatusForbidden},
+		{"admin target", adminToken, "admin_2", http.StatusForbidden},
+		{"unknown target", adminToken, "ghost", http.StatusNotFound},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/api/v1/admin/impersonate/"+tc.target, nil)
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tc.status, w.Code)
+		})
+	}
+
+	// Only the denied admin-on-admin attempt is audited
+	require.Len(t, auditLog.entries, 1)
+	assert.Equal(t, "admin.impersonate.denied", auditLog.entries[0].Action)
+}
+
+func TestUpdateUser_DisablingRevokesTokensAtOnce(t *testing.T) {
+	// Arrange: alice's account is cached by her first request
+	gw := testutil.NewTestGateway(t, testutil.Options{})
+	gw.CreateUser(t, "alice", "s3cret-pass", "user")
+	aliceToken := gw.Token(t, "alice", "user")
+	adminToken := gw.Token(t, "root", "admin")
+	require.Equal(t, http.StatusOK, gw.Do(t, http.MethodGet, "/api/v1/me", aliceToken, nil, nil).StatusCode)
+
+	// Act
+	var updated map[string]any
+	resp := gw.Do(t, http.MethodPatch, "/api/v1/admin/users/alice", adminToken, gin.H{"disabled": true}, &updated)
+	after := gw.Do(t, http.MethodGet, "/api/v1/me", aliceToken, nil, nil)
+
+	// Assert
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, true, updated["disabled"])
+	assert.Equal(t, http.StatusUnauthorized, after.StatusCode)
+}
+
+func TestUpdateUser_Validation(t *testing.T) {
+	// Arrange
+	gw := testutil.NewTestGateway(t, testutil.Options{})
+	gw.CreateUser(t, "root", "s3cret-pass", "admin")
+	adminToken := gw.Token(t, "root", "admin")
+
+	// Act
+	missing := gw.Do(t, http.MethodPatch, "/api/v1/admin/users/nobody", adminToken, gin.H{"role": "admin"}, nil)
+	self := gw.Do(t, http.MethodPatch, "/api/v1/admin/users/root", adminToken, gin.H{"disabled": true}, nil)
+	notAdmin := gw.Do(t, http.MethodPatch, "/api/v1/admin/users/root", gw.Token(t, "alice", "user"), gin.H{"role": "user"}, nil)
+
+	// Assert
+	assert.Equal(t, http.StatusNotFound, missing.StatusCode)
+	assert.Equal(t, http.StatusBadRequest, self.StatusCode)
+	assert.Equal(t, http.StatusForbidden, notAdmin.StatusCode)
+}
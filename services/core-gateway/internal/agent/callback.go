@@ -0,0 +1,120 @@
+package agent
+
+import (
+	"context"
+	"crypto/subtle"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/conversation"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/httperr"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/notify"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/validation"
+)
+
+// CallbackSecretHeader carries the shared secret on agent callbacks
+const CallbackSecretHeader = "X-Callback-Secret"
+
+// CallbackResult is a reply the agent finished asynchronously
+type CallbackResult struct {
+	RequestID   string   `json:"request_id"`
+	ThreadID    string   `json:"thread_id" binding:"required"`
+	UserID      string   `json:"user_id" binding:"required"`
+	TenantID    string   `json:"tenant_id"`
+	Reply       string   `json:"reply" binding:"required"`
+	Suggestions []string `json:"suggestions"`
+}
+
+// Notifier pushes a callback result to a live channel (Telegram chat,
+// WebSocket). Notify reports whether the result reached the user.
+type Notifier interface {
+	Notify(result CallbackResult) bool
+}
+
+// CallbackOptions configures the callback endpoint
+type CallbackOptions struct {
+	// Secret authenticates the agent; empty disables the endpoint (503)
+	Secret func() string
+	// History persists results (optional)
+	History conversation.Repository
+	// Notifiers are tried in order; every one that can reach the user is used
+	Notifiers []Notifier
+	// Notifications tells the user a task finished when no Notifier reached
+	// them (nil = no notifications)
+	Notifications *notify.Router
+}
+
+// CallbackHandler serves POST /internal/agent/callback
+type CallbackHandler struct {
+	opts CallbackOptions
+}
+
+func NewCallbackHandler(opts CallbackOptions) *CallbackHandler {
+	return &CallbackHandler{opts: opts}
+}
+
+// Receive persists an async result and pushes it to the originating channel
+func (h *CallbackHandler) Receive(c *gin.Context) {
+	secret := h.opts.Secret()
+	if secret == "" {
+		httperr.Respond(c, http.StatusServiceUnavailable, "Agent callbacks are not configured")
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(c.GetHeader(CallbackSecretHeader)), []byte(secret)) != 1 {
+		httperr.Respond(c, http.StatusUnauthorized, "Invalid callback secret")
+		return
+	}
+
+	var result CallbackResult
+	if !validation.BindJSON(c, &result) {
+		return
+	}
+	// The agent answers on the namespaced thread it was asked on
+	result.ThreadID = GatewayThreadID(result.ThreadID)
+	if err := conversation.ValidateThreadID(result.ThreadID); err != nil {
+		httperr.Respond(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	persisted := false
+	if h.opts.History != nil {
+		msg := &conversation.Message{
+			TenantID: result.TenantID,
+			ThreadID: result.ThreadID,
+			UserID:   result.UserID,
+			Role:     conversation.RoleAssistant,
+			Content:  result.Reply,
+		}
+		if err := h.opts.History.SaveMessage(c.Request.Context(), msg); err != nil {
+			log.Printf("[Agent] Failed to persist callback for thread %s: %v", result.ThreadID, err)
+			httperr.Respond(c, http.StatusInternalServerError, "Failed to persist result")
+			return
+		}
+		persisted = true
+	}
+
+	delivered := false
+	for _, n := range h.opts.Notifiers {
+		if n.Notify(result) {
+			delivered = true
+		}
+	}
+	if !delivered {
+		go h.opts.Notifications.Send(context.WithoutCancel(c.Request.Context()), notify.EventTaskDone, result.UserID, taskDoneMessage(result))
+	}
+	log.Printf("[Agent] Callback for thread %s (request %q): persisted=%t delivered=%t", result.ThreadID, result.RequestID, persisted, delivered)
+
+	c.JSON(http.StatusOK, gin.H{"persisted": persisted, "delivered": delivered})
+}
+
+// taskDonePreviewChars caps how much of the reply a task_done notification quotes
+const taskDonePreviewChars = 500
+
+func taskDoneMessage(result CallbackResult) string {
+	preview := []rune(result.Reply)
+	if len(preview) > taskDonePreviewChars {
+		preview = append(preview[:taskDonePreviewChars], '…')
+	}
+	return "Your request is done (thread " + result.ThreadID + "):\n\n" + string(preview)
+}
@@ -0,0 +1,92 @@
+package agent_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/agent"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/conversation"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/ctxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallback_PersistsAndDeliversOverWebSocket(t *testing.T) {
+	// Arrange: user_1 has a WebSocket open
+	gin.SetMode(gin.TestMode)
+	history := conversation.NewMemoryRepository()
+	ws := agent.NewWebSocketHandler(agent.NewHandler(echoService{}, agent.HandlerOptions{}), agent.WebSocketOptions{
+		AllowedOrigins: func() []string { return nil },
+	})
+	callbacks := agent.NewCallbackHandler(agent.CallbackOptions{
+		Secret:    func() string { return "s3cret" },
+		History:   history,
+		Notifiers: []agent.Notifier{ws},
+	})
+	r := gin.New()
+	r.GET("/ws", func(c *gin.Context) { ctxutil.SetUserID(c, "user_1"); ws.Serve(c) })
+	r.POST("/internal/agent/callback", callbacks.Receive)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	// One round trip guarantees the socket is registered before the callback
+	require.NoError(t, conn.WriteJSON(map[string]string{"message": "start report"}))
+	var ack map[string]string
+	require.NoError(t, conn.ReadJSON(&ack))
+
+	post := func(secret string) *http.Response {
+		// The agent answers on the namespaced thread it was asked on
+		body := `{"request_id":"req_1","thread_id":"` + agent.AgentThreadID(conversation.Scope{UserID: "user_1"}, "thread_9") + `","user_id":"user_1","reply":"report ready"}`
+		req, _ := http.NewRequest(http.MethodPost, server.URL+"/internal/agent/callback", bytes.NewBufferString(body))
+		req.Header.Set(agent.CallbackSecretHeader, secret)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	// Act
+	rejected := post("wrong")
+	accepted := post("s3cret")
+
+	// Assert: persisted for the owner and pushed to the open socket
+	assert.Equal(t, http.StatusUnauthorized, rejected.StatusCode)
+	assert.Equal(t, http.StatusOK, accepted.StatusCode)
+
+	messages, err := history.ListMessages(context.Background(), conversation.Scope{UserID: "user_1"}, "thread_9")
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "report ready", messages[0].Content)
+	assert.Equal(t, conversation.RoleAssistant, messages[0].Role)
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var pushed map[string]string
+	require.NoError(t, conn.ReadJSON(&pushed))
+	assert.Equal(t, "report ready", pushed["reply"])
+	assert.Equal(t, "thread_9", pushed["thread_id"])
+}
+
+func TestCallback_DisabledWithoutSecret(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/cb", agent.NewCallbackHandler(agent.CallbackOptions{Secret: func() string { return "" }}).Receive)
+
+	// Act
+	req, _ := http.NewRequest(http.MethodPost, "/cb", bytes.NewBufferString(`{}`))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
@@ -0,0 +1,193 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/safeurl"
+)
+
+// DefaultStreamIdleTimeout is how long a stream may go without data before it is cut
+const DefaultStreamIdleTimeout = 60 * time.Second
+
+// ClientOptions configures the PM Agent client
+type ClientOptions struct {
+	// StreamIdleTimeout aborts a streaming reply when no data arrives for this long
+	StreamIdleTimeout time.Duration
+	// MaxRetries for transient agent failures (0 = DefaultMaxRetries, negative disables)
+	MaxRetries int
+	// RetryBackoff is the first retry delay; it doubles on every retry
+	RetryBackoff time.Duration
+	// RequestDeadline bounds one Ask including all of its retries
+	RequestDeadline time.Duration
+	// Transport overrides the HTTP transport (see NewTransport for proxy/TLS settings)
+	Transport http.RoundTripper
+	// SecondaryURL takes over when the primary fails (empty = single-URL behavior)
+	SecondaryURL string
+	// FailoverCooldown is how long the primary is skipped after failing (default 30s)
+	FailoverCooldown time.Duration
+	// UserTransport carries calls to users' own agents (see WithAgentURL).
+	// Default safeurl.NewTransport, which only connects to public addresses.
+	UserTransport http.RoundTripper
+	// StrictDecoding rejects (and logs) agent replies with fields AgentResponse does not
+	// know; the default ignores them
+	StrictDecoding bool
+}
+
+// AgentClient implements the Service interface for calling PM Agent
+type AgentClient struct {
+	pmAgentURL string
+	httpClient *http.Client
+	// userClient calls users' own agents; it never follows redirects
+	userClient *http.Client
+	opts       ClientOptions
+	failover   *failover
+}
+
+// ValidateURL checks that the PM Agent URL is set and absolute.
+// An empty URL yields ErrNotConfigured.
+func ValidateURL(pmAgentURL string) error {
+	if strings.TrimSpace(pmAgentURL) == "" {
+		return ErrNotConfigured
+	}
+	u, err := url.Parse(pmAgentURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid PM Agent URL %q: must be an absolute http(s) URL", pmAgentURL)
+	}
+	return nil
+}
+
+// NewAgentClient creates a PM Agent client. An invalid or empty URL is not fatal
+// here: every call then fails with ErrNotConfigured instead of a relative POST.
+func NewAgentClient(pmAgentURL string, opts ClientOptions) *AgentClient {
+	if opts.StreamIdleTimeout <= 0 {
+		opts.StreamIdleTimeout = DefaultStreamIdleTimeout
+	}
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = DefaultMaxRetries
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = DefaultRetryBackoff
+	}
+	if opts.RequestDeadline <= 0 {
+		opts.RequestDeadline = DefaultRequestDeadline
+	}
+	if opts.FailoverCooldown <= 0 {
+		opts.FailoverCooldown = DefaultFailoverCooldown
+	}
+	if opts.UserTransport == nil {
+		opts.UserTransport = safeurl.NewTransport()
+	}
+	if ValidateURL(pmAgentURL) != nil {
+		pmAgentURL = ""
+	}
+	secondary := ""
+	if pmAgentURL != "" && ValidateURL(opts.SecondaryURL) == nil {
+		secondary = strings.TrimRight(opts.SecondaryURL, "/")
+	}
+	pmAgentURL = strings.TrimRight(pmAgentURL, "/")
+	return &AgentClient{
+		pmAgentURL: pmAgentURL,
+		httpClient: &http.Client{Transport: opts.Transport},
+		userClient: &http.Client{Transport: opts.UserTransport, CheckRedirect: refuseRedirects},
+		opts:       opts,
+		failover:   &failover{primary: pmAgentURL, secondary: secondary, cooldown: opts.FailoverCooldown, now: time.Now},
+	}
+}
+
+// askPayload is the JSON body of an agent call
+func askPayload(message, userID, threadID string) []byte {
+	jsonData, _ := json.Marshal(map[string]interface{}{
+		"message":   message,
+		"user_id":   userID,
+		"thread_id": threadID,
+	})
+	return jsonData
+}
+
+// Ask carries ctx's cancellation and values (e.g. WithForwardedHeaders) to the
+// agent call. The request deadline still applies on top of ctx.
+func (c *AgentClient) Ask(ctx context.Context, message string, userID string, threadID string) (*Reply, error) {
+	if !c.configuredFor(ctx) {
+		return nil, ErrNotConfigured
+	}
+
+	// Create payload for Python
+	jsonData := askPayload(message, userID, threadID)
+
+	ctx, cancel := context.WithTimeout(ctx, c.opts.RequestDeadline)
+	defer cancel()
+
+	resp, err := c.postWithRetry(ctx, "/ask", jsonData)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+
+	// Parse response. An agent may report an error next to a partial reply
+	// (e.g. a tool failed but it still answered); that reply is kept.
+	result, parseErr := decodeResponse(body, c.opts.StrictDecoding)
+	if result == nil {
+		result = &AgentResponse{}
+	}
+	agentError := string(result.Error)
+	usable := parseErr == nil && strings.TrimSpace(result.Reply) != ""
+
+	if resp.StatusCode != http.StatusOK {
+		if !usable {
+			return nil, fmt.Errorf("PM Agent returned error: %d", resp.StatusCode)
+		}
+		if agentError == "" {
+			agentError = fmt.Sprintf("PM Agent returned error: %d", resp.StatusCode)
+		}
+	}
+	if parseErr != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", parseErr)
+	}
+	if agentError != "" && !usable {
+		return nil, fmt.Errorf("PM Agent returned error: %s", agentError)
+	}
+
+	newThreadID := result.ThreadID
+	if newThreadID == "" {
+		newThreadID = threadID
+	}
+
+	return &Reply{
+		Text:        result.Reply,
+		ThreadID:    newThreadID,
+		Suggestions: result.Suggestions,
+		Raw:         string(body),
+		AgentError:  agentError,
+		Usage:       result.Usage,
+		Actions:     result.Actions,
+	}, nil
+}
+
+// Ping checks that the PM Agent answers GET /health
+func (c *AgentClient) Ping(ctx context.Context) error {
+	if c.pmAgentURL == "" {
+		return ErrNotConfigured
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.failover.target()+"/health", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to contact PM Agent: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("PM Agent health returned %d", resp.StatusCode)
+	}
+	return nil
+}
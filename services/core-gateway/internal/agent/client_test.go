@@ -0,0 +1,228 @@
+package agent_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/agent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgentClient_Ask_Suggestions(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected []string
+	}{
+		{"present", `{"reply":"Done","thread_id":"t1","suggestions":["Show the diff","Open a PR"]}`, []string{"Show the diff", "Open a PR"}},
+		{"absent", `{"reply":"Done","thread_id":"t1"}`, nil},
+		{"non-string entries skipped", `{"reply":"Done","thread_id":"t1","suggestions":["Retry",3,""]}`, []string{"Retry"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			agentServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(tt.body))
+			}))
+			defer agentServer.Close()
+			client := agent.NewAgentClient(agentServer.URL, agent.ClientOptions{})
+
+			// Act
+			reply, err := client.Ask(context.Background(), "hi", "user_1", "")
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, "Done", reply.Text)
+			assert.Equal(t, "t1", reply.ThreadID)
+			assert.Equal(t, tt.expected, reply.Suggestions)
+		})
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	assert.ErrorIs(t, agent.ValidateURL(""), agent.ErrNotConfigured)
+	assert.ErrorIs(t, agent.ValidateURL("  "), agent.ErrNotConfigured)
+	assert.Error(t, agent.ValidateURL("/ask"), "Relative URLs are rejected")
+	assert.Error(t, agent.ValidateURL("localhost:8000"), "A scheme is required")
+	assert.NoError(t, agent.ValidateURL("http://localhost:8000"))
+}
+
+func TestAgentClient_UnsetURL(t *testing.T) {
+	// Arrange
+	client := agent.NewAgentClient("", agent.ClientOptions{})
+
+	// Act
+	_, askErr := client.Ask(context.Background(), "hi", "user_1", "")
+	_, streamErr := client.AskStream(context.Background(), "hi", "user_1", "")
+
+	// Assert
+	assert.ErrorIs(t, askErr, agent.ErrNotConfigured)
+	assert.ErrorIs(t, streamErr, agent.ErrNotConfigured)
+}
+
+func TestAgentClient_AskHonorsCancellation(t *testing.T) {
+	// Arrange: the agent never answers
+	release := make(chan struct{})
+	agentServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer agentServer.Close()
+	defer close(release)
+	client := agent.NewAgentClient(agentServer.URL, agent.ClientOptions{RequestDeadline: time.Minute})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// Act
+	start := time.Now()
+	_, err := client.Ask(ctx, "hi", "user_1", "")
+
+	// Assert: the caller's deadline ends the call, not the agent's
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestHandler_AgentNotConfigured(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	h := agent.NewHandler(agent.NewAgentClient("", agent.ClientOptions{}), agent.HandlerOptions{})
+	r := gin.New()
+	r.POST("/ask", h.Ask)
+	r.POST("/ask/stream", h.AskStream)
+
+	for _, path := range []string{"/ask", "/ask/stream"} {
+		// Act
+		req, _ := http.NewRequest("POST", path, strings.NewReader(`{"message":"hello"}`))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code, path)
+		assert.JSONEq(t, `{"error":"agent not configured"}`, w.Body.String(), path)
+	}
+}
+
+func TestEchoService(t *testing.T) {
+	svc := agent.NewEchoService()
+
+	reply, err := svc.Ask(context.Background(), "hello there", "user_1", "thread_1")
+	require.NoError(t, err)
+	assert.Equal(t, "echo: hello there", reply.Text)
+	assert.Equal(t, "thread_1", reply.ThreadID)
+
+	events, err := svc.AskStream(context.Background(), "hello there", "user_1", "thread_1")
+	require.NoError(t, err)
+	var streamed strings.Builder
+	for ev := range events {
+		streamed.WriteString(ev.Data)
+	}
+	assert.Equal(t, "echo: hello there", streamed.String())
+}
+
+func TestAgentClient_Ask_ReplyAndError(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		text       string
+		agentError string
+		err        string
+	}{
+		{"reply only", http.StatusOK, `{"reply":"Done"}`, "Done", "", ""},
+		{"reply and error", http.StatusOK, `{"reply":"Half done","error":"search tool failed"}`, "Half done", "search tool failed", ""},
+		{"error object", http.StatusOK, `{"reply":"Half done","error":{"message":"tool timeout"}}`, "Half done", "tool timeout", ""},
+		{"non-200 with reply", http.StatusInternalServerError, `{"reply":"Half done","error":"tool crashed"}`, "Half done", "tool crashed", ""},
+		{"non-200 with reply, no error text", http.StatusInternalServerError, `{"reply":"Half done"}`, "Half done", "PM Agent returned error: 500", ""},
+		{"error without reply", http.StatusOK, `{"reply":"","error":"tool crashed"}`, "", "", "PM Agent returned error: tool crashed"},
+		{"non-200 without reply", http.StatusInternalServerError, `{"error":"tool crashed"}`, "", "", "PM Agent returned error: 500"},
+		{"non-200 unparseable", http.StatusInternalServerError, `oops`, "", "", "PM Agent returned error: 500"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			agentServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer agentServer.Close()
+			client := agent.NewAgentClient(agentServer.URL, agent.ClientOptions{})
+
+			// Act
+			reply, err := client.Ask(context.Background(), "hi", "user_1", "t1")
+
+			// Assert
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.text, reply.Text)
+			assert.Equal(t, tt.agentError, reply.AgentError)
+			assert.Equal(t, tt.agentError != "", reply.Partial())
+		})
+	}
+}
+
+func TestAgentClient_Ask_TypedUsageAndActions(t *testing.T) {
+	// Arrange: a token count beyond float64 precision must survive unchanged
+	agentServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"reply":"Done","thread_id":"t1","usage":{"total_tokens":9007199254740993},"actions":[{"type":"open_pr","number":12}]}`))
+	}))
+	defer agentServer.Close()
+	client := agent.NewAgentClient(agentServer.URL, agent.ClientOptions{})
+
+	// Act
+	reply, err := client.Ask(context.Background(), "hi", "user_1", "")
+
+	// Assert
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"total_tokens":9007199254740993}`, string(reply.Usage))
+	assert.Contains(t, string(reply.Usage), "9007199254740993")
+	assert.JSONEq(t, `[{"type":"open_pr","number":12}]`, string(reply.Actions))
+}
+
+func TestAgentClient_Ask_StrictDecoding(t *testing.T) {
+	tests := []struct {
+		name    string
+		strict  bool
+		body    string
+		wantErr bool
+	}{
+		{"lenient ignores unknown fields", false, `{"reply":"Done","model":"pm-1"}`, false},
+		{"strict accepts known fields", true, `{"reply":"Done","thread_id":"t1","error":{"message":"tool failed","code":3}}`, false},
+		{"strict rejects unknown fields", true, `{"reply":"Done","model":"pm-1"}`, true},
+		{"trailing data", false, `{"reply":"Done"} {"reply":"again"}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			agentServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(tt.body))
+			}))
+			defer agentServer.Close()
+			client := agent.NewAgentClient(agentServer.URL, agent.ClientOptions{StrictDecoding: tt.strict})
+
+			// Act
+			reply, err := client.Ask(context.Background(), "hi", "user_1", "")
+
+			// Assert
+			if tt.wantErr {
+				assert.ErrorContains(t, err, "failed to parse response")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "Done", reply.Text)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrShuttingDown ends in-flight streams that outlive the shutdown grace period
+var ErrShuttingDown = errors.New("server shutting down")
+
+// Drainer tracks in-flight streams so a graceful shutdown can let them finish
+// instead of severing them.
+type Drainer struct {
+	mu       sync.Mutex
+	active   sync.WaitGroup
+	draining bool
+	inFlight int
+	closing  chan struct{}
+}
+
+func NewDrainer() *Drainer {
+	return &Drainer{closing: make(chan struct{})}
+}
+
+// track registers a new stream. It returns false once shutdown has begun.
+func (d *Drainer) track() (release func(), ok bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.draining {
+		return nil, false
+	}
+	d.active.Add(1)
+	d.inFlight++
+	return func() {
+		d.mu.Lock()
+		d.inFlight--
+		d.mu.Unlock()
+		d.active.Done()
+	}, true
+}
+
+// InFlight returns the number of streams currently being served
+func (d *Drainer) InFlight() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.inFlight
+}
+
+// Shutdown stops new streams, gives active ones up to grace to complete, then
+// tells the rest to close with a terminal event and waits for them (bounded by ctx).
+func (d *Drainer) Shutdown(ctx context.Context, grace time.Duration) error {
+	d.mu.Lock()
+	d.draining = true
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.active.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(grace):
+	case <-ctx.Done():
+	}
+
+	close(d.closing)
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,132 @@
+package agent_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/agent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hangingService streams one token and then waits until the request is cancelled
+type hangingService struct{ echoService }
+
+func (hangingService) AskStream(ctx context.Context, message, userID, threadID string) (<-chan agent.StreamEvent, error) {
+	events := make(chan agent.StreamEvent)
+	go func() {
+		defer close(events)
+		events <- agent.StreamEvent{Type: agent.EventToken, Data: "Hello"}
+		<-ctx.Done()
+	}()
+	return events, nil
+}
+
+func TestDrainer_ClosesStreamsAfterGrace(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	drainer := agent.NewDrainer()
+	h := agent.NewHandler(hangingService{}, agent.HandlerOptions{Drain: drainer})
+	r := gin.New()
+	r.POST("/ask/stream", h.AskStream)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/ask/stream", "application/json", strings.NewReader(`{"message":"hi","thread_id":"thread_1"}`))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	// Wait until the stream is live
+	reader := bufio.NewReader(resp.Body)
+	first, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "event:message\n", first)
+
+	// Act
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	start := time.Now()
+	shutdownErr := drainer.Shutdown(ctx, 50*time.Millisecond)
+	rest, _ := io.ReadAll(reader)
+
+	// Assert: the stream got a terminal event instead of being severed
+	require.NoError(t, shutdownErr)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Contains(t, string(rest), "[server shutting down]")
+	assert.Contains(t, string(rest), "event:done\ndata:{\"error\":\"server shutting down\",\"thread_id\":\"thread_1\"}")
+
+	// New streams are refused once shutdown has begun
+	late, err := http.Post(server.URL+"/ask/stream", "application/json", strings.NewReader(`{"message":"hi"}`))
+	require.NoError(t, err)
+	late.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, late.StatusCode)
+}
+
+func TestDrainer_NoActiveStreams(t *testing.T) {
+	drainer := agent.NewDrainer()
+
+	start := time.Now()
+	err := drainer.Shutdown(context.Background(), time.Minute)
+
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), 100*time.Millisecond, "Nothing to wait for")
+}
+
+// deafService streams one token and then ignores cancellation until unblocked
+type deafService struct {
+	echoService
+	unblock chan struct{}
+}
+
+func (s deafService) AskStream(ctx context.Context, message, userID, threadID string) (<-chan agent.StreamEvent, error) {
+	events := make(chan agent.StreamEvent)
+	go func() {
+		defer close(events)
+		events <- agent.StreamEvent{Type: agent.EventToken, Data: "Hello"}
+		<-s.unblock
+	}()
+	return events, nil
+}
+
+func TestAskStream_EndsWhenClientDisconnects(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	svc := deafService{unblock: make(chan struct{})}
+	h := agent.NewHandler(svc, agent.HandlerOptions{})
+	returned := make(chan struct{})
+	r := gin.New()
+	r.POST("/ask/stream", func(c *gin.Context) {
+		defer close(returned)
+		h.AskStream(c)
+	})
+	server := httptest.NewServer(r)
+	defer server.Close()
+	defer close(svc.unblock)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, server.URL+"/ask/stream", strings.NewReader(`{"message":"hi"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	first, err := bufio.NewReader(resp.Body).ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "event:message\n", first)
+
+	// Act
+	cancel()
+
+	// Assert: the handler returns even though the service never closes its stream
+	select {
+	case <-returned:
+	case <-time.After(2 * time.Second):
+		t.Fatal("AskStream kept running after the client disconnected")
+	}
+}
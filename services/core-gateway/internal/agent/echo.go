@@ -0,0 +1,31 @@
+package agent
+
+import (
+	"context"
+	"strings"
+)
+
+// echoService is a dry-run Service for local development without a PM Agent
+type echoService struct{}
+
+// NewEchoService returns a Service that replies "echo: <message>" without calling the agent
+func NewEchoService() Service {
+	return echoService{}
+}
+
+func (echoService) Ask(ctx context.Context, message, userID, threadID string) (*Reply, error) {
+	return &Reply{Text: "echo: " + message, ThreadID: threadID}, nil
+}
+
+func (echoService) AskStream(ctx context.Context, message, userID, threadID string) (<-chan StreamEvent, error) {
+	words := strings.Fields("echo: " + message)
+	events := make(chan StreamEvent, len(words))
+	for i, w := range words {
+		if i > 0 {
+			w = " " + w
+		}
+		events <- StreamEvent{Type: EventToken, Data: w}
+	}
+	close(events)
+	return events, nil
+}
@@ -0,0 +1,53 @@
+package agent
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/metrics"
+)
+
+// DefaultFailoverCooldown is how long the primary is skipped after it fails
+const DefaultFailoverCooldown = 30 * time.Second
+
+// failover picks between the primary and an optional secondary agent URL.
+// When the primary fails it is marked unhealthy for a cooldown and requests
+// go to the secondary meanwhile.
+type failover struct {
+	primary   string
+	secondary string
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu            sync.Mutex
+	primaryDownAt time.Time
+}
+
+// target returns the URL to use for the next attempt
+func (f *failover) target() string {
+	if f.secondary == "" {
+		return f.primary
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.primaryDownAt.IsZero() && f.now().Sub(f.primaryDownAt) < f.cooldown {
+		return f.secondary
+	}
+	return f.primary
+}
+
+// primaryFailed marks the primary unhealthy and reports whether a secondary can take over
+func (f *failover) primaryFailed(reason error) bool {
+	if f.secondary == "" {
+		return false
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.primaryDownAt = f.now()
+	log.Printf("[Agent] Primary %s failed (%v); failing over to %s for %s", f.primary, reason, f.secondary, f.cooldown)
+	metrics.AgentFailovers.Inc()
+	return true
+}
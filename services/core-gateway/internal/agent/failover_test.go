@@ -0,0 +1,79 @@
+package agent_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/agent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgentClient_FailsOverToSecondary(t *testing.T) {
+	// Arrange: the primary keeps returning 503, the secondary answers
+	var primaryCalls, secondaryCalls atomic.Int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryCalls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer primary.Close()
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secondaryCalls.Add(1)
+		w.Write([]byte(`{"reply":"from secondary","thread_id":"t1"}`))
+	}))
+	defer secondary.Close()
+	client := agent.NewAgentClient(primary.URL, agent.ClientOptions{
+		RetryBackoff: 10 * time.Millisecond,
+		SecondaryURL: secondary.URL,
+	})
+
+	// Act
+	first, err := client.Ask(context.Background(), "hi", "user_1", "")
+	require.NoError(t, err)
+	second, err := client.Ask(context.Background(), "again", "user_1", "t1")
+	require.NoError(t, err)
+
+	// Assert: two 5xx trigger failover; the next call skips the primary during the cooldown
+	assert.Equal(t, "from secondary", first.Text)
+	assert.Equal(t, "from secondary", second.Text)
+	assert.Equal(t, int32(2), primaryCalls.Load())
+	assert.Equal(t, int32(2), secondaryCalls.Load())
+}
+
+func TestAgentClient_FailsOverOnConnectionError(t *testing.T) {
+	// Arrange: nothing listens on the primary
+	primary := httptest.NewServer(http.NotFoundHandler())
+	primary.Close()
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"reply":"ok"}`))
+	}))
+	defer secondary.Close()
+	client := agent.NewAgentClient(primary.URL, agent.ClientOptions{SecondaryURL: secondary.URL})
+
+	// Act
+	reply, err := client.Ask(context.Background(), "hi", "user_1", "")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "ok", reply.Text)
+}
+
+func TestAgentClient_NoSecondaryKeepsSingleURL(t *testing.T) {
+	// Arrange
+	agentServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer agentServer.Close()
+	client := agent.NewAgentClient(agentServer.URL, agent.ClientOptions{MaxRetries: -1})
+
+	// Act
+	_, err := client.Ask(context.Background(), "hi", "user_1", "")
+
+	// Assert
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "PM Agent returned error: 502")
+}
@@ -1,94 +1,535 @@
 package agent
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
-	"fmt"
-	"io"
+	"errors"
+	"log"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/apiversion"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/conversation"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/ctxutil"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/httperr"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/metrics"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/notify"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/usage"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/validation"
 )
 
-// AgentClient implements the Service interface for calling PM Agent
-type AgentClient struct {
-	pmAgentURL string
+// Handler handles HTTP requests for the agent
+type Handler struct {
+	service Service
+	opts    HandlerOptions
+}
+
+// HandlerOptions holds optional dependencies; nil fields disable the feature
+type HandlerOptions struct {
+	// History persists each turn (requires a database)
+	History conversation.Repository
+	// Usage tracks prompt/response sizes and enforces character budgets
+	Usage *usage.Tracker
+	// Budget returns a user's effective budget (nil = Usage.BudgetFor(role))
+	Budget func(ctx context.Context, userID, role string) usage.Budget
+	// Drain lets graceful shutdown wait for, then close, in-flight streams
+	Drain *Drainer
+	// Pool bounds concurrent agent calls and serves them by priority (streams bypass it)
+	Pool *Pool
+	// Threads caps threads per user (rejecting or evicting at the cap)
+	Threads *conversation.ThreadLimiter
+	// EmptyReplyFallback is sent in place of an empty agent reply (nil or "" = DefaultEmptyReplyFallback)
+	EmptyReplyFallback func() string
+	// DefaultSource is used for requests without a source (nil or "" = DefaultSource)
+	DefaultSource func() string
+	// ForwardHeaders lists inbound headers passed on to the agent (nil = DefaultForwardHeaders).
+	// Credentials such as Authorization and Cookie are never forwarded.
+	ForwardHeaders func() []string
+	// AgentURL returns the user's own agent URL, "" for the default agent (nil = always default)
+	AgentURL func(ctx context.Context, userID string) string
+	// Notifications tells users when their daily budget runs out (nil = no notifications)
+	Notifications *notify.Router
+	// RawDebug lets admins see the agent's unparsed body with ?debug=raw (nil = never)
+	RawDebug func() bool
 }
 
-func NewAgentClient(pmAgentURL string) *AgentClient {
-	return &AgentClient{pmAgentURL: pmAgentURL}
+func NewHandler(service Service, opts HandlerOptions) *Handler {
+	return &Handler{service: service, opts: opts}
 }
 
-func (c *AgentClient) Ask(message string, userID string, threadID string) (string, string, error) {
-	// Create payload for Python
-	payload := map[string]interface{}{
-		"message":   message,
-		"user_id":   userID,
-		"thread_id": threadID,
+type AskRequest struct {
+	Message string `json:"message" binding:"required"`
+	// Source is where the request comes from (one of Sources; empty = the default source)
+	Source   string `json:"source"`
+	ThreadID string `json:"thread_id"` // Optional: For conversation persistence
+}
+
+// askPriority ranks an HTTP ask by what the gateway authenticated, never by the
+// request body: service tokens (batch jobs) and anonymous callers queue behind
+// users. Interactive transports such as the Telegram bot use their own pooled
+// service instead.
+func askPriority(c *gin.Context) Priority {
+	if ctxutil.IsService(c) || ctxutil.IsAnonymous(c) {
+		return PriorityLow
 	}
-	jsonData, _ := json.Marshal(payload)
+	return PriorityNormal
+}
 
-	resp, err := http.Post(c.pmAgentURL+"/ask", "application/json", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", threadID, fmt.Errorf("failed to contact PM Agent: %w", err)
+func (h *Handler) Ask(c *gin.Context) {
+	var req AskRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+	if err := conversation.ValidateThreadID(req.ThreadID); err != nil {
+		httperr.Respond(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !h.resolveSource(c, &req) {
+		return
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return "", threadID, fmt.Errorf("PM Agent returned error: %d", resp.StatusCode)
+	h.ask(c, req.Message, req.ThreadID, askPriority(c), nil)
+}
+
+// ReplayLast re-asks the caller's last message in a thread, e.g. after the agent
+// errored. The message is asked again as stored; only the new reply is saved.
+func (h *Handler) ReplayLast(c *gin.Context) {
+	if h.opts.History == nil {
+		httperr.Respond(c, http.StatusServiceUnavailable, "Conversation history is not available")
+		return
 	}
 
-	body, _ := io.ReadAll(resp.Body)
-	
-	// Parse response
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return "", threadID, fmt.Errorf("failed to parse response: %w", err)
+	threadID := c.Param("thread_id")
+	messages, err := h.opts.History.ListMessages(c.Request.Context(), conversation.ScopeFrom(c), threadID)
+	if err != nil {
+		httperr.Respond(c, http.StatusInternalServerError, "Failed to list messages")
+		return
 	}
 
-	reply, _ := result["reply"].(string)
-	newThreadID, _ := result["thread_id"].(string)
-	if newThreadID == "" {
-		newThreadID = threadID
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == conversation.RoleUser {
+			h.ask(c, messages[i].Content, threadID, askPriority(c), &messages[i])
+			return
+		}
 	}
+	httperr.Respond(c, http.StatusNotFound, "No message to replay")
+}
 
-	return reply, newThreadID, nil
+// askError is an ask that was refused or failed, with its HTTP status
+type askError struct {
+	status  int
+	message string
 }
 
+// askResult is an answered ask
+type askResult struct {
+	reply *Reply
+	// text is the reply, or the fallback when the agent sent none
+	text    string
+	warning string
+}
 
-// Handler handles HTTP requests for the agent
-type Handler struct {
-	service Service
+// ask sends message to the agent and writes the JSON reply
+func (h *Handler) ask(c *gin.Context, message, threadID string, priority Priority, replay *conversation.Message) {
+	result, askErr := h.answer(c, message, threadID, priority, replay)
+	if askErr != nil {
+		httperr.Respond(c, askErr.status, askErr.message)
+		return
+	}
+	reply, text, warning := result.reply, result.text, result.warning
+
+	resp := gin.H{
+		"reply":     text,
+		"thread_id": reply.ThreadID,
+	}
+	if c.Query("debug") == "raw" && h.rawDebugAllowed(c) {
+		resp["raw"] = reply.Raw
+	}
+	// Version 1 clients only know reply and thread_id
+	if apiversion.Legacy(c) {
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+	if warning != "" {
+		resp["warning"] = warning
+	}
+	if reply.Partial() {
+		resp["agent_error"] = reply.AgentError
+	}
+	if len(reply.Suggestions) > 0 {
+		resp["suggestions"] = reply.Suggestions
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
-func NewHandler(service Service) *Handler {
-	return &Handler{service: service}
+// answer checks the caller's budget and thread cap, asks the agent and records
+// the turn. c identifies the caller and carries the request; nothing is written
+// to it, so HTTP and WebSocket asks share the same rules. replay is the stored
+// prompt when message is asked again (nil = a new prompt, saved before the ask).
+func (h *Handler) answer(c *gin.Context, message, threadID string, priority Priority, replay *conversation.Message) (*askResult, *askError) {
+	UserID, _ := ctxutil.UserID(c)
+	if err := h.admit(c, UserID, message, threadID); err != nil {
+		return nil, err
+	}
+
+	// The agent only ever sees the namespaced thread, so the gateway names new ones
+	if threadID == "" {
+		threadID = uuid.NewString()
+	}
+	prompt := replay
+	if prompt == nil {
+		prompt = h.savePrompt(c.Request.Context(), conversation.ScopeFrom(c), threadID, message)
+	}
+	// Looked up once the prompt is saved, so a new thread is anchored on its first turn
+	agentThreadID, askErr := h.agentThreadID(c, threadID)
+	if askErr != nil {
+		return nil, askErr
+	}
+
+	reply, err := h.callAgent(c, message, agentThreadID, priority)
+	if err != nil {
+		return nil, &askError{status: agentErrorStatus(err), message: err.Error()}
+	}
+	reply.ThreadID = threadID
+
+	result := &askResult{reply: reply, text: reply.Text}
+	switch {
+	case reply.Empty():
+		log.Printf("[Agent] Empty reply for thread %s (user %s); sending fallback", reply.ThreadID, UserID)
+		result.text, result.warning = h.emptyReplyFallback(), WarningEmptyReply
+	case reply.Partial():
+		log.Printf("[Agent] Partial reply for thread %s (user %s): %s", reply.ThreadID, UserID, reply.AgentError)
+		result.warning = WarningPartialReply
+	}
+
+	h.recordUsage(c, UserID, message, result.text)
+	h.saveReply(c.Request.Context(), prompt, result.text, reply.Partial(), nil)
+	return result, nil
 }
 
-type AskRequest struct {
-	Message  string `json:"message" binding:"required"`
-	Source   string `json:"source"`
-	ThreadID string `json:"thread_id"` // Optional: For conversation persistence
+// callAgent asks the agent on agentThreadID with c's agent URL and forwarded
+// headers, through the pool at priority when there is one
+func (h *Handler) callAgent(c *gin.Context, message, agentThreadID string, priority Priority) (*Reply, error) {
+	UserID, _ := ctxutil.UserID(c)
+	ctx := h.agentContext(c, c.Request.Context())
+	if h.opts.Pool == nil {
+		return h.service.Ask(ctx, message, UserID, agentThreadID)
+	}
+
+	// The pooled call's results are only read once Do reports it ran
+	var reply *Reply
+	var err error
+	if poolErr := h.opts.Pool.Do(c.Request.Context(), priority, func() {
+		reply, err = h.service.Ask(ctx, message, UserID, agentThreadID)
+	}); poolErr != nil {
+		return nil, poolErr
+	}
+	return reply, err
 }
 
-func (h *Handler) Ask(c *gin.Context) {
+// rawDebugAllowed gates ?debug=raw: admins only, and only while RawDebug is on
+func (h *Handler) rawDebugAllowed(c *gin.Context) bool {
+	if h.opts.RawDebug == nil || !h.opts.RawDebug() {
+		return false
+	}
+	role, _ := ctxutil.Role(c)
+	return role == "admin"
+}
+
+// AskStream relays the agent reply as Server-Sent Events. Reply tokens are sent
+// as `message` events, intermediate steps as `thinking` / `tool` events, and the
+// stream always ends with a `done` event carrying the thread_id (and an error
+// field if the agent failed mid-reply, or a warning if it sent no text).
+func (h *Handler) AskStream(c *gin.Context) {
 	var req AskRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+	if err := conversation.ValidateThreadID(req.ThreadID); err != nil {
+		httperr.Respond(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !h.resolveSource(c, &req) {
+		return
+	}
+
+	UserID, _ := ctxutil.UserID(c)
+	if err := h.admit(c, UserID, req.Message, req.ThreadID); err != nil {
+		httperr.Respond(c, err.status, err.message)
 		return
 	}
 
-	UserID := c.GetString("userID")
-	
-	reply, newThreadID, err := h.service.Ask(req.Message, UserID, req.ThreadID)
+	var shuttingDown <-chan struct{}
+	if h.opts.Drain != nil {
+		release, ok := h.opts.Drain.track()
+		if !ok {
+			httperr.Respond(c, http.StatusServiceUnavailable, "Server is shutting down")
+			return
+		}
+		defer release()
+		shuttingDown = h.opts.Drain.closing
+	}
+
+	// The thread must be known up front so a partial reply can still be persisted
+	threadID := req.ThreadID
+	if threadID == "" {
+		threadID = uuid.NewString()
+	}
+
+	prompt := h.savePrompt(c.Request.Context(), conversation.ScopeFrom(c), threadID, req.Message)
+	agentThreadID, askErr := h.agentThreadID(c, threadID)
+	if askErr != nil {
+		httperr.Respond(c, askErr.status, askErr.message)
+		return
+	}
+
+	// Cancelled on return so the agent stream stops if we end early (e.g. shutdown)
+	ctx, cancel := context.WithCancel(h.agentContext(c, c.Request.Context()))
+	defer cancel()
+
+	events, err := h.service.AskStream(ctx, req.Message, UserID, agentThreadID)
 	if err != nil {
-		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		httperr.Respond(c, agentErrorStatus(err), err.Error())
 		return
 	}
 
-	// Respond with same format as before
-	c.JSON(http.StatusOK, gin.H{
-		"reply":     reply,
-		"thread_id": newThreadID,
-	})
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-store")
+	c.Header("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+
+	var reply strings.Builder
+	var streamErr error
+	var result *StreamResult
+stream:
+	for {
+		var ev StreamEvent
+		select {
+		case next, ok := <-events:
+			if !ok {
+				break stream
+			}
+			ev = next
+		case <-shuttingDown:
+			streamErr = ErrShuttingDown
+			break stream
+		case <-c.Request.Context().Done():
+			// Client went away; don't rely on the service noticing ctx
+			break stream
+		}
+
+		if ev.Err != nil {
+			streamErr = ev.Err
+			break stream
+		}
+		switch ev.Type {
+		case EventResult:
+			// Carried on the done event below rather than streamed
+			result = ev.Result
+			continue
+		case EventThinking, EventTool:
+			c.SSEvent(ev.Type, ev.Data)
+		default:
+			reply.WriteString(ev.Data)
+			c.SSEvent("message", ev.Data)
+		}
+		c.Writer.Flush()
+	}
+
+	done := gin.H{"thread_id": threadID}
+	incomplete := c.Request.Context().Err() != nil
+	if streamErr != nil {
+		log.Printf("[Agent] Stream for thread %s ended early: %v", threadID, streamErr)
+		marker := "[truncated: agent stream failed]"
+		switch {
+		case errors.Is(streamErr, ErrAgentTimeout):
+			marker = "[truncated: agent timed out]"
+		case errors.Is(streamErr, ErrShuttingDown):
+			marker = "[server shutting down]"
+		}
+		c.SSEvent("message", "\n\n"+marker)
+		done["error"] = streamErr.Error()
+		incomplete = true
+	} else if strings.TrimSpace(reply.String()) == "" {
+		log.Printf("[Agent] Empty streamed reply for thread %s (user %s); sending fallback", threadID, UserID)
+		reply.Reset()
+		reply.WriteString(h.emptyReplyFallback())
+		c.SSEvent("message", reply.String())
+		done["warning"] = WarningEmptyReply
+	}
+	if result != nil {
+		if len(result.Usage) > 0 {
+			done["usage"] = result.Usage
+		}
+		if len(result.Actions) > 0 {
+			done["actions"] = result.Actions
+		}
+	}
+	c.SSEvent("done", done)
+	c.Writer.Flush()
+
+	h.recordUsage(c, UserID, req.Message, reply.String())
+	h.saveReply(context.WithoutCancel(c.Request.Context()), prompt, reply.String(), incomplete, result)
+}
+
+// resolveSource validates and defaults req.Source and counts the request by
+// source, answering 400 for unknown sources
+func (h *Handler) resolveSource(c *gin.Context, req *AskRequest) bool {
+	fallback := ""
+	if h.opts.DefaultSource != nil {
+		fallback = h.opts.DefaultSource()
+	}
+	source, err := NormalizeSource(req.Source, fallback)
+	if err != nil {
+		httperr.Respond(c, http.StatusBadRequest, err.Error())
+		return false
+	}
+	req.Source = source
+	metrics.AgentRequests.WithLabelValues(source).Inc()
+	return true
+}
+
+// agentContext attaches the allow-listed inbound headers and the caller's own
+// agent URL, if any, to ctx for the agent call
+func (h *Handler) agentContext(c *gin.Context, ctx context.Context) context.Context {
+	if h.opts.AgentURL != nil {
+		userID, _ := ctxutil.UserID(c)
+		ctx = WithAgentURL(ctx, h.opts.AgentURL(ctx, userID))
+	}
+	allow := DefaultForwardHeaders
+	if h.opts.ForwardHeaders != nil {
+		if configured := h.opts.ForwardHeaders(); configured != nil {
+			allow = configured
+		}
+	}
+	return WithForwardedHeaders(ctx, FilterHeaders(c.Request.Header, allow))
+}
+
+func (h *Handler) emptyReplyFallback() string {
+	if h.opts.EmptyReplyFallback == nil {
+		return DefaultEmptyReplyFallback
+	}
+	return EmptyReplyFallback(h.opts.EmptyReplyFallback())
+}
+
+// agentErrorStatus maps an agent call failure to an HTTP status
+func agentErrorStatus(err error) int {
+	if errors.Is(err, ErrNotConfigured) || errors.Is(err, ErrQueueFull) || errors.Is(err, ErrShuttingDown) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusBadGateway
+}
+
+// admit checks the caller's character budget and thread cap
+func (h *Handler) admit(c *gin.Context, userID, message, threadID string) *askError {
+	if err := h.checkBudget(c, userID, message); err != nil {
+		return err
+	}
+	return h.admitThread(c, threadID)
+}
+
+// checkBudget refuses the request with 429 when it would exceed the user's character budget
+func (h *Handler) checkBudget(c *gin.Context, userID, message string) *askError {
+	// Per-user quotas don't apply to service tokens
+	if h.opts.Usage == nil || ctxutil.IsService(c) {
+		return nil
+	}
+	role, _ := ctxutil.Role(c)
+	budget := h.opts.Usage.BudgetFor(role)
+	if h.opts.Budget != nil && !ctxutil.IsAnonymous(c) {
+		budget = h.opts.Budget(c.Request.Context(), userID, role)
+	}
+	if err := h.opts.Usage.CheckBudget(usageKey(c, userID), budget, message); err != nil {
+		if errors.Is(err, usage.ErrDailyBudget) && !ctxutil.IsAnonymous(c) {
+			go h.opts.Notifications.Send(context.WithoutCancel(c.Request.Context()), notify.EventQuotaExceeded, userID,
+				"You've used today's request budget. New requests will be accepted again tomorrow.")
+		}
+		return &askError{status: http.StatusTooManyRequests, message: err.Error()}
+	}
+	return nil
+}
+
+// admitThread enforces the per-user thread cap, refusing with 409 at the cap.
+// Anonymous callers all share one user, so they may only start new threads:
+// naming a thread_id is refused with 401.
+func (h *Handler) admitThread(c *gin.Context, threadID string) *askError {
+	if threadID != "" && ctxutil.IsAnonymous(c) {
+		return &askError{status: http.StatusUnauthorized, message: "Sign in to continue a thread"}
+	}
+	scope := conversation.ScopeFrom(c)
+	// Only users' threads are persisted, so only they count toward the cap
+	if h.opts.Threads == nil || scope.UserID == "" || ctxutil.IsAnonymous(c) {
+		return nil
+	}
+
+	err := h.opts.Threads.Admit(c.Request.Context(), scope, threadID)
+	if errors.Is(err, conversation.ErrThreadLimit) {
+		return &askError{status: http.StatusConflict, message: err.Error()}
+	}
+	if err != nil {
+		log.Printf("[Agent] Thread limit check failed for %s: %v", scope.UserID, err)
+		return &askError{status: http.StatusInternalServerError, message: "Failed to check thread limit"}
+	}
+	return nil
+}
+
+func (h *Handler) recordUsage(c *gin.Context, userID, message, reply string) {
+	if h.opts.Usage != nil && !ctxutil.IsService(c) {
+		role, _ := ctxutil.Role(c)
+		h.opts.Usage.Record(usageKey(c, userID), role, message, reply)
+	}
+}
+
+// usageKey namespaces usage by tenant so quotas never bleed across tenants.
+// Anonymous callers share one user ID, so they are counted per client IP.
+func usageKey(c *gin.Context, userID string) string {
+	if ctxutil.IsAnonymous(c) {
+		return ctxutil.AnonymousUserID + "@" + c.ClientIP()
+	}
+	tenantID, _ := ctxutil.TenantID(c)
+	return usage.Key(tenantID, userID)
+}
+
+// savePrompt persists the user's message before the agent is asked, marked
+// incomplete until saveReply records an answer, so a failed ask can be replayed.
+// It returns nil when the message is not persisted; failures are logged, never surfaced.
+func (h *Handler) savePrompt(ctx context.Context, scope conversation.Scope, threadID, message string) *conversation.Message {
+	// Service tokens and anonymous callers have no user to own the history
+	if h.opts.History == nil || threadID == "" || scope.UserID == "" || scope.UserID == ctxutil.AnonymousUserID {
+		return nil
+	}
+
+	prompt := &conversation.Message{TenantID: scope.TenantID, ThreadID: threadID, UserID: scope.UserID, Role: conversation.RoleUser, Content: message, Incomplete: true}
+	if err := h.opts.History.SaveMessage(ctx, prompt); err != nil {
+		log.Printf("[Agent] Failed to persist history for thread %s: %v", threadID, err)
+		return nil
+	}
+	return prompt
+}
+
+// saveReply persists the agent's reply to prompt and, unless the reply is
+// incomplete, marks prompt answered. Failures are logged, never surfaced.
+func (h *Handler) saveReply(ctx context.Context, prompt *conversation.Message, reply string, incomplete bool, result *StreamResult) {
+	if prompt == nil {
+		return
+	}
+
+	var metadata string
+	if result != nil && (len(result.Usage) > 0 || len(result.Actions) > 0) {
+		encoded, _ := json.Marshal(StreamResult{Usage: result.Usage, Actions: result.Actions})
+		metadata = string(encoded)
+	}
+
+	msg := &conversation.Message{TenantID: prompt.TenantID, ThreadID: prompt.ThreadID, UserID: prompt.UserID, Role: conversation.RoleAssistant, Content: reply, Incomplete: incomplete, Metadata: metadata}
+	if err := h.opts.History.SaveMessage(ctx, msg); err != nil {
+		log.Printf("[Agent] Failed to persist history for thread %s: %v", prompt.ThreadID, err)
+		return
+	}
+	if prompt.Incomplete && !incomplete {
+		if err := h.opts.History.MarkComplete(ctx, prompt.ID); err != nil {
+			log.Printf("[Agent] Failed to mark prompt %s answered: %v", prompt.ID, err)
+		}
+	}
 }
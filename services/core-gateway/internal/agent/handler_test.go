@@ -0,0 +1,527 @@
+package agent_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/agent"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/apiversion"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/conversation"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/ctxutil"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/notify"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/usage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAsk_CharacterBudgetExhausted(t *testing.T) {
+	// Arrange: echoService replies "echo: <msg>", so "hello" costs 5 + 11 = 16 chars
+	gin.SetMode(gin.TestMode)
+	tracker := usage.NewTracker(usage.Budget{DailyChars: 40}, nil)
+	h := agent.NewHandler(echoService{}, agent.HandlerOptions{Usage: tracker})
+
+	r := gin.New()
+	r.POST("/ask", func(c *gin.Context) {
+		ctxutil.SetUserID(c, "user_1")
+		ctxutil.SetRole(c, "user")
+		h.Ask(c)
+	})
+
+	ask := func() int {
+		req, _ := http.NewRequest("POST", "/ask", bytes.NewBufferString(`{"message":"hello"}`))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Act & Assert: 16 + 16 = 32 used; a third prompt (5 chars) would reach 37 and still fits
+	assert.Equal(t, http.StatusOK, ask())
+	assert.Equal(t, http.StatusOK, ask())
+	assert.Equal(t, http.StatusOK, ask())
+	// 48 used: budget exhausted
+	assert.Equal(t, http.StatusTooManyRequests, ask())
+	assert.Equal(t, 48, tracker.Used("user_1"))
+}
+
+// chanNotifier forwards notifications to a channel, since they are sent asynchronously
+type chanNotifier chan string
+
+func (n chanNotifier) Notify(_ context.Context, userID, message string) error {
+	n <- userID + ": " + message
+	return nil
+}
+
+func TestAsk_BudgetExhaustedNotifiesUser(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	sent := make(chanNotifier, 4)
+	router := notify.NewRouter(map[string]notify.Notifier{notify.ChannelEmail: sent}, notify.Options{
+		Routes: func() notify.Routes { return notify.Routes{Default: notify.ChannelEmail} },
+	})
+	h := agent.NewHandler(echoService{}, agent.HandlerOptions{
+		Usage:         usage.NewTracker(usage.Budget{DailyChars: 10}, nil),
+		Notifications: router,
+	})
+	r := gin.New()
+	r.POST("/ask", func(c *gin.Context) {
+		ctxutil.SetUserID(c, "user_1")
+		ctxutil.SetRole(c, "user")
+		h.Ask(c)
+	})
+	ask := func() int {
+		req, _ := http.NewRequest("POST", "/ask", bytes.NewBufferString(`{"message":"hello"}`))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Act
+	require.Equal(t, http.StatusOK, ask())
+	require.Equal(t, http.StatusTooManyRequests, ask())
+	require.Equal(t, http.StatusTooManyRequests, ask())
+
+	// Assert: one notification, the repeat falls within the cooldown
+	select {
+	case msg := <-sent:
+		assert.True(t, strings.HasPrefix(msg, "user_1: "), msg)
+	case <-time.After(time.Second):
+		t.Fatal("no quota notification sent")
+	}
+	select {
+	case msg := <-sent:
+		t.Fatalf("unexpected second notification: %s", msg)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestReplayLast(t *testing.T) {
+	// Arrange: the owner's thread ends with a failed turn (no assistant reply)
+	gin.SetMode(gin.TestMode)
+	history := conversation.NewMemoryRepository()
+	ctx := context.Background()
+	for _, m := range []conversation.Message{
+		{Role: conversation.RoleUser, Content: "first"},
+		{Role: conversation.RoleAssistant, Content: "answer"},
+		{Role: conversation.RoleUser, Content: "second"},
+	} {
+		m.UserID, m.ThreadID = "owner", "thread_1"
+		require.NoError(t, history.SaveMessage(ctx, &m))
+	}
+	h := agent.NewHandler(echoService{}, agent.HandlerOptions{History: history})
+
+	r := gin.New()
+	r.POST("/conversations/:thread_id/replay-last", func(c *gin.Context) {
+		ctxutil.SetUserID(c, c.GetHeader("X-User"))
+		h.ReplayLast(c)
+	})
+	replay := func(userID string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/conversations/thread_1/replay-last", nil)
+		req.Header.Set("X-User", userID)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// Act
+	owner := replay("owner")
+	intruder := replay("intruder")
+
+	// Assert
+	require.Equal(t, http.StatusOK, owner.Code)
+	assert.JSONEq(t, `{"reply":"echo: second","thread_id":"thread_1"}`, owner.Body.String())
+	assert.Equal(t, http.StatusNotFound, intruder.Code, "Only the owner can replay")
+}
+
+// downService fails every ask until it is brought back up
+type downService struct {
+	echoService
+	down bool
+}
+
+func (s *downService) Ask(ctx context.Context, message, userID, threadID string) (*agent.Reply, error) {
+	if s.down {
+		return nil, errors.New("agent unavailable")
+	}
+	return s.echoService.Ask(ctx, message, userID, threadID)
+}
+
+func TestReplayLast_AfterAgentError(t *testing.T) {
+	// Arrange: the first turn succeeds, the second fails
+	gin.SetMode(gin.TestMode)
+	history := conversation.NewMemoryRepository()
+	service := &downService{}
+	h := agent.NewHandler(service, agent.HandlerOptions{History: history})
+	r := gin.New()
+	r.Use(func(c *gin.Context) { ctxutil.SetUserID(c, "owner") })
+	r.POST("/ask", h.Ask)
+	r.POST("/conversations/:thread_id/replay-last", h.ReplayLast)
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", path, bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	require.Equal(t, http.StatusOK, post("/ask", `{"message":"first","thread_id":"thread_1"}`).Code)
+	service.down = true
+	require.Equal(t, http.StatusBadGateway, post("/ask", `{"message":"second","thread_id":"thread_1"}`).Code)
+	service.down = false
+
+	// Act
+	replayed := post("/conversations/thread_1/replay-last", "")
+
+	// Assert: the failed prompt is replayed, answered, and not stored twice
+	require.Equal(t, http.StatusOK, replayed.Code)
+	assert.JSONEq(t, `{"reply":"echo: second","thread_id":"thread_1"}`, replayed.Body.String())
+	messages, err := history.ListMessages(context.Background(), conversation.Scope{UserID: "owner"}, "thread_1")
+	require.NoError(t, err)
+	var contents []string
+	for _, m := range messages {
+		contents = append(contents, m.Content)
+		assert.False(t, m.Incomplete, m.Content)
+	}
+	assert.Equal(t, []string{"first", "echo: first", "second", "echo: second"}, contents)
+}
+
+func TestAsk_AnonymousBudgetIsPerClientIP(t *testing.T) {
+	// Arrange: anonymous callers get a tight budget, counted per IP
+	gin.SetMode(gin.TestMode)
+	tracker := usage.NewTracker(usage.Budget{}, map[string]usage.Budget{
+		ctxutil.RoleAnonymous: {DailyChars: 20},
+	})
+	history := conversation.NewMemoryRepository()
+	h := agent.NewHandler(echoService{}, agent.HandlerOptions{Usage: tracker, History: history})
+
+	r := gin.New()
+	r.POST("/ask", func(c *gin.Context) {
+		ctxutil.SetUserID(c, ctxutil.AnonymousUserID)
+		ctxutil.SetRole(c, ctxutil.RoleAnonymous)
+		h.Ask(c)
+	})
+	ask := func(ip string) int {
+		req, _ := http.NewRequest("POST", "/ask", bytes.NewBufferString(`{"message":"hello"}`))
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Act & Assert: "hello" + "echo: hello" = 16 chars, so the second ask from one IP exceeds 20
+	assert.Equal(t, http.StatusOK, ask("198.51.100.1"))
+	assert.Equal(t, http.StatusTooManyRequests, ask("198.51.100.1"))
+	assert.Equal(t, http.StatusOK, ask("198.51.100.2"), "Another IP has its own budget")
+
+	threads, _ := history.ListThreads(context.Background(), conversation.Scope{UserID: ctxutil.AnonymousUserID})
+	assert.Empty(t, threads, "Anonymous turns are not persisted")
+}
+
+func TestAsk_AnonymousCannotNameThread(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	h := agent.NewHandler(echoService{}, agent.HandlerOptions{})
+	r := gin.New()
+	anonymous := func(c *gin.Context) {
+		ctxutil.SetUserID(c, ctxutil.AnonymousUserID)
+		ctxutil.SetRole(c, ctxutil.RoleAnonymous)
+	}
+	r.POST("/ask", anonymous, h.Ask)
+	r.POST("/ask/stream", anonymous, h.AskStream)
+	ask := func(path, body string) int {
+		req, _ := http.NewRequest("POST", path, bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Act & Assert: every anonymous caller is the same user, so only new threads are allowed
+	assert.Equal(t, http.StatusOK, ask("/ask", `{"message":"hello"}`))
+	assert.Equal(t, http.StatusUnauthorized, ask("/ask", `{"message":"hello","thread_id":"thread_1"}`))
+	assert.Equal(t, http.StatusOK, ask("/ask/stream", `{"message":"hello"}`))
+	assert.Equal(t, http.StatusUnauthorized, ask("/ask/stream", `{"message":"hello","thread_id":"thread_1"}`))
+}
+
+func TestAsk_ThreadIDValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := agent.NewHandler(echoService{}, agent.HandlerOptions{})
+	r := gin.New()
+	r.POST("/ask", h.Ask)
+	r.POST("/ask/stream", h.AskStream)
+
+	tests := []struct {
+		name     string
+		threadID string
+		expected int
+	}{
+		{"empty", "", http.StatusOK},
+		{"uuid", "3f2b8c1e-9a4d-4c7e-8f21-6b5a0d9e7c13", http.StatusOK},
+		{"telegram chat id", "-1001234567890", http.StatusOK},
+		{"injection", "x'; DROP TABLE messages;--", http.StatusBadRequest},
+		{"too long", strings.Repeat("a", 65), http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		for _, path := range []string{"/ask", "/ask/stream"} {
+			t.Run(tt.name+" "+path, func(t *testing.T) {
+				body, _ := json.Marshal(map[string]string{"message": "hello", "thread_id": tt.threadID})
+				req, _ := http.NewRequest("POST", path, bytes.NewReader(body))
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+
+				assert.Equal(t, tt.expected, w.Code)
+			})
+		}
+	}
+}
+
+func TestAsk_DebugRawOnlyWhenPermitted(t *testing.T) {
+	// Arrange: the agent sends a field the gateway doesn't parse
+	agentServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"reply":"ok","thread_id":"t1","reply_v2":{"text":"ok"}}`))
+	}))
+	defer agentServer.Close()
+	rawDebug := false
+	h := agent.NewHandler(agent.NewAgentClient(agentServer.URL, agent.ClientOptions{}), agent.HandlerOptions{
+		RawDebug: func() bool { return rawDebug },
+	})
+
+	r := gin.New()
+	r.POST("/ask", func(c *gin.Context) {
+		ctxutil.SetUserID(c, "user_1")
+		ctxutil.SetRole(c, c.GetHeader("X-Role"))
+		h.Ask(c)
+	})
+	ask := func(role string) map[string]any {
+		req, _ := http.NewRequest("POST", "/ask?debug=raw", bytes.NewBufferString(`{"message":"hi"}`))
+		req.Header.Set("X-Role", role)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body
+	}
+
+	// Act: gin's debug mode (its default) grants nothing by itself
+	gin.SetMode(gin.DebugMode)
+	adminFlagOff := ask("admin")
+	rawDebug = true
+	admin := ask("admin")
+	user := ask("user")
+	gin.SetMode(gin.TestMode)
+
+	// Assert
+	assert.NotContains(t, adminFlagOff, "raw")
+	assert.Contains(t, admin["raw"], `"reply_v2"`)
+	assert.NotContains(t, user, "raw")
+	assert.Equal(t, "ok", user["reply"])
+}
+
+func TestAsk_AgentThreadIsNamespacedByCaller(t *testing.T) {
+	// Arrange: user_b names user_a's thread ID
+	gin.SetMode(gin.TestMode)
+	var agentThreads []string
+	agentServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]string
+		json.NewDecoder(r.Body).Decode(&payload)
+		agentThreads = append(agentThreads, payload["thread_id"])
+		w.Write([]byte(`{"reply":"ok","thread_id":"` + payload["thread_id"] + `"}`))
+	}))
+	defer agentServer.Close()
+	h := agent.NewHandler(agent.NewAgentClient(agentServer.URL, agent.ClientOptions{}), agent.HandlerOptions{})
+	r := gin.New()
+	r.POST("/ask", func(c *gin.Context) {
+		ctxutil.SetTenantID(c, "acme")
+		ctxutil.SetUserID(c, c.GetHeader("X-Test-User"))
+		h.Ask(c)
+	})
+	ask := func(userID string) map[string]any {
+		req, _ := http.NewRequest("POST", "/ask", bytes.NewBufferString(`{"message":"what was my plan?","thread_id":"thread_a"}`))
+		req.Header.Set("X-Test-User", userID)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body
+	}
+
+	// Act
+	owner := ask("user_a")
+	other := ask("user_b")
+
+	// Assert: each caller sees the thread ID they asked on, but the agent keeps them apart
+	assert.Equal(t, "thread_a", owner["thread_id"])
+	assert.Equal(t, "thread_a", other["thread_id"])
+	assert.Equal(t, []string{"acme/user_a/thread_a", "acme/user_b/thread_a"}, agentThreads)
+}
+
+func TestAsk_ThreadLimitRejectsNewThread(t *testing.T) {
+	// Arrange: the user already has one thread and the cap is one
+	gin.SetMode(gin.TestMode)
+	history := conversation.NewMemoryRepository()
+	require.NoError(t, history.SaveMessage(context.Background(), &conversation.Message{
+		UserID: "user_1", ThreadID: "thread_1", Role: conversation.RoleUser, Content: "hi",
+	}))
+	h := agent.NewHandler(echoService{}, agent.HandlerOptions{
+		History: history,
+		Threads: conversation.NewThreadLimiter(history, 1, conversation.ThreadLimitReject),
+	})
+	r := gin.New()
+	r.POST("/ask", func(c *gin.Context) { ctxutil.SetUserID(c, "user_1"); h.Ask(c) })
+	ask := func(body string) int {
+		req, _ := http.NewRequest("POST", "/ask", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Act & Assert
+	assert.Equal(t, http.StatusOK, ask(`{"message":"more","thread_id":"thread_1"}`))
+	assert.Equal(t, http.StatusConflict, ask(`{"message":"new topic"}`))
+}
+
+func TestAsk_EmptyReplyUsesFallback(t *testing.T) {
+	// Arrange: the agent answers with whitespace only
+	gin.SetMode(gin.TestMode)
+	agentServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"reply":"  \n","thread_id":"t1"}`))
+	}))
+	defer agentServer.Close()
+	client := agent.NewAgentClient(agentServer.URL, agent.ClientOptions{})
+
+	ask := func(fallback func() string) map[string]any {
+		h := agent.NewHandler(client, agent.HandlerOptions{EmptyReplyFallback: fallback})
+		r := gin.New()
+		r.POST("/ask", h.Ask)
+		req, _ := http.NewRequest("POST", "/ask", bytes.NewBufferString(`{"message":"hi"}`))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body
+	}
+
+	// Act
+	configured := ask(func() string { return "Nothing to say." })
+	defaulted := ask(nil)
+
+	// Assert
+	assert.Equal(t, "Nothing to say.", configured["reply"])
+	assert.Equal(t, agent.WarningEmptyReply, configured["warning"])
+	assert.Equal(t, agent.DefaultEmptyReplyFallback, defaulted["reply"])
+	// The gateway names new threads; the agent's own thread_id is not used
+	assert.NotEmpty(t, defaulted["thread_id"])
+	assert.NotEqual(t, "t1", defaulted["thread_id"])
+}
+
+func TestAsk_PartialReplyIsKeptAndPersistedIncomplete(t *testing.T) {
+	// Arrange: a tool failed, but the agent still answered part of the request
+	gin.SetMode(gin.TestMode)
+	agentServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"reply":"Here is what I found so far","error":"search tool failed","thread_id":"t1"}`))
+	}))
+	defer agentServer.Close()
+	history := conversation.NewMemoryRepository()
+	h := agent.NewHandler(agent.NewAgentClient(agentServer.URL, agent.ClientOptions{}), agent.HandlerOptions{History: history})
+	r := gin.New()
+	r.POST("/ask", func(c *gin.Context) {
+		ctxutil.SetUserID(c, "user_1")
+		h.Ask(c)
+	})
+	req, _ := http.NewRequest("POST", "/ask", bytes.NewBufferString(`{"message":"research this","thread_id":"t1"}`))
+	w := httptest.NewRecorder()
+
+	// Act
+	r.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "Here is what I found so far", body["reply"])
+	assert.Equal(t, agent.WarningPartialReply, body["warning"])
+	assert.Equal(t, "search tool failed", body["agent_error"])
+
+	messages, err := history.ListMessages(context.Background(), conversation.Scope{UserID: "user_1"}, "t1")
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "Here is what I found so far", messages[1].Content)
+	assert.True(t, messages[1].Incomplete)
+}
+
+func TestAsk_NonEmptyReplyHasNoWarning(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	h := agent.NewHandler(echoService{}, agent.HandlerOptions{})
+	r := gin.New()
+	r.POST("/ask", h.Ask)
+	req, _ := http.NewRequest("POST", "/ask", bytes.NewBufferString(`{"message":"hi"}`))
+	w := httptest.NewRecorder()
+
+	// Act
+	r.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "warning")
+}
+
+func TestAsk_LegacyAPIVersionShape(t *testing.T) {
+	// Arrange: the agent answers partially and suggests a follow-up
+	gin.SetMode(gin.TestMode)
+	agentServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"reply":"Partly done","error":"tool failed","thread_id":"t1","suggestions":["Retry"]}`))
+	}))
+	defer agentServer.Close()
+	h := agent.NewHandler(agent.NewAgentClient(agentServer.URL, agent.ClientOptions{}), agent.HandlerOptions{})
+	r := gin.New()
+	r.Use(apiversion.Negotiate(nil))
+	r.POST("/ask", h.Ask)
+	ask := func(version, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/ask", bytes.NewBufferString(body))
+		if version != "" {
+			req.Header.Set(apiversion.RequestHeader, version)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// Act
+	latest := ask("", `{"message":"hi","thread_id":"t1"}`)
+	legacy := ask(apiversion.V1, `{"message":"hi","thread_id":"t1"}`)
+	legacyInvalid := ask(apiversion.V1, `{}`)
+
+	// Assert: the latest shape carries the new fields
+	require.Equal(t, http.StatusOK, latest.Code)
+	assert.Equal(t, apiversion.Latest, latest.Header().Get(apiversion.Header))
+	var latestBody map[string]any
+	require.NoError(t, json.Unmarshal(latest.Body.Bytes(), &latestBody))
+	assert.Contains(t, latestBody, "suggestions")
+	assert.Contains(t, latestBody, "warning")
+
+	// Assert: version 1 gets only reply and thread_id
+	require.Equal(t, http.StatusOK, legacy.Code)
+	assert.Equal(t, apiversion.V1, legacy.Header().Get(apiversion.Header))
+	assert.JSONEq(t, `{"reply":"Partly done","thread_id":"t1"}`, legacy.Body.String())
+
+	// Assert: and a single error message instead of per-field errors
+	assert.Equal(t, http.StatusBadRequest, legacyInvalid.Code)
+	assert.JSONEq(t, `{"error":"message: required"}`, legacyInvalid.Body.String())
+}
+
+func TestEmptyReplyFallback(t *testing.T) {
+	assert.True(t, (&agent.Reply{Text: " \t\n"}).Empty())
+	assert.False(t, (&agent.Reply{Text: "ok"}).Empty())
+	assert.Equal(t, agent.DefaultEmptyReplyFallback, agent.EmptyReplyFallback("  "))
+	assert.Equal(t, "custom", agent.EmptyReplyFallback("custom"))
+}
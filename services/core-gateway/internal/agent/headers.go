@@ -0,0 +1,49 @@
+package agent
+
+import (
+	"context"
+	"net/http"
+)
+
+// DefaultForwardHeaders are forwarded to the PM Agent when no allow-list is configured
+var DefaultForwardHeaders = []string{"X-Request-ID", "Traceparent", "Tracestate"}
+
+// neverForward holds credentials that must not reach the agent, even if allow-listed
+var neverForward = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	CallbackSecretHeader:  true,
+}
+
+type forwardedHeadersKey struct{}
+
+// FilterHeaders keeps only the allow-listed headers of in, never credentials
+func FilterHeaders(in http.Header, allow []string) http.Header {
+	out := http.Header{}
+	for _, name := range allow {
+		name = http.CanonicalHeaderKey(name)
+		if neverForward[name] {
+			continue
+		}
+		if values := in.Values(name); len(values) > 0 {
+			out[name] = append([]string(nil), values...)
+		}
+	}
+	return out
+}
+
+// WithForwardedHeaders attaches headers for the client to send with agent calls made with ctx
+func WithForwardedHeaders(ctx context.Context, h http.Header) context.Context {
+	return context.WithValue(ctx, forwardedHeadersKey{}, h)
+}
+
+// setForwardedHeaders copies the headers attached to ctx onto an agent request
+func setForwardedHeaders(ctx context.Context, req *http.Request) {
+	h, _ := ctx.Value(forwardedHeadersKey{}).(http.Header)
+	for name, values := range h {
+		if !neverForward[name] {
+			req.Header[name] = values
+		}
+	}
+}
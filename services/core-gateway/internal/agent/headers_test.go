@@ -0,0 +1,67 @@
+package agent_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/agent"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/ctxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAsk_ForwardsOnlyAllowListedHeaders(t *testing.T) {
+	// Arrange: Authorization is allow-listed by mistake and must still be stripped
+	gin.SetMode(gin.TestMode)
+	var received http.Header
+	agentServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		w.Write([]byte(`{"reply":"Done","thread_id":"t1"}`))
+	}))
+	defer agentServer.Close()
+	allow := []string{"X-Request-ID", "traceparent", "Authorization"}
+	h := agent.NewHandler(agent.NewAgentClient(agentServer.URL, agent.ClientOptions{}), agent.HandlerOptions{
+		ForwardHeaders: func() []string { return allow },
+	})
+
+	r := gin.New()
+	r.POST("/ask", func(c *gin.Context) {
+		ctxutil.SetUserID(c, "user_1")
+		h.Ask(c)
+	})
+	req, _ := http.NewRequest("POST", "/ask", bytes.NewBufferString(`{"message":"hello"}`))
+	req.Header.Set("Authorization", "Bearer user-token")
+	req.Header.Set("Cookie", "session=secret")
+	req.Header.Set("X-Request-ID", "req-123")
+	req.Header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Set("X-Internal-Debug", "1")
+	w := httptest.NewRecorder()
+
+	// Act
+	r.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, received.Get("Authorization"))
+	assert.Empty(t, received.Get("Cookie"))
+	assert.Empty(t, received.Get("X-Internal-Debug"))
+	assert.Equal(t, "req-123", received.Get("X-Request-ID"))
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", received.Get("Traceparent"))
+	assert.Equal(t, "application/json", received.Get("Content-Type"))
+}
+
+func TestFilterHeaders(t *testing.T) {
+	// Arrange
+	in := http.Header{}
+	in.Set("Tracestate", "vendor=1")
+	in.Set("Proxy-Authorization", "Basic xyz")
+
+	// Act
+	out := agent.FilterHeaders(in, []string{"tracestate", "proxy-authorization", "X-Request-ID"})
+
+	// Assert
+	assert.Equal(t, http.Header{"Tracestate": {"vendor=1"}}, out)
+}
@@ -0,0 +1,51 @@
+package agent
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+type agentURLKey struct{}
+
+// WithAgentURL sends agent calls made with ctx to baseURL instead of the
+// configured agent and its failover. baseURL must already be validated
+// (see safeurl.Check); such calls use ClientOptions.UserTransport.
+// "" keeps the default agent.
+func WithAgentURL(ctx context.Context, baseURL string) context.Context {
+	return context.WithValue(ctx, agentURLKey{}, strings.TrimRight(baseURL, "/"))
+}
+
+func agentURLFrom(ctx context.Context) string {
+	u, _ := ctx.Value(agentURLKey{}).(string)
+	return u
+}
+
+// target returns the base URL for a call made with ctx and whether it is a
+// per-user override
+func (c *AgentClient) target(ctx context.Context) (string, bool) {
+	if u := agentURLFrom(ctx); u != "" {
+		return u, true
+	}
+	return c.failover.target(), false
+}
+
+// configuredFor reports whether a call made with ctx has an agent to go to
+func (c *AgentClient) configuredFor(ctx context.Context) bool {
+	return c.pmAgentURL != "" || agentURLFrom(ctx) != ""
+}
+
+// clientFor returns the HTTP client for the default agent or, when
+// overridden, for a user's own agent
+func (c *AgentClient) clientFor(overridden bool) *http.Client {
+	if overridden {
+		return c.userClient
+	}
+	return c.httpClient
+}
+
+// refuseRedirects keeps a user's agent from redirecting the gateway to hosts
+// outside the allow-list; the redirect response is returned as-is
+func refuseRedirects(req *http.Request, via []*http.Request) error {
+	return http.ErrUseLastResponse
+}
@@ -0,0 +1,95 @@
+package agent_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/agent"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/safeurl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgentClient_WithAgentURL(t *testing.T) {
+	// Arrange
+	var defaultCalls atomic.Int32
+	defaultAgent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defaultCalls.Add(1)
+		w.Write([]byte(`{"reply":"from default"}`))
+	}))
+	defer defaultAgent.Close()
+	ownAgent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"reply":"from own agent"}`))
+	}))
+	defer ownAgent.Close()
+	// Test servers listen on loopback, which the default user transport refuses
+	client := agent.NewAgentClient(defaultAgent.URL, agent.ClientOptions{UserTransport: http.DefaultTransport})
+
+	// Act
+	own, err := client.Ask(agent.WithAgentURL(context.Background(), ownAgent.URL+"/"), "hi", "user_1", "")
+	require.NoError(t, err)
+	fallback, err := client.Ask(agent.WithAgentURL(context.Background(), ""), "hi", "user_2", "")
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, "from own agent", own.Text)
+	assert.Equal(t, "from default", fallback.Text)
+	assert.Equal(t, int32(1), defaultCalls.Load())
+}
+
+func TestAgentClient_WithAgentURL_NoFailoverOrRedirects(t *testing.T) {
+	// Arrange: the user's agent is down and tries to bounce the gateway elsewhere
+	var internalCalls, secondaryCalls atomic.Int32
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		internalCalls.Add(1)
+	}))
+	defer internal.Close()
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secondaryCalls.Add(1)
+	}))
+	defer secondary.Close()
+	redirecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, internal.URL+"/ask", http.StatusTemporaryRedirect)
+	}))
+	defer redirecting.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+	client := agent.NewAgentClient(down.URL, agent.ClientOptions{
+		RetryBackoff:  time.Millisecond,
+		SecondaryURL:  secondary.URL,
+		UserTransport: http.DefaultTransport,
+	})
+
+	// Act
+	_, redirectErr := client.Ask(agent.WithAgentURL(context.Background(), redirecting.URL), "hi", "user_1", "")
+	_, downErr := client.Ask(agent.WithAgentURL(context.Background(), down.URL), "hi", "user_1", "")
+
+	// Assert
+	assert.Error(t, redirectErr)
+	assert.Error(t, downErr)
+	assert.Zero(t, internalCalls.Load())
+	assert.Zero(t, secondaryCalls.Load())
+}
+
+func TestAgentClient_WithAgentURL_RefusesInternalAddresses(t *testing.T) {
+	// Arrange: a URL that passed validation but resolves to loopback
+	var calls atomic.Int32
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer internal.Close()
+	client := agent.NewAgentClient("http://pm-agent:8000", agent.ClientOptions{MaxRetries: -1})
+
+	// Act
+	_, err := client.Ask(agent.WithAgentURL(context.Background(), internal.URL), "hi", "user_1", "")
+
+	// Assert
+	assert.ErrorIs(t, err, safeurl.ErrNotAllowed)
+	assert.Zero(t, calls.Load())
+}
@@ -0,0 +1,190 @@
+package agent
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrQueueFull is returned when the worker pool's queue has no room left
+var ErrQueueFull = errors.New("agent queue is full")
+
+// Priority orders queued agent calls; higher runs first
+type Priority int
+
+const (
+	PriorityLow    Priority = 0 // batch jobs
+	PriorityNormal Priority = 1 // default
+	PriorityHigh   Priority = 2 // interactive transports (Telegram)
+)
+
+// DefaultQueueSize bounds the pool's queue when PoolOptions.QueueSize is unset
+const DefaultQueueSize = 100
+
+// ClampPriority limits p to the known range
+func ClampPriority(p int) Priority {
+	switch {
+	case p < int(PriorityLow):
+		return PriorityLow
+	case p > int(PriorityHigh):
+		return PriorityHigh
+	}
+	return Priority(p)
+}
+
+// PoolOptions sizes the worker pool
+type PoolOptions struct {
+	// Workers is the number of concurrent agent calls
+	Workers int
+	// QueueSize bounds waiting calls (default 100); beyond it Do fails with ErrQueueFull
+	QueueSize int
+}
+
+// Pool runs agent calls on a bounded set of workers. Waiting calls are served
+// highest priority first, and in arrival order within a priority.
+type Pool struct {
+	mu        sync.Mutex
+	ready     *sync.Cond
+	queue     jobQueue
+	queueSize int
+	seq       uint64
+	closed    bool
+}
+
+type job struct {
+	priority Priority
+	seq      uint64
+	run      func()
+	done     chan struct{}
+	// cancelled jobs are dropped by the worker instead of run
+	cancelled bool
+}
+
+func NewPool(opts PoolOptions) *Pool {
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = DefaultQueueSize
+	}
+
+	p := &Pool{queueSize: opts.QueueSize}
+	p.ready = sync.NewCond(&p.mu)
+	for i := 0; i < opts.Workers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+// Do queues fn at priority and waits for it to run. It returns ErrQueueFull when
+// the queue is saturated, or ctx's error if ctx ends first (fn then never runs
+// if it had not started yet).
+func (p *Pool) Do(ctx context.Context, priority Priority, fn func()) error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return ErrShuttingDown
+	}
+	if p.queue.Len() >= p.queueSize {
+		p.mu.Unlock()
+		return ErrQueueFull
+	}
+	p.seq++
+	j := &job{priority: ClampPriority(int(priority)), seq: p.seq, run: fn, done: make(chan struct{})}
+	heap.Push(&p.queue, j)
+	p.ready.Signal()
+	p.mu.Unlock()
+
+	select {
+	case <-j.done:
+		return nil
+	case <-ctx.Done():
+		p.mu.Lock()
+		j.cancelled = true
+		p.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Queued reports how many calls are waiting for a worker
+func (p *Pool) Queued() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.queue.Len()
+}
+
+// Close stops the workers once the queue is empty; later calls to Do fail
+func (p *Pool) Close() {
+	p.mu.Lock()
+	p.closed = true
+	p.ready.Broadcast()
+	p.mu.Unlock()
+}
+
+func (p *Pool) work() {
+	for {
+		p.mu.Lock()
+		for p.queue.Len() == 0 && !p.closed {
+			p.ready.Wait()
+		}
+		if p.queue.Len() == 0 {
+			p.mu.Unlock()
+			return
+		}
+		j := heap.Pop(&p.queue).(*job)
+		cancelled := j.cancelled
+		p.mu.Unlock()
+
+		if !cancelled {
+			j.run()
+		}
+		close(j.done)
+	}
+}
+
+// Service wraps svc so every Ask runs on the pool at priority (e.g. the Telegram
+// bot at PriorityHigh). Streams hold their connection open, so they bypass the pool.
+func (p *Pool) Service(svc Service, priority Priority) Service {
+	return &pooledService{Service: svc, pool: p, priority: priority}
+}
+
+type pooledService struct {
+	Service
+	pool     *Pool
+	priority Priority
+}
+
+func (s *pooledService) Ask(ctx context.Context, message string, userID string, threadID string) (*Reply, error) {
+	// The job may still be running when Do gives up on a cancelled ctx, so its
+	// results are only read once Do reports it ran
+	var reply *Reply
+	var err error
+	if perr := s.pool.Do(ctx, s.priority, func() {
+		reply, err = s.Service.Ask(ctx, message, userID, threadID)
+	}); perr != nil {
+		return nil, perr
+	}
+	return reply, err
+}
+
+// jobQueue is a max-heap on priority, FIFO within a priority
+type jobQueue []*job
+
+func (q jobQueue) Len() int { return len(q) }
+func (q jobQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+	return q[i].seq < q[j].seq
+}
+func (q jobQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *jobQueue) Push(x any)   { *q = append(*q, x.(*job)) }
+func (q *jobQueue) Pop() any {
+	old := *q
+	n := len(old)
+	j := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return j
+}
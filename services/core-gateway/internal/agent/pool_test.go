@@ -0,0 +1,187 @@
+package agent_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/agent"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/ctxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPool_ServesHigherPriorityFirst(t *testing.T) {
+	// Arrange: saturate the single worker so every later call has to queue
+	pool := agent.NewPool(agent.PoolOptions{Workers: 1})
+	defer pool.Close()
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go pool.Do(context.Background(), agent.PriorityNormal, func() {
+		close(started)
+		<-release
+	})
+	<-started
+
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	queued := 0
+	submit := func(name string, p agent.Priority) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pool.Do(context.Background(), p, func() {
+				mu.Lock()
+				order = append(order, name)
+				mu.Unlock()
+			})
+		}()
+		// Wait until queued so arrival order is deterministic
+		queued++
+		require.Eventually(t, func() bool { return pool.Queued() == queued }, time.Second, time.Millisecond)
+	}
+
+	// Act: batch work arrives before interactive work
+	submit("low-1", agent.PriorityLow)
+	submit("normal", agent.PriorityNormal)
+	submit("high", agent.PriorityHigh)
+	submit("low-2", agent.PriorityLow)
+	close(release)
+	wg.Wait()
+
+	// Assert
+	assert.Equal(t, []string{"high", "normal", "low-1", "low-2"}, order)
+}
+
+func TestPool_RejectsWhenQueueFull(t *testing.T) {
+	// Arrange
+	pool := agent.NewPool(agent.PoolOptions{Workers: 1, QueueSize: 1})
+	defer pool.Close()
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go pool.Do(context.Background(), agent.PriorityNormal, func() { close(started); <-release })
+	<-started
+	go pool.Do(context.Background(), agent.PriorityNormal, func() {})
+	require.Eventually(t, func() bool { return pool.Queued() == 1 }, time.Second, time.Millisecond)
+
+	// Act
+	err := pool.Do(context.Background(), agent.PriorityHigh, func() {})
+	close(release)
+
+	// Assert
+	assert.ErrorIs(t, err, agent.ErrQueueFull)
+}
+
+// slowService answers once released, ignoring ctx like an agent mid-reply
+type slowService struct {
+	echoService
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s slowService) Ask(ctx context.Context, message, userID, threadID string) (*agent.Reply, error) {
+	close(s.started)
+	<-s.release
+	return echoService{}.Ask(ctx, message, userID, threadID)
+}
+
+// Run with -race: the running call must not write results the caller already returned
+func TestPool_ServiceCancelledWhileRunning(t *testing.T) {
+	// Arrange
+	pool := agent.NewPool(agent.PoolOptions{Workers: 1})
+	defer pool.Close()
+	svc := slowService{started: make(chan struct{}), release: make(chan struct{})}
+	ctx, cancel := context.WithCancel(context.Background())
+	type outcome struct {
+		reply *agent.Reply
+		err   error
+	}
+	result := make(chan outcome, 1)
+	go func() {
+		reply, err := pool.Service(svc, agent.PriorityNormal).Ask(ctx, "hi", "user_1", "")
+		result <- outcome{reply, err}
+	}()
+	<-svc.started
+
+	// Act: the caller gives up just as the agent answers
+	cancel()
+	close(svc.release)
+	got := <-result
+
+	// Assert: either the reply or the cancellation, never a mix of both
+	if got.err != nil {
+		assert.ErrorIs(t, got.err, context.Canceled)
+		assert.Nil(t, got.reply)
+	} else {
+		assert.NotNil(t, got.reply)
+	}
+	require.NoError(t, pool.Do(context.Background(), agent.PriorityNormal, func() {}), "the worker is free again")
+}
+
+func TestClampPriority(t *testing.T) {
+	assert.Equal(t, agent.PriorityLow, agent.ClampPriority(-5))
+	assert.Equal(t, agent.PriorityNormal, agent.ClampPriority(1))
+	assert.Equal(t, agent.PriorityHigh, agent.ClampPriority(99))
+}
+
+// recordingService records the order in which asks reach the agent
+type recordingService struct {
+	echoService
+	mu    sync.Mutex
+	asked []string
+}
+
+func (s *recordingService) Ask(ctx context.Context, message, userID, threadID string) (*agent.Reply, error) {
+	s.mu.Lock()
+	s.asked = append(s.asked, message)
+	s.mu.Unlock()
+	return echoService{}.Ask(ctx, message, userID, threadID)
+}
+
+func TestAsk_PriorityIgnoresRequestBody(t *testing.T) {
+	// Arrange: saturate the single worker so both asks have to queue
+	gin.SetMode(gin.TestMode)
+	pool := agent.NewPool(agent.PoolOptions{Workers: 1})
+	defer pool.Close()
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go pool.Do(context.Background(), agent.PriorityNormal, func() { close(started); <-release })
+	<-started
+
+	svc := &recordingService{}
+	h := agent.NewHandler(svc, agent.HandlerOptions{Pool: pool})
+	r := gin.New()
+	r.POST("/ask", func(c *gin.Context) {
+		if service := c.GetHeader("X-Service"); service != "" {
+			ctxutil.SetService(c, service)
+		}
+		ctxutil.SetUserID(c, "user_1")
+		h.Ask(c)
+	})
+	var wg sync.WaitGroup
+	ask := func(body, service string, queued int) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest("POST", "/ask", bytes.NewBufferString(body))
+			req.Header.Set("X-Service", service)
+			r.ServeHTTP(httptest.NewRecorder(), req)
+		}()
+		require.Eventually(t, func() bool { return pool.Queued() == queued }, time.Second, time.Millisecond)
+	}
+
+	// Act: a batch job claims to be an interactive CLI call
+	ask(`{"message":"batch","source":"cli","priority":2}`, "nightly-report", 1)
+	ask(`{"message":"user"}`, "", 2)
+	close(release)
+	wg.Wait()
+
+	// Assert: the user's ask is served first
+	assert.Equal(t, []string{"user", "batch"}, svc.asked)
+}
@@ -0,0 +1,78 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"strings"
+)
+
+// AgentResponse is the body of the PM Agent's POST /ask reply.
+// Usage and actions are agent-defined; they are kept as raw JSON so their
+// numbers survive unchanged instead of becoming float64.
+type AgentResponse struct {
+	Reply       string          `json:"reply"`
+	ThreadID    string          `json:"thread_id"`
+	Suggestions Suggestions     `json:"suggestions,omitempty"`
+	Error       ErrorMessage    `json:"error,omitempty"`
+	Usage       json.RawMessage `json:"usage,omitempty"`
+	Actions     json.RawMessage `json:"actions,omitempty"`
+}
+
+// Suggestions are follow-up prompts; blank and non-string entries are dropped
+type Suggestions []string
+
+func (s *Suggestions) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil
+	}
+	*s = nil
+	for _, entry := range raw {
+		var text string
+		if json.Unmarshal(entry, &text) == nil && text != "" {
+			*s = append(*s, text)
+		}
+	}
+	return nil
+}
+
+// ErrorMessage is the agent's "error": a string, or an object with a "message"
+type ErrorMessage string
+
+func (e *ErrorMessage) UnmarshalJSON(data []byte) error {
+	var text string
+	if json.Unmarshal(data, &text) == nil {
+		*e = ErrorMessage(strings.TrimSpace(text))
+		return nil
+	}
+	var obj struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(data, &obj) == nil {
+		*e = ErrorMessage(strings.TrimSpace(obj.Message))
+	}
+	return nil
+}
+
+// decodeResponse parses an agent reply. In strict mode a field AgentResponse
+// does not know fails the decode and is logged, to spot agent/gateway drift.
+func decodeResponse(body []byte, strict bool) (*AgentResponse, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	if strict {
+		dec.DisallowUnknownFields()
+	}
+	var resp AgentResponse
+	if err := dec.Decode(&resp); err != nil {
+		if strict && strings.HasPrefix(err.Error(), "json: unknown field") {
+			log.Printf("[Agent] Unexpected field in agent response (strict decoding): %v", err)
+		}
+		return nil, err
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("unexpected data after the JSON object")
+	}
+	return &resp, nil
+}
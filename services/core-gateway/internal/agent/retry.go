@@ -0,0 +1,94 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Retry defaults, used when the corresponding ClientOptions field is zero
+const (
+	DefaultMaxRetries      = 2
+	DefaultRetryBackoff    = 200 * time.Millisecond
+	DefaultRequestDeadline = 2 * time.Minute
+)
+
+// postWithRetry POSTs body to path, retrying transient failures (connection
+// errors, 502/503/504). All attempts share ctx's deadline, so retries stop once
+// the budget is spent instead of each getting a full timeout of its own.
+//
+// With a secondary URL configured, a connection error or a second consecutive
+// 5xx from the primary switches the rest of the request to the secondary.
+// Calls sent to a user's own agent (WithAgentURL) never fail over.
+func (c *AgentClient) postWithRetry(ctx context.Context, path string, body []byte) (*http.Response, error) {
+	backoff := c.opts.RetryBackoff
+	base, overridden := c.target(ctx)
+	primary5xx := 0
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+path, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		setForwardedHeaders(ctx, req)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := c.clientFor(overridden).Do(req)
+		if err == nil && !retryableStatus(resp.StatusCode) {
+			return resp, nil
+		}
+
+		// Final failure: return the same errors callers saw before retries existed
+		var failure error
+		if err != nil {
+			failure = fmt.Errorf("failed to contact PM Agent: %w", err)
+		} else {
+			resp.Body.Close()
+			failure = fmt.Errorf("PM Agent returned error: %d", resp.StatusCode)
+		}
+
+		if !overridden && base == c.failover.primary && ctx.Err() == nil {
+			if err == nil {
+				primary5xx++
+			}
+			if (err != nil || primary5xx >= 2) && c.failover.primaryFailed(failure) {
+				// Switching targets doesn't spend a retry
+				base = c.failover.secondary
+				attempt--
+				continue
+			}
+		}
+		if attempt >= c.opts.MaxRetries || ctx.Err() != nil {
+			return nil, failure
+		}
+
+		remaining := time.Until(deadlineOf(ctx))
+		if remaining <= backoff {
+			log.Printf("[Agent] %s attempt %d failed (%v); %s of budget left, not retrying", path, attempt+1, failure, remaining.Round(time.Millisecond))
+			return nil, failure
+		}
+		log.Printf("[Agent] %s attempt %d failed (%v); retrying in %s, %s of budget left", path, attempt+1, failure, backoff, remaining.Round(time.Millisecond))
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed to contact PM Agent: %w", errors.Join(failure, ctx.Err()))
+		}
+		backoff *= 2
+	}
+}
+
+func retryableStatus(code int) bool {
+	return code == http.StatusBadGateway || code == http.StatusServiceUnavailable || code == http.StatusGatewayTimeout
+}
+
+// deadlineOf returns ctx's deadline, or a far-future time when it has none
+func deadlineOf(ctx context.Context) time.Time {
+	if d, ok := ctx.Deadline(); ok {
+		return d
+	}
+	return time.Now().Add(24 * time.Hour)
+}
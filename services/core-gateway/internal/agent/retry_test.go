@@ -0,0 +1,78 @@
+package agent_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/agent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgentClient_RetriesTransientFailures(t *testing.T) {
+	// Arrange: the agent is unavailable twice, then answers
+	var calls atomic.Int32
+	agentServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"reply":"ok","thread_id":"t1"}`))
+	}))
+	defer agentServer.Close()
+	client := agent.NewAgentClient(agentServer.URL, agent.ClientOptions{RetryBackoff: 10 * time.Millisecond})
+
+	// Act
+	reply, err := client.Ask(context.Background(), "hi", "user_1", "")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "ok", reply.Text)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestAgentClient_RetriesStayWithinDeadline(t *testing.T) {
+	// Arrange: every attempt is slow and then fails, so only the deadline ends the retries
+	agentServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(150 * time.Millisecond):
+			w.WriteHeader(http.StatusGatewayTimeout)
+		case <-r.Context().Done():
+		}
+	}))
+	defer agentServer.Close()
+	deadline := 400 * time.Millisecond
+	client := agent.NewAgentClient(agentServer.URL, agent.ClientOptions{
+		MaxRetries:      10,
+		RetryBackoff:    20 * time.Millisecond,
+		RequestDeadline: deadline,
+	})
+
+	// Act
+	start := time.Now()
+	_, err := client.Ask(context.Background(), "hi", "user_1", "")
+	elapsed := time.Since(start)
+
+	// Assert: 10 retries × 150ms would take >1.5s; the shared deadline caps it
+	require.Error(t, err)
+	assert.Less(t, elapsed, deadline+100*time.Millisecond)
+}
+
+func TestAgentClient_DoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	agentServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer agentServer.Close()
+	client := agent.NewAgentClient(agentServer.URL, agent.ClientOptions{RetryBackoff: time.Millisecond})
+
+	_, err := client.Ask(context.Background(), "hi", "user_1", "")
+
+	assert.EqualError(t, err, "PM Agent returned error: 400")
+	assert.Equal(t, int32(1), calls.Load())
+}
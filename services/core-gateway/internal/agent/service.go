@@ -1,6 +1,91 @@
 package agent
 
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// ErrAgentTimeout is reported when the PM Agent stops sending stream data
+var ErrAgentTimeout = errors.New("agent timed out")
+
+// ErrNotConfigured is returned when no PM Agent URL is set
+var ErrNotConfigured = errors.New("agent not configured")
+
+// Stream event types emitted by the agent
+const (
+	EventToken    = "token"    // part of the final reply
+	EventThinking = "thinking" // intermediate reasoning step
+	EventTool     = "tool"     // tool call / tool progress
+	EventDone     = "done"     // end of stream
+	EventResult   = "result"   // terminal structured object (usage, actions); ends the stream
+)
+
+// StreamEvent is a single event of a streamed agent reply.
+// A non-nil Err is always the last event on the channel.
+type StreamEvent struct {
+	Type string
+	Data string
+	Err  error
+	// Result is set on EventResult
+	Result *StreamResult
+}
+
+// StreamResult is the structured object some agents send after the last token.
+// Usage and actions are agent-defined and passed through as-is.
+type StreamResult struct {
+	ThreadID string          `json:"thread_id,omitempty"`
+	Usage    json.RawMessage `json:"usage,omitempty"`
+	Actions  json.RawMessage `json:"actions,omitempty"`
+}
+
+// Reply is the agent's answer to a single ask
+type Reply struct {
+	Text     string
+	ThreadID string
+	// Suggestions are optional follow-up prompts the user may pick next
+	Suggestions []string
+	// Raw is the agent's unparsed response body, for ?debug=raw (empty if not from HTTP)
+	Raw string
+	// AgentError is the failure the agent reported alongside a partial Text
+	AgentError string
+	// Usage and Actions are the agent's structured extras, passed through as-is
+	Usage   json.RawMessage
+	Actions json.RawMessage
+}
+
+// Partial reports whether the agent failed part-way but still answered
+func (r *Reply) Partial() bool {
+	return r.AgentError != ""
+}
+
+// WarningEmptyReply flags a response whose agent reply was empty or whitespace
+const WarningEmptyReply = "empty_reply"
+
+// WarningPartialReply flags a reply the agent answered despite an error (see agent_error)
+const WarningPartialReply = "partial_reply"
+
+// DefaultEmptyReplyFallback is shown instead of an empty agent reply
+const DefaultEmptyReplyFallback = "The agent returned an empty answer. Please try rephrasing your request."
+
+// Empty reports whether the agent answered with no visible text
+// (e.g. it refused or failed silently)
+func (r *Reply) Empty() bool {
+	return strings.TrimSpace(r.Text) == ""
+}
+
+// EmptyReplyFallback returns fallback, or DefaultEmptyReplyFallback when it is blank
+func EmptyReplyFallback(fallback string) string {
+	if strings.TrimSpace(fallback) == "" {
+		return DefaultEmptyReplyFallback
+	}
+	return fallback
+}
+
 // Service defines the interface for interacting with the PM Agent
 type Service interface {
-	Ask(message string, userID string, threadID string) (response string, newThreadID string, err error)
+	// Ask returns the agent's reply; cancelling ctx aborts the call
+	Ask(ctx context.Context, message string, userID string, threadID string) (*Reply, error)
+	AskStream(ctx context.Context, message string, userID string, threadID string) (<-chan StreamEvent, error)
 }
@@ -0,0 +1,46 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Request sources accepted in AskRequest.Source
+const (
+	SourceAPI      = "api"
+	SourceCLI      = "cli"
+	SourceTelegram = "telegram"
+	SourceWeb      = "web"
+	// SourceBatch marks bulk jobs, queued at low priority
+	SourceBatch = "batch"
+)
+
+// Sources lists every accepted source
+var Sources = []string{SourceAPI, SourceCLI, SourceTelegram, SourceWeb, SourceBatch}
+
+// DefaultSource is assumed when a request names none
+const DefaultSource = SourceAPI
+
+// NormalizeSource lower-cases source and checks it is known. An empty source
+// becomes fallback, or DefaultSource when fallback is empty or unknown.
+func NormalizeSource(source, fallback string) (string, error) {
+	if source = strings.ToLower(strings.TrimSpace(source)); source != "" {
+		if !knownSource(source) {
+			return "", fmt.Errorf("unknown source %q (want one of %s)", source, strings.Join(Sources, ", "))
+		}
+		return source, nil
+	}
+	if fallback = strings.ToLower(strings.TrimSpace(fallback)); knownSource(fallback) {
+		return fallback, nil
+	}
+	return DefaultSource, nil
+}
+
+func knownSource(source string) bool {
+	for _, known := range Sources {
+		if source == known {
+			return true
+		}
+	}
+	return false
+}
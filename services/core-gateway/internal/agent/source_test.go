@@ -0,0 +1,63 @@
+package agent_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/agent"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/ctxutil"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAsk_Source(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name          string
+		body          string
+		defaultSource string
+		status        int
+		counted       string
+	}{
+		{"valid", `{"message":"hi","source":"CLI"}`, "", http.StatusOK, "cli"},
+		{"empty defaults to api", `{"message":"hi"}`, "", http.StatusOK, "api"},
+		{"empty uses the configured default", `{"message":"hi"}`, "web", http.StatusOK, "web"},
+		{"unknown configured default falls back to api", `{"message":"hi"}`, "fax", http.StatusOK, "api"},
+		{"invalid", `{"message":"hi","source":"carrier-pigeon"}`, "", http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			h := agent.NewHandler(echoService{}, agent.HandlerOptions{
+				DefaultSource: func() string { return tt.defaultSource },
+			})
+			r := gin.New()
+			r.POST("/ask", func(c *gin.Context) {
+				ctxutil.SetUserID(c, "user_1")
+				h.Ask(c)
+			})
+			var before float64
+			if tt.counted != "" {
+				before = testutil.ToFloat64(metrics.AgentRequests.WithLabelValues(tt.counted))
+			}
+			req, _ := http.NewRequest(http.MethodPost, "/ask", bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+
+			// Act
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, tt.status, w.Code)
+			if tt.counted != "" {
+				assert.Equal(t, before+1, testutil.ToFloat64(metrics.AgentRequests.WithLabelValues(tt.counted)))
+			} else {
+				assert.Contains(t, w.Body.String(), `unknown source \"carrier-pigeon\"`)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package agent
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// AskStream calls the PM Agent's SSE endpoint (/ask/stream) and forwards each
+// `data:` event on the returned channel. The channel is closed when the agent
+// sends `[DONE]`, the stream ends, or ctx is cancelled. If the agent goes
+// silent for longer than StreamIdleTimeout, a final ErrAgentTimeout event is sent.
+func (c *AgentClient) AskStream(ctx context.Context, message string, userID string, threadID string) (<-chan StreamEvent, error) {
+	if !c.configuredFor(ctx) {
+		return nil, ErrNotConfigured
+	}
+
+	jsonData := askPayload(message, userID, threadID)
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	base, overridden := c.target(ctx)
+	req, err := http.NewRequestWithContext(streamCtx, http.MethodPost, base+"/ask/stream", bytes.NewBuffer(jsonData))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	setForwardedHeaders(ctx, req)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := c.clientFor(overridden).Do(req)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to contact PM Agent: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		cancel()
+		return nil, fmt.Errorf("PM Agent returned error: %d", resp.StatusCode)
+	}
+
+	events := make(chan StreamEvent)
+	go func() {
+		defer close(events)
+		defer cancel()
+		defer resp.Body.Close()
+
+		// Watchdog: cut the stream if the agent stalls
+		var timedOut atomic.Bool
+		idle := time.AfterFunc(c.opts.StreamIdleTimeout, func() {
+			timedOut.Store(true)
+			cancel()
+		})
+		defer idle.Stop()
+
+		send := func(ev StreamEvent) bool {
+			select {
+			case events <- ev:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		var eventName string
+		var data []string
+		// dispatch emits the accumulated SSE event; returns false when the stream should end
+		dispatch := func() bool {
+			defer func() { eventName, data = "", data[:0] }()
+			if len(data) == 0 {
+				return true
+			}
+			ev := parseStreamEvent(eventName, strings.Join(data, "\n"))
+			switch {
+			case ev.Type == EventDone:
+				return false
+			case ev.Type == EventResult && ev.Result == nil:
+				// Never let a broken terminal object leak into the reply text
+				log.Printf("[Agent] Ignoring malformed result event for thread %s", threadID)
+				return true
+			case ev.Type == EventResult:
+				send(ev)
+				return false
+			}
+			return send(ev)
+		}
+
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			idle.Reset(c.opts.StreamIdleTimeout)
+			line := scanner.Text()
+
+			switch {
+			case line == "":
+				// Blank line dispatches the accumulated event
+				if !dispatch() {
+					return
+				}
+			case strings.HasPrefix(line, "event:"):
+				eventName = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+			case strings.HasPrefix(line, "data:"):
+				data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+			}
+		}
+
+		switch {
+		case timedOut.Load():
+			send(StreamEvent{Err: ErrAgentTimeout})
+		case ctx.Err() != nil:
+			// Caller went away; nothing to report
+		case scanner.Err() != nil:
+			send(StreamEvent{Err: fmt.Errorf("agent stream interrupted: %w", scanner.Err())})
+		default:
+			// Stream closed without a trailing blank line
+			dispatch()
+		}
+	}()
+
+	return events, nil
+}
+
+// parseStreamEvent maps an agent SSE event to a typed StreamEvent. Agents may
+// type events via the SSE `event:` field or a JSON payload
+// ({"type": "tool", "content": "..."}); anything else is a plain token.
+//
+// A terminal structured object arrives as `event: result`, as `event: done`
+// with a JSON body, or as {"type": "result", ...}. It yields an EventResult
+// whose Result is nil when the object is malformed.
+func parseStreamEvent(eventName, data string) StreamEvent {
+	if data == "[DONE]" {
+		return StreamEvent{Type: EventDone}
+	}
+	if eventName == EventResult || (eventName == EventDone && strings.HasPrefix(strings.TrimSpace(data), "{")) {
+		return parseResult(data)
+	}
+	if isEventType(eventName) {
+		return StreamEvent{Type: eventName, Data: data}
+	}
+
+	var typed struct {
+		Type    string `json:"type"`
+		Content string `json:"content"`
+	}
+	if strings.HasPrefix(data, "{") && json.Unmarshal([]byte(data), &typed) == nil {
+		if typed.Type == EventResult {
+			return parseResult(data)
+		}
+		if isEventType(typed.Type) {
+			return StreamEvent{Type: typed.Type, Data: typed.Content}
+		}
+	}
+
+	return StreamEvent{Type: EventToken, Data: data}
+}
+
+func parseResult(data string) StreamEvent {
+	var result StreamResult
+	if err := json.Unmarshal([]byte(data), &result); err != nil {
+		return StreamEvent{Type: EventResult}
+	}
+	return StreamEvent{Type: EventResult, Result: &result}
+}
+
+func isEventType(t string) bool {
+	switch t {
+	case EventToken, EventThinking, EventTool, EventDone:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,196 @@
+package agent_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/agent"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/conversation"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/ctxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAskStream_AgentStallsAfterPartialOutput(t *testing.T) {
+	// Arrange: the agent sends two chunks, then hangs until the client gives up
+	agentServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/ask/stream", r.URL.Path)
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: Hello\n\n")
+		fmt.Fprint(w, "data: , wor\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer agentServer.Close()
+
+	client := agent.NewAgentClient(agentServer.URL, agent.ClientOptions{StreamIdleTimeout: 50 * time.Millisecond})
+	history := conversation.NewMemoryRepository()
+	h := agent.NewHandler(client, agent.HandlerOptions{History: history})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/ask/stream", func(c *gin.Context) {
+		ctxutil.SetUserID(c, "user_1")
+		h.AskStream(c)
+	})
+
+	// Act
+	body := bytes.NewBufferString(`{"message":"hi","thread_id":"thread_1"}`)
+	req, _ := http.NewRequest("POST", "/ask/stream", body)
+	w := httptest.NewRecorder()
+
+	start := time.Now()
+	r.ServeHTTP(w, req)
+
+	// Assert: partial output flushed, marker appended, terminal event carries the error
+	assert.Less(t, time.Since(start), 2*time.Second)
+	assert.Equal(t, http.StatusOK, w.Code)
+	out := w.Body.String()
+	assert.Contains(t, out, "event:message\ndata:Hello\n\n")
+	assert.Contains(t, out, "data:, wor\n\n")
+	assert.Contains(t, out, "[truncated: agent timed out]")
+	assert.Contains(t, out, "event:done\ndata:{\"error\":\"agent timed out\",\"thread_id\":\"thread_1\"}\n\n")
+
+	// Assert: partial reply persisted as incomplete
+	messages, _ := history.ListMessages(context.Background(), conversation.Scope{UserID: "user_1"}, "thread_1")
+	require.Len(t, messages, 2)
+	assert.Equal(t, conversation.RoleUser, messages[0].Role)
+	assert.Equal(t, "Hello, wor", messages[1].Content)
+	assert.True(t, messages[1].Incomplete)
+}
+
+func TestAskStream_CompletesNormally(t *testing.T) {
+	agentServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "data: line one\ndata: line two\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer agentServer.Close()
+
+	client := agent.NewAgentClient(agentServer.URL, agent.ClientOptions{})
+
+	events, err := client.AskStream(context.Background(), "hi", "user_1", "thread_1")
+	require.NoError(t, err)
+
+	var chunks []agent.StreamEvent
+	for ev := range events {
+		chunks = append(chunks, ev)
+	}
+
+	require.Len(t, chunks, 1)
+	assert.Equal(t, "line one\nline two", chunks[0].Data)
+	assert.NoError(t, chunks[0].Err)
+}
+
+func TestAskStream_MixedEventTypes(t *testing.T) {
+	// Arrange: JSON-typed events, an SSE-named event, and a plain token
+	agentServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `data: {"type":"thinking","content":"Planning the answer"}`+"\n\n")
+		fmt.Fprint(w, "event: tool\ndata: web_search(\"kospi\")\n\n")
+		fmt.Fprint(w, `data: {"type":"token","content":"KOSPI is "}`+"\n\n")
+		fmt.Fprint(w, "data: up 1%\n\n")
+		fmt.Fprint(w, `data: {"type":"done"}`+"\n\n")
+		fmt.Fprint(w, "data: never forwarded\n\n")
+	}))
+	defer agentServer.Close()
+
+	client := agent.NewAgentClient(agentServer.URL, agent.ClientOptions{})
+	history := conversation.NewMemoryRepository()
+	h := agent.NewHandler(client, agent.HandlerOptions{History: history})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/ask/stream", func(c *gin.Context) {
+		ctxutil.SetUserID(c, "user_1")
+		h.AskStream(c)
+	})
+
+	// Act
+	req, _ := http.NewRequest("POST", "/ask/stream", bytes.NewBufferString(`{"message":"kospi?","thread_id":"thread_1"}`))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	// Assert: each type forwarded as its own SSE event, in order
+	out := w.Body.String()
+	expected := "event:thinking\ndata:Planning the answer\n\n" +
+		"event:tool\ndata:web_search(\"kospi\")\n\n" +
+		"event:message\ndata:KOSPI is \n\n" +
+		"event:message\ndata:up 1%\n\n" +
+		"event:done\ndata:{\"thread_id\":\"thread_1\"}\n\n"
+	assert.Equal(t, expected, out)
+
+	// Only tokens make up the persisted reply
+	messages, _ := history.ListMessages(context.Background(), conversation.Scope{UserID: "user_1"}, "thread_1")
+	require.Len(t, messages, 2)
+	assert.Equal(t, "KOSPI is up 1%", messages[1].Content)
+	assert.False(t, messages[1].Incomplete)
+}
+
+func TestAskStream_TerminalResultObject(t *testing.T) {
+	tests := []struct {
+		name         string
+		terminal     string
+		wantDone     string
+		wantMetadata string
+	}{
+		{
+			name:         "valid object is forwarded on done and persisted",
+			terminal:     "event: result\ndata: {\"thread_id\":\"thread_1\",\"usage\":{\"total_tokens\":42},\"actions\":[{\"type\":\"create_task\"}]}\n\n",
+			wantDone:     `{"actions":[{"type":"create_task"}],"thread_id":"thread_1","usage":{"total_tokens":42}}`,
+			wantMetadata: `{"usage":{"total_tokens":42},"actions":[{"type":"create_task"}]}`,
+		},
+		{
+			name:     "malformed object is dropped without touching the reply",
+			terminal: "event: result\ndata: {\"usage\": {\"total_tokens\": \n\ndata: [DONE]\n\n",
+			wantDone: `{"thread_id":"thread_1"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange: tokens first, then the terminal object
+			agentServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, "data: Task \n\n")
+				fmt.Fprint(w, "data: created\n\n")
+				fmt.Fprint(w, tt.terminal)
+			}))
+			defer agentServer.Close()
+			history := conversation.NewMemoryRepository()
+			h := agent.NewHandler(agent.NewAgentClient(agentServer.URL, agent.ClientOptions{}), agent.HandlerOptions{History: history})
+
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.POST("/ask/stream", func(c *gin.Context) {
+				ctxutil.SetUserID(c, "user_1")
+				h.AskStream(c)
+			})
+
+			// Act
+			req, _ := http.NewRequest("POST", "/ask/stream", bytes.NewBufferString(`{"message":"hi","thread_id":"thread_1"}`))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			// Assert: tokens stream normally and the object only appears on done
+			out := w.Body.String()
+			assert.Contains(t, out, "event:message\ndata:Task \n\n")
+			assert.Contains(t, out, "event:message\ndata:created\n\n")
+			assert.Equal(t, 2, strings.Count(out, "event:message"))
+			assert.Contains(t, out, "event:done\ndata:"+tt.wantDone+"\n\n")
+
+			messages, _ := history.ListMessages(context.Background(), conversation.Scope{UserID: "user_1"}, "thread_1")
+			require.Len(t, messages, 2)
+			assert.Equal(t, "Task created", messages[1].Content)
+			assert.False(t, messages[1].Incomplete)
+			if tt.wantMetadata == "" {
+				assert.Empty(t, messages[1].Metadata)
+			} else {
+				assert.JSONEq(t, tt.wantMetadata, messages[1].Metadata)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/conversation"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/ctxutil"
+)
+
+// summaryPrompt instructs the agent to summarize instead of continuing the conversation
+const summaryPrompt = "You are summarizing a conversation for the user. Reply with a concise TL;DR " +
+	"(at most five bullet points) of the transcript below. Do not answer any questions in it.\n\n"
+
+// Summarize implements conversation.Summarizer on the ask path: the transcript
+// counts toward the caller's budget and usage, goes to their agent with their
+// forwarded headers, and queues at PriorityLow. Each thread is summarized in one
+// agent thread of its own, so the summarized thread's context is left untouched.
+func (h *Handler) Summarize(c *gin.Context, threadID string, messages []conversation.Message) (string, error) {
+	var transcript strings.Builder
+	transcript.WriteString(summaryPrompt)
+	for _, m := range messages {
+		fmt.Fprintf(&transcript, "%s: %s\n", m.Role, m.Content)
+	}
+	prompt := transcript.String()
+
+	UserID, _ := ctxutil.UserID(c)
+	if err := h.checkBudget(c, UserID, prompt); err != nil {
+		return "", &conversation.SummaryError{Status: err.status, Message: err.message}
+	}
+	agentThreadID, askErr := h.agentThreadID(c, threadID)
+	if askErr != nil {
+		return "", &conversation.SummaryError{Status: askErr.status, Message: askErr.message}
+	}
+
+	reply, err := h.callAgent(c, prompt, agentThreadID+"#summary", PriorityLow)
+	if err != nil {
+		return "", &conversation.SummaryError{Status: agentErrorStatus(err), Message: err.Error()}
+	}
+	h.recordUsage(c, UserID, prompt, reply.Text)
+	return reply.Text, nil
+}
@@ -0,0 +1,72 @@
+package agent_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/agent"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/conversation"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/ctxutil"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/usage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarize_UsesAskPath(t *testing.T) {
+	// Arrange: the agent records the thread_id of every call
+	gin.SetMode(gin.TestMode)
+	var agentThreads []string
+	agentServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]string
+		json.NewDecoder(r.Body).Decode(&payload)
+		agentThreads = append(agentThreads, payload["thread_id"])
+		w.Write([]byte(`{"reply":"- a release was planned"}`))
+	}))
+	defer agentServer.Close()
+
+	ctx := context.Background()
+	history := conversation.NewMemoryRepository()
+	save := func(content string) {
+		require.NoError(t, history.SaveMessage(ctx, &conversation.Message{UserID: "user_1", ThreadID: "thread_1", Role: conversation.RoleUser, Content: content}))
+	}
+	save("plan the release")
+	tracker := usage.NewTracker(usage.Budget{DailyChars: 1000}, nil)
+	asks := agent.NewHandler(agent.NewAgentClient(agentServer.URL, agent.ClientOptions{}), agent.HandlerOptions{History: history, Usage: tracker})
+	h := conversation.NewHandler(history, conversation.HandlerOptions{Summarizer: asks})
+	r := gin.New()
+	r.POST("/conversations/:thread_id/summarize", func(c *gin.Context) {
+		ctxutil.SetUserID(c, "user_1")
+		ctxutil.SetRole(c, "user")
+		h.Summarize(c)
+	})
+	summarize := func() int {
+		req, _ := http.NewRequest("POST", "/conversations/thread_1/summarize", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Act
+	first := summarize()
+	save("add a rollback step")
+	second := summarize()
+	save(strings.Repeat("a very long message ", 50))
+	overBudget := summarize()
+
+	// Assert: both summaries share one agent thread apart from the thread's own
+	assert.Equal(t, http.StatusOK, first)
+	assert.Equal(t, http.StatusOK, second)
+	require.Len(t, agentThreads, 2)
+	assert.Equal(t, agentThreads[0], agentThreads[1])
+	assert.True(t, strings.HasSuffix(agentThreads[0], "#summary"), agentThreads[0])
+	assert.Equal(t, "thread_1", agent.GatewayThreadID(agentThreads[0]))
+
+	// The transcripts count toward the caller's budget, which refuses the third
+	assert.Greater(t, tracker.Used("user_1"), 0)
+	assert.Equal(t, http.StatusTooManyRequests, overBudget)
+}
@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/conversation"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/ctxutil"
+)
+
+// AgentThreadID is the thread_id sent to the agent for scope's thread. The agent
+// keys its checkpoints on thread_id alone, while any caller can name any gateway
+// thread ID, so the ID is namespaced as tenant/user/thread: two users asking on
+// the same thread ID never share an agent conversation.
+func AgentThreadID(scope conversation.Scope, threadID string) string {
+	return url.PathEscape(scope.TenantID) + "/" + url.PathEscape(scope.UserID) + "/" + threadID
+}
+
+// AnchoredThreadID is AgentThreadID plus "#" and the thread's ContextAnchor in
+// history, if it has one: every context reset, and a thread ID asked on again
+// after its thread was deleted, gets a fresh agent conversation.
+func AnchoredThreadID(ctx context.Context, history conversation.Repository, scope conversation.Scope, threadID string) (string, error) {
+	agentThreadID := AgentThreadID(scope, threadID)
+	if history == nil || scope.UserID == "" {
+		return agentThreadID, nil
+	}
+	messages, err := history.ListMessages(ctx, scope, threadID)
+	if err != nil {
+		return "", err
+	}
+	if anchor := conversation.ContextAnchor(messages); anchor != "" {
+		agentThreadID += "#" + anchor
+	}
+	return agentThreadID, nil
+}
+
+// GatewayThreadID is the gateway thread of an agent thread ID (see
+// AnchoredThreadID), e.g. one the agent sends back on a callback. IDs that are
+// not namespaced are returned as they are.
+func GatewayThreadID(agentThreadID string) string {
+	threadID := agentThreadID[strings.LastIndex(agentThreadID, "/")+1:]
+	threadID, _, _ = strings.Cut(threadID, "#")
+	return threadID
+}
+
+// agentThreadID is the agent thread c's caller asks threadID on (see AnchoredThreadID)
+func (h *Handler) agentThreadID(c *gin.Context, threadID string) (string, *askError) {
+	agentThreadID, err := AnchoredThreadID(c.Request.Context(), h.opts.History, agentScope(c), threadID)
+	if err != nil {
+		log.Printf("[Agent] Failed to look up the context anchor of thread %s: %v", threadID, err)
+		return "", &askError{status: http.StatusInternalServerError, message: "Failed to load conversation"}
+	}
+	return agentThreadID, nil
+}
+
+// agentScope is whose namespace c's asks use: the caller's tenant and user, or
+// the service name for service tokens, which have no user
+func agentScope(c *gin.Context) conversation.Scope {
+	scope := conversation.ScopeFrom(c)
+	if service, ok := ctxutil.Service(c); ok {
+		scope.UserID = "service:" + service
+	}
+	return scope
+}
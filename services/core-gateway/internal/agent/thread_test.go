@@ -0,0 +1,135 @@
+package agent_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/agent"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/conversation"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/ctxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgentThreadID(t *testing.T) {
+	tests := []struct {
+		name     string
+		scope    conversation.Scope
+		expected string
+	}{
+		{"tenant and user", conversation.Scope{TenantID: "acme", UserID: "user_1"}, "acme/user_1/thread_1"},
+		{"no tenant", conversation.Scope{UserID: "user_1"}, "/user_1/thread_1"},
+		{"separators are escaped", conversation.Scope{TenantID: "a/b", UserID: "c#d"}, "a%2Fb/c%23d/thread_1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			agentThreadID := agent.AgentThreadID(tt.scope, "thread_1")
+
+			// Assert
+			assert.Equal(t, tt.expected, agentThreadID)
+			assert.Equal(t, "thread_1", agent.GatewayThreadID(agentThreadID))
+		})
+	}
+}
+
+func TestGatewayThreadID_NotNamespaced(t *testing.T) {
+	assert.Equal(t, "thread_1", agent.GatewayThreadID("thread_1"))
+}
+
+func TestGatewayThreadID_Anchored(t *testing.T) {
+	assert.Equal(t, "thread_1", agent.GatewayThreadID("acme/user_1/thread_1#2f1c0f9e"))
+}
+
+func TestAsk_ContextResetStartsNewAgentThread(t *testing.T) {
+	// Arrange: the agent records the thread_id of every ask
+	gin.SetMode(gin.TestMode)
+	var agentThreads []string
+	agentServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]string
+		json.NewDecoder(r.Body).Decode(&payload)
+		agentThreads = append(agentThreads, payload["thread_id"])
+		w.Write([]byte(`{"reply":"Done","thread_id":"` + payload["thread_id"] + `"}`))
+	}))
+	defer agentServer.Close()
+
+	ctx := context.Background()
+	scope := conversation.Scope{UserID: "user_1"}
+	history := conversation.NewMemoryRepository()
+	h := agent.NewHandler(agent.NewAgentClient(agentServer.URL, agent.ClientOptions{}), agent.HandlerOptions{History: history})
+	r := gin.New()
+	r.POST("/ask", func(c *gin.Context) {
+		ctxutil.SetUserID(c, "user_1")
+		h.Ask(c)
+	})
+	ask := func(message string) {
+		req, _ := http.NewRequest("POST", "/ask", bytes.NewBufferString(`{"message":"`+message+`","thread_id":"thread_1"}`))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+
+	// Act: two turns, a reset, then one more turn
+	ask("plan the release")
+	ask("add a rollback step")
+	require.NoError(t, history.SaveMessage(ctx, conversation.ResetMarker(scope, "thread_1")))
+	ask("next topic")
+
+	// Assert: the turns before the reset share an agent thread; the reset starts a new one
+	require.Len(t, agentThreads, 3)
+	assert.Equal(t, agentThreads[0], agentThreads[1])
+	assert.NotEqual(t, agentThreads[1], agentThreads[2])
+	for _, agentThreadID := range agentThreads {
+		assert.Equal(t, "thread_1", agent.GatewayThreadID(agentThreadID))
+	}
+
+	// The rotated thread's history is untouched apart from the new turn
+	messages, err := history.ListMessages(ctx, scope, "thread_1")
+	require.NoError(t, err)
+	require.Len(t, messages, 7)
+	assert.Equal(t, "plan the release", messages[0].Content)
+}
+
+func TestAsk_DeletedThreadIDStartsNewAgentThread(t *testing.T) {
+	// Arrange: the agent records the thread_id of every ask
+	gin.SetMode(gin.TestMode)
+	var agentThreads []string
+	agentServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]string
+		json.NewDecoder(r.Body).Decode(&payload)
+		agentThreads = append(agentThreads, payload["thread_id"])
+		w.Write([]byte(`{"reply":"Done","thread_id":"` + payload["thread_id"] + `"}`))
+	}))
+	defer agentServer.Close()
+
+	ctx := context.Background()
+	scope := conversation.Scope{UserID: "user_1"}
+	history := conversation.NewMemoryRepository()
+	h := agent.NewHandler(agent.NewAgentClient(agentServer.URL, agent.ClientOptions{}), agent.HandlerOptions{History: history})
+	r := gin.New()
+	r.POST("/ask", func(c *gin.Context) {
+		ctxutil.SetUserID(c, "user_1")
+		h.Ask(c)
+	})
+	ask := func(message string) {
+		req, _ := http.NewRequest("POST", "/ask", bytes.NewBufferString(`{"message":"`+message+`","thread_id":"thread_1"}`))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+
+	// Act: the thread is deleted, then its ID is asked on again
+	ask("my salary is confidential")
+	require.NoError(t, conversation.DeleteThread(ctx, history.(conversation.Prunable), conversation.OwnedThread{Scope: scope, ThreadID: "thread_1"}))
+	ask("what did I tell you?")
+
+	// Assert: the agent does not resume the deleted conversation
+	require.Len(t, agentThreads, 2)
+	assert.NotEqual(t, agentThreads[0], agentThreads[1])
+}
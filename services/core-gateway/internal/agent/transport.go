@@ -0,0 +1,83 @@
+package agent
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// DefaultConnectTimeout bounds dialing the agent and the TLS handshake, so an
+// unreachable agent fails fast; generation time is bounded separately
+const DefaultConnectTimeout = 5 * time.Second
+
+// TransportOptions configures how the gateway reaches the PM Agent
+type TransportOptions struct {
+	// ProxyURL routes agent traffic through an HTTP proxy. When empty the standard
+	// HTTP_PROXY/HTTPS_PROXY/NO_PROXY env vars apply unless DisableEnvProxy is set.
+	ProxyURL        string
+	DisableEnvProxy bool
+	// InsecureSkipVerify disables TLS verification (internal self-signed agents only)
+	InsecureSkipVerify bool
+	// CAFile is a PEM bundle trusted in addition to the system roots
+	CAFile string
+	// ConnectTimeout bounds the TCP connect and, separately, the TLS handshake
+	// (0 = DefaultConnectTimeout)
+	ConnectTimeout time.Duration
+	// ResponseTimeout bounds the wait for response headers once the request is
+	// sent, i.e. the agent's generation time for /ask (0 = only the request deadline).
+	// Streams send headers before generating, so it doesn't cut long streams.
+	ResponseTimeout time.Duration
+}
+
+// NewTransport builds the HTTP transport for AgentClient from opts
+func NewTransport(opts TransportOptions) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	connectTimeout := opts.ConnectTimeout
+	if connectTimeout <= 0 {
+		connectTimeout = DefaultConnectTimeout
+	}
+	dialer := &net.Dialer{Timeout: connectTimeout, KeepAlive: 30 * time.Second}
+	transport.DialContext = dialer.DialContext
+	transport.TLSHandshakeTimeout = connectTimeout
+	transport.ResponseHeaderTimeout = opts.ResponseTimeout
+
+	switch {
+	case opts.ProxyURL != "":
+		proxyURL, err := url.Parse(opts.ProxyURL)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", opts.ProxyURL)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	case opts.DisableEnvProxy:
+		transport.Proxy = nil
+	default:
+		transport.Proxy = http.ProxyFromEnvironment
+	}
+
+	if opts.InsecureSkipVerify || opts.CAFile != "" {
+		tlsConfig := &tls.Config{InsecureSkipVerify: opts.InsecureSkipVerify}
+		if opts.CAFile != "" {
+			pem, err := os.ReadFile(opts.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read CA file: %w", err)
+			}
+			pool, err := x509.SystemCertPool()
+			if err != nil {
+				pool = x509.NewCertPool()
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in CA file %s", opts.CAFile)
+			}
+			tlsConfig.RootCAs = pool
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	return transport, nil
+}
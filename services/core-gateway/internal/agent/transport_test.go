@@ -0,0 +1,149 @@
+package agent_test
+
+import (
+	"context"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/agent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTransport_ConfiguredProxy(t *testing.T) {
+	// Arrange: the "proxy" answers on behalf of an unreachable agent host
+	var proxiedURL string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxiedURL = r.URL.String()
+		w.Write([]byte(`{"reply":"via proxy","thread_id":"t1"}`))
+	}))
+	defer proxy.Close()
+
+	transport, err := agent.NewTransport(agent.TransportOptions{ProxyURL: proxy.URL})
+	require.NoError(t, err)
+	client := agent.NewAgentClient("http://pm-agent.internal:8000", agent.ClientOptions{Transport: transport, MaxRetries: -1})
+
+	// Act
+	reply, err := client.Ask(context.Background(), "hi", "user_1", "")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "via proxy", reply.Text)
+	assert.Equal(t, "http://pm-agent.internal:8000/ask", proxiedURL)
+}
+
+func TestNewTransport_ProxySelection(t *testing.T) {
+	req, _ := http.NewRequest("GET", "http://pm-agent.internal:8000/ask", nil)
+
+	disabled, err := agent.NewTransport(agent.TransportOptions{DisableEnvProxy: true})
+	require.NoError(t, err)
+	assert.Nil(t, disabled.Proxy, "No proxy when env proxies are disabled")
+
+	configured, err := agent.NewTransport(agent.TransportOptions{ProxyURL: "http://proxy.corp:3128", DisableEnvProxy: true})
+	require.NoError(t, err)
+	proxyURL, err := configured.Proxy(req)
+	require.NoError(t, err)
+	assert.Equal(t, "http://proxy.corp:3128", proxyURL.String(), "An explicit proxy wins over the env setting")
+
+	_, err = agent.NewTransport(agent.TransportOptions{ProxyURL: "::not a url"})
+	assert.Error(t, err)
+}
+
+func TestNewTransport_CustomCA(t *testing.T) {
+	// Arrange: a TLS agent with a self-signed cert, trusted through CAFile
+	agentServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"reply":"secure","thread_id":"t1"}`))
+	}))
+	defer agentServer.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: agentServer.Certificate().Raw})
+	require.NoError(t, os.WriteFile(caFile, certPEM, 0600))
+
+	untrusted, _ := agent.NewTransport(agent.TransportOptions{DisableEnvProxy: true})
+	trusted, err := agent.NewTransport(agent.TransportOptions{DisableEnvProxy: true, CAFile: caFile})
+	require.NoError(t, err)
+
+	// Act
+	_, untrustedErr := agent.NewAgentClient(agentServer.URL, agent.ClientOptions{Transport: untrusted, MaxRetries: -1}).Ask(context.Background(), "hi", "user_1", "")
+	reply, trustedErr := agent.NewAgentClient(agentServer.URL, agent.ClientOptions{Transport: trusted, MaxRetries: -1}).Ask(context.Background(), "hi", "user_1", "")
+
+	// Assert
+	assert.Error(t, untrustedErr, "Self-signed certs are rejected by default")
+	require.NoError(t, trustedErr)
+	assert.Equal(t, "secure", reply.Text)
+}
+
+func TestNewTransport_InvalidCAFile(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, []byte("not a cert"), 0600))
+
+	_, err := agent.NewTransport(agent.TransportOptions{CAFile: caFile})
+
+	assert.ErrorContains(t, err, "no certificates")
+}
+
+func TestNewTransport_ConnectTimeoutFailsFast(t *testing.T) {
+	// Arrange: the "agent" accepts connections but never completes a TLS handshake
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	go func() {
+		var conns []net.Conn
+		defer func() {
+			for _, conn := range conns {
+				conn.Close()
+			}
+		}()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conns = append(conns, conn)
+		}
+	}()
+	transport, err := agent.NewTransport(agent.TransportOptions{ConnectTimeout: 50 * time.Millisecond})
+	require.NoError(t, err)
+	client := agent.NewAgentClient("https://"+ln.Addr().String(), agent.ClientOptions{Transport: transport, MaxRetries: -1})
+
+	// Act
+	start := time.Now()
+	_, err = client.Ask(context.Background(), "hi", "user_1", "")
+
+	// Assert: well before the request deadline
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "TLS handshake timeout")
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+func TestNewTransport_ResponseTimeout(t *testing.T) {
+	// Arrange: generation takes 150ms
+	agentServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(150 * time.Millisecond)
+		w.Write([]byte(`{"reply":"done","thread_id":"t1"}`))
+	}))
+	defer agentServer.Close()
+	ask := func(opts agent.TransportOptions) error {
+		transport, err := agent.NewTransport(opts)
+		require.NoError(t, err)
+		client := agent.NewAgentClient(agentServer.URL, agent.ClientOptions{Transport: transport, MaxRetries: -1})
+		_, err = client.Ask(context.Background(), "hi", "user_1", "")
+		return err
+	}
+
+	// Act
+	patient := ask(agent.TransportOptions{ConnectTimeout: 20 * time.Millisecond, ResponseTimeout: time.Second})
+	impatient := ask(agent.TransportOptions{ResponseTimeout: 30 * time.Millisecond})
+
+	// Assert: a short connect timeout doesn't cut a slow generation, a short response timeout does
+	assert.NoError(t, patient)
+	require.Error(t, impatient)
+	assert.Contains(t, impatient.Error(), "timeout awaiting response headers")
+}
@@ -0,0 +1,171 @@
+package agent
+
+import (
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/conversation"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/httperr"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/middleware"
+)
+
+// DefaultWebSocketReadLimit caps one frame when no body limit is configured
+const DefaultWebSocketReadLimit = 1 << 20
+
+// WebSocketHandler serves the interactive ask endpoint over a WebSocket
+type WebSocketHandler struct {
+	asks     *Handler
+	opts     WebSocketOptions
+	upgrader websocket.Upgrader
+
+	// clients are the open connections per user, for pushing callback results
+	mu      sync.Mutex
+	clients map[conversation.Scope]map[*wsClient]struct{}
+}
+
+// wsClient serializes writes: replies and pushed results may race
+type wsClient struct {
+	mu   sync.Mutex
+	conn *websocket.Conn
+}
+
+func (w *wsClient) writeJSON(v any) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.conn.WriteJSON(v)
+}
+
+// WebSocketOptions configures the WebSocket handler. Both are read on every
+// upgrade so config reloads apply without a restart.
+type WebSocketOptions struct {
+	// AllowedOrigins are the browser origins accepted for upgrades ("*" allows
+	// any), to prevent cross-site WebSocket hijacking
+	AllowedOrigins func() []string
+	// ReadLimit caps one frame in bytes, as BodyLimit does not see frames
+	// (nil or 0 = DefaultWebSocketReadLimit)
+	ReadLimit func() int64
+}
+
+// NewWebSocketHandler creates the handler. Each frame is answered through asks,
+// so budgets, thread caps, history and usage apply as they do to POST /ask.
+func NewWebSocketHandler(asks *Handler, opts WebSocketOptions) *WebSocketHandler {
+	return &WebSocketHandler{
+		asks: asks,
+		opts: opts,
+		upgrader: websocket.Upgrader{
+			// Origin is validated in Serve before upgrading
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+		clients: map[conversation.Scope]map[*wsClient]struct{}{},
+	}
+}
+
+// Notify pushes an async agent result to the user's open connections
+func (h *WebSocketHandler) Notify(result CallbackResult) bool {
+	scope := conversation.Scope{TenantID: result.TenantID, UserID: result.UserID}
+	h.mu.Lock()
+	clients := make([]*wsClient, 0, len(h.clients[scope]))
+	for client := range h.clients[scope] {
+		clients = append(clients, client)
+	}
+	h.mu.Unlock()
+
+	delivered := false
+	for _, client := range clients {
+		if err := client.writeJSON(wsResponse{Reply: result.Reply, ThreadID: result.ThreadID, Suggestions: result.Suggestions}); err == nil {
+			delivered = true
+		}
+	}
+	return delivered
+}
+
+func (h *WebSocketHandler) register(scope conversation.Scope, client *wsClient) (unregister func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.clients[scope] == nil {
+		h.clients[scope] = map[*wsClient]struct{}{}
+	}
+	h.clients[scope][client] = struct{}{}
+
+	return func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.clients[scope], client)
+		if len(h.clients[scope]) == 0 {
+			delete(h.clients, scope)
+		}
+	}
+}
+
+type wsResponse struct {
+	Reply       string   `json:"reply,omitempty"`
+	ThreadID    string   `json:"thread_id,omitempty"`
+	Suggestions []string `json:"suggestions,omitempty"`
+	Warning     string   `json:"warning,omitempty"`
+	AgentError  string   `json:"agent_error,omitempty"`
+	Error       string   `json:"error,omitempty"`
+}
+
+func (h *WebSocketHandler) readLimit() int64 {
+	if h.opts.ReadLimit != nil {
+		if limit := h.opts.ReadLimit(); limit > 0 {
+			return limit
+		}
+	}
+	return DefaultWebSocketReadLimit
+}
+
+// Serve upgrades the connection and answers each {"message", "thread_id"} frame
+func (h *WebSocketHandler) Serve(c *gin.Context) {
+	// Non-browser clients don't send Origin; browsers always do
+	if origin := c.GetHeader("Origin"); origin != "" && !middleware.OriginAllowed(origin, h.opts.AllowedOrigins()) {
+		log.Printf("[WebSocket] Rejected upgrade from origin %q", origin)
+		httperr.Abort(c, http.StatusForbidden, "Origin not allowed")
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("[WebSocket] Upgrade failed: %v", err)
+		return
+	}
+	defer conn.Close()
+	conn.SetReadLimit(h.readLimit())
+
+	client := &wsClient{conn: conn}
+	defer h.register(conversation.ScopeFrom(c), client)()
+	for {
+		var req AskRequest
+		if err := conn.ReadJSON(&req); err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Printf("[WebSocket] Read failed: %v", err)
+			}
+			return
+		}
+		if req.Message == "" {
+			client.writeJSON(wsResponse{Error: "message is required"})
+			continue
+		}
+		if err := conversation.ValidateThreadID(req.ThreadID); err != nil {
+			client.writeJSON(wsResponse{Error: err.Error()})
+			continue
+		}
+
+		result, askErr := h.asks.answer(c, req.Message, req.ThreadID, PriorityNormal, nil)
+		if askErr != nil {
+			client.writeJSON(wsResponse{ThreadID: req.ThreadID, Error: askErr.message})
+			continue
+		}
+		reply := result.reply
+		resp := wsResponse{Reply: result.text, ThreadID: reply.ThreadID, Suggestions: reply.Suggestions, Warning: result.warning}
+		if reply.Partial() {
+			resp.AgentError = reply.AgentError
+		}
+		if err := client.writeJSON(resp); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package agent_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/agent"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/conversation"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/ctxutil"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/usage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type echoService struct{}
+
+func (echoService) Ask(ctx context.Context, message, userID, threadID string) (*agent.Reply, error) {
+	return &agent.Reply{Text: "echo: " + message, ThreadID: "thread_1"}, nil
+}
+
+func (echoService) AskStream(ctx context.Context, message, userID, threadID string) (<-chan agent.StreamEvent, error) {
+	events := make(chan agent.StreamEvent, 1)
+	events <- agent.StreamEvent{Type: agent.EventToken, Data: "echo: " + message}
+	close(events)
+	return events, nil
+}
+
+func newWebSocketServer(allowedOrigins []string) *httptest.Server {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	ws := agent.NewWebSocketHandler(agent.NewHandler(echoService{}, agent.HandlerOptions{}), agent.WebSocketOptions{
+		AllowedOrigins: func() []string { return allowedOrigins },
+	})
+	r.GET("/ws", ws.Serve)
+	return httptest.NewServer(r)
+}
+
+func TestWebSocket_OriginCheck(t *testing.T) {
+	server := newWebSocketServer([]string{"https://app.woorung.dev"})
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+
+	t.Run("allowed origin upgrades", func(t *testing.T) {
+		header := http.Header{"Origin": {"https://app.woorung.dev"}}
+		conn, resp, err := websocket.DefaultDialer.Dial(wsURL, header)
+		require.NoError(t, err)
+		defer conn.Close()
+		assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+
+		require.NoError(t, conn.WriteJSON(map[string]string{"message": "hi", "thread_id": "thread_1"}))
+		var reply map[string]string
+		require.NoError(t, conn.ReadJSON(&reply))
+		assert.Equal(t, "echo: hi", reply["reply"])
+		assert.Equal(t, "thread_1", reply["thread_id"])
+	})
+
+	t.Run("disallowed origin is rejected before upgrade", func(t *testing.T) {
+		header := http.Header{"Origin": {"https://evil.example.com"}}
+		_, resp, err := websocket.DefaultDialer.Dial(wsURL, header)
+		require.ErrorIs(t, err, websocket.ErrBadHandshake)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("non-browser client without origin upgrades", func(t *testing.T) {
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		require.NoError(t, err)
+		conn.Close()
+	})
+}
+
+func TestWebSocket_EmptyAllowListRejectsBrowsers(t *testing.T) {
+	server := newWebSocketServer(nil)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+
+	_, resp, err := websocket.DefaultDialer.Dial(wsURL, http.Header{"Origin": {"http://localhost:3000"}})
+	require.Error(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func TestWebSocket_SharesAskRules(t *testing.T) {
+	// Arrange: echoService replies "echo: <msg>", so "hello" costs 5 + 11 = 16 chars
+	gin.SetMode(gin.TestMode)
+	tracker := usage.NewTracker(usage.Budget{DailyChars: 20}, nil)
+	history := conversation.NewMemoryRepository()
+	ws := agent.NewWebSocketHandler(agent.NewHandler(echoService{}, agent.HandlerOptions{Usage: tracker, History: history}), agent.WebSocketOptions{
+		AllowedOrigins: func() []string { return nil },
+	})
+	r := gin.New()
+	r.GET("/ws", func(c *gin.Context) {
+		ctxutil.SetUserID(c, "user_1")
+		ctxutil.SetRole(c, "user")
+		ws.Serve(c)
+	})
+	server := httptest.NewServer(r)
+	defer server.Close()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ask := func() map[string]string {
+		require.NoError(t, conn.WriteJSON(map[string]string{"message": "hello", "thread_id": "thread_1"}))
+		var reply map[string]string
+		require.NoError(t, conn.ReadJSON(&reply))
+		return reply
+	}
+
+	// Act
+	first := ask()
+	second := ask()
+
+	// Assert: the frame was recorded like POST /ask, and the budget now refuses the next one
+	assert.Equal(t, "echo: hello", first["reply"])
+	assert.Equal(t, 16, tracker.Used("user_1"))
+	messages, err := history.ListMessages(context.Background(), conversation.Scope{UserID: "user_1"}, "thread_1")
+	require.NoError(t, err)
+	assert.Len(t, messages, 2)
+	assert.Empty(t, second["reply"])
+	assert.Contains(t, second["error"], "budget")
+}
+
+func TestWebSocket_ReadLimit(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	ws := agent.NewWebSocketHandler(agent.NewHandler(echoService{}, agent.HandlerOptions{}), agent.WebSocketOptions{
+		AllowedOrigins: func() []string { return nil },
+		ReadLimit:      func() int64 { return 64 },
+	})
+	r := gin.New()
+	r.GET("/ws", ws.Serve)
+	server := httptest.NewServer(r)
+	defer server.Close()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// Act
+	require.NoError(t, conn.WriteJSON(map[string]string{"message": strings.Repeat("a", 100)}))
+	_, _, err = conn.ReadMessage()
+
+	// Assert: the oversized frame closes the connection instead of reaching the agent
+	var closeErr *websocket.CloseError
+	require.ErrorAs(t, err, &closeErr)
+	assert.Equal(t, websocket.CloseMessageTooBig, closeErr.Code)
+}
@@ -0,0 +1,159 @@
+// Package agenturl lets users send their requests to their own agent instance
+// ("bring your own agent") instead of the gateway's PM Agent.
+//
+// URLs are checked with safeurl against the operator's allow-list when set
+// and again on every use, so the gateway can't be pointed at internal
+// services (SSRF) even after the allow-list shrinks.
+package agenturl
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/safeurl"
+)
+
+var (
+	// ErrNotSet is returned when a user has no custom agent URL
+	ErrNotSet = errors.New("no custom agent URL set")
+	// ErrDisabled is returned when the allow-list is empty
+	ErrDisabled = errors.New("custom agent URLs are disabled")
+	// ErrNotAllowed is returned for URLs safeurl refuses
+	ErrNotAllowed = safeurl.ErrNotAllowed
+)
+
+// Setting is a user's custom agent URL (table: user_agent_urls)
+type Setting struct {
+	UserID    string    `gorm:"primaryKey"`
+	URL       string    `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null"`
+}
+
+func (Setting) TableName() string { return "user_agent_urls" }
+
+// Store persists custom agent URLs
+type Store interface {
+	// Get returns ErrNotSet when userID has no setting
+	Get(ctx context.Context, userID string) (*Setting, error)
+	// Save creates or replaces the setting of s.UserID
+	Save(ctx context.Context, s *Setting) error
+	// Delete is a no-op when userID has no setting
+	Delete(ctx context.Context, userID string) error
+}
+
+// Validate checks raw with safeurl.Check and returns it normalized (no
+// trailing slash). An empty allow-list disables custom URLs.
+func Validate(raw string, allowed []string) (string, error) {
+	if len(allowed) == 0 {
+		return "", ErrDisabled
+	}
+	u, err := safeurl.Check(raw, allowed)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(u.String(), "/"), nil
+}
+
+// Options configures a Service
+type Options struct {
+	// Allowed returns the allow-list (see Validate); read on every call so reloads apply
+	Allowed func() []string
+	Now     func() time.Time
+}
+
+// Service validates, stores and resolves custom agent URLs
+type Service struct {
+	store Store
+	opts  Options
+}
+
+func NewService(store Store, opts Options) *Service {
+	if opts.Allowed == nil {
+		opts.Allowed = func() []string { return nil }
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &Service{store: store, opts: opts}
+}
+
+// Set validates rawURL and stores it as userID's agent URL
+func (s *Service) Set(ctx context.Context, userID, rawURL string) (*Setting, error) {
+	normalized, err := Validate(rawURL, s.opts.Allowed())
+	if err != nil {
+		return nil, err
+	}
+	setting := &Setting{UserID: userID, URL: normalized, UpdatedAt: s.opts.Now().UTC()}
+	if err := s.store.Save(ctx, setting); err != nil {
+		return nil, err
+	}
+	return setting, nil
+}
+
+// Get returns userID's setting, or ErrNotSet
+func (s *Service) Get(ctx context.Context, userID string) (*Setting, error) {
+	return s.store.Get(ctx, userID)
+}
+
+// Clear removes userID's setting, returning them to the default agent
+func (s *Service) Clear(ctx context.Context, userID string) error {
+	return s.store.Delete(ctx, userID)
+}
+
+// URL returns the agent URL to use for userID, or "" for the default agent.
+// A stored URL the current allow-list no longer permits is ignored.
+func (s *Service) URL(ctx context.Context, userID string) string {
+	if s == nil || userID == "" {
+		return ""
+	}
+	setting, err := s.store.Get(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, ErrNotSet) {
+			log.Printf("[AgentURL] Lookup for %s failed, using the default agent: %v", userID, err)
+		}
+		return ""
+	}
+	normalized, err := Validate(setting.URL, s.opts.Allowed())
+	if err != nil {
+		return ""
+	}
+	return normalized
+}
+
+type memoryStore struct {
+	mu       sync.Mutex
+	settings map[string]Setting
+}
+
+// NewMemoryStore returns a process-local Store (local dev and tests)
+func NewMemoryStore() Store {
+	return &memoryStore{settings: map[string]Setting{}}
+}
+
+func (s *memoryStore) Get(ctx context.Context, userID string) (*Setting, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	setting, ok := s.settings[userID]
+	if !ok {
+		return nil, ErrNotSet
+	}
+	return &setting, nil
+}
+
+func (s *memoryStore) Save(ctx context.Context, setting *Setting) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settings[setting.UserID] = *setting
+	return nil
+}
+
+func (s *memoryStore) Delete(ctx context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.settings, userID)
+	return nil
+}
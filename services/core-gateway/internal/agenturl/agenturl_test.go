@@ -0,0 +1,113 @@
+package agenturl_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/agenturl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var allowList = []string{"https://agents.example.com", "https://*.byo.example.com", "http://plain.example.com:9000", "http://127.0.0.1:8000"}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"exact origin", "https://agents.example.com/", "https://agents.example.com"},
+		{"path is kept", "https://agents.example.com/team-a", "https://agents.example.com/team-a"},
+		{"wildcard subdomain", "https://alice.byo.example.com", "https://alice.byo.example.com"},
+		{"host is case-insensitive", "https://Agents.Example.com", "https://Agents.Example.com"},
+		{"explicit port", "http://plain.example.com:9000", "http://plain.example.com:9000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := agenturl.Validate(tt.raw, allowList)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestValidate_RejectsSSRF(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"cloud metadata", "http://169.254.169.254/latest/meta-data"},
+		{"loopback", "http://127.0.0.1:8080"},
+		{"internal service", "http://pm-agent.internal:8000"},
+		{"scheme downgrade", "http://agents.example.com"},
+		{"other port", "https://agents.example.com:8443"},
+		{"port on allowed host", "http://plain.example.com:22"},
+		{"allow-listed loopback", "http://127.0.0.1:8000"},
+		{"wildcard apex", "https://byo.example.com"},
+		{"suffix lookalike", "https://evilbyo.example.com"},
+		{"allowed name as subdomain", "https://agents.example.com.evil.net"},
+		{"credentials", "https://admin@agents.example.com"},
+		{"query", "https://agents.example.com?next=http://127.0.0.1"},
+		{"not a URL", "agents.example.com"},
+		{"other scheme", "file:///etc/passwd"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := agenturl.Validate(tt.raw, allowList)
+
+			assert.ErrorIs(t, err, agenturl.ErrNotAllowed)
+		})
+	}
+}
+
+func TestValidate_EmptyAllowListDisables(t *testing.T) {
+	_, err := agenturl.Validate("https://agents.example.com", nil)
+
+	assert.ErrorIs(t, err, agenturl.ErrDisabled)
+}
+
+func TestService_URL(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	allowed := allowList
+	urls := agenturl.NewService(agenturl.NewMemoryStore(), agenturl.Options{
+		Allowed: func() []string { return allowed },
+	})
+	_, err := urls.Set(ctx, "user_1", "https://alice.byo.example.com/")
+	require.NoError(t, err)
+
+	// Act & Assert: the override applies to its user only
+	assert.Equal(t, "https://alice.byo.example.com", urls.URL(ctx, "user_1"))
+	assert.Empty(t, urls.URL(ctx, "user_2"))
+
+	// Act & Assert: a stored URL the allow-list no longer covers falls back to the default
+	allowed = []string{"https://agents.example.com"}
+	assert.Empty(t, urls.URL(ctx, "user_1"))
+
+	// Act & Assert: cleared URLs fall back too
+	allowed = allowList
+	require.NoError(t, urls.Clear(ctx, "user_1"))
+	assert.Empty(t, urls.URL(ctx, "user_1"))
+
+	// A nil service (no database) always uses the default agent
+	var none *agenturl.Service
+	assert.Empty(t, none.URL(ctx, "user_1"))
+}
+
+func TestService_SetRejectsDisallowed(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	urls := agenturl.NewService(agenturl.NewMemoryStore(), agenturl.Options{
+		Allowed: func() []string { return allowList },
+	})
+
+	// Act
+	_, err := urls.Set(ctx, "user_1", "http://169.254.169.254")
+
+	// Assert
+	assert.ErrorIs(t, err, agenturl.ErrNotAllowed)
+	_, err = urls.Get(ctx, "user_1")
+	assert.ErrorIs(t, err, agenturl.ErrNotSet)
+}
@@ -0,0 +1,93 @@
+package agenturl
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/ctxutil"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/httperr"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/validation"
+)
+
+// Handler serves the agent URL of the caller (/api/v1/me/agent-url) or, on
+// admin routes with a :user_id parameter, of that user
+type Handler struct {
+	urls *Service
+}
+
+func NewHandler(urls *Service) *Handler {
+	return &Handler{urls: urls}
+}
+
+type SetRequest struct {
+	URL string `json:"url" binding:"required"`
+}
+
+// Get returns the custom agent URL, 404 when the default agent is used
+func (h *Handler) Get(c *gin.Context) {
+	setting, err := h.urls.Get(c.Request.Context(), targetUser(c))
+	if errors.Is(err, ErrNotSet) {
+		httperr.Respond(c, http.StatusNotFound, "No custom agent URL set")
+		return
+	}
+	if err != nil {
+		httperr.Respond(c, http.StatusInternalServerError, "Failed to look up agent URL")
+		return
+	}
+	c.JSON(http.StatusOK, settingJSON(setting))
+}
+
+// Set validates and stores a custom agent URL
+func (h *Handler) Set(c *gin.Context) {
+	// An impersonating admin must not redirect the user's traffic unnoticed;
+	// admins use the admin route instead
+	if _, ok := ctxutil.ImpersonatedBy(c); ok {
+		httperr.Respond(c, http.StatusForbidden, "Agent URLs cannot be changed while impersonating")
+		return
+	}
+	var req SetRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	userID := targetUser(c)
+	setting, err := h.urls.Set(c.Request.Context(), userID, req.URL)
+	switch {
+	case errors.Is(err, ErrDisabled):
+		httperr.Respond(c, http.StatusForbidden, "Custom agent URLs are disabled")
+		return
+	case errors.Is(err, ErrNotAllowed):
+		httperr.Respond(c, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		log.Printf("[AgentURL] Failed to save URL for %s: %v", userID, err)
+		httperr.Respond(c, http.StatusInternalServerError, "Failed to save agent URL")
+		return
+	}
+	c.JSON(http.StatusOK, settingJSON(setting))
+}
+
+// Clear returns the user to the default agent
+func (h *Handler) Clear(c *gin.Context) {
+	if err := h.urls.Clear(c.Request.Context(), targetUser(c)); err != nil {
+		httperr.Respond(c, http.StatusInternalServerError, "Failed to clear agent URL")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// targetUser is the :user_id of admin routes, else the caller
+func targetUser(c *gin.Context) string {
+	if userID := c.Param("user_id"); userID != "" {
+		return userID
+	}
+	userID, _ := ctxutil.UserID(c)
+	return userID
+}
+
+func settingJSON(s *Setting) gin.H {
+	return gin.H{"user_id": s.UserID, "url": s.URL, "updated_at": s.UpdatedAt.Format(time.RFC3339)}
+}
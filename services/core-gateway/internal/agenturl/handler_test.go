@@ -0,0 +1,63 @@
+package agenturl_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/agenturl"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/ctxutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	urls := agenturl.NewService(agenturl.NewMemoryStore(), agenturl.Options{
+		Allowed: func() []string { return allowList },
+	})
+	h := agenturl.NewHandler(urls)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		ctxutil.SetUserID(c, "user_1")
+		if admin := c.GetHeader("X-Impersonator"); admin != "" {
+			ctxutil.SetImpersonatedBy(c, admin)
+		}
+	})
+	r.GET("/me/agent-url", h.Get)
+	r.PUT("/me/agent-url", h.Set)
+	r.DELETE("/me/agent-url", h.Clear)
+	r.PUT("/admin/users/:user_id/agent-url", h.Set)
+	do := func(method, path, body, impersonator string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if impersonator != "" {
+			req.Header.Set("X-Impersonator", impersonator)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// Act
+	unset := do(http.MethodGet, "/me/agent-url", "", "")
+	ssrf := do(http.MethodPut, "/me/agent-url", `{"url":"http://169.254.169.254"}`, "")
+	impersonated := do(http.MethodPut, "/me/agent-url", `{"url":"https://agents.example.com"}`, "admin_1")
+	set := do(http.MethodPut, "/me/agent-url", `{"url":"https://agents.example.com"}`, "")
+	got := do(http.MethodGet, "/me/agent-url", "", "")
+	admin := do(http.MethodPut, "/admin/users/user_2/agent-url", `{"url":"https://bob.byo.example.com"}`, "")
+	cleared := do(http.MethodDelete, "/me/agent-url", "", "")
+
+	// Assert
+	assert.Equal(t, http.StatusNotFound, unset.Code)
+	assert.Equal(t, http.StatusBadRequest, ssrf.Code)
+	assert.Equal(t, http.StatusForbidden, impersonated.Code)
+	assert.Equal(t, http.StatusOK, set.Code)
+	assert.Contains(t, got.Body.String(), `"url":"https://agents.example.com"`)
+	assert.Equal(t, http.StatusOK, admin.Code)
+	assert.Contains(t, admin.Body.String(), `"user_id":"user_2"`)
+	assert.Equal(t, http.StatusNoContent, cleared.Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/me/agent-url", "", "").Code)
+}
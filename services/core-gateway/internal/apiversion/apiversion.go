@@ -0,0 +1,84 @@
+// Package apiversion lets clients pick a response shape while it changes.
+// Clients send Accept-Version; every response says which version it uses in
+// X-Woorung-API-Version. Handlers check Legacy to serve the older shape.
+package apiversion
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/httperr"
+)
+
+// Header names
+const (
+	// Header reports the version a response was served with
+	Header = "X-Woorung-API-Version"
+	// RequestHeader asks for a version (missing = the configured default)
+	RequestHeader = "Accept-Version"
+)
+
+// Versions, oldest first
+const (
+	// V1 is the original shape: /ask returns only reply and thread_id, and
+	// validation errors are a single {"error": "..."} message
+	V1 = "1"
+	// V2 adds suggestions, warning and agent_error to /ask and reports
+	// validation errors per field as {"errors": {...}}
+	V2 = "2"
+	// Latest is served when neither the client nor the config picks a version
+	Latest = V2
+)
+
+// Supported lists every version a client may request
+var Supported = []string{V1, V2}
+
+// Valid reports whether version is one of Supported
+func Valid(version string) bool {
+	return slices.Contains(Supported, version)
+}
+
+type versionKey struct{}
+
+// Negotiate resolves the request's version, records it for From and sets
+// Header on the response. Unknown versions are rejected with 400.
+// defaultVersion is read per request (nil or "" = Latest); an unsupported
+// default also falls back to Latest.
+func Negotiate(defaultVersion func() string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Vary", "Accept-Version")
+		version := strings.TrimSpace(c.GetHeader(RequestHeader))
+		if version == "" {
+			version = Latest
+			if defaultVersion != nil && Valid(defaultVersion()) {
+				version = defaultVersion()
+			}
+		}
+		if !Valid(version) {
+			c.Header(Header, Latest)
+			httperr.Abort(c, http.StatusBadRequest, fmt.Sprintf("Unsupported API version %q (supported: %s)", version, strings.Join(Supported, ", ")))
+			return
+		}
+		c.Set(versionKey{}, version)
+		c.Header(Header, version)
+		c.Next()
+	}
+}
+
+// From returns the request's negotiated version, Latest when Negotiate didn't run
+func From(c *gin.Context) string {
+	if v, ok := c.Get(versionKey{}); ok {
+		if version, ok := v.(string); ok {
+			return version
+		}
+	}
+	return Latest
+}
+
+// Legacy reports whether the client asked for the V1 shape
+func Legacy(c *gin.Context) bool {
+	return From(c) == V1
+}
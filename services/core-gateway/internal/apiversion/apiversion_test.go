@@ -0,0 +1,58 @@
+package apiversion_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/apiversion"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name           string
+		defaultVersion func() string
+		accept         string
+		status         int
+		served         string
+	}{
+		{"latest by default", nil, "", http.StatusOK, apiversion.Latest},
+		{"client asks for legacy", nil, "1", http.StatusOK, apiversion.V1},
+		{"client asks for latest", nil, "2", http.StatusOK, apiversion.V2},
+		{"configured default", func() string { return "1" }, "", http.StatusOK, apiversion.V1},
+		{"client overrides configured default", func() string { return "1" }, "2", http.StatusOK, apiversion.V2},
+		{"unsupported default falls back to latest", func() string { return "9" }, "", http.StatusOK, apiversion.Latest},
+		{"unsupported request", nil, "9", http.StatusBadRequest, apiversion.Latest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.Use(apiversion.Negotiate(tt.defaultVersion))
+			var seen string
+			r.GET("/v", func(c *gin.Context) {
+				seen = apiversion.From(c)
+				c.Status(http.StatusOK)
+			})
+			req, _ := http.NewRequest(http.MethodGet, "/v", nil)
+			if tt.accept != "" {
+				req.Header.Set(apiversion.RequestHeader, tt.accept)
+			}
+			w := httptest.NewRecorder()
+
+			// Act
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.served, w.Header().Get(apiversion.Header))
+			if tt.status == http.StatusOK {
+				assert.Equal(t, tt.served, seen)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+// Package audit records security-sensitive actions (impersonation, lockouts, ...)
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+)
+
+type Entry struct {
+	Action   string                 `json:"action"`
+	ActorID  string                 `json:"actor_id"`
+	TargetID string                 `json:"target_id,omitempty"`
+	Fields   map[string]interface{} `json:"fields,omitempty"`
+	Time     time.Time              `json:"time"`
+}
+
+type Logger interface {
+	Log(ctx context.Context, entry Entry)
+}
+
+type stdLogger struct{}
+
+// NewStdLogger writes audit entries as JSON lines to the standard logger
+func NewStdLogger() Logger {
+	return stdLogger{}
+}
+
+func (stdLogger) Log(ctx context.Context, entry Entry) {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+	line, _ := json.Marshal(entry)
+	log.Printf("[AUDIT] %s", line)
+}
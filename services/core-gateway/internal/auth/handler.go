@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/audit"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/httperr"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/validation"
+)
+
+// Handler serves the public /auth endpoints
+type Handler struct {
+	login   *LoginService
+	lockout *Lockout
+	audit   audit.Logger
+}
+
+func NewHandler(login *LoginService, lockout *Lockout, auditLogger audit.Logger) *Handler {
+	return &Handler{login: login, lockout: lockout, audit: auditLogger}
+}
+
+type LoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	// ExpiresIn requests a token lifetime in seconds, up to the configured maximum (0 = default)
+	ExpiresIn int `json:"expires_in" binding:"gte=0"`
+}
+
+// Login exchanges username/password for an access token.
+// Repeated failures per username or client IP trigger a temporary lockout (429).
+func (h *Handler) Login(c *gin.Context) {
+	var req LoginRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	userKey := "user:" + req.Username
+	keys := []string{userKey, "ip:" + c.ClientIP()}
+	for _, key := range keys {
+		if locked, retryAfter := h.lockout.Locked(key); locked {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			httperr.Respond(c, http.StatusTooManyRequests, "Too many failed login attempts. Try again later.")
+			return
+		}
+	}
+
+	tokens, err := h.login.Login(c.Request.Context(), req.Username, req.Password, time.Duration(req.ExpiresIn)*time.Second)
+	if errors.Is(err, ErrExpiryTooLong) {
+		httperr.Respond(c, http.StatusBadRequest, fmt.Sprintf("expires_in may be at most %d seconds", int(h.login.tokens.MaxExpiry().Seconds())))
+		return
+	}
+	if errors.Is(err, ErrInvalidCredentials) {
+		for _, key := range keys {
+			if h.lockout.Fail(key) {
+				h.audit.Log(c.Request.Context(), audit.Entry{
+					Action:   "auth.lockout",
+					ActorID:  c.ClientIP(),
+					TargetID: req.Username,
+					Fields:   gin.H{"key": key},
+				})
+			}
+		}
+		httperr.Respond(c, http.StatusUnauthorized, "Invalid username or password")
+		return
+	}
+	if err != nil {
+		log.Printf("[Auth] Login failed for %s: %v", req.Username, err)
+		httperr.Respond(c, http.StatusInternalServerError, "Login failed")
+		return
+	}
+
+	// The IP's failures stand: one valid account must not clear a guessing client
+	h.lockout.Reset(userKey)
+	c.JSON(http.StatusOK, gin.H{"token": tokens.AccessToken, "refresh_token": tokens.RefreshToken})
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// Refresh exchanges a refresh token from /auth/login or an earlier refresh for a
+// new access token and a replacement refresh token; the old one stops working
+func (h *Handler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	tokens, err := h.login.Refresh(c.Request.Context(), req.RefreshToken)
+	if errors.Is(err, ErrInvalidRefreshToken) {
+		httperr.Respond(c, http.StatusUnauthorized, "Invalid or expired refresh token")
+		return
+	}
+	if err != nil {
+		log.Printf("[Auth] Token refresh failed: %v", err)
+		httperr.Respond(c, http.StatusInternalServerError, "Token refresh failed")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"access_token": tokens.AccessToken, "refresh_token": tokens.RefreshToken})
+}
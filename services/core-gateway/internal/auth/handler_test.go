@@ -0,0 +1,288 @@
+package auth_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/audit"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/auth"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeUsers map[string]*user.User
+
+func (f fakeUsers) FindByID(ctx context.Context, id string) (*user.User, error) {
+	for _, u := range f {
+		if u.ID == id {
+			return u, nil
+		}
+	}
+	return nil, user.ErrNotFound
+}
+
+func (f fakeUsers) FindByUsername(ctx context.Context, username string) (*user.User, error) {
+	if u, ok := f[username]; ok {
+		return u, nil
+	}
+	return nil, user.ErrNotFound
+}
+
+func (f fakeUsers) Create(ctx context.Context, u *user.User) error {
+	f[u.Username] = u
+	return nil
+}
+
+type recordingAudit struct {
+	entries []audit.Entry
+}
+
+func (r *recordingAudit) Log(ctx context.Context, entry audit.Entry) {
+	r.entries = append(r.entries, entry)
+}
+
+type fakeClock struct{ now time.Time }
+
+func (f *fakeClock) Now() time.Time { return f.now }
+
+func newLoginRouter(t *testing.T, clock *fakeClock, auditLog audit.Logger) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	hash, err := auth.HashPassword("correct-horse")
+	require.NoError(t, err)
+	users := fakeUsers{"alice": {ID: "user_1", Username: "alice", PasswordHash: hash, Role: "user"}}
+
+	jwtService := auth.NewJWTService("secret", time.Hour)
+	lockout := auth.NewLockout(auth.LockoutConfig{MaxAttempts: 3, Window: time.Minute, Cooldown: 5 * time.Minute, Now: clock.Now})
+	h := auth.NewHandler(auth.NewLoginService(users, jwtService), lockout, auditLog)
+
+	r := gin.New()
+	r.POST("/auth/login", h.Login)
+	return r
+}
+
+func login(r *gin.Engine, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "/auth/login", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestLogin_Credentials(t *testing.T) {
+	r := newLoginRouter(t, &fakeClock{now: time.Now()}, &recordingAudit{})
+
+	ok := login(r, `{"username":"alice","password":"correct-horse"}`)
+	assert.Equal(t, http.StatusOK, ok.Code)
+	assert.Contains(t, ok.Body.String(), `"refresh_token":`)
+	assert.Equal(t, http.StatusUnauthorized, login(r, `{"username":"alice","password":"wrong"}`).Code)
+	assert.Equal(t, http.StatusUnauthorized, login(r, `{"username":"bob","password":"x"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, login(r, `{}`).Code)
+}
+
+func TestLogin_ExpiresIn(t *testing.T) {
+	// Arrange: the default maximum is 24h
+	r := newLoginRouter(t, &fakeClock{now: time.Now()}, &recordingAudit{})
+	verifier := auth.NewJWTService("secret", time.Hour)
+
+	// Act
+	normal := login(r, `{"username":"alice","password":"correct-horse","expires_in":600}`)
+	overLimit := login(r, `{"username":"alice","password":"correct-horse","expires_in":172800}`)
+
+	// Assert
+	require.Equal(t, http.StatusOK, normal.Code)
+	var body struct {
+		Token string `json:"token"`
+	}
+	require.NoError(t, json.Unmarshal(normal.Body.Bytes(), &body))
+	claims, err := verifier.ValidateToken(body.Token)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(10*time.Minute), claims.ExpiresAt.Time, 5*time.Second)
+
+	assert.Equal(t, http.StatusBadRequest, overLimit.Code)
+	assert.Contains(t, overLimit.Body.String(), "expires_in may be at most 86400 seconds")
+	assert.Equal(t, http.StatusBadRequest, login(r, `{"username":"alice","password":"correct-horse","expires_in":-1}`).Code)
+}
+
+func TestLogin_LockoutAfterRepeatedFailures(t *testing.T) {
+	// Arrange
+	clock := &fakeClock{now: time.Now()}
+	auditLog := &recordingAudit{}
+	r := newLoginRouter(t, clock, auditLog)
+
+	// Act: three failures trigger the lockout
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusUnauthorized, login(r, `{"username":"alice","password":"wrong"}`).Code)
+	}
+
+	// Assert: even the correct password is refused during the cooldown
+	w := login(r, `{"username":"alice","password":"correct-horse"}`)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "300", w.Header().Get("Retry-After"))
+
+	// Lockout is audited (username and IP keys both locked)
+	require.NotEmpty(t, auditLog.entries)
+	assert.Equal(t, "auth.lockout", auditLog.entries[0].Action)
+	assert.Equal(t, "alice", auditLog.entries[0].TargetID)
+
+	// Act: cooldown passes
+	clock.now = clock.now.Add(5*time.Minute + time.Second)
+
+	// Assert: login works again and resets the counter
+	assert.Equal(t, http.StatusOK, login(r, `{"username":"alice","password":"correct-horse"}`).Code)
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, http.StatusUnauthorized, login(r, `{"username":"alice","password":"wrong"}`).Code)
+	}
+	assert.Equal(t, http.StatusOK, login(r, `{"username":"alice","password":"correct-horse"}`).Code)
+}
+
+func TestLockout_FailuresOutsideWindowDontAccumulate(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	lockout := auth.NewLockout(auth.LockoutConfig{MaxAttempts: 2, Window: time.Minute, Now: clock.Now})
+
+	assert.False(t, lockout.Fail("user:alice"))
+	clock.now = clock.now.Add(2 * time.Minute)
+	assert.False(t, lockout.Fail("user:alice"), "First failure expired with its window")
+	assert.True(t, lockout.Fail("user:alice"))
+
+	locked, _ := lockout.Locked("user:alice")
+	assert.True(t, locked)
+}
+
+func TestLogin_SuccessKeepsIPFailures(t *testing.T) {
+	// Arrange: two failures from this client
+	r := newLoginRouter(t, &fakeClock{now: time.Now()}, &recordingAudit{})
+	for i := 0; i < 2; i++ {
+		require.Equal(t, http.StatusUnauthorized, login(r, `{"username":"bob","password":"guess"}`).Code)
+	}
+
+	// Act: a valid login from the same client, then one more guess
+	require.Equal(t, http.StatusOK, login(r, `{"username":"alice","password":"correct-horse"}`).Code)
+	guess := login(r, `{"username":"carol","password":"guess"}`)
+
+	// Assert: the valid account did not clear the client's failures
+	assert.Equal(t, http.StatusUnauthorized, guess.Code)
+	assert.Equal(t, http.StatusTooManyRequests, login(r, `{"username":"alice","password":"correct-horse"}`).Code)
+}
+
+func TestLockout_SweepsExpiredKeys(t *testing.T) {
+	// Arrange
+	clock := &fakeClock{now: time.Now()}
+	lockout := auth.NewLockout(auth.LockoutConfig{MaxAttempts: 2, Window: time.Minute, Cooldown: 5 * time.Minute, Now: clock.Now})
+	lockout.Fail("user:alice")
+	lockout.Fail("user:mallory")
+	lockout.Fail("user:mallory")
+
+	// Act: alice's window has passed, mallory is still locked out
+	clock.now = clock.now.Add(2 * time.Minute)
+	removed := lockout.Sweep()
+
+	// Assert
+	assert.Equal(t, 1, removed)
+	assert.Equal(t, 1, lockout.Len())
+	locked, _ := lockout.Locked("user:mallory")
+	assert.True(t, locked)
+}
+
+func TestLockout_FailSweepsOncePerWindow(t *testing.T) {
+	// Arrange: failures for many one-off usernames
+	clock := &fakeClock{now: time.Now()}
+	lockout := auth.NewLockout(auth.LockoutConfig{MaxAttempts: 5, Window: time.Minute, Now: clock.Now})
+	for i := 0; i < 100; i++ {
+		lockout.Fail(fmt.Sprintf("user:guess-%d", i))
+	}
+
+	// Act
+	clock.now = clock.now.Add(2 * time.Minute)
+	lockout.Fail("user:latest")
+
+	// Assert: the expired usernames were dropped
+	assert.Equal(t, 1, lockout.Len())
+}
+
+func TestRefresh_IssuesTokensForCurrentUser(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	users := fakeUsers{"alice": {ID: "user_1", Username: "alice", Role: "user"}}
+	jwtService := auth.NewJWTService("secret", time.Hour)
+	h := auth.NewHandler(auth.NewLoginService(users, jwtService), auth.NewLockout(auth.LockoutConfig{}), &recordingAudit{})
+	r := gin.New()
+	r.POST("/auth/refresh", h.Refresh)
+	pair, err := jwtService.GenerateTokenPair("user_1", "user", "")
+	require.NoError(t, err)
+	refresh := func(token string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"refresh_token": token})
+		req, _ := http.NewRequest(http.MethodPost, "/auth/refresh", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// Act: alice is promoted after the refresh token was issued
+	users["alice"].Role = "admin"
+	w := refresh(pair.RefreshToken)
+
+	// Assert: both new tokens carry the new role
+	require.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	claims, err := jwtService.ValidateToken(body.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "admin", claims.Role)
+	claims, err = jwtService.ValidateRefreshToken(body.RefreshToken)
+	require.NoError(t, err)
+	assert.Equal(t, "admin", claims.Role)
+
+	assert.Equal(t, http.StatusUnauthorized, refresh(pair.AccessToken).Code)
+	assert.Equal(t, http.StatusUnauthorized, refresh("not.a.token").Code)
+	assert.Equal(t, http.StatusBadRequest, refresh("").Code)
+	users["alice"].Disabled = true
+	assert.Equal(t, http.StatusUnauthorized, refresh(body.RefreshToken).Code)
+}
+
+func TestRefresh_RotatesRefreshToken(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	users := fakeUsers{"alice": {ID: "user_1", Username: "alice", Role: "user"}}
+	jwtService := auth.NewJWTService("secret", time.Hour)
+	h := auth.NewHandler(auth.NewLoginService(users, jwtService), auth.NewLockout(auth.LockoutConfig{}), &recordingAudit{})
+	r := gin.New()
+	r.POST("/auth/refresh", h.Refresh)
+	pair, err := jwtService.GenerateTokenPair("user_1", "user", "")
+	require.NoError(t, err)
+	refresh := func(token string) (int, string) {
+		body, _ := json.Marshal(map[string]string{"refresh_token": token})
+		req, _ := http.NewRequest(http.MethodPost, "/auth/refresh", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var resp struct {
+			RefreshToken string `json:"refresh_token"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.RefreshToken
+	}
+
+	// Act
+	firstCode, rotated := refresh(pair.RefreshToken)
+	replayCode, _ := refresh(pair.RefreshToken)
+	nextCode, _ := refresh(rotated)
+
+	// Assert: a refresh token is good for one exchange; its replacement carries on
+	assert.Equal(t, http.StatusOK, firstCode)
+	assert.NotEmpty(t, rotated)
+	assert.NotEqual(t, pair.RefreshToken, rotated)
+	assert.Equal(t, http.StatusUnauthorized, replayCode)
+	assert.Equal(t, http.StatusOK, nextCode)
+}
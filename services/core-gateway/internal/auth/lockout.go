@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// LockoutConfig configures brute-force protection. Zero values fall back to defaults.
+type LockoutConfig struct {
+	// MaxAttempts failures within Window trigger a lockout (default 5)
+	MaxAttempts int
+	// Window over which failures are counted (default 15m)
+	Window time.Duration
+	// Cooldown during which further attempts are refused (default 15m)
+	Cooldown time.Duration
+	// Now overrides the clock (tests). Defaults to time.Now.
+	Now func() time.Time
+}
+
+type attempts struct {
+	failures    int
+	windowStart time.Time
+	lockedUntil time.Time
+}
+
+// expired reports whether a's failures have left the window and any lockout is over
+func (a *attempts) expired(now time.Time, window time.Duration) bool {
+	return now.Sub(a.windowStart) > window && !now.Before(a.lockedUntil)
+}
+
+// Lockout counts failed login attempts per key (username or client IP).
+// Expired keys are swept at most once per Window, so failures for ever-new
+// usernames cannot grow it without bound.
+type Lockout struct {
+	cfg       LockoutConfig
+	mu        sync.Mutex
+	entries   map[string]*attempts
+	lastSweep time.Time
+}
+
+func NewLockout(cfg LockoutConfig) *Lockout {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 5
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = 15 * time.Minute
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = 15 * time.Minute
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	return &Lockout{cfg: cfg, entries: make(map[string]*attempts)}
+}
+
+// Locked reports whether key is locked out and for how much longer
+func (l *Lockout) Locked(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	a, ok := l.entries[key]
+	if !ok {
+		return false, 0
+	}
+	if remaining := a.lockedUntil.Sub(l.cfg.Now()); remaining > 0 {
+		return true, remaining
+	}
+	return false, 0
+}
+
+// Fail records a failed attempt and reports whether it triggered a lockout
+func (l *Lockout) Fail(key string) bool {
+	now := l.cfg.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) >= l.cfg.Window {
+		l.sweep(now)
+	}
+	a, ok := l.entries[key]
+	if !ok || now.Sub(a.windowStart) > l.cfg.Window {
+		a = &attempts{windowStart: now}
+		l.entries[key] = a
+	}
+
+	a.failures++
+	if a.failures >= l.cfg.MaxAttempts {
+		a.lockedUntil = now.Add(l.cfg.Cooldown)
+		a.failures = 0
+		a.windowStart = now
+		return true
+	}
+	return false
+}
+
+// Reset clears the failure count for key (after a successful login)
+func (l *Lockout) Reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.entries, key)
+}
+
+// Sweep drops keys whose failures have left the window and whose lockout is
+// over, and returns how many were removed
+func (l *Lockout) Sweep() int {
+	now := l.cfg.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.sweep(now)
+}
+
+func (l *Lockout) sweep(now time.Time) int {
+	removed := 0
+	for key, a := range l.entries {
+		if a.expired(now, l.cfg.Window) {
+			delete(l.entries, key)
+			removed++
+		}
+	}
+	l.lastSweep = now
+	return removed
+}
+
+// Len returns the number of keys with recorded failures
+func (l *Lockout) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.entries)
+}
@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/user"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrInvalidCredentials = errors.New("invalid username or password")
+
+// ErrInvalidRefreshToken is returned for refresh tokens that are invalid, expired,
+// already exchanged, or belong to a user who no longer exists or has been disabled
+var ErrInvalidRefreshToken = errors.New("invalid refresh token")
+
+// dummyHash is compared against when the user doesn't exist so response timing
+// doesn't reveal which usernames are registered.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("woorung-gaksi"), bcrypt.DefaultCost)
+
+// LoginService verifies stored credentials and issues access tokens
+type LoginService struct {
+	users  user.Repository
+	tokens Service
+	spent  *spentTokens
+}
+
+func NewLoginService(users user.Repository, tokens Service) *LoginService {
+	return &LoginService{users: users, tokens: tokens, spent: newSpentTokens(time.Now)}
+}
+
+// Login returns an access and refresh token for valid credentials, ErrInvalidCredentials otherwise.
+// expiry overrides the default access token lifetime (0 = default); asking for more
+// than the token service's MaxExpiry fails with ErrExpiryTooLong.
+func (s *LoginService) Login(ctx context.Context, username, password string, expiry time.Duration) (TokenPair, error) {
+	if expiry > s.tokens.MaxExpiry() {
+		return TokenPair{}, ErrExpiryTooLong
+	}
+
+	u, err := s.users.FindByUsername(ctx, username)
+	if errors.Is(err, user.ErrNotFound) {
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return TokenPair{}, ErrInvalidCredentials
+	}
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)); err != nil {
+		return TokenPair{}, ErrInvalidCredentials
+	}
+	if u.Disabled {
+		return TokenPair{}, ErrInvalidCredentials
+	}
+
+	pair, err := s.tokens.GenerateTokenPair(u.ID, u.Role, u.TenantID)
+	if err != nil || expiry == 0 {
+		return pair, err
+	}
+	pair.AccessToken, err = s.tokens.IssueToken(Claims{UserID: u.ID, Role: u.Role, TenantID: u.TenantID}, expiry)
+	return pair, err
+}
+
+// Refresh exchanges a refresh token for a new token pair. The user must still
+// exist and be enabled, and the new tokens carry their current role and tenant.
+// Each refresh token is exchanged once: presenting it again fails with
+// ErrInvalidRefreshToken, so the caller must keep the rotated one.
+func (s *LoginService) Refresh(ctx context.Context, refreshToken string) (TokenPair, error) {
+	claims, err := s.tokens.ValidateRefreshToken(refreshToken)
+	if err != nil {
+		return TokenPair{}, ErrInvalidRefreshToken
+	}
+
+	u, err := s.users.FindByID(ctx, claims.UserID)
+	if errors.Is(err, user.ErrNotFound) {
+		return TokenPair{}, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return TokenPair{}, err
+	}
+	if u.Disabled {
+		return TokenPair{}, ErrInvalidRefreshToken
+	}
+	if !s.spent.Spend(refreshToken, claims.ExpiresAt.Time) {
+		return TokenPair{}, ErrInvalidRefreshToken
+	}
+	return s.tokens.GenerateTokenPair(u.ID, u.Role, u.TenantID)
+}
+
+// spentTokens remembers exchanged refresh tokens until they expire. Like
+// Lockout it is held in memory, so it covers a single gateway instance.
+// Expired tokens are swept at most once per spentSweepInterval.
+type spentTokens struct {
+	now       func() time.Time
+	mu        sync.Mutex
+	expiry    map[string]time.Time
+	lastSweep time.Time
+}
+
+const spentSweepInterval = time.Hour
+
+func newSpentTokens(now func() time.Time) *spentTokens {
+	return &spentTokens{now: now, expiry: make(map[string]time.Time)}
+}
+
+// Spend marks token as exchanged until expiresAt, reporting false if it already was
+func (s *spentTokens) Spend(token string, expiresAt time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.lastSweep) >= spentSweepInterval {
+		for t, exp := range s.expiry {
+			if !now.Before(exp) {
+				delete(s.expiry, t)
+			}
+		}
+		s.lastSweep = now
+	}
+	if _, ok := s.expiry[token]; ok {
+		return false
+	}
+	s.expiry[token] = expiresAt
+	return true
+}
+
+// HashPassword hashes a plaintext password for storage
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(hash), err
+}
@@ -1,14 +1,59 @@
 package auth
 
-import "github.com/golang-jwt/jwt/v5"
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
 
 type Claims struct {
 	UserID string `json:"user_id"`
 	Role   string `json:"role"`
+	// ImpersonatedBy is the admin user ID when the token was minted for support impersonation
+	ImpersonatedBy string `json:"impersonated_by,omitempty"`
+	// TenantID scopes all data access when multi-tenancy is enabled
+	TenantID string `json:"tenant_id,omitempty"`
+	// Service identifies the caller of a service-to-service token (no user_id/role)
+	Service string `json:"service,omitempty"`
+	// TokenType is TokenTypeRefresh for refresh tokens; empty for access tokens
+	TokenType string `json:"token_type,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// TokenTypeRefresh marks tokens that may only be exchanged at /auth/refresh
+const TokenTypeRefresh = "refresh"
+
+// TokenPair is a short-lived access token and the refresh token that renews it
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+}
+
+// ServiceName returns the calling service for service tokens: the `service`
+// claim, or `sub` when the token carries no user_id. Empty for user tokens.
+func (c *Claims) ServiceName() string {
+	if c.Service != "" {
+		return c.Service
+	}
+	if c.UserID == "" {
+		return c.Subject
+	}
+	return ""
+}
+
 type Service interface {
 	GenerateToken(userID, role string) (string, error)
+	// GenerateTokenPair issues an access token plus a long-lived refresh token
+	// for a user of tenantID ("" outside multi-tenancy)
+	GenerateTokenPair(userID, role, tenantID string) (TokenPair, error)
+	// ValidateRefreshToken verifies a refresh token; access tokens are rejected with ErrNotRefreshToken
+	ValidateRefreshToken(refreshToken string) (*Claims, error)
+	GenerateImpersonationToken(userID, role, tenantID, impersonatorID string, expiry time.Duration) (string, error)
+	GenerateServiceToken(service string, expiry time.Duration) (string, error)
+	// IssueToken signs arbitrary claims; registered claims (exp, iat, iss) are set by the service
+	IssueToken(claims Claims, expiry time.Duration) (string, error)
+	// MaxExpiry is the longest lifetime any issued token gets; longer requests are clamped
+	MaxExpiry() time.Duration
+	// ValidateToken verifies an access token; refresh tokens are rejected with ErrRefreshToken
 	ValidateToken(tokenString string) (*Claims, error)
 }
@@ -1,50 +1,284 @@
 package auth
 
 import (
+	"crypto/rsa"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/clock"
 )
 
+// Signing algorithms accepted in Keys.Algorithm
+const (
+	AlgorithmHS256 = "hs256"
+	AlgorithmRS256 = "rs256"
+)
+
+// ErrVerifyOnly is returned when a service built without a private key is asked to sign
+var ErrVerifyOnly = errors.New("token service has no signing key (verification only)")
+
+// DefaultMaxExpiry caps token lifetimes when no maximum is configured
+const DefaultMaxExpiry = 24 * time.Hour
+
+// DefaultRefreshExpiry is the refresh token lifetime when none is configured
+const DefaultRefreshExpiry = 30 * 24 * time.Hour
+
+// ErrRefreshToken is returned when a refresh token is presented as an access token
+var ErrRefreshToken = errors.New("refresh tokens cannot be used as access tokens")
+
+// ErrNotRefreshToken is returned when anything but a refresh token is exchanged for an access token
+var ErrNotRefreshToken = errors.New("not a refresh token")
+
+// ErrExpiryTooLong is returned when a caller asks for a token outliving MaxExpiry
+var ErrExpiryTooLong = errors.New("requested expiry exceeds the maximum")
+
+// Keys configures JWT signing with rotation. New tokens are signed with
+// Secret (or PrivateKeyPEM for rs256) and stamped with KID in the "kid" header;
+// tokens carrying a previous kid still verify against Previous until those keys
+// are removed.
+type Keys struct {
+	// Algorithm is AlgorithmHS256 (default) or AlgorithmRS256
+	Algorithm string
+	KID       string
+	Secret    string
+	// PrivateKeyPEM signs rs256 tokens; without it the service can only verify
+	PrivateKeyPEM []byte
+	// PublicKeyPEM verifies rs256 tokens (derived from PrivateKeyPEM when empty)
+	PublicKeyPEM []byte
+	// Previous maps retired kids to their secrets, or PEM public keys for rs256
+	// (verification only). Tokens without a kid use the "" entry, or the current
+	// key when there is none.
+	Previous map[string]string
+	// PreviousSecret verifies tokens that fail against their kid's key, so Secret can
+	// be replaced in place without logging everyone out (hs256, verification only)
+	PreviousSecret string
+	// MaxExpiry caps the lifetime of every issued access token (0 = DefaultMaxExpiry)
+	MaxExpiry time.Duration
+	// RefreshExpiry is the lifetime of refresh tokens (0 = DefaultRefreshExpiry)
+	RefreshExpiry time.Duration
+	// Clock stamps and checks token times (nil = wall clock)
+	Clock clock.Clock
+}
+
 type jwtService struct {
-	secretKey []byte
+	kid    string
+	method jwt.SigningMethod
+	// signKey is nil for verify-only services
+	signKey   interface{}
+	verifyBy  map[string]interface{}
+	previous  interface{}
 	issuer    string
 	expiry    time.Duration
+	maxExpiry time.Duration
+	refresh   time.Duration
+	clock     clock.Clock
 }
 
 func NewJWTService(secret string, expiry time.Duration) Service {
-	return &jwtService{
-		secretKey: []byte(secret),
-		issuer:    "woorung-gaksi",
-		expiry:    expiry,
+	return NewJWTServiceWithKeys(Keys{Secret: secret}, expiry)
+}
+
+// NewRSAJWTService creates an RS256 service. With only publicKeyPEM it verifies
+// tokens but cannot issue them, which is what downstream services need.
+func NewRSAJWTService(privateKeyPEM, publicKeyPEM []byte, expiry time.Duration) (Service, error) {
+	return NewJWTServiceFromKeys(Keys{Algorithm: AlgorithmRS256, PrivateKeyPEM: privateKeyPEM, PublicKeyPEM: publicKeyPEM}, expiry)
+}
+
+// NewJWTServiceWithKeys creates an HS256 service that supports key rotation by kid.
+// Use NewJWTServiceFromKeys when the algorithm is configurable.
+func NewJWTServiceWithKeys(keys Keys, expiry time.Duration) Service {
+	keys.Algorithm = AlgorithmHS256
+	s, _ := NewJWTServiceFromKeys(keys, expiry)
+	return s
+}
+
+// NewJWTServiceFromKeys creates a service for keys.Algorithm, failing on an
+// unknown algorithm or unparseable rs256 keys
+func NewJWTServiceFromKeys(keys Keys, expiry time.Duration) (Service, error) {
+	s := &jwtService{kid: keys.KID, verifyBy: map[string]interface{}{}}
+	switch strings.ToLower(keys.Algorithm) {
+	case "", AlgorithmHS256:
+		s.method = jwt.SigningMethodHS256
+		s.signKey = []byte(keys.Secret)
+		for kid, secret := range keys.Previous {
+			s.verifyBy[kid] = []byte(secret)
+		}
+		s.verifyBy[keys.KID] = s.signKey
+		if previous := previousSecret(keys); previous != nil {
+			s.previous = previous
+		}
+	case AlgorithmRS256:
+		s.method = jwt.SigningMethodRS256
+		public, err := rsaKeys(keys, s)
+		if err != nil {
+			return nil, err
+		}
+		s.verifyBy[keys.KID] = public
+	default:
+		return nil, fmt.Errorf("unsupported jwt algorithm %q (use %s or %s)", keys.Algorithm, AlgorithmHS256, AlgorithmRS256)
+	}
+	if _, ok := s.verifyBy[""]; !ok {
+		s.verifyBy[""] = s.verifyBy[keys.KID]
 	}
+
+	maxExpiry := keys.MaxExpiry
+	if maxExpiry <= 0 {
+		maxExpiry = DefaultMaxExpiry
+	}
+	if expiry <= 0 || expiry > maxExpiry {
+		expiry = maxExpiry
+	}
+	refresh := keys.RefreshExpiry
+	if refresh <= 0 {
+		refresh = DefaultRefreshExpiry
+	}
+
+	s.issuer = "woorung-gaksi"
+	s.expiry = expiry
+	s.maxExpiry = maxExpiry
+	s.refresh = refresh
+	s.clock = clock.Or(keys.Clock)
+	return s, nil
+}
+
+// rsaKeys sets s.signKey and the previous kids' keys, and returns the current public key
+func rsaKeys(keys Keys, s *jwtService) (*rsa.PublicKey, error) {
+	var public *rsa.PublicKey
+	if len(keys.PrivateKeyPEM) > 0 {
+		private, err := jwt.ParseRSAPrivateKeyFromPEM(keys.PrivateKeyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("jwt private key: %w", err)
+		}
+		s.signKey = private
+		public = &private.PublicKey
+	}
+	if len(keys.PublicKeyPEM) > 0 {
+		parsed, err := jwt.ParseRSAPublicKeyFromPEM(keys.PublicKeyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("jwt public key: %w", err)
+		}
+		public = parsed
+	}
+	if public == nil {
+		return nil, errors.New("rs256 needs a private or public key")
+	}
+	for kid, pem := range keys.Previous {
+		parsed, err := jwt.ParseRSAPublicKeyFromPEM([]byte(pem))
+		if err != nil {
+			return nil, fmt.Errorf("jwt previous key %q: %w", kid, err)
+		}
+		s.verifyBy[kid] = parsed
+	}
+	return public, nil
+}
+
+func (s *jwtService) MaxExpiry() time.Duration {
+	return s.maxExpiry
 }
 
 func (s *jwtService) GenerateToken(userID, role string) (string, error) {
-	claims := &Claims{
-		UserID: userID,
-		Role:   role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.expiry)),
-			Issuer:    s.issuer,
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
+	return s.sign(&Claims{UserID: userID, Role: role}, s.expiry)
+}
+
+func (s *jwtService) GenerateTokenPair(userID, role, tenantID string) (TokenPair, error) {
+	access, err := s.sign(&Claims{UserID: userID, Role: role, TenantID: tenantID}, s.expiry)
+	if err != nil {
+		return TokenPair{}, err
 	}
+	// The jti keeps every refresh token distinct, so a spent one is never reissued
+	refreshClaims := &Claims{UserID: userID, Role: role, TenantID: tenantID, TokenType: TokenTypeRefresh}
+	refreshClaims.ID = uuid.NewString()
+	refresh, err := s.signFor(refreshClaims, s.refresh)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	return TokenPair{AccessToken: access, RefreshToken: refresh}, nil
+}
+
+func (s *jwtService) ValidateRefreshToken(refreshToken string) (*Claims, error) {
+	claims, err := s.verify(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	if claims.TokenType != TokenTypeRefresh || claims.UserID == "" {
+		return nil, ErrNotRefreshToken
+	}
+	return claims, nil
+}
+
+func (s *jwtService) GenerateImpersonationToken(userID, role, tenantID, impersonatorID string, expiry time.Duration) (string, error) {
+	return s.sign(&Claims{UserID: userID, Role: role, TenantID: tenantID, ImpersonatedBy: impersonatorID}, expiry)
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(s.secretKey)
+func (s *jwtService) GenerateServiceToken(service string, expiry time.Duration) (string, error) {
+	claims := &Claims{Service: service}
+	claims.Subject = service
+	return s.sign(claims, expiry)
+}
+
+func (s *jwtService) IssueToken(claims Claims, expiry time.Duration) (string, error) {
+	return s.sign(&claims, expiry)
+}
+
+// sign clamps expiry to the maximum so no issuance path can mint longer-lived access tokens
+func (s *jwtService) sign(claims *Claims, expiry time.Duration) (string, error) {
+	if expiry > s.maxExpiry {
+		expiry = s.maxExpiry
+	}
+	claims.TokenType = ""
+	return s.signFor(claims, expiry)
+}
+
+// signFor signs claims valid for expiry from now; only refresh tokens bypass the cap
+func (s *jwtService) signFor(claims *Claims, expiry time.Duration) (string, error) {
+	if s.signKey == nil {
+		return "", ErrVerifyOnly
+	}
+	now := s.clock.Now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        claims.ID,
+		Subject:   claims.Subject,
+		ExpiresAt: jwt.NewNumericDate(now.Add(expiry)),
+		Issuer:    s.issuer,
+		IssuedAt:  jwt.NewNumericDate(now),
+	}
+
+	token := jwt.NewWithClaims(s.method, claims)
+	if s.kid != "" {
+		token.Header["kid"] = s.kid
+	}
+	return token.SignedString(s.signKey)
+}
+
+// previousSecret is the fallback verification key, unless it is unset or the current secret
+func previousSecret(keys Keys) []byte {
+	if keys.PreviousSecret == "" || keys.PreviousSecret == keys.Secret {
+		return nil
+	}
+	return []byte(keys.PreviousSecret)
 }
 
 func (s *jwtService) ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		// Validating the algorithm is HMAC
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return s.secretKey, nil
-	})
+	claims, err := s.verify(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.TokenType == TokenTypeRefresh {
+		return nil, ErrRefreshToken
+	}
+	return claims, nil
+}
+
+// verify checks the signature and registered claims of any token type
+func (s *jwtService) verify(tokenString string) (*Claims, error) {
+	token, err := s.parse(tokenString, nil)
+	if errors.Is(err, jwt.ErrTokenSignatureInvalid) && s.previous != nil {
+		token, err = s.parse(tokenString, s.previous)
+	}
 
 	if err != nil {
 		return nil, err
@@ -56,3 +290,22 @@ func (s *jwtService) ValidateToken(tokenString string) (*Claims, error) {
 
 	return nil, errors.New("invalid token")
 }
+
+// parse verifies with the key of the token's kid, or with override when set
+func (s *jwtService) parse(tokenString string, override interface{}) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		// Only the configured algorithm is accepted (no alg confusion between HMAC and RSA)
+		if token.Method.Alg() != s.method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		kid, _ := token.Header["kid"].(string)
+		key, ok := s.verifyBy[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		if override != nil {
+			return override, nil
+		}
+		return key, nil
+	}, jwt.WithTimeFunc(s.clock.Now))
+}
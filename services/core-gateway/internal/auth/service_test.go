@@ -1,11 +1,18 @@
 package auth_test
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
 	"testing"
 	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/auth"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/clock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestJWTService_Cycle(t *testing.T) {
@@ -34,8 +41,260 @@ func TestJWTService_Cycle(t *testing.T) {
 	assert.Equal(t, "woorung-gaksi", claims.Issuer)
 }
 
+func TestJWTService_ClampsExpiryToMax(t *testing.T) {
+	// Arrange
+	service := auth.NewJWTServiceWithKeys(auth.Keys{Secret: "secret", MaxExpiry: 2 * time.Hour}, 48*time.Hour)
+
+	// Act
+	defaulted, err := service.GenerateToken("user_1", "user")
+	require.NoError(t, err)
+	requested, err := service.IssueToken(auth.Claims{UserID: "user_1"}, 30*24*time.Hour)
+	require.NoError(t, err)
+	short, err := service.IssueToken(auth.Claims{UserID: "user_1"}, time.Minute)
+	require.NoError(t, err)
+
+	// Assert
+	for token, want := range map[string]time.Duration{defaulted: 2 * time.Hour, requested: 2 * time.Hour, short: time.Minute} {
+		claims, err := service.ValidateToken(token)
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(want), claims.ExpiresAt.Time, 5*time.Second)
+	}
+	assert.Equal(t, 2*time.Hour, service.MaxExpiry())
+}
+
 func TestJWTService_InvalidToken(t *testing.T) {
 	service := auth.NewJWTService("secret", time.Hour)
 	_, err := service.ValidateToken("invalid.token.string")
 	assert.Error(t, err)
 }
+
+func TestJWTService_KeyRotation(t *testing.T) {
+	// Arrange: tokens issued before and after rotating from key "k1" to "k2"
+	before := auth.NewJWTServiceWithKeys(auth.Keys{KID: "k1", Secret: "old_secret"}, time.Hour)
+	after := auth.NewJWTServiceWithKeys(auth.Keys{
+		KID:      "k2",
+		Secret:   "new_secret",
+		Previous: map[string]string{"k1": "old_secret"},
+	}, time.Hour)
+	oldToken, err := before.GenerateToken("user_1", "user")
+	require.NoError(t, err)
+
+	// Act
+	claims, err := after.ValidateToken(oldToken)
+	newToken, genErr := after.GenerateToken("user_2", "user")
+
+	// Assert: old tokens still validate, new ones carry the new kid
+	require.NoError(t, err)
+	assert.Equal(t, "user_1", claims.UserID)
+	require.NoError(t, genErr)
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken, &auth.Claims{})
+	require.NoError(t, err)
+	assert.Equal(t, "k2", parsed.Header["kid"])
+	_, err = before.ValidateToken(newToken)
+	assert.Error(t, err, "the old deployment doesn't know k2")
+}
+
+func TestJWTService_RetiredKeyRejected(t *testing.T) {
+	// Arrange: k1 has been dropped from the previous keys
+	old := auth.NewJWTServiceWithKeys(auth.Keys{KID: "k1", Secret: "old_secret"}, time.Hour)
+	token, _ := old.GenerateToken("user_1", "user")
+	current := auth.NewJWTServiceWithKeys(auth.Keys{KID: "k2", Secret: "new_secret"}, time.Hour)
+
+	// Act
+	_, err := current.ValidateToken(token)
+
+	// Assert
+	assert.ErrorContains(t, err, `unknown signing key "k1"`)
+}
+
+func TestJWTService_LegacyTokensWithoutKID(t *testing.T) {
+	// Arrange: a token from before kids were configured
+	legacy := auth.NewJWTService("old_secret", time.Hour)
+	token, _ := legacy.GenerateToken("user_1", "user")
+	rotated := auth.NewJWTServiceWithKeys(auth.Keys{
+		KID:      "k1",
+		Secret:   "new_secret",
+		Previous: map[string]string{"": "old_secret"},
+	}, time.Hour)
+
+	// Act
+	claims, err := rotated.ValidateToken(token)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "user_1", claims.UserID)
+}
+
+func TestJWTService_ExpiresOnFakeClock(t *testing.T) {
+	// Arrange
+	fake := clock.NewFake(time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC))
+	service := auth.NewJWTServiceWithKeys(auth.Keys{Secret: "super_secret_key", Clock: fake}, time.Hour)
+	token, err := service.GenerateToken("user_123", "user")
+	require.NoError(t, err)
+
+	// Act & Assert: valid until the expiry, which is stamped from the fake clock
+	fake.Advance(59 * time.Minute)
+	claims, err := service.ValidateToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC), claims.ExpiresAt.UTC())
+
+	fake.Advance(time.Minute)
+	_, err = service.ValidateToken(token)
+	assert.ErrorIs(t, err, jwt.ErrTokenExpired)
+}
+
+func TestJWTService_PreviousSecret(t *testing.T) {
+	// Arrange: a token issued before API_SECRET was changed in place
+	oldToken, err := auth.NewJWTService("old_secret", time.Hour).GenerateToken("user_123", "user")
+	require.NoError(t, err)
+	rotated := auth.NewJWTServiceWithKeys(auth.Keys{Secret: "new_secret", PreviousSecret: "old_secret"}, time.Hour)
+	newOnly := auth.NewJWTService("new_secret", time.Hour)
+
+	// Act
+	newToken, err := rotated.GenerateToken("user_456", "user")
+	require.NoError(t, err)
+	oldClaims, oldErr := rotated.ValidateToken(oldToken)
+	newClaims, newErr := rotated.ValidateToken(newToken)
+	_, withoutFallback := newOnly.ValidateToken(oldToken)
+
+	// Assert: both secrets verify, new tokens are signed with the current one only
+	require.NoError(t, oldErr)
+	assert.Equal(t, "user_123", oldClaims.UserID)
+	require.NoError(t, newErr)
+	assert.Equal(t, "user_456", newClaims.UserID)
+	_, err = newOnly.ValidateToken(newToken)
+	assert.NoError(t, err)
+	_, err = auth.NewJWTService("old_secret", time.Hour).ValidateToken(newToken)
+	assert.ErrorIs(t, err, jwt.ErrTokenSignatureInvalid)
+	assert.ErrorIs(t, withoutFallback, jwt.ErrTokenSignatureInvalid)
+}
+
+func TestJWTService_PreviousSecretStillChecksExpiry(t *testing.T) {
+	// Arrange
+	fake := clock.NewFake(time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC))
+	oldToken, err := auth.NewJWTServiceWithKeys(auth.Keys{Secret: "old_secret", Clock: fake}, time.Hour).GenerateToken("user_123", "user")
+	require.NoError(t, err)
+	rotated := auth.NewJWTServiceWithKeys(auth.Keys{Secret: "new_secret", PreviousSecret: "old_secret", Clock: fake}, time.Hour)
+
+	// Act
+	fake.Advance(2 * time.Hour)
+	_, err = rotated.ValidateToken(oldToken)
+
+	// Assert
+	assert.ErrorIs(t, err, jwt.ErrTokenExpired)
+}
+
+func TestJWTService_RefreshToken(t *testing.T) {
+	// Arrange: refresh tokens outlive the access token cap
+	service := auth.NewJWTServiceWithKeys(auth.Keys{Secret: "secret", MaxExpiry: time.Hour, RefreshExpiry: 7 * 24 * time.Hour}, time.Hour)
+	pair, err := service.GenerateTokenPair("user_1", "admin", "")
+	require.NoError(t, err)
+
+	// Act
+	claims, err := service.ValidateRefreshToken(pair.RefreshToken)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "user_1", claims.UserID)
+	assert.Equal(t, "admin", claims.Role)
+	assert.Equal(t, auth.TokenTypeRefresh, claims.TokenType)
+
+	// Every refresh token is distinct, even within the same second
+	again, err := service.GenerateTokenPair("user_1", "admin", "")
+	require.NoError(t, err)
+	assert.NotEqual(t, pair.RefreshToken, again.RefreshToken)
+
+	// A refresh token is not an access token, and vice versa
+	_, err = service.ValidateToken(pair.RefreshToken)
+	assert.ErrorIs(t, err, auth.ErrRefreshToken)
+	_, err = service.ValidateRefreshToken(pair.AccessToken)
+	assert.ErrorIs(t, err, auth.ErrNotRefreshToken)
+
+	// IssueToken can't be used to mint refresh tokens
+	forged, err := service.IssueToken(auth.Claims{UserID: "user_1", TokenType: auth.TokenTypeRefresh}, time.Hour)
+	require.NoError(t, err)
+	_, err = service.ValidateRefreshToken(forged)
+	assert.ErrorIs(t, err, auth.ErrNotRefreshToken)
+}
+
+func TestJWTService_RefreshTokenExpires(t *testing.T) {
+	// Arrange
+	now := clock.NewFake(time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC))
+	service := auth.NewJWTServiceWithKeys(auth.Keys{Secret: "secret", RefreshExpiry: 48 * time.Hour, Clock: now}, time.Hour)
+	pair, err := service.GenerateTokenPair("user_1", "user", "")
+	require.NoError(t, err)
+
+	// Act: the access token has expired but the refresh token has not
+	now.Advance(47 * time.Hour)
+	_, accessErr := service.ValidateToken(pair.AccessToken)
+	_, refreshErr := service.ValidateRefreshToken(pair.RefreshToken)
+	now.Advance(2 * time.Hour)
+	_, expiredErr := service.ValidateRefreshToken(pair.RefreshToken)
+
+	// Assert
+	assert.ErrorIs(t, accessErr, jwt.ErrTokenExpired)
+	assert.NoError(t, refreshErr)
+	assert.ErrorIs(t, expiredErr, jwt.ErrTokenExpired)
+}
+
+func rsaPEM(t *testing.T) (private, public []byte) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	private = pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	public = pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	return private, public
+}
+
+func TestRSAJWTService_VerifiesWithPublicKeyOnly(t *testing.T) {
+	// Arrange
+	private, public := rsaPEM(t)
+	signer, err := auth.NewRSAJWTService(private, nil, time.Hour)
+	require.NoError(t, err)
+	verifier, err := auth.NewRSAJWTService(nil, public, time.Hour)
+	require.NoError(t, err)
+
+	// Act
+	token, err := signer.GenerateToken("user_1", "admin")
+	require.NoError(t, err)
+	claims, verifyErr := verifier.ValidateToken(token)
+	_, signErr := verifier.GenerateToken("user_1", "admin")
+
+	// Assert
+	require.NoError(t, verifyErr)
+	assert.Equal(t, "user_1", claims.UserID)
+	assert.ErrorIs(t, signErr, auth.ErrVerifyOnly)
+}
+
+func TestJWTService_RejectsOtherAlgorithm(t *testing.T) {
+	// Arrange
+	private, public := rsaPEM(t)
+	rs256, err := auth.NewRSAJWTService(private, public, time.Hour)
+	require.NoError(t, err)
+	hs256 := auth.NewJWTService(string(public), time.Hour)
+
+	// Act: an HMAC token keyed with the public key must not pass as RS256
+	rsToken, err := rs256.GenerateToken("user_1", "user")
+	require.NoError(t, err)
+	hsToken, err := hs256.GenerateToken("user_1", "admin")
+	require.NoError(t, err)
+	_, hsAcceptsRS := hs256.ValidateToken(rsToken)
+	_, rsAcceptsHS := rs256.ValidateToken(hsToken)
+
+	// Assert
+	assert.ErrorContains(t, hsAcceptsRS, "unexpected signing method")
+	assert.ErrorContains(t, rsAcceptsHS, "unexpected signing method")
+}
+
+func TestNewJWTServiceFromKeys_Errors(t *testing.T) {
+	for name, keys := range map[string]auth.Keys{
+		"unknown algorithm":  {Algorithm: "es256"},
+		"rs256 without keys": {Algorithm: auth.AlgorithmRS256},
+		"rs256 bad pem":      {Algorithm: auth.AlgorithmRS256, PublicKeyPEM: []byte("not a key")},
+	} {
+		_, err := auth.NewJWTServiceFromKeys(keys, time.Hour)
+		assert.Error(t, err, name)
+	}
+}
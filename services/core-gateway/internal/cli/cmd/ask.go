@@ -7,7 +7,6 @@ import (
 	"io"
 	"net/http"
 	"os"
-	"path/filepath"
 
 	"github.com/spf13/cobra"
 )
@@ -16,11 +15,46 @@ import (
 var askCmd = &cobra.Command{
 	Use:   "ask [message]",
 	Short: "Send a message to the PM Agent",
-	Long:  `Send a natural language request to the Woorung-Gaksi system via the Gateway.`,
-	Args:  cobra.MinimumNArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		message := args[0]
-		sendRequest(message)
+	Long: `Send a natural language request to the Woorung-Gaksi system via the Gateway.
+
+Use --pick N to send the Nth follow-up suggestion from the previous reply.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if pick, _ := cmd.Flags().GetInt("pick"); pick > 0 {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.MinimumNArgs(1)(cmd, args)
+	},
+	// Free-form text: don't offer file names
+	ValidArgsFunction: cobra.NoFileCompletions,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var message string
+		if pick, _ := cmd.Flags().GetInt("pick"); pick > 0 {
+			suggestion, err := pickSuggestion(pick)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "> %s\n", suggestion)
+			message = suggestion
+		} else {
+			message = args[0]
+		}
+
+		return send(cmd, message)
+	},
+}
+
+// retryCmd resends the previous prompt
+var retryCmd = &cobra.Command{
+	Use:   "retry",
+	Short: "Resend your last message (e.g. after the agent errored)",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		message, err := lastPrompt()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "> %s\n", message)
+		return send(cmd, message)
 	},
 }
 
@@ -28,54 +62,52 @@ var askCmd = &cobra.Command{
 var resetCmd = &cobra.Command{
 	Use:   "reset",
 	Short: "Reset the current conversation session",
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		sessionFile := getSessionFilePath()
 		if err := os.Remove(sessionFile); err != nil && !os.IsNotExist(err) {
-			fmt.Printf("Error resetting session: %v\n", err)
-		} else {
-			fmt.Println("Session reset successfully. A new conversation will start next time.")
+			return fmt.Errorf("resetting session: %w", err)
 		}
+		fmt.Fprintln(cmd.OutOrStdout(), "Session reset successfully. A new conversation will start next time.")
+		return nil
 	},
 }
 
 func init() {
+	askCmd.Flags().Bool("stream", false, "Stream the reply as it is generated")
+	askCmd.Flags().IntP("pick", "p", 0, "Send the Nth suggestion from the previous reply")
+	askCmd.Flags().String("token", "", `Access token to use; "-" reads it from stdin`)
+	retryCmd.Flags().Bool("stream", false, "Stream the reply as it is generated")
+	retryCmd.Flags().String("token", "", `Access token to use; "-" reads it from stdin`)
 	rootCmd.AddCommand(askCmd)
+	rootCmd.AddCommand(retryCmd)
 	rootCmd.AddCommand(resetCmd)
 }
 
-func getSessionFilePath() string {
-	home, err := os.UserHomeDir()
+// send remembers message for `woorung retry` and sends it, streaming if --stream is set
+func send(cmd *cobra.Command, message string) error {
+	saveLastPrompt(message)
+	token, err := tokenFlag(cmd)
 	if err != nil {
-		return ".woorung_session"
+		return err
 	}
-	return filepath.Join(home, ".woorung_session")
-}
-
-func loadThreadID() string {
-	data, err := os.ReadFile(getSessionFilePath())
-	if err != nil {
-		return ""
+	if stream, _ := cmd.Flags().GetBool("stream"); stream {
+		return streamTo(message, token, cmd.OutOrStdout())
 	}
-	return string(data)
+	return sendRequest(message, token, cmd.OutOrStdout())
 }
 
-func saveThreadID(threadID string) {
-	if threadID == "" {
-		return
+func lastPrompt() (string, error) {
+	message := loadSession().LastPrompt
+	if message == "" {
+		return "", fmt.Errorf("no previous message to retry")
 	}
-	os.WriteFile(getSessionFilePath(), []byte(threadID), 0600)
+	return message, nil
 }
 
-func sendRequest(message string) {
-	// TODO: Load URL from config or env
-	url := "http://localhost:8080/api/v1/ask" 
-
-	token := os.Getenv("WOORUNG_TOKEN")
-	if token == "" {
-		fmt.Println("Error: WOORUNG_TOKEN environment variable not set.")
-		fmt.Println("Tip: Check the Gateway server logs for the [DEV MODE] Access Token.")
-		return
-	}
+// sendRequest calls /ask and prints the reply and its suggestions to out
+func sendRequest(message, token string, out io.Writer) error {
+	baseURL, httpClient := gatewayClient()
+	url := baseURL + "/api/v1/ask"
 
 	threadID := loadThreadID()
 
@@ -90,32 +122,57 @@ func sendRequest(message string) {
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+token)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
-		fmt.Printf("Error sending request: %v\n", err)
-		return
+		return fmt.Errorf("sending request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, _ := io.ReadAll(resp.Body)
-	
-	// Parse response to capture thread_id
-	var result map[string]interface{}
+
+	// Parse response to capture thread_id and follow-up suggestions
+	var result struct {
+		ThreadID    string   `json:"thread_id"`
+		Suggestions []string `json:"suggestions"`
+	}
 	if err := json.Unmarshal(body, &result); err == nil {
-		if tid, ok := result["thread_id"].(string); ok && tid != "" {
-			saveThreadID(tid)
+		if result.ThreadID != "" {
+			s := loadSession()
+			s.ThreadID = result.ThreadID
+			s.Suggestions = result.Suggestions
+			saveSession(s)
 			// Optional: Print session ID for debugging
 			// fmt.Printf("[Session: %s]\n", tid[:8])
 		}
 	} else {
 		// If fails to parse JSON, just print body string later
 	}
-	
+
 	var prettyJSON bytes.Buffer
 	if err := json.Indent(&prettyJSON, body, "", "  "); err == nil {
-		fmt.Printf("[Woorung Reply]:\n%s\n", prettyJSON.String())
+		fmt.Fprintf(out, "[Woorung Reply]:\n%s\n", prettyJSON.String())
 	} else {
-		fmt.Printf("[Woorung Reply]: %s\n", string(body))
+		fmt.Fprintf(out, "[Woorung Reply]: %s\n", string(body))
+	}
+	printSuggestions(out, result.Suggestions)
+	return nil
+}
+
+// printSuggestions lists follow-ups numbered for `woorung ask --pick N`
+func printSuggestions(out io.Writer, suggestions []string) {
+	if len(suggestions) == 0 {
+		return
+	}
+	fmt.Fprintln(out, "\nSuggestions (woorung ask --pick N):")
+	for i, s := range suggestions {
+		fmt.Fprintf(out, "  %d. %s\n", i+1, s)
+	}
+}
+
+func pickSuggestion(n int) (string, error) {
+	suggestions := loadSession().Suggestions
+	if n < 1 || n > len(suggestions) {
+		return "", fmt.Errorf("no suggestion #%d (previous reply had %d)", n, len(suggestions))
 	}
+	return suggestions[n-1], nil
 }
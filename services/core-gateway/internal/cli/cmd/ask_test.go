@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runCLI runs the CLI in-process and returns its exit code, stdout and stderr.
+// Flags are reset afterwards since the command tree is shared between tests.
+func runCLI(t *testing.T, args ...string) (int, string, string) {
+	t.Helper()
+	return runCLIWithInput(t, "", args...)
+}
+
+// runCLIWithInput is runCLI with stdin
+func runCLIWithInput(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	t.Cleanup(func() { resetFlags(rootCmd) })
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func resetFlags(cmd *cobra.Command) {
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		f.Value.Set(f.DefValue)
+		f.Changed = false
+	})
+	for _, sub := range cmd.Commands() {
+		resetFlags(sub)
+	}
+}
+
+func TestRun_Ask(t *testing.T) {
+	// Arrange
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("WOORUNG_TOKEN", "token")
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/ask", r.URL.Path)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		w.Write([]byte(`{"reply":"Done","thread_id":"thread_1","suggestions":["Open a PR"]}`))
+	}))
+	defer gateway.Close()
+	t.Setenv("WOORUNG_URL", gateway.URL)
+
+	// Act
+	code, stdout, stderr := runCLI(t, "ask", "hello")
+
+	// Assert
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout, "[Woorung Reply]:\n{\n  \"reply\": \"Done\"")
+	assert.Contains(t, stdout, "1. Open a PR")
+	assert.Empty(t, stderr)
+	assert.Equal(t, "thread_1", loadThreadID())
+}
+
+func TestRun_AskErrors(t *testing.T) {
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	tests := []struct {
+		name   string
+		token  string
+		args   []string
+		stderr string
+	}{
+		{"no token", "", []string{"ask", "hello"}, "Error: WOORUNG_TOKEN environment variable not set.\nTip:"},
+		{"gateway down", "token", []string{"ask", "hello"}, "Error: sending request:"},
+		{"no suggestion to pick", "token", []string{"ask", "--pick", "2"}, "Error: no suggestion #2 (previous reply had 0)"},
+		{"no message", "token", []string{"ask"}, "Error: requires at least 1 arg(s)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			t.Setenv("HOME", t.TempDir())
+			t.Setenv("WOORUNG_TOKEN", tt.token)
+			t.Setenv("WOORUNG_URL", closed.URL)
+
+			// Act
+			code, _, stderr := runCLI(t, tt.args...)
+
+			// Assert
+			assert.Equal(t, 1, code)
+			assert.True(t, strings.HasPrefix(stderr, tt.stderr), stderr)
+		})
+	}
+}
+
+func TestPrintSuggestions(t *testing.T) {
+	var out bytes.Buffer
+	printSuggestions(&out, []string{"Show the diff", "Open a PR"})
+	assert.Equal(t, "\nSuggestions (woorung ask --pick N):\n  1. Show the diff\n  2. Open a PR\n", out.String())
+
+	out.Reset()
+	printSuggestions(&out, nil)
+	assert.Empty(t, out.String())
+}
+
+func TestPickSuggestion(t *testing.T) {
+	// Arrange
+	t.Setenv("HOME", t.TempDir())
+	saveSession(session{ThreadID: "thread_1", Suggestions: []string{"Show the diff", "Open a PR"}})
+
+	// Act
+	picked, err := pickSuggestion(2)
+	_, outOfRange := pickSuggestion(3)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "Open a PR", picked)
+	assert.Error(t, outOfRange)
+}
+
+func TestLoadSession_LegacyPlainThreadID(t *testing.T) {
+	// Arrange: older CLIs wrote the bare thread ID
+	t.Setenv("HOME", t.TempDir())
+	require.NoError(t, os.WriteFile(getSessionFilePath(), []byte("thread_legacy"), 0600))
+
+	// Act & Assert
+	assert.Equal(t, "thread_legacy", loadThreadID())
+}
+
+func TestLastPrompt_PersistedAlongsideThread(t *testing.T) {
+	// Arrange
+	t.Setenv("HOME", t.TempDir())
+	_, err := lastPrompt()
+	require.Error(t, err, "Nothing to retry in a fresh session")
+
+	// Act
+	saveThreadID("thread_1")
+	saveLastPrompt("deploy the staging build")
+	saveThreadID("thread_2")
+
+	// Assert
+	message, err := lastPrompt()
+	require.NoError(t, err)
+	assert.Equal(t, "deploy the staging build", message)
+	assert.Equal(t, "thread_2", loadThreadID())
+}
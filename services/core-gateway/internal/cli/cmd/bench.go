@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	// benchMaxThrottleRetries is how often one request is retried after 429
+	benchMaxThrottleRetries = 5
+	// benchDefaultBackoff is the wait after a 429 without a usable Retry-After
+	benchDefaultBackoff = time.Second
+)
+
+// errBenchUnauthorized stops a benchmark whose token the gateway rejects,
+// since every remaining request would fail the same way
+var errBenchUnauthorized = errors.New("gateway rejected the token (401)")
+
+// benchCmd measures gateway → agent latency under concurrent load
+var benchCmd = &cobra.Command{
+	Use:   "bench [prompt]",
+	Short: "Benchmark gateway → agent latency",
+	Long: `Send the same prompt to /ask many times and summarize latency and errors.
+
+Requests run --concurrency at a time until --requests have completed. Each
+starts a new conversation, so your current session is untouched. A 429 is
+retried after the gateway's Retry-After; the wait is not counted as latency.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		requests, _ := cmd.Flags().GetInt("requests")
+		if concurrency < 1 || requests < 1 {
+			return fmt.Errorf("--concurrency and --requests must be at least 1")
+		}
+		token, err := tokenFlag(cmd)
+		if err != nil {
+			return err
+		}
+		summary, err := runBench(args[0], token, concurrency, requests)
+		if err != nil {
+			return err
+		}
+		summary.print(cmd.OutOrStdout())
+		return nil
+	},
+}
+
+func init() {
+	benchCmd.Flags().IntP("concurrency", "c", 1, "Requests in flight at once")
+	benchCmd.Flags().IntP("requests", "n", 10, "Total requests to send")
+	benchCmd.Flags().String("token", "", `Access token to use; "-" reads it from stdin`)
+	rootCmd.AddCommand(benchCmd)
+}
+
+// benchSummary is the outcome of a benchmark run
+type benchSummary struct {
+	Requests  int
+	Errors    int
+	Throttled int
+	Elapsed   time.Duration
+	P50       time.Duration
+	P90       time.Duration
+	P99       time.Duration
+}
+
+// ErrorRate is the share of requests that failed, from 0 to 1
+func (s benchSummary) ErrorRate() float64 {
+	if s.Requests == 0 {
+		return 0
+	}
+	return float64(s.Errors) / float64(s.Requests)
+}
+
+func (s benchSummary) print(out io.Writer) {
+	fmt.Fprintf(out, "Requests:   %d\n", s.Requests)
+	fmt.Fprintf(out, "Errors:     %d (%.1f%%)\n", s.Errors, s.ErrorRate()*100)
+	fmt.Fprintf(out, "Throttled:  %d\n", s.Throttled)
+	fmt.Fprintf(out, "Elapsed:    %s\n", s.Elapsed.Round(time.Millisecond))
+	if s.Elapsed > 0 {
+		fmt.Fprintf(out, "Throughput: %.2f req/s\n", float64(s.Requests)/s.Elapsed.Seconds())
+	}
+	fmt.Fprintf(out, "Latency:    p50 %s  p90 %s  p99 %s\n",
+		s.P50.Round(time.Millisecond), s.P90.Round(time.Millisecond), s.P99.Round(time.Millisecond))
+}
+
+// benchResult is one request's outcome
+type benchResult struct {
+	latency   time.Duration
+	throttled int
+	err       error
+}
+
+// runBench sends requests prompts with concurrency workers and summarizes them.
+// Latency percentiles cover successful requests only.
+func runBench(prompt, token string, concurrency, requests int) (benchSummary, error) {
+	baseURL, httpClient := gatewayClient()
+	payload, _ := json.Marshal(map[string]string{"message": prompt, "source": "batch"})
+
+	jobs := make(chan struct{})
+	results := make(chan benchResult)
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for range min(concurrency, requests) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range jobs {
+				results <- benchOne(httpClient, baseURL+"/api/v1/ask", token, payload)
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for range requests {
+			select {
+			case jobs <- struct{}{}:
+			case <-stop:
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	start := time.Now()
+	summary := benchSummary{}
+	var latencies []time.Duration
+	var fatal error
+	for r := range results {
+		if errors.Is(r.err, errBenchUnauthorized) && fatal == nil {
+			fatal = r.err
+			close(stop)
+		}
+		summary.Requests++
+		summary.Throttled += r.throttled
+		if r.err != nil {
+			summary.Errors++
+			continue
+		}
+		latencies = append(latencies, r.latency)
+	}
+	if fatal != nil {
+		return summary, fatal
+	}
+	summary.Elapsed = time.Since(start)
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	summary.P50 = percentile(latencies, 50)
+	summary.P90 = percentile(latencies, 90)
+	summary.P99 = percentile(latencies, 99)
+	return summary, nil
+}
+
+// benchOne sends one /ask, waiting out 429s up to benchMaxThrottleRetries times
+func benchOne(httpClient *http.Client, url, token string, payload []byte) benchResult {
+	var result benchResult
+	for {
+		req, _ := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		start := time.Now()
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			result.err = err
+			return result
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		result.latency = time.Since(start)
+
+		switch {
+		case resp.StatusCode == http.StatusTooManyRequests && result.throttled < benchMaxThrottleRetries:
+			result.throttled++
+			time.Sleep(retryAfter(resp.Header.Get("Retry-After")))
+			continue
+		case resp.StatusCode == http.StatusUnauthorized:
+			result.err = errBenchUnauthorized
+		case resp.StatusCode != http.StatusOK:
+			result.err = fmt.Errorf("gateway returned %d", resp.StatusCode)
+		}
+		return result
+	}
+}
+
+// retryAfter parses a Retry-After in seconds, else benchDefaultBackoff
+func retryAfter(header string) time.Duration {
+	if secs, err := strconv.Atoi(header); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second
+	}
+	return benchDefaultBackoff
+}
+
+// percentile returns the nearest-rank p-th percentile of sorted (0 when empty)
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun_Bench(t *testing.T) {
+	// Arrange: every 4th request fails and the first one is throttled once
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("WOORUNG_TOKEN", "token")
+	var calls atomic.Int32
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/ask", r.URL.Path)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		switch n := calls.Add(1); {
+		case n == 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case n%4 == 0:
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.Write([]byte(`{"reply":"ok"}`))
+		}
+	}))
+	defer gateway.Close()
+	t.Setenv("WOORUNG_URL", gateway.URL)
+
+	// Act
+	code, stdout, stderr := runCLI(t, "bench", "-c", "2", "-n", "8", "hello")
+
+	// Assert
+	require.Equal(t, 0, code, stderr)
+	assert.Contains(t, stdout, "Requests:   8\n")
+	assert.Contains(t, stdout, "Errors:     2 (25.0%)\n")
+	assert.Contains(t, stdout, "Throttled:  1\n")
+	assert.Contains(t, stdout, "Latency:    p50 ")
+	assert.Empty(t, loadThreadID(), "bench must not touch the session")
+}
+
+func TestRun_BenchStopsOnUnauthorized(t *testing.T) {
+	// Arrange
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("WOORUNG_TOKEN", "expired")
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer gateway.Close()
+	t.Setenv("WOORUNG_URL", gateway.URL)
+
+	// Act
+	code, _, stderr := runCLI(t, "bench", "-n", "50", "hello")
+
+	// Assert
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "Error: gateway rejected the token (401)")
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := make([]time.Duration, 100)
+	for i := range sorted {
+		sorted[i] = time.Duration(i+1) * time.Millisecond
+	}
+
+	assert.Equal(t, 50*time.Millisecond, percentile(sorted, 50))
+	assert.Equal(t, 90*time.Millisecond, percentile(sorted, 90))
+	assert.Equal(t, 99*time.Millisecond, percentile(sorted, 99))
+	assert.Equal(t, time.Duration(0), percentile(nil, 50))
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const chatHelp = `Commands: /new (start a new thread), /reset (clear the session), /exit`
+
+// chatCmd opens an interactive session with the PM Agent
+var chatCmd = &cobra.Command{
+	Use:   "chat",
+	Short: "Start an interactive chat session (REPL)",
+	Long: `Chat with the PM Agent interactively. Replies are streamed and the
+conversation thread is kept across turns and invocations.
+
+` + chatHelp,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runChat(cmd.InOrStdin(), cmd.OutOrStdout())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(chatCmd)
+}
+
+// runChat reads prompts from in until /exit or EOF and streams each reply to out
+func runChat(in io.Reader, out io.Writer) {
+	fmt.Fprintln(out, "Woorung-Gaksi chat. "+chatHelp)
+
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(out, "woorung> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		switch line {
+		case "":
+			continue
+		case "/exit", "/quit":
+			return
+		case "/new":
+			s := loadSession()
+			s.ThreadID = ""
+			s.Suggestions = nil
+			saveSession(s)
+			fmt.Fprintln(out, "Started a new thread.")
+			continue
+		case "/reset":
+			if err := os.Remove(getSessionFilePath()); err != nil && !os.IsNotExist(err) {
+				fmt.Fprintf(out, "Error resetting session: %v\n", err)
+			} else {
+				fmt.Fprintln(out, "Session reset.")
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "/") {
+			fmt.Fprintf(out, "Unknown command %s. %s\n", line, chatHelp)
+			continue
+		}
+
+		// A failed turn is reported and the session carries on
+		saveLastPrompt(line)
+		token, err := accessToken()
+		if err == nil {
+			err = streamTo(line, token, out)
+		}
+		if err != nil {
+			fmt.Fprintf(out, "Error: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunChat_ScriptedSession(t *testing.T) {
+	// Arrange: a stub gateway that streams "reply to <msg>" and assigns thread_A
+	var threadIDs []string
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/ask/stream", r.URL.Path)
+		var req map[string]string
+		json.NewDecoder(r.Body).Decode(&req)
+		threadIDs = append(threadIDs, req["thread_id"])
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "event:message\ndata:reply to %s\n\n", req["message"])
+		fmt.Fprint(w, "event:done\ndata:{\"thread_id\":\"thread_A\"}\n\n")
+	}))
+	defer gateway.Close()
+
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("WOORUNG_URL", gateway.URL)
+	t.Setenv("WOORUNG_TOKEN", "token")
+	script := "hello\nmore\n/new\nagain\n/bogus\n/exit\nnever sent\n"
+
+	// Act
+	var out bytes.Buffer
+	runChat(strings.NewReader(script), &out)
+
+	// Assert: the thread carries across turns until /new
+	assert.Equal(t, []string{"", "thread_A", ""}, threadIDs)
+	assert.Contains(t, out.String(), "woorung> reply to hello\n")
+	assert.Contains(t, out.String(), "woorung> reply to more\n")
+	assert.Contains(t, out.String(), "Started a new thread.")
+	assert.Contains(t, out.String(), "Unknown command /bogus")
+	assert.NotContains(t, out.String(), "never sent")
+	assert.Equal(t, "again", loadSession().LastPrompt)
+}
+
+func TestRunChat_ResetAndEOF(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	saveThreadID("thread_old")
+
+	var out bytes.Buffer
+	runChat(strings.NewReader("/reset\n"), &out)
+
+	assert.Contains(t, out.String(), "Session reset.")
+	assert.Empty(t, loadThreadID())
+}
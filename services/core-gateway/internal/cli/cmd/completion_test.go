@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompletionCmd(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish", "powershell"} {
+		t.Run(shell, func(t *testing.T) {
+			// Arrange
+			var out bytes.Buffer
+			rootCmd.SetOut(&out)
+			rootCmd.SetArgs([]string{"completion", shell})
+			defer rootCmd.SetOut(nil)
+
+			// Act
+			err := rootCmd.Execute()
+
+			// Assert
+			assert.NoError(t, err)
+			assert.NotEmpty(t, out.String())
+			assert.Contains(t, out.String(), "woorung")
+		})
+	}
+}
+
+func TestCompletionCmd_RejectsUnknownShell(t *testing.T) {
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetErr(&out)
+	rootCmd.SetArgs([]string{"completion", "tcsh"})
+	defer rootCmd.SetOut(nil)
+	defer rootCmd.SetErr(nil)
+
+	assert.Error(t, rootCmd.Execute())
+}
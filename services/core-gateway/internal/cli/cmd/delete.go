@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// deleteCmd removes a conversation and its messages on the gateway
+var deleteCmd = &cobra.Command{
+	Use:   "delete [thread_id]",
+	Short: "Delete a conversation and all of its messages",
+	Long: `Permanently delete one of your conversations from the gateway.
+
+Without a thread ID the current session's conversation is deleted and the
+session is reset.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		threadID := loadThreadID()
+		if len(args) == 1 {
+			threadID = args[0]
+		}
+		if threadID == "" {
+			return fmt.Errorf("no thread given and no current session")
+		}
+		return deleteThread(cmd.OutOrStdout(), threadID)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(deleteCmd)
+}
+
+func deleteThread(out io.Writer, threadID string) error {
+	token, err := accessToken()
+	if err != nil {
+		return err
+	}
+
+	baseURL, httpClient := gatewayClient()
+	req, err := http.NewRequest(http.MethodDelete, baseURL+"/api/v1/conversations/"+threadID, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("gateway returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	// The deleted thread can't be continued
+	if s := loadSession(); s.ThreadID == threadID {
+		saveSession(session{LastPrompt: s.LastPrompt})
+	}
+	fmt.Fprintf(out, "Deleted conversation %s\n", threadID)
+	return nil
+}
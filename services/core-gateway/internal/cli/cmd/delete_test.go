@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteThread_ResetsCurrentSession(t *testing.T) {
+	// Arrange
+	var gotMethod, gotPath string
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod, gotPath = r.Method, r.URL.Path
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer gateway.Close()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("WOORUNG_URL", gateway.URL)
+	t.Setenv("WOORUNG_TOKEN", "token")
+	saveSession(session{ThreadID: "thread_1", LastPrompt: "hi"})
+	var out bytes.Buffer
+
+	// Act
+	err := deleteThread(&out, "thread_1")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodDelete, gotMethod)
+	assert.Equal(t, "/api/v1/conversations/thread_1", gotPath)
+	assert.Empty(t, loadThreadID())
+	assert.Equal(t, "hi", loadSession().LastPrompt)
+	assert.Contains(t, out.String(), "Deleted conversation thread_1")
+}
+
+func TestDeleteThread_ReportsNotFound(t *testing.T) {
+	// Arrange
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"Conversation not found"}`, http.StatusNotFound)
+	}))
+	defer gateway.Close()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("WOORUNG_URL", gateway.URL)
+	t.Setenv("WOORUNG_TOKEN", "token")
+	saveSession(session{ThreadID: "thread_1"})
+
+	// Act
+	err := deleteThread(&bytes.Buffer{}, "other")
+
+	// Assert
+	assert.ErrorContains(t, err, "404")
+	assert.Equal(t, "thread_1", loadThreadID())
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/config"
+	"github.com/spf13/cobra"
+)
+
+// envNamePattern keeps --env a plain file name under config/envs
+var envNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// initConfigCmd writes a starter gateway config
+var initConfigCmd = &cobra.Command{
+	Use:   "init-config",
+	Short: "Write a commented sample gateway config to config/envs/<env>.yaml",
+	Long: `Write a commented sample config for the gateway, relative to the current
+directory (run it from services/core-gateway). The sample uses SQLite storage and a
+freshly generated JWT secret, and lists the most useful optional keys.
+
+An existing file is never overwritten unless --force is given.`,
+	Args:              cobra.NoArgs,
+	ValidArgsFunction: cobra.NoFileCompletions,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		env, _ := cmd.Flags().GetString("env")
+		force, _ := cmd.Flags().GetBool("force")
+		path, err := initConfig(env, force)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Wrote %s. Start the gateway with APP_ENV=%s.\n", path, env)
+		return nil
+	},
+}
+
+func init() {
+	initConfigCmd.Flags().String("env", "local", "env name; the file is config/envs/<env>.yaml")
+	initConfigCmd.Flags().Bool("force", false, "overwrite an existing config file")
+	rootCmd.AddCommand(initConfigCmd)
+}
+
+// initConfig writes config.Sample(env) to config/envs/<env>.yaml and returns its path
+func initConfig(env string, force bool) (string, error) {
+	if !envNamePattern.MatchString(env) {
+		return "", fmt.Errorf("invalid env %q: use letters, digits, '-' and '_'", env)
+	}
+	data, err := config.Sample(env)
+	if err != nil {
+		return "", fmt.Errorf("failed to render the sample config: %w", err)
+	}
+
+	path := filepath.Join("config", "envs", env+".yaml")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	// The file holds the JWT secret
+	f, err := os.OpenFile(path, flags, 0600)
+	if errors.Is(err, fs.ErrExist) {
+		return "", fmt.Errorf("%s already exists; pass --force to overwrite it", path)
+	}
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return "", err
+	}
+	return path, f.Close()
+}
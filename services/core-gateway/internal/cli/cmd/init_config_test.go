@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitConfig_GeneratedFileLoads(t *testing.T) {
+	for _, env := range []string{"local", "staging"} {
+		t.Run(env, func(t *testing.T) {
+			// Arrange: config.Load reads config/envs relative to the working directory
+			t.Chdir(t.TempDir())
+
+			// Act
+			code, stdout, stderr := runCLI(t, "init-config", "--env", env)
+			cfg, err := config.LoadWithOptions(env, config.LoadOptions{Strict: true})
+
+			// Assert
+			require.Equal(t, 0, code, stderr)
+			assert.Contains(t, stdout, filepath.Join("config", "envs", env+".yaml"))
+			require.NoError(t, err)
+			assert.Equal(t, "sqlite", cfg.DB.Driver)
+			assert.Len(t, cfg.JWT.Secret, 64)
+			if env == "local" {
+				assert.Equal(t, "debug", cfg.Server.Mode)
+			} else {
+				assert.Empty(t, cfg.InsecureDefaults(env), "the sample is safe outside local")
+			}
+		})
+	}
+}
+
+func TestInitConfig_RefusesToOverwriteWithoutForce(t *testing.T) {
+	// Arrange
+	t.Chdir(t.TempDir())
+	path := filepath.Join("config", "envs", "local.yaml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte("server:\n  port: \"9999\"\n"), 0600))
+
+	// Act
+	code, _, stderr := runCLI(t, "init-config")
+	kept, _ := os.ReadFile(path)
+	forcedCode, _, _ := runCLI(t, "init-config", "--force")
+	forced, _ := os.ReadFile(path)
+
+	// Assert
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "--force")
+	assert.Equal(t, "server:\n  port: \"9999\"\n", string(kept))
+	assert.Equal(t, 0, forcedCode)
+	assert.Contains(t, string(forced), "woorung init-config")
+}
+
+func TestInitConfig_RejectsPathLikeEnv(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	code, _, stderr := runCLI(t, "init-config", "--env", "../secrets")
+
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "invalid env")
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// credentials is the token saved by `woorung login` (~/.woorung_credentials).
+// It is kept apart from the session so `woorung reset` doesn't log out.
+type credentials struct {
+	Token string `json:"token"`
+	// LastUsed drives the local idle logout (WOORUNG_IDLE_TIMEOUT)
+	LastUsed time.Time `json:"last_used,omitempty"`
+}
+
+func getCredentialsFilePath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".woorung_credentials"
+	}
+	return filepath.Join(home, ".woorung_credentials")
+}
+
+func loadCredentials() credentials {
+	var c credentials
+	data, err := os.ReadFile(getCredentialsFilePath())
+	if err == nil {
+		json.Unmarshal(data, &c)
+	}
+	return c
+}
+
+func saveCredentials(c credentials) error {
+	data, _ := json.Marshal(c)
+	return os.WriteFile(getCredentialsFilePath(), data, 0600)
+}
+
+// idleTimeout is how long a saved token may go unused before it is discarded
+// (env: WOORUNG_IDLE_TIMEOUT, a duration such as "30m"; unset or 0 = never)
+func idleTimeout() (time.Duration, error) {
+	raw := os.Getenv("WOORUNG_IDLE_TIMEOUT")
+	if raw == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid WOORUNG_IDLE_TIMEOUT %q: %w", raw, err)
+	}
+	return d, nil
+}
+
+// savedToken returns the token saved by `woorung login` and marks it used.
+// A token idle for longer than idleTimeout is deleted instead, so a shared
+// machine left alone doesn't stay logged in.
+func savedToken() (string, error) {
+	c := loadCredentials()
+	if c.Token == "" {
+		return "", nil
+	}
+	timeout, err := idleTimeout()
+	if err != nil {
+		return "", err
+	}
+	if timeout > 0 && !c.LastUsed.IsZero() && now().Sub(c.LastUsed) > timeout {
+		if err := os.Remove(getCredentialsFilePath()); err != nil && !os.IsNotExist(err) {
+			return "", fmt.Errorf("clearing expired credentials: %w", err)
+		}
+		return "", fmt.Errorf("session expired after %s of inactivity; run 'woorung login' again", timeout)
+	}
+	c.LastUsed = now()
+	saveCredentials(c)
+	return c.Token, nil
+}
+
+// loginCmd saves an access token for later commands
+var loginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Save an access token for the gateway",
+	Long: `Save an access token so later commands don't need WOORUNG_TOKEN.
+
+Credentials are read from stdin so they never appear in process arguments or
+shell history (like docker login):
+
+  echo "$TOKEN" | woorung login --token-stdin
+  echo "$PASSWORD" | woorung login -u alice --password-stdin
+
+WOORUNG_TOKEN still takes precedence when set. On shared machines, set
+WOORUNG_IDLE_TIMEOUT (e.g. "30m") to discard the saved token after that long unused.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tokenStdin, _ := cmd.Flags().GetBool("token-stdin")
+		passwordStdin, _ := cmd.Flags().GetBool("password-stdin")
+		username, _ := cmd.Flags().GetString("username")
+
+		var token string
+		var err error
+		switch {
+		case tokenStdin && passwordStdin:
+			return fmt.Errorf("--token-stdin and --password-stdin are mutually exclusive")
+		case tokenStdin:
+			token, err = readSecret(cmd.InOrStdin(), "token")
+		case passwordStdin:
+			if username == "" {
+				return fmt.Errorf("--password-stdin requires --username")
+			}
+			var password string
+			if password, err = readSecret(cmd.InOrStdin(), "password"); err == nil {
+				token, err = loginWithPassword(username, password)
+			}
+		default:
+			return fmt.Errorf("pass --token-stdin, or --username with --password-stdin")
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := saveCredentials(credentials{Token: token, LastUsed: now()}); err != nil {
+			return fmt.Errorf("saving credentials: %w", err)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), "Login succeeded.")
+		return nil
+	},
+}
+
+func init() {
+	loginCmd.Flags().Bool("token-stdin", false, "Read the access token from stdin")
+	loginCmd.Flags().Bool("password-stdin", false, "Read the password from stdin and log in with --username")
+	loginCmd.Flags().StringP("username", "u", "", "Username for --password-stdin")
+	rootCmd.AddCommand(loginCmd)
+}
+
+// loginWithPassword exchanges credentials for a token via POST /auth/login
+func loginWithPassword(username, password string) (string, error) {
+	baseURL, httpClient := gatewayClient()
+	payload, _ := json.Marshal(map[string]string{"username": username, "password": password})
+
+	resp, err := httpClient.Post(baseURL+"/auth/login", "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("gateway returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	var result struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil || result.Token == "" {
+		return "", fmt.Errorf("unexpected login response: %s", strings.TrimSpace(string(body)))
+	}
+	return result.Token, nil
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tokenGateway answers /auth/login for alice and records the bearer token of each ask
+func tokenGateway(t *testing.T, seen *[]string) {
+	t.Helper()
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/auth/login" {
+			var req map[string]string
+			json.NewDecoder(r.Body).Decode(&req)
+			if req["username"] != "alice" || req["password"] != "s3cret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"token":"password-token"}`))
+			return
+		}
+		*seen = append(*seen, r.Header.Get("Authorization"))
+		w.Write([]byte(`{"reply":"Done","thread_id":"thread_1"}`))
+	}))
+	t.Cleanup(gateway.Close)
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("WOORUNG_URL", gateway.URL)
+	t.Setenv("WOORUNG_TOKEN", "")
+}
+
+func TestLogin_TokenStdin(t *testing.T) {
+	// Arrange
+	var seen []string
+	tokenGateway(t, &seen)
+
+	// Act
+	code, stdout, stderr := runCLIWithInput(t, "stdin-token\n", "login", "--token-stdin")
+	askCode, _, _ := runCLI(t, "ask", "hello")
+
+	// Assert
+	require.Equal(t, 0, code, stderr)
+	assert.Equal(t, "Login succeeded.\n", stdout)
+	assert.Equal(t, 0, askCode)
+	assert.Equal(t, []string{"Bearer stdin-token"}, seen)
+	info, err := os.Stat(getCredentialsFilePath())
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestLogin_PasswordStdin(t *testing.T) {
+	// Arrange
+	var seen []string
+	tokenGateway(t, &seen)
+
+	// Act
+	wrong, _, stderr := runCLIWithInput(t, "nope\n", "login", "-u", "alice", "--password-stdin")
+	code, _, _ := runCLIWithInput(t, "s3cret\n", "login", "-u", "alice", "--password-stdin")
+
+	// Assert
+	assert.Equal(t, 1, wrong)
+	assert.Contains(t, stderr, "gateway returned 401")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "password-token", loadCredentials().Token)
+}
+
+func TestAsk_TokenFromStdin(t *testing.T) {
+	// Arrange: a saved login is overridden by --token -
+	var seen []string
+	tokenGateway(t, &seen)
+	require.NoError(t, saveCredentials(credentials{Token: "saved-token"}))
+
+	// Act
+	code, _, stderr := runCLIWithInput(t, "piped-token\n", "ask", "--token", "-", "hello")
+	empty, _, emptyErr := runCLIWithInput(t, "", "ask", "--token", "-", "hello")
+
+	// Assert
+	require.Equal(t, 0, code, stderr)
+	assert.Equal(t, []string{"Bearer piped-token"}, seen)
+	assert.Equal(t, 1, empty)
+	assert.Equal(t, "Error: no token on stdin\n", emptyErr)
+}
+
+func TestSavedToken_IdleLogout(t *testing.T) {
+	// Arrange
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("WOORUNG_TOKEN", "")
+	t.Setenv("WOORUNG_IDLE_TIMEOUT", "30m")
+	at := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	pinNow(t, at)
+	code, _, _ := runCLIWithInput(t, "saved-token\n", "login", "--token-stdin")
+	require.Equal(t, 0, code)
+
+	// Act: use within the window keeps the session alive and slides it
+	pinNow(t, at.Add(20*time.Minute))
+	token, err := accessToken()
+	require.NoError(t, err)
+	assert.Equal(t, "saved-token", token)
+
+	pinNow(t, at.Add(20*time.Minute+31*time.Minute))
+	_, expired := accessToken()
+	_, afterwards := accessToken()
+
+	// Assert: the stale token is gone and a new login is required
+	assert.ErrorContains(t, expired, "session expired after 30m0s of inactivity")
+	assert.ErrorIs(t, afterwards, errNoToken)
+	_, statErr := os.Stat(getCredentialsFilePath())
+	assert.True(t, os.IsNotExist(statErr))
+}
@@ -1,9 +1,14 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"strings"
 
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/client"
 	"github.com/spf13/cobra"
 )
 
@@ -11,15 +16,88 @@ var rootCmd = &cobra.Command{
 	Use:   "woorung",
 	Short: "CLI Client for Woorung-Gaksi",
 	Long:  "Control your AI Factory from the terminal. Neovim ready.",
+	// Errors are reported once, by run
+	SilenceErrors: true,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// Arguments were valid, so later errors shouldn't print the usage text
+		cmd.SilenceUsage = true
+	},
 	Run: func(cmd *cobra.Command, args []string) {
-		// Do Stuff Here
-		fmt.Println("Hi! I'm your Woorung-Gaksi CLI. Try 'woorung help'")
+		fmt.Fprintln(cmd.OutOrStdout(), "Hi! I'm your Woorung-Gaksi CLI. Try 'woorung help'")
 	},
 }
 
+// errNoToken is returned by commands that call the gateway without WOORUNG_TOKEN
+var errNoToken = errors.New("WOORUNG_TOKEN environment variable not set.\nTip: Run 'woorung login -u <username> --password-stdin' (or --token-stdin); in debug mode the Gateway server logs also print a [DEV MODE] Access Token.")
+
+// Execute runs the CLI on the process arguments and exits with its status.
+// It is the only place the CLI exits.
 func Execute() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the CLI with args and the given streams and returns the exit
+// code: 0 on success, 1 after printing the command's error to stderr
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	rootCmd.SetArgs(args)
+	rootCmd.SetIn(stdin)
+	rootCmd.SetOut(stdout)
+	rootCmd.SetErr(stderr)
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// accessToken returns WOORUNG_TOKEN, else the token saved by `woorung login`,
+// or errNoToken when there is neither
+func accessToken() (string, error) {
+	if t := os.Getenv("WOORUNG_TOKEN"); t != "" {
+		return t, nil
+	}
+	if t, err := savedToken(); t != "" || err != nil {
+		return t, err
+	}
+	return "", errNoToken
+}
+
+// tokenFlag resolves cmd's --token flag ("-" reads stdin), falling back to accessToken
+func tokenFlag(cmd *cobra.Command) (string, error) {
+	switch flag, _ := cmd.Flags().GetString("token"); flag {
+	case "":
+		return accessToken()
+	case "-":
+		return readSecret(cmd.InOrStdin(), "token")
+	default:
+		return flag, nil
+	}
+}
+
+// readSecret reads a single credential from r, so it never shows up in
+// process arguments or shell history
+func readSecret(r io.Reader, what string) (string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("reading %s from stdin: %w", what, err)
+	}
+	secret := strings.TrimSpace(string(data))
+	if secret == "" {
+		return "", fmt.Errorf("no %s on stdin", what)
 	}
+	return secret, nil
+}
+
+// gatewayURL is the Core Gateway base URL (env: WOORUNG_URL), possibly a
+// unix:// socket URL; use gatewayClient to send requests to it
+func gatewayURL() string {
+	if url := os.Getenv("WOORUNG_URL"); url != "" {
+		return strings.TrimRight(url, "/")
+	}
+	return "http://localhost:8080"
+}
+
+// gatewayClient resolves gatewayURL to a request base URL and HTTP client
+func gatewayClient() (string, *http.Client) {
+	return client.ForURL(gatewayURL())
 }
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// session is the CLI's local conversation state (~/.woorung_session)
+type session struct {
+	ThreadID    string   `json:"thread_id"`
+	Suggestions []string `json:"suggestions,omitempty"`
+	// LastPrompt is resent by `woorung retry`
+	LastPrompt string `json:"last_prompt,omitempty"`
+}
+
+func getSessionFilePath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".woorung_session"
+	}
+	return filepath.Join(home, ".woorung_session")
+}
+
+// loadSession reads the session file. Older CLIs stored the bare thread ID, which is still accepted.
+func loadSession() session {
+	data, err := os.ReadFile(getSessionFilePath())
+	if err != nil {
+		return session{}
+	}
+
+	var s session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return session{ThreadID: strings.TrimSpace(string(data))}
+	}
+	return s
+}
+
+func saveSession(s session) {
+	data, _ := json.Marshal(s)
+	os.WriteFile(getSessionFilePath(), data, 0600)
+}
+
+func loadThreadID() string {
+	return loadSession().ThreadID
+}
+
+func saveThreadID(threadID string) {
+	if threadID == "" {
+		return
+	}
+	s := loadSession()
+	s.ThreadID = threadID
+	saveSession(s)
+}
+
+// saveLastPrompt remembers message before it is sent, so it can be retried even if the request fails
+func saveLastPrompt(message string) {
+	s := loadSession()
+	s.LastPrompt = message
+	saveSession(s)
+}
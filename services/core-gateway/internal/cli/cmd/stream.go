@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	ansiDim   = "\033[2m"
+	ansiReset = "\033[0m"
+)
+
+// streamTo calls /ask/stream and prints the reply to out as it arrives.
+// Tool calls and reasoning steps are shown dimmed on their own lines.
+func streamTo(message, token string, out io.Writer) error {
+	baseURL, httpClient := gatewayClient()
+	url := baseURL + "/api/v1/ask/stream"
+
+	payload := map[string]string{
+		"message":   message,
+		"source":    "cli",
+		"thread_id": loadThreadID(),
+	}
+	jsonData, _ := json.Marshal(payload)
+
+	req, _ := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("[Woorung Error %d]: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	printStream(resp.Body, out)
+	return nil
+}
+
+// printStream renders gateway SSE events to out and persists the thread_id from the done event
+func printStream(r io.Reader, out io.Writer) {
+	var event string
+	var data []string
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(line, "data:"))
+		case line == "" && len(data) > 0:
+			renderEvent(out, event, strings.Join(data, "\n"))
+			event, data = "", nil
+		}
+	}
+}
+
+func renderEvent(out io.Writer, event, data string) {
+	switch event {
+	case "thinking", "tool":
+		fmt.Fprintf(out, "\n%s[%s] %s%s\n", ansiDim, event, data, ansiReset)
+	case "done":
+		var done struct {
+			ThreadID string `json:"thread_id"`
+			Error    string `json:"error"`
+		}
+		if json.Unmarshal([]byte(data), &done) == nil {
+			saveThreadID(done.ThreadID)
+			if done.Error != "" {
+				fmt.Fprintf(out, "\n[Woorung Error]: %s", done.Error)
+			}
+		}
+		fmt.Fprintln(out)
+	default:
+		fmt.Fprint(out, data)
+	}
+}
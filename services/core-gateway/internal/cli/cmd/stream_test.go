@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintStream_MixedEvents(t *testing.T) {
+	// Arrange: isolate the session file
+	t.Setenv("HOME", t.TempDir())
+	sse := "event:tool\ndata:web_search\n\n" +
+		"event:message\ndata:Hello \n\n" +
+		"event:message\ndata:world\n\n" +
+		"event:done\ndata:{\"thread_id\":\"thread_9\"}\n\n"
+
+	// Act
+	var out bytes.Buffer
+	printStream(strings.NewReader(sse), &out)
+
+	// Assert
+	assert.Equal(t, "\n"+ansiDim+"[tool] web_search"+ansiReset+"\nHello world\n", out.String())
+	assert.Equal(t, "thread_9", loadThreadID())
+}
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/spf13/cobra"
+)
+
+// now is the clock used for token expiry and idle logout (tests pin it)
+var now = time.Now
+
+// tokenCmd groups local token utilities
+var tokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Inspect access tokens locally",
+}
+
+// tokenInspectCmd decodes a JWT without sending it anywhere
+var tokenInspectCmd = &cobra.Command{
+	Use:   "inspect <token>",
+	Short: "Decode a token and print its claims, expiry and time-to-live",
+	Long: `Decode a token locally instead of pasting it into a website.
+
+The signature is not checked unless --secret (HMAC) or --public-key (RSA/ECDSA PEM)
+is given. Pass "-" as the token to read it from stdin; with no token, WOORUNG_TOKEN is used.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		raw, err := tokenArg(cmd, args)
+		if err != nil {
+			return err
+		}
+		secret, _ := cmd.Flags().GetString("secret")
+		publicKey, _ := cmd.Flags().GetString("public-key")
+		return inspectToken(cmd.OutOrStdout(), raw, secret, publicKey)
+	},
+}
+
+func init() {
+	tokenInspectCmd.Flags().String("secret", "", "HMAC secret to verify the signature with")
+	tokenInspectCmd.Flags().String("public-key", "", "PEM file with the RSA/ECDSA public key to verify with")
+	tokenCmd.AddCommand(tokenInspectCmd)
+	rootCmd.AddCommand(tokenCmd)
+}
+
+func tokenArg(cmd *cobra.Command, args []string) (string, error) {
+	var raw string
+	switch {
+	case len(args) == 0:
+		raw = os.Getenv("WOORUNG_TOKEN")
+	case args[0] == "-":
+		data, err := io.ReadAll(cmd.InOrStdin())
+		if err != nil {
+			return "", fmt.Errorf("reading token from stdin: %w", err)
+		}
+		raw = string(data)
+	default:
+		raw = args[0]
+	}
+	raw = strings.TrimPrefix(strings.TrimSpace(raw), "Bearer ")
+	if raw == "" {
+		return "", fmt.Errorf("no token given (pass one, \"-\" for stdin, or set WOORUNG_TOKEN)")
+	}
+	return raw, nil
+}
+
+// inspectToken prints the header and claims of raw as a table, then its expiry
+func inspectToken(out io.Writer, raw, secret, publicKeyFile string) error {
+	claims := jwt.MapClaims{}
+	token, _, err := jwt.NewParser().ParseUnverified(raw, claims)
+	if err != nil {
+		return fmt.Errorf("not a valid token: %w", err)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "alg\t%v\n", token.Header["alg"])
+	if kid, ok := token.Header["kid"]; ok {
+		fmt.Fprintf(w, "kid\t%v\n", kid)
+	}
+	keys := make([]string, 0, len(claims))
+	for k := range claims {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s\t%s\n", k, claimValue(k, claims[k]))
+	}
+
+	exp, _ := claims.GetExpirationTime()
+	switch {
+	case exp == nil:
+		fmt.Fprintf(w, "ttl\tnever expires\n")
+	case exp.After(now()):
+		fmt.Fprintf(w, "ttl\t%s\n", exp.Sub(now()).Round(time.Second))
+	default:
+		fmt.Fprintf(w, "ttl\texpired\n")
+	}
+
+	if secret != "" || publicKeyFile != "" {
+		fmt.Fprintf(w, "signature\t%s\n", verifySignature(raw, secret, publicKeyFile))
+	} else {
+		fmt.Fprintf(w, "signature\tnot verified (pass --secret or --public-key)\n")
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if exp != nil && !exp.After(now()) {
+		fmt.Fprintf(out, "\nWARNING: token expired %s ago\n", now().Sub(exp.Time).Round(time.Second))
+	}
+	return nil
+}
+
+// claimValue renders NumericDate claims as timestamps, everything else as-is
+func claimValue(key string, v interface{}) string {
+	switch key {
+	case "exp", "iat", "nbf":
+		if secs, ok := v.(float64); ok {
+			return time.Unix(int64(secs), 0).UTC().Format(time.RFC3339)
+		}
+	}
+	return fmt.Sprint(v)
+}
+
+func verifySignature(raw, secret, publicKeyFile string) string {
+	keyFunc := func(t *jwt.Token) (interface{}, error) {
+		if secret != "" {
+			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("token uses %s, not HMAC", t.Method.Alg())
+			}
+			return []byte(secret), nil
+		}
+		pem, err := os.ReadFile(publicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		switch t.Method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+			return jwt.ParseRSAPublicKeyFromPEM(pem)
+		case *jwt.SigningMethodECDSA:
+			return jwt.ParseECPublicKeyFromPEM(pem)
+		}
+		return nil, fmt.Errorf("token uses %s, not RSA/ECDSA", t.Method.Alg())
+	}
+
+	// Expiry is reported separately, so only the signature is checked here
+	if _, err := jwt.NewParser(jwt.WithoutClaimsValidation()).Parse(raw, keyFunc); err != nil {
+		return "INVALID (" + err.Error() + ")"
+	}
+	return "valid"
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func signedToken(t *testing.T, exp time.Time) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": "alice",
+		"role":    "admin",
+		"exp":     exp.Unix(),
+	})
+	token.Header["kid"] = "2026-10"
+	signed, err := token.SignedString([]byte("secret"))
+	require.NoError(t, err)
+	return signed
+}
+
+func pinNow(t *testing.T, at time.Time) {
+	t.Cleanup(func() { now = time.Now })
+	now = func() time.Time { return at }
+}
+
+func TestInspectToken_PrintsClaims(t *testing.T) {
+	// Arrange
+	at := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	pinNow(t, at)
+	var out bytes.Buffer
+
+	// Act
+	err := inspectToken(&out, signedToken(t, at.Add(90*time.Minute)), "secret", "")
+
+	// Assert
+	require.NoError(t, err)
+	printed := out.String()
+	assert.Regexp(t, `alg\s+HS256`, printed)
+	assert.Regexp(t, `kid\s+2026-10`, printed)
+	assert.Regexp(t, `user_id\s+alice`, printed)
+	assert.Regexp(t, `role\s+admin`, printed)
+	assert.Regexp(t, `exp\s+2026-10-16T13:30:00Z`, printed)
+	assert.Regexp(t, `ttl\s+1h30m0s`, printed)
+	assert.Regexp(t, `signature\s+valid`, printed)
+	assert.NotContains(t, printed, "WARNING")
+}
+
+func TestInspectToken_WarnsWhenExpiredAndWrongSecret(t *testing.T) {
+	// Arrange
+	at := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	pinNow(t, at)
+	var out bytes.Buffer
+
+	// Act
+	err := inspectToken(&out, signedToken(t, at.Add(-2*time.Hour)), "wrong", "")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Regexp(t, `ttl\s+expired`, out.String())
+	assert.Regexp(t, `signature\s+INVALID`, out.String())
+	assert.Contains(t, out.String(), "WARNING: token expired 2h0m0s ago")
+}
+
+func TestInspectToken_RejectsGarbage(t *testing.T) {
+	assert.Error(t, inspectToken(&bytes.Buffer{}, "not-a-token", "", ""))
+}
@@ -0,0 +1,29 @@
+package clock_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFake(t *testing.T) {
+	// Arrange
+	start := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+
+	// Act & Assert
+	assert.Equal(t, start, fake.Now())
+	fake.Advance(90 * time.Second)
+	assert.Equal(t, start.Add(90*time.Second), fake.Now())
+	fake.Set(start)
+	assert.Equal(t, start, fake.Now())
+}
+
+func TestOr(t *testing.T) {
+	fake := clock.NewFake(time.Unix(0, 0))
+
+	assert.Same(t, fake, clock.Or(fake))
+	assert.IsType(t, clock.Real{}, clock.Or(nil))
+}
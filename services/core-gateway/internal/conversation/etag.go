@@ -0,0 +1,56 @@
+package conversation
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// messagesETag identifies a thread's state by its newest message, so it changes
+// whenever a turn is added (messages are append-only; deleting the thread is a 404)
+func messagesETag(messages []Message) string {
+	last := messages[len(messages)-1]
+	return etag(fmt.Sprintf("%d|%s|%d", len(messages), last.ID, last.CreatedAt.UnixNano()))
+}
+
+// threadsETag changes whenever any thread gains a message, appears or disappears
+func threadsETag(threads []Thread) string {
+	var b strings.Builder
+	for _, t := range threads {
+		fmt.Fprintf(&b, "%s|%d|%d\n", t.ID, t.MessageCount, t.LastMessageAt.UnixNano())
+	}
+	return etag(b.String())
+}
+
+// etag is weak: it tracks the data, not the exact bytes of the JSON encoding
+func etag(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return `W/"` + hex.EncodeToString(sum[:12]) + `"`
+}
+
+// notModified sets the ETag header and answers 304 when If-None-Match already
+// holds it. Clients must revalidate before reusing a cached copy.
+func notModified(c *gin.Context, tag string) bool {
+	c.Header("ETag", tag)
+	c.Header("Cache-Control", "private, no-cache")
+	if !etagMatches(c.GetHeader("If-None-Match"), tag) {
+		return false
+	}
+	c.Status(http.StatusNotModified)
+	return true
+}
+
+// etagMatches applies the weak comparison If-None-Match uses (RFC 9110 §13.1.2)
+func etagMatches(header, tag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(tag, "W/") {
+			return true
+		}
+	}
+	return false
+}
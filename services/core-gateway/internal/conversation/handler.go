@@ -0,0 +1,191 @@
+package conversation
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/ctxutil"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/httperr"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/idempotency"
+)
+
+type Handler struct {
+	repo      Repository
+	opts      HandlerOptions
+	summaries summaryCache
+}
+
+// HandlerOptions configures optional handler features
+type HandlerOptions struct {
+	// Summarizer powers POST /conversations/:thread_id/summarize (503 when nil)
+	Summarizer Summarizer
+	// Idempotency, when set, forgets stored responses of deleted threads
+	Idempotency idempotency.Store
+	// SummaryCacheTTL bounds how long a summary is reused (default DefaultSummaryCacheTTL)
+	SummaryCacheTTL time.Duration
+	// SummaryCacheSize caps the cached summaries (default DefaultSummaryCacheSize)
+	SummaryCacheSize int
+	Now              func() time.Time
+}
+
+func NewHandler(repo Repository, opts HandlerOptions) *Handler {
+	if opts.SummaryCacheTTL <= 0 {
+		opts.SummaryCacheTTL = DefaultSummaryCacheTTL
+	}
+	if opts.SummaryCacheSize <= 0 {
+		opts.SummaryCacheSize = DefaultSummaryCacheSize
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &Handler{repo: repo, opts: opts, summaries: summaryCache{
+		entries: map[string]cachedSummary{},
+		ttl:     opts.SummaryCacheTTL,
+		size:    opts.SummaryCacheSize,
+		now:     opts.Now,
+	}}
+}
+
+// ViewReply renders a stored message as plain text.
+// Message IDs are random UUIDs, so the URL itself acts as the access capability
+// for links handed out in truncated Telegram replies.
+func (h *Handler) ViewReply(c *gin.Context) {
+	msg, err := h.repo.GetMessage(c.Request.Context(), c.Param("thread_id"), c.Param("message_id"))
+	if errors.Is(err, ErrNotFound) {
+		c.String(http.StatusNotFound, "Reply not found")
+		return
+	}
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Failed to load reply")
+		return
+	}
+
+	c.String(http.StatusOK, msg.Content)
+}
+
+// ListThreads returns the caller's threads, most recent first.
+// It answers 304 when If-None-Match matches the list's ETag.
+func (h *Handler) ListThreads(c *gin.Context) {
+	threads, err := h.repo.ListThreads(c.Request.Context(), ScopeFrom(c))
+	if err != nil {
+		httperr.Respond(c, http.StatusInternalServerError, "Failed to list conversations")
+		return
+	}
+	if notModified(c, threadsETag(threads)) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"threads": threads})
+}
+
+// ListMessages returns the messages of one of the caller's threads.
+// It answers 304 when If-None-Match matches the thread's ETag.
+func (h *Handler) ListMessages(c *gin.Context) {
+	threadID := c.Param("thread_id")
+	messages, err := h.repo.ListMessages(c.Request.Context(), ScopeFrom(c), threadID)
+	if err != nil {
+		httperr.Respond(c, http.StatusInternalServerError, "Failed to list messages")
+		return
+	}
+	if len(messages) == 0 {
+		httperr.Respond(c, http.StatusNotFound, "Conversation not found")
+		return
+	}
+	if notModified(c, messagesETag(messages)) {
+		return
+	}
+
+	out := make([]gin.H, 0, len(messages))
+	for _, m := range messages {
+		out = append(out, gin.H{
+			"id":         m.ID,
+			"role":       m.Role,
+			"content":    m.Content,
+			"incomplete": m.Incomplete,
+			"created_at": m.CreatedAt,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"thread_id": threadID, "messages": out})
+}
+
+// Rotate starts the agent's context afresh in one of the caller's threads.
+// The history is kept; a context_reset marker is appended and returned.
+func (h *Handler) Rotate(c *gin.Context) {
+	ctx := c.Request.Context()
+	scope := ScopeFrom(c)
+	threadID := c.Param("thread_id")
+	messages, err := h.repo.ListMessages(ctx, scope, threadID)
+	if err != nil {
+		httperr.Respond(c, http.StatusInternalServerError, "Failed to list messages")
+		return
+	}
+	if len(messages) == 0 {
+		httperr.Respond(c, http.StatusNotFound, "Conversation not found")
+		return
+	}
+
+	marker := ResetMarker(scope, threadID)
+	if err := h.repo.SaveMessage(ctx, marker); err != nil {
+		log.Printf("[Conversation] Failed to rotate thread %s of %s: %v", threadID, scope.UserID, err)
+		httperr.Respond(c, http.StatusInternalServerError, "Failed to rotate conversation")
+		return
+	}
+	h.summaries.forget(cacheKey(scope, threadID))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"thread_id": threadID,
+		"marker": gin.H{
+			"id":         marker.ID,
+			"role":       marker.Role,
+			"content":    marker.Content,
+			"created_at": marker.CreatedAt,
+		},
+	})
+}
+
+// DeleteThread removes one of the caller's threads with all of its messages,
+// cached summary and replayable responses. Other users' threads are 404. Asking
+// on the thread ID again starts a fresh agent conversation (see ContextAnchor).
+func (h *Handler) DeleteThread(c *gin.Context) {
+	store, ok := h.repo.(Prunable)
+	if !ok {
+		httperr.Respond(c, http.StatusServiceUnavailable, "Deleting conversations is not available")
+		return
+	}
+
+	ctx := c.Request.Context()
+	scope := ScopeFrom(c)
+	threadID := c.Param("thread_id")
+	messages, err := h.repo.ListMessages(ctx, scope, threadID)
+	if err != nil {
+		httperr.Respond(c, http.StatusInternalServerError, "Failed to list messages")
+		return
+	}
+	if len(messages) == 0 {
+		httperr.Respond(c, http.StatusNotFound, "Conversation not found")
+		return
+	}
+
+	if err := DeleteThread(ctx, store, OwnedThread{Scope: scope, ThreadID: threadID}); err != nil {
+		log.Printf("[Conversation] Failed to delete thread %s of %s: %v", threadID, scope.UserID, err)
+		httperr.Respond(c, http.StatusInternalServerError, "Failed to delete conversation")
+		return
+	}
+	h.summaries.forget(cacheKey(scope, threadID))
+	if h.opts.Idempotency != nil {
+		if err := h.opts.Idempotency.ForgetThread(ctx, idempotency.Owner(scope.TenantID, scope.UserID), threadID); err != nil {
+			log.Printf("[Conversation] Failed to forget idempotent responses for thread %s: %v", threadID, err)
+		}
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ScopeFrom builds the data scope from the authenticated request context
+func ScopeFrom(c *gin.Context) Scope {
+	tenantID, _ := ctxutil.TenantID(c)
+	userID, _ := ctxutil.UserID(c)
+	return Scope{TenantID: tenantID, UserID: userID}
+}
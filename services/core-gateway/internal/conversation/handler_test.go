@@ -0,0 +1,255 @@
+package conversation_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/auth"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/conversation"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/idempotency"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTenantRouter(t *testing.T) (*gin.Engine, auth.Service) {
+	gin.SetMode(gin.TestMode)
+	jwtService := auth.NewJWTService("secret", time.Hour)
+
+	// Same user ID and thread ID in two tenants
+	repo := conversation.NewMemoryRepository()
+	ctx := context.Background()
+	for _, tenant := range []string{"acme", "globex"} {
+		require.NoError(t, repo.SaveMessage(ctx, &conversation.Message{
+			TenantID: tenant, UserID: "user_1", ThreadID: "thread_1",
+			Role: conversation.RoleUser, Content: "secret of " + tenant,
+		}))
+	}
+
+	h := conversation.NewHandler(repo, conversation.HandlerOptions{})
+	r := gin.New()
+	api := r.Group("/api/v1", middleware.AuthMiddleware(jwtService), middleware.RequireTenant(func() bool { return true }))
+	api.GET("/conversations", h.ListThreads)
+	api.GET("/conversations/:thread_id/messages", h.ListMessages)
+	return r, jwtService
+}
+
+func get(r *gin.Engine, path, token string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", path, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestConversations_TenantIsolation(t *testing.T) {
+	r, jwtService := setupTenantRouter(t)
+	acmeToken, _ := jwtService.IssueToken(auth.Claims{UserID: "user_1", Role: "user", TenantID: "acme"}, time.Hour)
+	otherToken, _ := jwtService.IssueToken(auth.Claims{UserID: "user_1", Role: "user", TenantID: "initech"}, time.Hour)
+
+	// Act: acme lists its messages
+	w := get(r, "/api/v1/conversations/thread_1/messages", acmeToken)
+
+	// Assert: only acme's data is visible
+	require.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		Messages []map[string]interface{} `json:"messages"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.Len(t, body.Messages, 1)
+	assert.Equal(t, "secret of acme", body.Messages[0]["content"])
+
+	// A tenant with no data sees nothing, even with the same user and thread IDs
+	assert.Equal(t, http.StatusNotFound, get(r, "/api/v1/conversations/thread_1/messages", otherToken).Code)
+
+	w = get(r, "/api/v1/conversations", otherToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"threads":[]}`, w.Body.String())
+
+	w = get(r, "/api/v1/conversations", acmeToken)
+	var threads struct {
+		Threads []conversation.Thread `json:"threads"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &threads))
+	require.Len(t, threads.Threads, 1)
+	assert.Equal(t, 1, threads.Threads[0].MessageCount)
+}
+
+func TestConversations_RejectsTokenWithoutTenant(t *testing.T) {
+	r, jwtService := setupTenantRouter(t)
+	token, _ := jwtService.GenerateToken("user_1", "user")
+
+	w := get(r, "/api/v1/conversations", token)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestDeleteThread_OwnerOnly(t *testing.T) {
+	// Arrange: user_1 owns thread_1; a replayable /ask response references it
+	gin.SetMode(gin.TestMode)
+	jwtService := auth.NewJWTService("secret", time.Hour)
+	repo := conversation.NewMemoryRepository()
+	ctx := context.Background()
+	for _, content := range []string{"hello", "hi there"} {
+		require.NoError(t, repo.SaveMessage(ctx, &conversation.Message{
+			UserID: "user_1", ThreadID: "thread_1", Role: conversation.RoleUser, Content: content,
+		}))
+	}
+	keys := idempotency.NewMemoryStore()
+	_, _, err := keys.Begin(ctx, "key_1", "user_1", "", time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, keys.Complete(ctx, "key_1", "user_1", http.StatusOK, `{"reply":"hi there","thread_id":"thread_1"}`))
+
+	h := conversation.NewHandler(repo, conversation.HandlerOptions{Idempotency: keys})
+	r := gin.New()
+	api := r.Group("/api/v1", middleware.AuthMiddleware(jwtService))
+	api.DELETE("/conversations/:thread_id", h.DeleteThread)
+	api.GET("/conversations/:thread_id/messages", h.ListMessages)
+	del := func(token string) int {
+		req, _ := http.NewRequest(http.MethodDelete, "/api/v1/conversations/thread_1", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	ownerToken, _ := jwtService.GenerateToken("user_1", "user")
+	otherToken, _ := jwtService.GenerateToken("user_2", "user")
+
+	// Act
+	otherCode := del(otherToken)
+	stillThere := get(r, "/api/v1/conversations/thread_1/messages", ownerToken).Code
+	ownerCode := del(ownerToken)
+
+	// Assert
+	assert.Equal(t, http.StatusNotFound, otherCode, "other users can't see the thread exists")
+	assert.Equal(t, http.StatusOK, stillThere)
+	assert.Equal(t, http.StatusNoContent, ownerCode)
+	assert.Equal(t, http.StatusNotFound, get(r, "/api/v1/conversations/thread_1/messages", ownerToken).Code)
+	assert.Equal(t, http.StatusNotFound, del(ownerToken))
+
+	_, created, err := keys.Begin(ctx, "key_1", "user_1", "", time.Hour)
+	require.NoError(t, err)
+	assert.True(t, created, "the stored response for the deleted thread is gone")
+}
+
+func TestRotate_KeepsHistoryAndMarksBoundary(t *testing.T) {
+	// Arrange: user_1 has one turn in thread_1
+	gin.SetMode(gin.TestMode)
+	jwtService := auth.NewJWTService("secret", time.Hour)
+	repo := conversation.NewMemoryRepository()
+	ctx := context.Background()
+	for _, msg := range []conversation.Message{
+		{Role: conversation.RoleUser, Content: "plan the release"},
+		{Role: conversation.RoleAssistant, Content: "Here is the plan"},
+	} {
+		msg.UserID, msg.ThreadID = "user_1", "thread_1"
+		require.NoError(t, repo.SaveMessage(ctx, &msg))
+	}
+	h := conversation.NewHandler(repo, conversation.HandlerOptions{})
+	r := gin.New()
+	api := r.Group("/api/v1", middleware.AuthMiddleware(jwtService))
+	api.POST("/conversations/:thread_id/rotate", h.Rotate)
+	api.GET("/conversations/:thread_id/messages", h.ListMessages)
+	rotate := func(token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, "/api/v1/conversations/thread_1/rotate", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	ownerToken, _ := jwtService.GenerateToken("user_1", "user")
+	otherToken, _ := jwtService.GenerateToken("user_2", "user")
+
+	// Act
+	otherCode := rotate(otherToken).Code
+	w := rotate(ownerToken)
+
+	// Assert: the marker is returned and appended, earlier messages are kept
+	assert.Equal(t, http.StatusNotFound, otherCode)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var rotated struct {
+		Marker struct {
+			ID   string `json:"id"`
+			Role string `json:"role"`
+		} `json:"marker"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rotated))
+	assert.Equal(t, conversation.RoleContextReset, rotated.Marker.Role)
+
+	history := get(r, "/api/v1/conversations/thread_1/messages", ownerToken)
+	require.Equal(t, http.StatusOK, history.Code)
+	var body struct {
+		Messages []struct {
+			ID      string `json:"id"`
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	require.NoError(t, json.Unmarshal(history.Body.Bytes(), &body))
+	require.Len(t, body.Messages, 3)
+	assert.Equal(t, "plan the release", body.Messages[0].Content)
+	assert.Equal(t, rotated.Marker.ID, body.Messages[2].ID)
+	assert.Equal(t, conversation.RoleContextReset, body.Messages[2].Role)
+
+	messages, err := repo.ListMessages(ctx, conversation.Scope{UserID: "user_1"}, "thread_1")
+	require.NoError(t, err)
+	// The agent's context now starts at the marker instead of the first prompt
+	assert.Equal(t, messages[2].ID, conversation.ContextAnchor(messages))
+	assert.Equal(t, messages[0].ID, conversation.ContextAnchor(messages[:2]))
+}
+
+func TestListMessages_ETag(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	jwtService := auth.NewJWTService("secret", time.Hour)
+	repo := conversation.NewMemoryRepository()
+	ctx := context.Background()
+	save := func(content string) {
+		require.NoError(t, repo.SaveMessage(ctx, &conversation.Message{
+			UserID: "user_1", ThreadID: "thread_1", Role: conversation.RoleUser, Content: content,
+		}))
+	}
+	save("hello")
+	h := conversation.NewHandler(repo, conversation.HandlerOptions{})
+	r := gin.New()
+	api := r.Group("/api/v1", middleware.AuthMiddleware(jwtService))
+	api.GET("/conversations", h.ListThreads)
+	api.GET("/conversations/:thread_id/messages", h.ListMessages)
+	token, _ := jwtService.GenerateToken("user_1", "user")
+	getIfNoneMatch := func(path, etag string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("If-None-Match", etag)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, path := range []string{"/api/v1/conversations/thread_1/messages", "/api/v1/conversations"} {
+		t.Run(path, func(t *testing.T) {
+			first := get(r, path, token)
+			require.Equal(t, http.StatusOK, first.Code)
+			etag := first.Header().Get("ETag")
+			require.NotEmpty(t, etag)
+
+			// Act: unchanged thread, then a new message
+			unchanged := getIfNoneMatch(path, etag)
+			save("another turn")
+			changed := getIfNoneMatch(path, etag)
+
+			// Assert
+			assert.Equal(t, http.StatusNotModified, unchanged.Code)
+			assert.Empty(t, unchanged.Body.String())
+			assert.Equal(t, etag, unchanged.Header().Get("ETag"))
+			assert.Equal(t, http.StatusOK, changed.Code)
+			assert.NotEmpty(t, changed.Body.String())
+			assert.NotEqual(t, etag, changed.Header().Get("ETag"))
+			assert.Equal(t, http.StatusNotModified, getIfNoneMatch(path, `"other", `+changed.Header().Get("ETag")).Code)
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package conversation
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type memoryRepository struct {
+	mu       sync.RWMutex
+	messages []Message
+}
+
+// NewMemoryRepository returns a process-local Repository (tests and DB-less local runs)
+func NewMemoryRepository() Repository {
+	return &memoryRepository{}
+}
+
+func (r *memoryRepository) SaveMessage(ctx context.Context, msg *Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if msg.ID == "" {
+		msg.ID = uuid.NewString()
+	}
+	if msg.CreatedAt.IsZero() {
+		msg.CreatedAt = time.Now()
+	}
+	r.messages = append(r.messages, *msg)
+	return nil
+}
+
+func (r *memoryRepository) GetMessage(ctx context.Context, threadID, messageID string) (*Message, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, m := range r.messages {
+		if m.ID == messageID && m.ThreadID == threadID {
+			msg := m
+			return &msg, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryRepository) ListThreads(ctx context.Context, scope Scope) ([]Thread, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	byID := map[string]*Thread{}
+	for _, m := range r.messages {
+		if !inScope(m, scope) {
+			continue
+		}
+		t, ok := byID[m.ThreadID]
+		if !ok {
+			t = &Thread{ID: m.ThreadID}
+			byID[m.ThreadID] = t
+		}
+		t.MessageCount++
+		if m.CreatedAt.After(t.LastMessageAt) {
+			t.LastMessageAt = m.CreatedAt
+		}
+	}
+
+	threads := make([]Thread, 0, len(byID))
+	for _, t := range byID {
+		threads = append(threads, *t)
+	}
+	sort.Slice(threads, func(i, j int) bool { return threads[i].LastMessageAt.After(threads[j].LastMessageAt) })
+	return threads, nil
+}
+
+func (r *memoryRepository) ListMessages(ctx context.Context, scope Scope, threadID string) ([]Message, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var out []Message
+	for _, m := range r.messages {
+		if m.ThreadID == threadID && inScope(m, scope) {
+			out = append(out, m)
+		}
+	}
+	return out, nil
+}
+
+func (r *memoryRepository) MarkComplete(ctx context.Context, messageID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.messages {
+		if r.messages[i].ID == messageID {
+			r.messages[i].Incomplete = false
+		}
+	}
+	return nil
+}
+
+func (r *memoryRepository) DeleteMessagesBefore(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	return r.deleteWhere(limit, func(m Message) bool { return m.CreatedAt.Before(cutoff) }), nil
+}
+
+func (r *memoryRepository) ListAllThreads(ctx context.Context) ([]OwnedThread, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	type key struct {
+		scope    Scope
+		threadID string
+	}
+	byKey := map[key]*OwnedThread{}
+	var out []*OwnedThread
+	for _, m := range r.messages {
+		k := key{Scope{TenantID: m.TenantID, UserID: m.UserID}, m.ThreadID}
+		t, ok := byKey[k]
+		if !ok {
+			t = &OwnedThread{Scope: k.scope, ThreadID: m.ThreadID}
+			byKey[k] = t
+			out = append(out, t)
+		}
+		if m.CreatedAt.After(t.LastMessageAt) {
+			t.LastMessageAt = m.CreatedAt
+		}
+	}
+
+	threads := make([]OwnedThread, 0, len(out))
+	for _, t := range out {
+		threads = append(threads, *t)
+	}
+	return threads, nil
+}
+
+func (r *memoryRepository) DeleteThreadMessages(ctx context.Context, t OwnedThread, limit int) (int64, error) {
+	return r.deleteWhere(limit, func(m Message) bool { return m.ThreadID == t.ThreadID && inScope(m, t.Scope) }), nil
+}
+
+// deleteWhere removes up to limit messages matching match
+func (r *memoryRepository) deleteWhere(limit int, match func(Message) bool) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	kept := r.messages[:0]
+	for _, m := range r.messages {
+		if deleted < int64(limit) && match(m) {
+			deleted++
+			continue
+		}
+		kept = append(kept, m)
+	}
+	r.messages = kept
+	return deleted
+}
+
+func inScope(m Message, scope Scope) bool {
+	return m.TenantID == scope.TenantID && m.UserID == scope.UserID
+}
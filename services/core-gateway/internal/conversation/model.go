@@ -0,0 +1,53 @@
+package conversation
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrNotFound = errors.New("message not found")
+
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Message is a single persisted turn of a thread
+type Message struct {
+	ID       string `gorm:"primaryKey"`
+	TenantID string `gorm:"index;not null;default:''"`
+	ThreadID string `gorm:"index;not null"`
+	UserID   string `gorm:"index;not null"`
+	Role     string `gorm:"not null"`
+	Content  string `gorm:"type:text;not null"`
+	// Incomplete marks replies that were cut short (e.g. the agent timed out mid-stream)
+	// and prompts the agent has not answered yet (e.g. it errored)
+	Incomplete bool `gorm:"not null;default:false"`
+	// Metadata is agent-provided JSON for a reply (e.g. {"usage": ..., "actions": ...})
+	Metadata  string `gorm:"type:text"`
+	CreatedAt time.Time
+}
+
+// Thread summarizes a conversation derived from its messages
+type Thread struct {
+	ID            string    `json:"thread_id"`
+	MessageCount  int       `json:"message_count"`
+	LastMessageAt time.Time `json:"last_message_at"`
+}
+
+// Scope restricts queries to a single user within a tenant.
+// TenantID is empty when multi-tenancy is disabled.
+type Scope struct {
+	TenantID string
+	UserID   string
+}
+
+type Repository interface {
+	SaveMessage(ctx context.Context, msg *Message) error
+	GetMessage(ctx context.Context, threadID, messageID string) (*Message, error)
+	ListThreads(ctx context.Context, scope Scope) ([]Thread, error)
+	ListMessages(ctx context.Context, scope Scope, threadID string) ([]Message, error)
+	// MarkComplete clears Incomplete, e.g. on a prompt once the agent answered it
+	MarkComplete(ctx context.Context, messageID string) error
+}
@@ -0,0 +1,161 @@
+package conversation
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/metrics"
+)
+
+// Retention defaults
+const (
+	DefaultPruneInterval  = time.Hour
+	DefaultPruneBatchSize = 500
+)
+
+// Prunable is implemented by repositories that can delete old history.
+// Both methods delete at most limit messages per call so no single statement
+// holds locks for long; callers repeat until fewer than limit are removed.
+type Prunable interface {
+	// DeleteMessagesBefore removes messages created before cutoff
+	DeleteMessagesBefore(ctx context.Context, cutoff time.Time, limit int) (int64, error)
+	// ListAllThreads lists every thread with its owner (for per-user caps)
+	ListAllThreads(ctx context.Context) ([]OwnedThread, error)
+	// DeleteThreadMessages removes messages of one thread
+	DeleteThreadMessages(ctx context.Context, t OwnedThread, limit int) (int64, error)
+}
+
+// OwnedThread is a thread together with the user who owns it
+type OwnedThread struct {
+	Scope
+	ThreadID      string
+	LastMessageAt time.Time
+}
+
+// RetentionPolicy limits how much history is kept. Zero values disable each limit.
+type RetentionPolicy struct {
+	// MaxAge deletes messages older than this
+	MaxAge time.Duration
+	// MaxThreadsPerUser keeps only each user's most recently active threads
+	MaxThreadsPerUser int
+	// BatchSize caps rows per delete statement (default 500)
+	BatchSize int
+}
+
+// Enabled reports whether any limit is set
+func (p RetentionPolicy) Enabled() bool {
+	return p.MaxAge > 0 || p.MaxThreadsPerUser > 0
+}
+
+// Pruner enforces a RetentionPolicy on a repository
+type Pruner struct {
+	store  Prunable
+	policy RetentionPolicy
+	now    func() time.Time
+}
+
+func NewPruner(store Prunable, policy RetentionPolicy) *Pruner {
+	if policy.BatchSize <= 0 {
+		policy.BatchSize = DefaultPruneBatchSize
+	}
+	return &Pruner{store: store, policy: policy, now: time.Now}
+}
+
+// Run prunes every interval until ctx is done
+func (p *Pruner) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultPruneInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := p.Prune(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("[Retention] Prune failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Prune applies the policy once and returns the number of messages deleted
+func (p *Pruner) Prune(ctx context.Context) (int64, error) {
+	var total int64
+
+	if p.policy.MaxAge > 0 {
+		cutoff := p.now().Add(-p.policy.MaxAge)
+		n, err := p.drain(func() (int64, error) {
+			return p.store.DeleteMessagesBefore(ctx, cutoff, p.policy.BatchSize)
+		})
+		total += n
+		p.report("age", n)
+		if err != nil {
+			return total, err
+		}
+	}
+
+	if p.policy.MaxThreadsPerUser > 0 {
+		n, err := p.pruneExcessThreads(ctx)
+		total += n
+		p.report("thread_cap", n)
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+func (p *Pruner) pruneExcessThreads(ctx context.Context) (int64, error) {
+	threads, err := p.store.ListAllThreads(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	byUser := map[Scope][]OwnedThread{}
+	for _, t := range threads {
+		byUser[t.Scope] = append(byUser[t.Scope], t)
+	}
+
+	var total int64
+	for _, owned := range byUser {
+		if len(owned) <= p.policy.MaxThreadsPerUser {
+			continue
+		}
+		sort.Slice(owned, func(i, j int) bool { return owned[i].LastMessageAt.After(owned[j].LastMessageAt) })
+		for _, t := range owned[p.policy.MaxThreadsPerUser:] {
+			n, err := p.drain(func() (int64, error) {
+				return p.store.DeleteThreadMessages(ctx, t, p.policy.BatchSize)
+			})
+			total += n
+			if err != nil {
+				return total, err
+			}
+		}
+	}
+	return total, nil
+}
+
+// drain repeats a batched delete until a batch comes back short
+func (p *Pruner) drain(deleteBatch func() (int64, error)) (int64, error) {
+	var total int64
+	for {
+		n, err := deleteBatch()
+		total += n
+		if err != nil || n < int64(p.policy.BatchSize) {
+			return total, err
+		}
+	}
+}
+
+func (p *Pruner) report(reason string, n int64) {
+	if n == 0 {
+		return
+	}
+	log.Printf("[Retention] Deleted %d messages (%s)", n, reason)
+	metrics.HistoryPruned.WithLabelValues(reason).Add(float64(n))
+}
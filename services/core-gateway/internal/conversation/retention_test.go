@@ -0,0 +1,71 @@
+package conversation_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/conversation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func seed(t *testing.T, repo conversation.Repository, userID, threadID string, createdAt time.Time, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		require.NoError(t, repo.SaveMessage(context.Background(), &conversation.Message{
+			ThreadID: threadID, UserID: userID, Role: conversation.RoleUser, Content: "hi", CreatedAt: createdAt,
+		}))
+	}
+}
+
+func TestPruner_DeletesMessagesPastCutoff(t *testing.T) {
+	// Arrange: more old rows than one batch, so the pruner must loop
+	repo := conversation.NewMemoryRepository()
+	now := time.Now()
+	seed(t, repo, "user_1", "old", now.Add(-40*24*time.Hour), 7)
+	seed(t, repo, "user_1", "recent", now.Add(-time.Hour), 2)
+	pruner := conversation.NewPruner(repo.(conversation.Prunable), conversation.RetentionPolicy{
+		MaxAge:    30 * 24 * time.Hour,
+		BatchSize: 3,
+	})
+
+	// Act
+	deleted, err := pruner.Prune(context.Background())
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), deleted)
+	threads, _ := repo.ListThreads(context.Background(), conversation.Scope{UserID: "user_1"})
+	require.Len(t, threads, 1)
+	assert.Equal(t, "recent", threads[0].ID)
+}
+
+func TestPruner_CapsThreadsPerUser(t *testing.T) {
+	// Arrange
+	repo := conversation.NewMemoryRepository()
+	now := time.Now()
+	seed(t, repo, "user_1", "oldest", now.Add(-3*time.Hour), 2)
+	seed(t, repo, "user_1", "middle", now.Add(-2*time.Hour), 2)
+	seed(t, repo, "user_1", "newest", now.Add(-time.Hour), 2)
+	seed(t, repo, "user_2", "other", now.Add(-5*time.Hour), 2)
+	pruner := conversation.NewPruner(repo.(conversation.Prunable), conversation.RetentionPolicy{MaxThreadsPerUser: 2})
+
+	// Act
+	deleted, err := pruner.Prune(context.Background())
+
+	// Assert: only user_1's least recent thread goes
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+	threads, _ := repo.ListThreads(context.Background(), conversation.Scope{UserID: "user_1"})
+	require.Len(t, threads, 2)
+	assert.Equal(t, "newest", threads[0].ID)
+	assert.Equal(t, "middle", threads[1].ID)
+	others, _ := repo.ListThreads(context.Background(), conversation.Scope{UserID: "user_2"})
+	assert.Len(t, others, 1)
+}
+
+func TestRetentionPolicy_OptIn(t *testing.T) {
+	assert.False(t, conversation.RetentionPolicy{}.Enabled())
+	assert.True(t, conversation.RetentionPolicy{MaxThreadsPerUser: 10}.Enabled())
+}
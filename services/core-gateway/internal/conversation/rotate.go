@@ -0,0 +1,33 @@
+package conversation
+
+// RoleContextReset marks a context boundary in a thread: the agent continues on
+// a fresh agent thread from it, while the turns before it stay in the stored history
+const RoleContextReset = "context_reset"
+
+// contextResetNote is the marker's content, shown by clients that render it as text
+const contextResetNote = "Context reset: earlier messages are kept but no longer sent to the agent"
+
+// ResetMarker is the context_reset message that starts the agent's context
+// afresh in scope's thread from now on
+func ResetMarker(scope Scope, threadID string) *Message {
+	return &Message{TenantID: scope.TenantID, ThreadID: threadID, UserID: scope.UserID, Role: RoleContextReset, Content: contextResetNote}
+}
+
+// ContextAnchor is the message the agent's context for a thread starts at: the
+// last context_reset marker, else the thread's first prompt, or "" for neither.
+// The agent thread is keyed on it, so a reset, or a thread ID asked on again
+// after its thread was deleted, starts a fresh agent conversation. So does
+// retention pruning a thread's first prompt. messages are in chronological order.
+func ContextAnchor(messages []Message) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == RoleContextReset {
+			return messages[i].ID
+		}
+	}
+	for _, m := range messages {
+		if m.Role == RoleUser {
+			return m.ID
+		}
+	}
+	return ""
+}
@@ -0,0 +1,141 @@
+package conversation
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/httperr"
+)
+
+// DefaultSummaryCacheTTL bounds how long an unchanged thread's summary is reused
+const DefaultSummaryCacheTTL = time.Hour
+
+// DefaultSummaryCacheSize caps the cached summaries; expired entries are dropped first
+const DefaultSummaryCacheSize = 1000
+
+// Summarizer condenses the messages of threadID, one of c's caller's threads,
+// into a short summary. c carries the caller, e.g. for their budget.
+type Summarizer interface {
+	Summarize(c *gin.Context, threadID string, messages []Message) (string, error)
+}
+
+// SummaryError is a summary that was refused or failed, with its HTTP status
+type SummaryError struct {
+	Status  int
+	Message string
+}
+
+func (e *SummaryError) Error() string { return e.Message }
+
+// summaryCache keeps the last summary per thread until new messages arrive,
+// at most for ttl and for size threads
+type summaryCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedSummary
+	ttl     time.Duration
+	size    int
+	now     func() time.Time
+}
+
+type cachedSummary struct {
+	summary      string
+	messageCount int
+	lastMessage  time.Time
+	expiresAt    time.Time
+}
+
+func cacheKey(scope Scope, threadID string) string {
+	return scope.TenantID + "/" + scope.UserID + "/" + threadID
+}
+
+func (c *summaryCache) get(key string, messages []Message) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok || e.messageCount != len(messages) || !e.lastMessage.Equal(lastMessageAt(messages)) || !c.now().Before(e.expiresAt) {
+		return "", false
+	}
+	return e.summary, true
+}
+
+// put stores summary, making room when the cache is full
+func (c *summaryCache) put(key string, messages []Message, summary string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.size {
+		for k, e := range c.entries {
+			if !now.Before(e.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= c.size {
+			clear(c.entries)
+		}
+	}
+	c.entries[key] = cachedSummary{summary: summary, messageCount: len(messages), lastMessage: lastMessageAt(messages), expiresAt: now.Add(c.ttl)}
+}
+
+func (c *summaryCache) forget(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+}
+
+func lastMessageAt(messages []Message) time.Time {
+	var last time.Time
+	for _, m := range messages {
+		if m.CreatedAt.After(last) {
+			last = m.CreatedAt
+		}
+	}
+	return last
+}
+
+// Summarize returns a TL;DR of one of the caller's threads. The summary is
+// produced outside the thread, so its context is left untouched, and cached
+// until new messages arrive or it expires.
+func (h *Handler) Summarize(c *gin.Context) {
+	if h.opts.Summarizer == nil {
+		httperr.Respond(c, http.StatusServiceUnavailable, "Summaries are not available")
+		return
+	}
+
+	scope := ScopeFrom(c)
+	threadID := c.Param("thread_id")
+	messages, err := h.repo.ListMessages(c.Request.Context(), scope, threadID)
+	if err != nil {
+		httperr.Respond(c, http.StatusInternalServerError, "Failed to list messages")
+		return
+	}
+	if len(messages) == 0 {
+		httperr.Respond(c, http.StatusNotFound, "Conversation not found")
+		return
+	}
+
+	key := cacheKey(scope, threadID)
+	if summary, ok := h.summaries.get(key, messages); ok {
+		c.JSON(http.StatusOK, gin.H{"thread_id": threadID, "summary": summary, "cached": true})
+		return
+	}
+
+	summary, err := h.opts.Summarizer.Summarize(c, threadID, messages)
+	var refused *SummaryError
+	if errors.As(err, &refused) {
+		httperr.Respond(c, refused.Status, refused.Message)
+		return
+	}
+	if err != nil {
+		httperr.Respond(c, http.StatusBadGateway, err.Error())
+		return
+	}
+	h.summaries.put(key, messages, summary)
+
+	c.JSON(http.StatusOK, gin.H{"thread_id": threadID, "summary": summary, "cached": false})
+}
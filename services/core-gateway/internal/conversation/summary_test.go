@@ -0,0 +1,161 @@
+package conversation_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/auth"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/conversation"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/ctxutil"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSummarizer counts calls and summarizes as "N messages"
+type fakeSummarizer struct {
+	calls int
+}
+
+func (f *fakeSummarizer) Summarize(c *gin.Context, threadID string, messages []conversation.Message) (string, error) {
+	f.calls++
+	return fmt.Sprintf("%d messages", len(messages)), nil
+}
+
+func TestSummarize(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	jwtService := auth.NewJWTService("secret", time.Hour)
+	repo := conversation.NewMemoryRepository()
+	ctx := context.Background()
+	save := func(content string) {
+		require.NoError(t, repo.SaveMessage(ctx, &conversation.Message{
+			UserID: "owner", ThreadID: "thread_1", Role: conversation.RoleUser, Content: content, CreatedAt: time.Now(),
+		}))
+	}
+	save("first")
+	save("second")
+
+	summarizer := &fakeSummarizer{}
+	h := conversation.NewHandler(repo, conversation.HandlerOptions{Summarizer: summarizer})
+	r := gin.New()
+	r.POST("/conversations/:thread_id/summarize", middleware.AuthMiddleware(jwtService), h.Summarize)
+
+	summarize := func(userID string) (int, map[string]any) {
+		token, _ := jwtService.GenerateToken(userID, "user")
+		req, _ := http.NewRequest("POST", "/conversations/thread_1/summarize", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var body map[string]any
+		json.Unmarshal(w.Body.Bytes(), &body)
+		return w.Code, body
+	}
+
+	// Act & Assert: first call asks the agent
+	code, body := summarize("owner")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "2 messages", body["summary"])
+	assert.Equal(t, false, body["cached"])
+
+	// Unchanged thread is served from cache
+	_, body = summarize("owner")
+	assert.Equal(t, true, body["cached"])
+	assert.Equal(t, 1, summarizer.calls)
+
+	// A new message invalidates the cache
+	save("third")
+	_, body = summarize("owner")
+	assert.Equal(t, "3 messages", body["summary"])
+	assert.Equal(t, 2, summarizer.calls)
+
+	// Other users can't summarize the owner's thread
+	code, _ = summarize("intruder")
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, 2, summarizer.calls)
+}
+
+func TestSummarize_NotConfigured(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := conversation.NewHandler(conversation.NewMemoryRepository(), conversation.HandlerOptions{})
+	r := gin.New()
+	r.POST("/conversations/:thread_id/summarize", h.Summarize)
+
+	req, _ := http.NewRequest("POST", "/conversations/thread_1/summarize", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+// refusingSummarizer refuses every summary like an exhausted budget
+type refusingSummarizer struct{}
+
+func (refusingSummarizer) Summarize(c *gin.Context, threadID string, messages []conversation.Message) (string, error) {
+	return "", &conversation.SummaryError{Status: http.StatusTooManyRequests, Message: "daily budget exceeded"}
+}
+
+// summarizeRouter serves summaries of owner's threads thread_1 and thread_2
+func summarizeRouter(t *testing.T, opts conversation.HandlerOptions) func(threadID string) (int, map[string]any) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	repo := conversation.NewMemoryRepository()
+	for _, threadID := range []string{"thread_1", "thread_2"} {
+		require.NoError(t, repo.SaveMessage(context.Background(), &conversation.Message{
+			UserID: "owner", ThreadID: threadID, Role: conversation.RoleUser, Content: "hello",
+		}))
+	}
+	h := conversation.NewHandler(repo, opts)
+	r := gin.New()
+	r.POST("/conversations/:thread_id/summarize", func(c *gin.Context) { ctxutil.SetUserID(c, "owner") }, h.Summarize)
+	return func(threadID string) (int, map[string]any) {
+		req, _ := http.NewRequest("POST", "/conversations/"+threadID+"/summarize", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var body map[string]any
+		json.Unmarshal(w.Body.Bytes(), &body)
+		return w.Code, body
+	}
+}
+
+func TestSummarize_CacheIsBounded(t *testing.T) {
+	// Arrange: summaries are kept for an hour, and for one thread at a time
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	summarizer := &fakeSummarizer{}
+	summarize := summarizeRouter(t, conversation.HandlerOptions{
+		Summarizer:       summarizer,
+		SummaryCacheTTL:  time.Hour,
+		SummaryCacheSize: 1,
+		Now:              func() time.Time { return now },
+	})
+
+	// Act & Assert: a second thread pushes the first one out
+	summarize("thread_1")
+	summarize("thread_2")
+	_, body := summarize("thread_1")
+	assert.Equal(t, false, body["cached"])
+	assert.Equal(t, 3, summarizer.calls)
+
+	// An unchanged thread is summarized again once its summary expired
+	_, body = summarize("thread_1")
+	assert.Equal(t, true, body["cached"])
+	now = now.Add(time.Hour)
+	_, body = summarize("thread_1")
+	assert.Equal(t, false, body["cached"])
+	assert.Equal(t, 4, summarizer.calls)
+}
+
+func TestSummarize_RefusedWithStatus(t *testing.T) {
+	summarize := summarizeRouter(t, conversation.HandlerOptions{Summarizer: refusingSummarizer{}})
+
+	code, body := summarize("thread_1")
+
+	assert.Equal(t, http.StatusTooManyRequests, code)
+	assert.Equal(t, "daily budget exceeded", body["error"])
+}
@@ -0,0 +1,27 @@
+package conversation
+
+import (
+	"errors"
+	"regexp"
+)
+
+// MaxThreadIDLength bounds client-supplied thread IDs
+const MaxThreadIDLength = 64
+
+// ErrInvalidThreadID is returned for thread IDs that are too long or contain
+// characters outside [A-Za-z0-9_-]
+var ErrInvalidThreadID = errors.New("invalid thread_id: use up to 64 letters, digits, '-' or '_'")
+
+// threadIDPattern accepts UUIDs and Telegram chat IDs (which may be negative, e.g. -1001234)
+var threadIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ValidateThreadID checks a client-supplied thread ID. Empty is valid and means "new thread".
+func ValidateThreadID(threadID string) error {
+	if threadID == "" {
+		return nil
+	}
+	if len(threadID) > MaxThreadIDLength || !threadIDPattern.MatchString(threadID) {
+		return ErrInvalidThreadID
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package conversation_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/conversation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateThreadID(t *testing.T) {
+	tests := []struct {
+		name     string
+		threadID string
+		valid    bool
+	}{
+		{"empty starts a new thread", "", true},
+		{"uuid", "3f2b8c1e-9a4d-4c7e-8f21-6b5a0d9e7c13", true},
+		{"telegram private chat", "123456789", true},
+		{"telegram group chat", "-1001234567890", true},
+		{"underscore", "thread_1", true},
+		{"max length", strings.Repeat("a", conversation.MaxThreadIDLength), true},
+		{"too long", strings.Repeat("a", conversation.MaxThreadIDLength+1), false},
+		{"sql injection", "1'; DROP TABLE messages;--", false},
+		{"path traversal", "../../etc/passwd", false},
+		{"whitespace", "thread 1", false},
+		{"newline", "thread\n1", false},
+		{"non-ascii", "스레드", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := conversation.ValidateThreadID(tt.threadID)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, conversation.ErrInvalidThreadID)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package conversation
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+)
+
+// DefaultMaxThreadsPerUser applies when no cap is configured
+const DefaultMaxThreadsPerUser = 100
+
+// ErrThreadLimit is returned when a user at the cap starts another thread under the reject policy
+var ErrThreadLimit = errors.New("thread limit reached: delete or reuse an existing conversation")
+
+// Thread limit policies
+const (
+	ThreadLimitReject = "reject" // refuse the new thread (409)
+	ThreadLimitEvict  = "evict"  // delete the least recently active thread to make room
+)
+
+// ThreadLimiter caps how many threads each user may have
+type ThreadLimiter struct {
+	repo   Repository
+	max    int
+	policy string
+}
+
+// NewThreadLimiter caps threads per user at max (0 = DefaultMaxThreadsPerUser,
+// negative disables). Evicting requires a Prunable repository; otherwise new
+// threads beyond the cap are rejected.
+func NewThreadLimiter(repo Repository, max int, policy string) *ThreadLimiter {
+	if max == 0 {
+		max = DefaultMaxThreadsPerUser
+	}
+	if _, ok := repo.(Prunable); !ok || policy != ThreadLimitEvict {
+		policy = ThreadLimitReject
+	}
+	return &ThreadLimiter{repo: repo, max: max, policy: policy}
+}
+
+// Admit checks that scope may post to threadID. Existing threads always pass;
+// an empty threadID means a new thread.
+func (l *ThreadLimiter) Admit(ctx context.Context, scope Scope, threadID string) error {
+	if l == nil || l.max < 0 {
+		return nil
+	}
+
+	threads, err := l.repo.ListThreads(ctx, scope)
+	if err != nil {
+		return err
+	}
+	for _, t := range threads {
+		if t.ID == threadID {
+			return nil
+		}
+	}
+	if len(threads) < l.max {
+		return nil
+	}
+	if l.policy == ThreadLimitReject {
+		return ErrThreadLimit
+	}
+
+	// Oldest activity first; free exactly enough room for one new thread
+	sort.Slice(threads, func(i, j int) bool { return threads[i].LastMessageAt.Before(threads[j].LastMessageAt) })
+	prunable := l.repo.(Prunable)
+	for _, t := range threads[:len(threads)-l.max+1] {
+		if err := DeleteThread(ctx, prunable, OwnedThread{Scope: scope, ThreadID: t.ID}); err != nil {
+			return err
+		}
+		log.Printf("[Conversation] Evicted thread %s of %s (limit %d)", t.ID, scope.UserID, l.max)
+	}
+	return nil
+}
+
+// DeleteThread removes every message of t in batches
+func DeleteThread(ctx context.Context, store Prunable, t OwnedThread) error {
+	for {
+		n, err := store.DeleteThreadMessages(ctx, t, DefaultPruneBatchSize)
+		if err != nil || n < DefaultPruneBatchSize {
+			return err
+		}
+	}
+}
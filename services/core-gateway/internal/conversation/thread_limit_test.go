@@ -0,0 +1,67 @@
+package conversation_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/conversation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThreadLimiter_Reject(t *testing.T) {
+	// Arrange: user_1 is exactly at the cap of 2
+	repo := conversation.NewMemoryRepository()
+	now := time.Now()
+	seed(t, repo, "user_1", "a", now.Add(-2*time.Hour), 1)
+	seed(t, repo, "user_1", "b", now.Add(-time.Hour), 1)
+	limiter := conversation.NewThreadLimiter(repo, 2, conversation.ThreadLimitReject)
+	scope := conversation.Scope{UserID: "user_1"}
+	ctx := context.Background()
+
+	// Act & Assert: existing threads continue, new ones are refused
+	assert.NoError(t, limiter.Admit(ctx, scope, "a"))
+	assert.ErrorIs(t, limiter.Admit(ctx, scope, ""), conversation.ErrThreadLimit)
+	assert.ErrorIs(t, limiter.Admit(ctx, scope, "c"), conversation.ErrThreadLimit)
+	assert.NoError(t, limiter.Admit(ctx, conversation.Scope{UserID: "user_2"}, ""), "other users are unaffected")
+}
+
+func TestThreadLimiter_BelowCap(t *testing.T) {
+	// Arrange: one slot left
+	repo := conversation.NewMemoryRepository()
+	seed(t, repo, "user_1", "a", time.Now(), 1)
+	limiter := conversation.NewThreadLimiter(repo, 2, conversation.ThreadLimitReject)
+
+	// Act
+	err := limiter.Admit(context.Background(), conversation.Scope{UserID: "user_1"}, "")
+
+	// Assert
+	assert.NoError(t, err)
+}
+
+func TestThreadLimiter_EvictsOldestIdleThread(t *testing.T) {
+	// Arrange: user_1 is at the cap; "a" has been idle longest
+	repo := conversation.NewMemoryRepository()
+	now := time.Now()
+	seed(t, repo, "user_1", "a", now.Add(-3*time.Hour), 2)
+	seed(t, repo, "user_1", "b", now.Add(-time.Hour), 1)
+	limiter := conversation.NewThreadLimiter(repo, 2, conversation.ThreadLimitEvict)
+	scope := conversation.Scope{UserID: "user_1"}
+
+	// Act
+	err := limiter.Admit(context.Background(), scope, "c")
+
+	// Assert: room is made for exactly one new thread
+	require.NoError(t, err)
+	threads, _ := repo.ListThreads(context.Background(), scope)
+	require.Len(t, threads, 1)
+	assert.Equal(t, "b", threads[0].ID)
+}
+
+func TestThreadLimiter_NegativeDisables(t *testing.T) {
+	repo := conversation.NewMemoryRepository()
+	seed(t, repo, "user_1", "a", time.Now(), 1)
+	limiter := conversation.NewThreadLimiter(repo, -1, conversation.ThreadLimitReject)
+	assert.NoError(t, limiter.Admit(context.Background(), conversation.Scope{UserID: "user_1"}, ""))
+}
@@ -0,0 +1,80 @@
+// Package ctxutil provides typed accessors for values stored on the gin context,
+// so handlers and middleware don't share typo-prone string keys.
+package ctxutil
+
+import "github.com/gin-gonic/gin"
+
+// Anonymous callers on optional-auth routes get this user ID and role
+const (
+	AnonymousUserID = "anonymous"
+	RoleAnonymous   = "anonymous"
+)
+
+// key is unexported so no other package can collide with these entries
+type key int
+
+const (
+	userIDKey key = iota
+	roleKey
+	tenantIDKey
+	impersonatedByKey
+	requestIDKey
+	serviceKey
+)
+
+// SetUserID stores the authenticated user's ID
+func SetUserID(c *gin.Context, userID string) { c.Set(userIDKey, userID) }
+
+// UserID returns the authenticated user's ID
+func UserID(c *gin.Context) (string, bool) { return get(c, userIDKey) }
+
+// SetRole stores the authenticated user's role
+func SetRole(c *gin.Context, role string) { c.Set(roleKey, role) }
+
+// Role returns the authenticated user's role
+func Role(c *gin.Context) (string, bool) { return get(c, roleKey) }
+
+// SetTenantID stores the tenant the token is scoped to
+func SetTenantID(c *gin.Context, tenantID string) { c.Set(tenantIDKey, tenantID) }
+
+// TenantID returns the tenant the token is scoped to
+func TenantID(c *gin.Context) (string, bool) { return get(c, tenantIDKey) }
+
+// SetImpersonatedBy stores the admin ID behind an impersonation token
+func SetImpersonatedBy(c *gin.Context, adminID string) { c.Set(impersonatedByKey, adminID) }
+
+// ImpersonatedBy returns the admin ID behind an impersonation token
+func ImpersonatedBy(c *gin.Context) (string, bool) { return get(c, impersonatedByKey) }
+
+// IsAnonymous reports whether the request came without a token on an optional-auth route
+func IsAnonymous(c *gin.Context) bool {
+	userID, _ := UserID(c)
+	return userID == AnonymousUserID
+}
+
+// SetService marks the request as made with a service token for the named service
+func SetService(c *gin.Context, service string) { c.Set(serviceKey, service) }
+
+// Service returns the calling service's name for service tokens
+func Service(c *gin.Context) (string, bool) { return get(c, serviceKey) }
+
+// IsService reports whether the request was made with a service token
+func IsService(c *gin.Context) bool {
+	_, ok := Service(c)
+	return ok
+}
+
+// SetRequestID stores the request's correlation ID
+func SetRequestID(c *gin.Context, requestID string) { c.Set(requestIDKey, requestID) }
+
+// RequestID returns the request's correlation ID
+func RequestID(c *gin.Context) (string, bool) { return get(c, requestIDKey) }
+
+func get(c *gin.Context, k key) (string, bool) {
+	v, ok := c.Get(k)
+	if !ok {
+		return "", false
+	}
+	s, ok := v.(string)
+	return s, ok
+}
@@ -0,0 +1,66 @@
+package ctxutil_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/ctxutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessors(t *testing.T) {
+	tests := []struct {
+		name string
+		set  func(*gin.Context, string)
+		get  func(*gin.Context) (string, bool)
+	}{
+		{"UserID", ctxutil.SetUserID, ctxutil.UserID},
+		{"Role", ctxutil.SetRole, ctxutil.Role},
+		{"TenantID", ctxutil.SetTenantID, ctxutil.TenantID},
+		{"ImpersonatedBy", ctxutil.SetImpersonatedBy, ctxutil.ImpersonatedBy},
+		{"RequestID", ctxutil.SetRequestID, ctxutil.RequestID},
+		{"Service", ctxutil.SetService, ctxutil.Service},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+" absent", func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+			value, ok := tt.get(c)
+
+			assert.False(t, ok)
+			assert.Empty(t, value)
+		})
+
+		t.Run(tt.name+" present", func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			tt.set(c, "value_1")
+
+			value, ok := tt.get(c)
+
+			assert.True(t, ok)
+			assert.Equal(t, "value_1", value)
+		})
+	}
+}
+
+func TestIsService(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	assert.False(t, ctxutil.IsService(c))
+
+	ctxutil.SetService(c, "scheduler")
+	assert.True(t, ctxutil.IsService(c))
+}
+
+func TestAccessors_IgnoreStringKeys(t *testing.T) {
+	// Arrange: a legacy string key must not be mistaken for the typed entry
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Set("userID", "user_1")
+
+	// Act
+	_, ok := ctxutil.UserID(c)
+
+	// Assert
+	assert.False(t, ok)
+}
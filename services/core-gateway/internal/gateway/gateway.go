@@ -0,0 +1,391 @@
+// Package gateway wires the HTTP router: the middleware chain, the handlers
+// and their routes. main builds the external services (storage, agent client,
+// Telegram, notifications) and hands them over as Deps; tests pass fakes.
+package gateway
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/config"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/account"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/admin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/agent"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/agenturl"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/apiversion"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/audit"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/auth"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/clock"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/conversation"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/ctxutil"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/health"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/httperr"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/idempotency"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/infrastructure/database"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/limits"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/metrics"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/middleware"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/notify"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/stats"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/telegramlink"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/usage"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/user"
+)
+
+// Deps are the services the router is built from. Startup-only settings are
+// read from Config; everything reloadable is read through config.Current(),
+// so config.Store must have been called before requests are served.
+type Deps struct {
+	Env    string
+	Config *config.Config
+	// Storage is the database; nil serves without history, accounts and login
+	Storage *database.Backend
+	JWT     auth.Service
+	Agent   agent.Service
+	// Pool bounds concurrent asks (nil = unbounded); WSAgent serves the websocket (default Agent)
+	Pool    *agent.Pool
+	WSAgent agent.Service
+	// TelegramLinks enables POST /me/telegram-link (nil = disabled)
+	TelegramLinks *telegramlink.Service
+	// CallbackNotifiers receive async agent results besides the websocket
+	CallbackNotifiers []agent.Notifier
+	Notifications     *notify.Router
+	// AccessLog receives the request log (nil = io.Discard)
+	AccessLog io.Writer
+	// Clock counts usage days and idempotency TTLs (nil = wall clock)
+	Clock clock.Clock
+}
+
+// Gateway is the wired router and the parts main needs at shutdown
+type Gateway struct {
+	Router  *gin.Engine
+	Drainer *agent.Drainer
+
+	unregister func()
+}
+
+// Close detaches the gateway from config reloads. Call it after the server has
+// shut down when the process goes on, as in tests that build many gateways.
+func (g *Gateway) Close() {
+	g.unregister()
+}
+
+// anonymousBudget applies to optional-auth routes unless usage.roles.anonymous is set
+var anonymousBudget = usage.Budget{MaxRequestChars: 500, DailyChars: 5000}
+
+// New builds the router. It fails on settings the gateway cannot serve with.
+func New(deps Deps) (*Gateway, error) {
+	cfg := deps.Config
+	if _, err := agent.NormalizeSource(cfg.PMAgent.DefaultSource, ""); err != nil {
+		return nil, errors.New("invalid pm_agent.default_source: " + err.Error())
+	}
+	if deps.AccessLog == nil {
+		deps.AccessLog = io.Discard
+	}
+	if deps.WSAgent == nil {
+		deps.WSAgent = deps.Agent
+	}
+
+	// 1. Middleware
+	r := gin.New()
+	r.Use(middleware.RequestID())
+	r.Use(middleware.RequestLogger(deps.AccessLog, func() middleware.AccessLogConfig {
+		server := config.Current().Server
+		return middleware.AccessLogConfig{
+			Format:        server.AccessLogFormat,
+			SampleRate:    server.LogSampleRate,
+			SlowThreshold: time.Duration(server.LogSlowMS) * time.Millisecond,
+		}
+	}))
+	// Inside the logger, so panicked requests are logged with their 500
+	r.Use(middleware.Recovery())
+	r.NoRoute(func(c *gin.Context) { httperr.Respond(c, http.StatusNotFound, "Not found") })
+	r.Use(middleware.RequireHTTPS(func() middleware.HTTPSConfig {
+		server := config.Current().Server
+		return middleware.HTTPSConfig{
+			Enabled:        server.RequireHTTPS,
+			TrustedProxies: server.TrustedProxies,
+			ExemptPaths:    []string{"/health"},
+		}
+	}))
+	if invalid := middleware.InvalidProxies(cfg.Server.TrustedProxies); len(invalid) > 0 {
+		log.Printf("⚠️ server.trusted_proxies entries are not IPs or CIDRs and are ignored: %v", invalid)
+	}
+	// X-Forwarded-For is only believed from trusted proxies, so clients cannot
+	// pick the IP that rate limits, login lockouts and anonymous usage key on
+	if err := r.SetTrustedProxies(middleware.ValidProxies(cfg.Server.TrustedProxies)); err != nil {
+		return nil, errors.New("invalid server.trusted_proxies: " + err.Error())
+	}
+	if invalid := middleware.InvalidProxies(cfg.Server.MetricsAuth.AllowedIPs); len(invalid) > 0 {
+		log.Printf("⚠️ server.metrics_auth.allowed_ips entries are not IPs or CIDRs and are ignored: %v", invalid)
+	}
+	r.Use(apiversion.Negotiate(func() string { return config.Current().Server.DefaultAPIVersion }))
+	if v := cfg.Server.DefaultAPIVersion; v != "" && !apiversion.Valid(v) {
+		log.Printf("⚠️ server.default_api_version %q is not supported; serving version %s", v, apiversion.Latest)
+	}
+	r.Use(middleware.SecurityHeaders(func() middleware.SecurityHeadersConfig {
+		headers := config.Current().Server.SecurityHeaders
+		return middleware.SecurityHeadersConfig{
+			FrameOptions:          headers.FrameOptions,
+			ReferrerPolicy:        headers.ReferrerPolicy,
+			HSTSMaxAge:            time.Duration(headers.HSTSMaxAgeSeconds) * time.Second,
+			HSTSIncludeSubdomains: headers.HSTSIncludeSubdomains,
+		}
+	}))
+	r.Use(middleware.CORS(func() middleware.CORSConfig {
+		server := config.Current().Server
+		return middleware.CORSConfig{
+			AllowedOrigins: server.CORSOrigins,
+			ExposeHeaders:  server.CORSExposeHeaders,
+			MaxAge:         time.Duration(server.CORSMaxAgeSeconds) * time.Second,
+		}
+	}))
+	r.Use(middleware.RequestDeadline(func() middleware.DeadlineConfig {
+		return middleware.DeadlineConfig{Timeout: time.Duration(config.Current().Server.RequestTimeoutSeconds) * time.Second}
+	}))
+	currentLimits := func() middleware.RouteLimits { return RouteLimits(config.Current()) }
+	// Rows in the limits table override the configured limits per user or role
+	var limitOverrides *limits.Service
+	if deps.Storage != nil {
+		limitOverrides = limits.NewService(deps.Storage.Limits, limits.Options{Now: clock.Or(deps.Clock).Now})
+	}
+	r.Use(middleware.RateLimitWithOverrides(currentLimits, rateOverride(deps.JWT, limitOverrides)))
+	r.Use(middleware.BodyLimit(currentLimits))
+
+	// 2. Stores. Idempotency keys in the database survive restarts and are shared across replicas.
+	storage := deps.Storage
+	var messageRepo conversation.Repository
+	idempotencyStore := idempotency.NewMemoryStoreWithClock(deps.Clock)
+	// Accounts are checked on every request; the cache is invalidated by writes through it
+	var users *user.Cache
+	if storage != nil {
+		messageRepo = storage.Messages
+		idempotencyStore = storage.IdempotencyKeys
+		users = user.NewCache(storage.Users, user.CacheOptions{Now: clock.Or(deps.Clock).Now})
+		metrics.RegisterStore("cached_users", users.Len)
+	}
+
+	// 3. Handlers
+	healthHandler := health.NewHealthHandler()
+	defaults, roles := UsageBudgets(cfg)
+	usageTracker := usage.NewTrackerWithClock(defaults, roles, deps.Clock)
+	unregister := config.OnReload(func(next *config.Config) { usageTracker.SetBudgets(UsageBudgets(next)) })
+	budget := func(ctx context.Context, userID, role string) usage.Budget {
+		return limitOverrides.Budget(ctx, userID, role, usageTracker.BudgetFor(role))
+	}
+	streamDrainer := agent.NewDrainer()
+	// In-memory store sizes, exported as woorung_store_entries (rate-limit buckets register themselves)
+	metrics.RegisterStore("usage_users", usageTracker.Len)
+	metrics.RegisterStore("inflight_streams", streamDrainer.InFlight)
+	if sized, ok := idempotencyStore.(interface{ Len() int }); ok {
+		metrics.RegisterStore("idempotency_keys", sized.Len)
+	}
+	if deps.Pool != nil {
+		metrics.RegisterStore("agent_queue", deps.Pool.Queued)
+	}
+	var threadLimiter *conversation.ThreadLimiter
+	if messageRepo != nil {
+		threadLimiter = conversation.NewThreadLimiter(messageRepo, cfg.History.MaxThreadsPerUser, cfg.History.ThreadLimitPolicy)
+	}
+	// Bring-your-own-agent: per-user agent URLs, restricted to pm_agent.user_url_allow_list
+	var agentURLs *agenturl.Service
+	if storage != nil {
+		agentURLs = agenturl.NewService(storage.AgentURLs, agenturl.Options{
+			Allowed: func() []string { return config.Current().PMAgent.UserURLAllowList },
+		})
+	}
+	agentOpts := agent.HandlerOptions{
+		History:            messageRepo,
+		Usage:              usageTracker,
+		Budget:             budget,
+		Drain:              streamDrainer,
+		Pool:               deps.Pool,
+		Threads:            threadLimiter,
+		EmptyReplyFallback: func() string { return config.Current().PMAgent.EmptyReplyFallback },
+		ForwardHeaders:     func() []string { return config.Current().PMAgent.ForwardHeaders },
+		DefaultSource:      func() string { return config.Current().PMAgent.DefaultSource },
+		AgentURL:           agentURLs.URL,
+		Notifications:      deps.Notifications,
+		RawDebug:           func() bool { return deps.Env == "local" || config.Current().PMAgent.RawDebug },
+	}
+	agentHandler := agent.NewHandler(deps.Agent, agentOpts)
+	// WSAgent is already bound to the pool, so its asks skip the handler's
+	wsOpts := agentOpts
+	wsOpts.Pool = nil
+	wsHandler := agent.NewWebSocketHandler(agent.NewHandler(deps.WSAgent, wsOpts), agent.WebSocketOptions{
+		AllowedOrigins: func() []string { return config.Current().Server.WebSocketOrigins },
+		ReadLimit:      func() int64 { return currentLimits().JSONLimit("/api/v1/ws") },
+	})
+	callbackHandler := agent.NewCallbackHandler(agent.CallbackOptions{
+		Secret:        func() string { return config.Current().PMAgent.CallbackSecret },
+		History:       messageRepo,
+		Notifiers:     append(deps.CallbackNotifiers, wsHandler),
+		Notifications: deps.Notifications,
+	})
+	statsHandler := stats.NewHandler(stats.Options{History: messageRepo, Usage: usageTracker, Budget: budget})
+	accountOpts := account.Options{History: messageRepo, Usage: usageTracker}
+	if storage != nil {
+		accountOpts.Users, accountOpts.Eraser = users, storage.Accounts
+	}
+	accountHandler := account.NewHandler(accountOpts)
+	auditLogger := audit.NewStdLogger()
+	var conversationHandler *conversation.Handler
+	if messageRepo != nil {
+		conversationHandler = conversation.NewHandler(messageRepo, conversation.HandlerOptions{
+			Summarizer:  agentHandler,
+			Idempotency: idempotencyStore,
+		})
+	}
+
+	// 4. Routes
+	// Public
+	r.GET("/health", healthHandler.Check)
+	r.GET("/metrics", middleware.EndpointGuard(func() middleware.EndpointGuardConfig {
+		auth := config.Current().Server.MetricsAuth
+		return middleware.EndpointGuardConfig{Token: auth.Token, AllowedIPs: auth.AllowedIPs}
+	}), metrics.Handler())
+	r.POST("/internal/agent/callback", callbackHandler.Receive)
+	if conversationHandler != nil {
+		r.GET("/replies/:thread_id/:message_id", conversationHandler.ViewReply)
+	}
+	r.GET("/", func(c *gin.Context) {
+		c.JSON(200, gin.H{
+			"service": "Woorung-Gaksi Core Gateway",
+			"env":     deps.Env,
+			"status":  "running",
+		})
+	})
+
+	// Protected API
+	api := r.Group("/api/v1")
+	api.Use(middleware.RouteAuth(deps.JWT, func() []string { return config.Current().Auth.OptionalRoutes }))
+	api.Use(middleware.RequireTenant(func() bool { return config.Current().Tenancy.Enabled }))
+	if storage != nil {
+		// Tokens of deleted or disabled accounts stop working immediately
+		api.Use(middleware.RejectDisabledUsers(users))
+	}
+	{
+		api.GET("/me", func(c *gin.Context) {
+			userID, _ := ctxutil.UserID(c)
+			role, _ := ctxutil.Role(c)
+			resp := gin.H{"user_id": userID, "role": role}
+			if service, ok := ctxutil.Service(c); ok {
+				resp = gin.H{"service": service}
+			}
+			if impersonatedBy, ok := ctxutil.ImpersonatedBy(c); ok {
+				resp["impersonated_by"] = impersonatedBy
+			}
+			c.JSON(200, resp)
+		})
+		api.POST("/ask", middleware.NoStore(), middleware.Idempotency(idempotencyStore, idempotency.DefaultTTL), agentHandler.Ask)
+		api.POST("/ask/stream", middleware.NoStore(), agentHandler.AskStream)
+		api.GET("/ws", wsHandler.Serve)
+		api.GET("/stats", middleware.RequireUser(), statsHandler.Get)
+		api.GET("/me/export", middleware.RequireUser(), middleware.NoStore(), accountHandler.Export)
+		api.DELETE("/me", middleware.RequireUser(), accountHandler.Delete)
+		if deps.TelegramLinks != nil {
+			api.POST("/me/telegram-link", middleware.RequireUser(), middleware.NoStore(), telegramlink.NewHandler(deps.TelegramLinks).CreateCode)
+		}
+		if agentURLs != nil {
+			agentURLHandler := agenturl.NewHandler(agentURLs)
+			api.GET("/me/agent-url", middleware.RequireUser(), agentURLHandler.Get)
+			api.PUT("/me/agent-url", middleware.RequireUser(), agentURLHandler.Set)
+			api.DELETE("/me/agent-url", middleware.RequireUser(), agentURLHandler.Clear)
+		}
+
+		if conversationHandler != nil {
+			conversations := api.Group("/conversations", middleware.RequireUser())
+			conversations.GET("", conversationHandler.ListThreads)
+			conversations.GET("/:thread_id/messages", conversationHandler.ListMessages)
+			conversations.DELETE("/:thread_id", conversationHandler.DeleteThread)
+			conversations.POST("/:thread_id/summarize", conversationHandler.Summarize)
+			conversations.POST("/:thread_id/rotate", conversationHandler.Rotate)
+			conversations.POST("/:thread_id/replay-last", agentHandler.ReplayLast)
+		}
+	}
+
+	// Auth & Admin API (requires a user store)
+	if storage != nil {
+		lockoutCfg := cfg.Auth.Lockout
+		lockout := auth.NewLockout(auth.LockoutConfig{
+			MaxAttempts: lockoutCfg.MaxAttempts,
+			Window:      time.Duration(lockoutCfg.WindowSeconds) * time.Second,
+			Cooldown:    time.Duration(lockoutCfg.CooldownSeconds) * time.Second,
+			Now:         clock.Or(deps.Clock).Now,
+		})
+		metrics.RegisterStore("login_lockouts", lockout.Len)
+		authHandler := auth.NewHandler(auth.NewLoginService(users, deps.JWT), lockout, auditLogger)
+		r.POST("/auth/login", middleware.NoStore(), authHandler.Login)
+		r.POST("/auth/refresh", middleware.NoStore(), authHandler.Refresh)
+
+		adminHandler := admin.NewHandler(users, deps.JWT, auditLogger)
+		adminAPI := api.Group("/admin", middleware.RequireRole("admin"))
+		{
+			adminAPI.POST("/impersonate/:user_id", adminHandler.Impersonate)
+			adminAPI.PATCH("/users/:user_id", adminHandler.UpdateUser)
+			adminAPI.GET("/config", admin.Config(config.Current))
+			agentURLHandler := agenturl.NewHandler(agentURLs)
+			adminAPI.GET("/users/:user_id/agent-url", agentURLHandler.Get)
+			adminAPI.PUT("/users/:user_id/agent-url", agentURLHandler.Set)
+			adminAPI.DELETE("/users/:user_id/agent-url", agentURLHandler.Clear)
+			limitsHandler := limits.NewHandler(limitOverrides)
+			adminAPI.GET("/limits", limitsHandler.List)
+			adminAPI.PUT("/limits/:subject_type/:subject_id", limitsHandler.Set)
+			adminAPI.DELETE("/limits/:subject_type/:subject_id", limitsHandler.Clear)
+		}
+	}
+
+	for _, route := range middleware.UnknownLimitRoutes(currentLimits(), r.Routes()) {
+		log.Printf("⚠️ server.limits.routes: %q matches no route; its limits are ignored", route)
+	}
+
+	return &Gateway{Router: r, Drainer: streamDrainer, unregister: unregister}, nil
+}
+
+// rateOverride finds the caller's rate limit override from their bearer token.
+// Service tokens and anonymous callers keep the per-IP limits.
+func rateOverride(jwtService auth.Service, overrides *limits.Service) middleware.RateOverride {
+	if overrides == nil {
+		return nil
+	}
+	return func(c *gin.Context) (string, int, bool) {
+		claims, ok := middleware.BearerClaims(c, jwtService)
+		if !ok || claims.ServiceName() != "" || claims.UserID == "" {
+			return "", 0, false
+		}
+		rpm, ok := overrides.RequestsPerMinute(c.Request.Context(), claims.UserID, claims.Role)
+		return "user:" + usage.Key(claims.TenantID, claims.UserID), rpm, ok
+	}
+}
+
+// UsageBudgets maps the usage config section to tracker budgets
+func UsageBudgets(cfg *config.Config) (usage.Budget, map[string]usage.Budget) {
+	roles := make(map[string]usage.Budget, len(cfg.Usage.Roles)+1)
+	roles[ctxutil.RoleAnonymous] = anonymousBudget
+	for role, b := range cfg.Usage.Roles {
+		roles[role] = usage.Budget{MaxRequestChars: b.MaxRequestChars, DailyChars: b.DailyChars}
+	}
+	return usage.Budget{MaxRequestChars: cfg.Usage.MaxRequestChars, DailyChars: cfg.Usage.DailyChars}, roles
+}
+
+// RouteLimits maps the server.limits config section to middleware limits
+func RouteLimits(cfg *config.Config) middleware.RouteLimits {
+	toLimit := func(l config.RouteLimit) middleware.Limit {
+		return middleware.Limit{MaxBodyBytes: l.MaxBodyBytes, RequestsPerMinute: l.RequestsPerMinute, Burst: l.Burst}
+	}
+	limits := middleware.RouteLimits{
+		Default:           toLimit(cfg.Server.Limits.Default),
+		Routes:            make(map[string]middleware.Limit, len(cfg.Server.Limits.Routes)),
+		MaxJSONBytes:      cfg.Server.MaxJSONBytes,
+		MaxMultipartBytes: cfg.Server.MaxMultipartBytes,
+	}
+	for route, l := range cfg.Server.Limits.Routes {
+		limits.Routes[route] = toLimit(l)
+	}
+	return limits
+}
@@ -0,0 +1,85 @@
+package health
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Status is the last probe result for a dependency
+type Status struct {
+	Healthy   bool
+	Err       string
+	CheckedAt time.Time
+}
+
+// Registry tracks the health of dependencies (e.g. the PM Agent) so other
+// components can react to outages without probing themselves.
+type Registry struct {
+	mu     sync.RWMutex
+	status map[string]Status
+	now    func() time.Time
+}
+
+func NewRegistry() *Registry {
+	return &Registry{status: map[string]Status{}, now: time.Now}
+}
+
+// Set records a probe result; a nil err means healthy. Transitions are logged.
+func (r *Registry) Set(name string, err error) {
+	next := Status{Healthy: err == nil, CheckedAt: r.now()}
+	if err != nil {
+		next.Err = err.Error()
+	}
+
+	r.mu.Lock()
+	prev, known := r.status[name]
+	r.status[name] = next
+	r.mu.Unlock()
+
+	if (known && prev.Healthy != next.Healthy) || (!known && !next.Healthy) {
+		if next.Healthy {
+			log.Printf("[Health] %s recovered", name)
+		} else {
+			log.Printf("[Health] %s is down: %s", name, next.Err)
+		}
+	}
+}
+
+// Healthy reports the last known state. Dependencies not probed yet count as healthy.
+func (r *Registry) Healthy(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s, ok := r.status[name]
+	return !ok || s.Healthy
+}
+
+// Status returns the last probe result for name
+func (r *Registry) Status(name string) (Status, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s, ok := r.status[name]
+	return s, ok
+}
+
+// Watch probes name every interval until ctx is done
+func (r *Registry) Watch(ctx context.Context, name string, interval time.Duration, check func(context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		probeCtx, cancel := context.WithTimeout(ctx, interval)
+		err := check(probeCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+		r.Set(name, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Package httperr writes API error responses. Clients get the usual
+// {"error": "...", "request_id": "..."} envelope unless they ask for
+// text/plain, which is handier when debugging with curl or a browser.
+// The request ID lets users quote a failure that support can find in the logs.
+package httperr
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/ctxutil"
+)
+
+// Respond writes msg with status, formatted per the request's Accept header.
+// Server errors are also logged with the request ID from the response.
+func Respond(c *gin.Context, status int, msg string) {
+	requestID, _ := ctxutil.RequestID(c)
+	if status >= http.StatusInternalServerError {
+		log.Printf("[HTTP] %d %s %s (request_id=%s): %s", status, c.Request.Method, c.Request.URL.Path, requestID, msg)
+	}
+	if wantsText(c) {
+		c.String(status, msg+"\n"+textRequestID(requestID))
+		return
+	}
+	c.JSON(status, envelope(gin.H{"error": msg}, requestID))
+}
+
+// RespondFields writes per-field validation messages as {"errors": {"field": "msg"}},
+// or one "field: msg" line per field for text/plain
+func RespondFields(c *gin.Context, status int, fields map[string]string) {
+	requestID, _ := ctxutil.RequestID(c)
+	if wantsText(c) {
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var b strings.Builder
+		for _, name := range names {
+			b.WriteString(name + ": " + fields[name] + "\n")
+		}
+		c.String(status, b.String()+textRequestID(requestID))
+		return
+	}
+	c.JSON(status, envelope(gin.H{"errors": fields}, requestID))
+}
+
+// Render returns the content type and body Respond would write for r, for code
+// that writes outside the gin handler chain
+func Render(r *http.Request, requestID, msg string) (string, []byte) {
+	if wantsText(&gin.Context{Request: r}) {
+		return binding.MIMEPlain + "; charset=utf-8", []byte(msg + "\n" + textRequestID(requestID))
+	}
+	body, _ := json.Marshal(envelope(gin.H{"error": msg}, requestID))
+	return binding.MIMEJSON + "; charset=utf-8", body
+}
+
+// Abort is Respond for middleware: it also stops the handler chain
+func Abort(c *gin.Context, status int, msg string) {
+	c.Abort()
+	Respond(c, status, msg)
+}
+
+// wantsText reports whether the client prefers text/plain over JSON.
+// A missing Accept header or */* keeps the JSON default.
+func wantsText(c *gin.Context) bool {
+	if c.GetHeader("Accept") == "" {
+		return false
+	}
+	return c.NegotiateFormat(binding.MIMEJSON, binding.MIMEPlain) == binding.MIMEPlain
+}
+
+// envelope adds request_id to an error body when the request has one
+func envelope(body gin.H, requestID string) gin.H {
+	if requestID != "" {
+		body["request_id"] = requestID
+	}
+	return body
+}
+
+// textRequestID is the request ID line of text/plain errors
+func textRequestID(requestID string) string {
+	if requestID == "" {
+		return ""
+	}
+	return "Request ID: " + requestID + "\n"
+}
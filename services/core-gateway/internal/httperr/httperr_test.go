@@ -0,0 +1,88 @@
+package httperr_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/ctxutil"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/httperr"
+	"github.com/stretchr/testify/assert"
+)
+
+func errorRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/missing", func(c *gin.Context) {
+		httperr.Respond(c, http.StatusNotFound, "Conversation not found")
+	})
+	return r
+}
+
+func get(r *gin.Engine, accept string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(http.MethodGet, "/missing", nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRespond_JSONByDefault(t *testing.T) {
+	for _, accept := range []string{"", "application/json", "*/*"} {
+		// Act
+		w := get(errorRouter(), accept)
+
+		// Assert
+		assert.Equal(t, http.StatusNotFound, w.Code, accept)
+		assert.Contains(t, w.Header().Get("Content-Type"), "application/json", accept)
+		assert.JSONEq(t, `{"error":"Conversation not found"}`, w.Body.String(), accept)
+	}
+}
+
+func TestRespond_PlainText(t *testing.T) {
+	// Act
+	w := get(errorRouter(), "text/plain")
+
+	// Assert: same status, human-readable body
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/plain")
+	assert.Equal(t, "Conversation not found\n", w.Body.String())
+}
+
+func TestAbort_StopsChain(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	reached := false
+	r.Use(func(c *gin.Context) { httperr.Abort(c, http.StatusForbidden, "Insufficient permissions") })
+	r.GET("/missing", func(c *gin.Context) { reached = true })
+
+	// Act
+	w := get(r, "text/plain")
+
+	// Assert
+	assert.False(t, reached)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "Insufficient permissions\n", w.Body.String())
+}
+
+func TestRespond_IncludesRequestID(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) { ctxutil.SetRequestID(c, "req-42") })
+	r.GET("/missing", func(c *gin.Context) {
+		httperr.Respond(c, http.StatusNotFound, "Conversation not found")
+	})
+
+	// Act
+	asJSON := get(r, "")
+	asText := get(r, "text/plain")
+
+	// Assert
+	assert.JSONEq(t, `{"error":"Conversation not found","request_id":"req-42"}`, asJSON.Body.String())
+	assert.Equal(t, "Conversation not found\nRequest ID: req-42\n", asText.Body.String())
+}
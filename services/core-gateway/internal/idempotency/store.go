@@ -0,0 +1,204 @@
+// Package idempotency remembers responses by Idempotency-Key so retried
+// requests are answered once instead of being executed twice.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/clock"
+)
+
+// DefaultTTL is how long a stored response can be replayed
+const DefaultTTL = 24 * time.Hour
+
+// SweepEvery is how many claims a store takes between sweeps of expired records
+const SweepEvery = 100
+
+// DefaultSweepInterval is how often RunSweeper deletes expired records
+const DefaultSweepInterval = time.Hour
+
+// Record is the stored outcome of one keyed request (table: idempotency_keys).
+// Keys are scoped per user, so two users can't collide or read each other's responses.
+type Record struct {
+	Key          string `gorm:"primaryKey"`
+	UserID       string `gorm:"primaryKey"`
+	StatusCode   int    `gorm:"not null;default:0"`
+	ResponseJSON string `gorm:"type:text"`
+	// Fingerprint identifies the request the key was first used for (see Fingerprint)
+	Fingerprint string `gorm:"not null;default:''"`
+	// Completed is false while the first request is still being handled
+	Completed bool      `gorm:"not null;default:false"`
+	CreatedAt time.Time `gorm:"not null"`
+	ExpiresAt time.Time `gorm:"index;not null"`
+}
+
+func (Record) TableName() string { return "idempotency_keys" }
+
+// Store persists idempotency records
+type Store interface {
+	// Begin claims key for userID's request with fingerprint. When a live record
+	// already exists it is returned with created=false instead of claiming the key again.
+	Begin(ctx context.Context, key, userID, fingerprint string, ttl time.Duration) (rec *Record, created bool, err error)
+	// Complete stores the response for a claimed key
+	Complete(ctx context.Context, key, userID string, statusCode int, responseJSON string) error
+	// Release drops a claimed key so the request may be retried (e.g. after a 5xx)
+	Release(ctx context.Context, key, userID string) error
+	// ForgetThread drops userID's stored responses that belong to threadID,
+	// so a deleted conversation can't be replayed
+	ForgetThread(ctx context.Context, userID, threadID string) error
+	// DeleteExpired drops every record whose TTL has passed and returns how many
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+// Fingerprint identifies a request by its method, path and body, so a key
+// reused for a different request is told apart from a retry
+func Fingerprint(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// RunSweeper deletes expired records every interval until ctx is done, so keys
+// that are never used again don't pile up
+func RunSweeper(ctx context.Context, store Store, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultSweepInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := store.DeleteExpired(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("[Idempotency] Sweep failed: %v", err)
+		} else if n > 0 {
+			log.Printf("[Idempotency] Swept %d expired keys", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Owner is the user ID records are stored under: keys are scoped per tenant and caller
+func Owner(tenantID, caller string) string {
+	if tenantID == "" {
+		return caller
+	}
+	return tenantID + "/" + caller
+}
+
+// ResponseThreadID returns the thread_id of a stored JSON response ("" if none)
+func ResponseThreadID(responseJSON string) string {
+	var resp struct {
+		ThreadID string `json:"thread_id"`
+	}
+	if json.Unmarshal([]byte(responseJSON), &resp) != nil {
+		return ""
+	}
+	return resp.ThreadID
+}
+
+type memoryStore struct {
+	mu      sync.Mutex
+	records map[[2]string]*Record
+	now     func() time.Time
+	// claims counts Begin calls since the last sweep
+	claims int
+}
+
+// NewMemoryStore returns a process-local Store, used when no database is configured
+func NewMemoryStore() Store {
+	return NewMemoryStoreWithClock(nil)
+}
+
+// NewMemoryStoreWithClock is NewMemoryStore with TTLs measured on c (nil = wall clock)
+func NewMemoryStoreWithClock(c clock.Clock) Store {
+	return &memoryStore{records: map[[2]string]*Record{}, now: clock.Or(c).Now}
+}
+
+func (s *memoryStore) Begin(ctx context.Context, key, userID, fingerprint string, ttl time.Duration) (*Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if s.claims++; s.claims >= SweepEvery {
+		s.deleteExpired(now)
+	}
+	id := [2]string{key, userID}
+	if rec, ok := s.records[id]; ok && now.Before(rec.ExpiresAt) {
+		existing := *rec
+		return &existing, false, nil
+	}
+
+	rec := &Record{Key: key, UserID: userID, Fingerprint: fingerprint, CreatedAt: now, ExpiresAt: now.Add(ttl)}
+	s.records[id] = rec
+	created := *rec
+	return &created, true, nil
+}
+
+func (s *memoryStore) Complete(ctx context.Context, key, userID string, statusCode int, responseJSON string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rec, ok := s.records[[2]string{key, userID}]; ok {
+		rec.StatusCode = statusCode
+		rec.ResponseJSON = responseJSON
+		rec.Completed = true
+	}
+	return nil
+}
+
+func (s *memoryStore) Release(ctx context.Context, key, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, [2]string{key, userID})
+	return nil
+}
+
+func (s *memoryStore) ForgetThread(ctx context.Context, userID, threadID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, rec := range s.records {
+		if rec.UserID == userID && ResponseThreadID(rec.ResponseJSON) == threadID {
+			delete(s.records, id)
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) DeleteExpired(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.deleteExpired(s.now()), nil
+}
+
+// deleteExpired drops the records expired at now; s.mu must be held
+func (s *memoryStore) deleteExpired(now time.Time) int64 {
+	s.claims = 0
+	var deleted int64
+	for id, rec := range s.records {
+		if !now.Before(rec.ExpiresAt) {
+			delete(s.records, id)
+			deleted++
+		}
+	}
+	return deleted
+}
+
+// Len returns the number of stored records, including expired ones not yet reclaimed
+func (s *memoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.records)
+}
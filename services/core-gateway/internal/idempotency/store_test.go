@@ -0,0 +1,118 @@
+package idempotency_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/clock"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/idempotency"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_ConcurrentBegin(t *testing.T) {
+	// Arrange
+	store := idempotency.NewMemoryStore()
+	var created atomic.Int32
+	var wg sync.WaitGroup
+
+	// Act: many replicas' worth of requests claim the same key at once
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, ok, err := store.Begin(context.Background(), "key-1", "user_1", "", time.Hour)
+			assert.NoError(t, err)
+			if ok {
+				created.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Assert
+	assert.Equal(t, int32(1), created.Load())
+}
+
+func TestMemoryStore_CompleteAndRelease(t *testing.T) {
+	store := idempotency.NewMemoryStore()
+	ctx := context.Background()
+
+	_, _, err := store.Begin(ctx, "key-1", "user_1", "", time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, store.Complete(ctx, "key-1", "user_1", 200, `{"ok":true}`))
+
+	rec, created, err := store.Begin(ctx, "key-1", "user_1", "", time.Hour)
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.True(t, rec.Completed)
+	assert.Equal(t, `{"ok":true}`, rec.ResponseJSON)
+
+	require.NoError(t, store.Release(ctx, "key-1", "user_1"))
+	_, created, _ = store.Begin(ctx, "key-1", "user_1", "", time.Hour)
+	assert.True(t, created, "A released key can be claimed again")
+}
+
+func TestMemoryStore_ExpiredKeyIsReclaimed(t *testing.T) {
+	// Arrange
+	fake := clock.NewFake(time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC))
+	store := idempotency.NewMemoryStoreWithClock(fake)
+	ctx := context.Background()
+	_, _, _ = store.Begin(ctx, "key-1", "user_1", "", time.Hour)
+
+	// Act & Assert: held until the TTL has passed
+	fake.Advance(time.Hour - time.Second)
+	_, created, err := store.Begin(ctx, "key-1", "user_1", "", time.Hour)
+	require.NoError(t, err)
+	assert.False(t, created)
+
+	fake.Advance(time.Second)
+	_, created, err = store.Begin(ctx, "key-1", "user_1", "", time.Hour)
+	require.NoError(t, err)
+	assert.True(t, created)
+}
+
+func TestMemoryStore_SweepsExpiredRecords(t *testing.T) {
+	// Arrange: a key that is never used again expires
+	fake := clock.NewFake(time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC))
+	store := idempotency.NewMemoryStoreWithClock(fake)
+	ctx := context.Background()
+	_, _, err := store.Begin(ctx, "abandoned", "user_1", "", time.Hour)
+	require.NoError(t, err)
+	fake.Advance(2 * time.Hour)
+
+	// Act: enough new claims to trigger a sweep
+	for i := 0; i < idempotency.SweepEvery; i++ {
+		_, _, err := store.Begin(ctx, fmt.Sprintf("key-%d", i), "user_1", "", time.Hour)
+		require.NoError(t, err)
+	}
+
+	// Assert
+	assert.Equal(t, idempotency.SweepEvery, store.(interface{ Len() int }).Len())
+	n, err := store.DeleteExpired(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n, "the sweep already dropped the abandoned key")
+}
+
+func TestMemoryStore_DeleteExpired(t *testing.T) {
+	// Arrange
+	fake := clock.NewFake(time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC))
+	store := idempotency.NewMemoryStoreWithClock(fake)
+	ctx := context.Background()
+	_, _, _ = store.Begin(ctx, "short", "user_1", "", time.Minute)
+	_, _, _ = store.Begin(ctx, "long", "user_1", "", time.Hour)
+	fake.Advance(time.Minute)
+
+	// Act
+	n, err := store.DeleteExpired(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	_, created, _ := store.Begin(ctx, "long", "user_1", "", time.Hour)
+	assert.False(t, created)
+}
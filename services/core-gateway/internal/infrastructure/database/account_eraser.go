@@ -0,0 +1,67 @@
+package database
+
+import (
+	"context"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/account"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/agenturl"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/conversation"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/idempotency"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/limits"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/telegramlink"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/user"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type accountEraser struct {
+	db *gorm.DB
+}
+
+// NewAccountEraser returns an account.Eraser that deletes a user's messages,
+// idempotency keys, Telegram links and pending link codes, agent URL and limit
+// override and tombstones the account in a single transaction
+func NewAccountEraser(db *gorm.DB) account.Eraser {
+	return &accountEraser{db: db}
+}
+
+func (e *accountEraser) EraseUser(ctx context.Context, scope conversation.Scope) (account.Erased, error) {
+	var erased account.Erased
+	err := e.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		owned := tx.Model(&conversation.Message{}).Where("tenant_id = ? AND user_id = ?", scope.TenantID, scope.UserID)
+		var threads int64
+		if err := owned.Distinct("thread_id").Count(&threads).Error; err != nil {
+			return err
+		}
+		erased.Threads = int(threads)
+
+		res := tx.Where("tenant_id = ? AND user_id = ?", scope.TenantID, scope.UserID).Delete(&conversation.Message{})
+		if res.Error != nil {
+			return res.Error
+		}
+		erased.Messages = res.RowsAffected
+
+		if err := tx.Where("user_id = ?", idempotency.Owner(scope.TenantID, scope.UserID)).Delete(&idempotency.Record{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", scope.UserID).Delete(&telegramlink.Link{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", scope.UserID).Delete(&telegramlink.Code{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", scope.UserID).Delete(&agenturl.Setting{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("subject_type = ? AND subject_id = ?", limits.SubjectUser, scope.UserID).Delete(&limits.Override{}).Error; err != nil {
+			return err
+		}
+
+		tomb := user.Tombstone(scope.UserID)
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"username", "password_hash", "disabled", "updated_at"}),
+		}).Create(&tomb).Error
+	})
+	return erased, err
+}
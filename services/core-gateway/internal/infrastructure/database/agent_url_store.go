@@ -0,0 +1,42 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/agenturl"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type agentURLStore struct {
+	db *gorm.DB
+}
+
+// NewAgentURLStore returns a GORM-backed agenturl.Store (table: user_agent_urls)
+func NewAgentURLStore(db *gorm.DB) agenturl.Store {
+	return &agentURLStore{db: db}
+}
+
+func (s *agentURLStore) Get(ctx context.Context, userID string) (*agenturl.Setting, error) {
+	var setting agenturl.Setting
+	err := s.db.WithContext(ctx).First(&setting, "user_id = ?", userID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, agenturl.ErrNotSet
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &setting, nil
+}
+
+func (s *agentURLStore) Save(ctx context.Context, setting *agenturl.Setting) error {
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"url", "updated_at"}),
+	}).Create(setting).Error
+}
+
+func (s *agentURLStore) Delete(ctx context.Context, userID string) error {
+	return s.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&agenturl.Setting{}).Error
+}
@@ -0,0 +1,101 @@
+package database
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/config"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/account"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/agenturl"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/conversation"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/idempotency"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/limits"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/telegramlink"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/user"
+	"gorm.io/gorm"
+)
+
+// Storage drivers selectable with db.driver
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+	DriverMemory   = "memory"
+)
+
+// Backend bundles the repositories of one storage driver, so handlers only
+// ever see the repository interfaces.
+type Backend struct {
+	Driver          string
+	Messages        conversation.Repository
+	Users           user.Repository
+	IdempotencyKeys idempotency.Store
+	// Accounts erases a user's data (DELETE /api/v1/me)
+	Accounts account.Eraser
+	// TelegramLinks maps Telegram users to gateway users
+	TelegramLinks telegramlink.Store
+	// AgentURLs holds users' custom agent URLs
+	AgentURLs agenturl.Store
+	// Limits holds per-user and per-role rate limit and budget overrides
+	Limits limits.Store
+}
+
+// Open connects to the configured driver (default postgres) and migrates its schema
+func Open(cfg config.Config) (*Backend, error) {
+	switch cfg.DB.Driver {
+	case "", DriverPostgres:
+		db, err := NewPostgresDB(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return NewGORMBackend(DriverPostgres, db)
+	case DriverSQLite:
+		db, err := NewSQLiteDB(cfg.DB.Path)
+		if err != nil {
+			return nil, err
+		}
+		return NewGORMBackend(DriverSQLite, db)
+	case DriverMemory:
+		log.Println("⚠️ Using in-memory storage: data is lost on restart")
+		return NewMemoryBackend(), nil
+	}
+	return nil, fmt.Errorf("unknown db.driver %q (want postgres, sqlite or memory)", cfg.DB.Driver)
+}
+
+// NewGORMBackend migrates db and wraps it in GORM-backed repositories
+func NewGORMBackend(driver string, db *gorm.DB) (*Backend, error) {
+	if err := db.AutoMigrate(&user.User{}, &conversation.Message{}, &idempotency.Record{}, &telegramlink.Link{}, &telegramlink.Code{}, &agenturl.Setting{}, &limits.Override{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	}
+	return &Backend{
+		Driver:          driver,
+		Messages:        NewMessageRepository(db),
+		Users:           NewUserRepository(db),
+		IdempotencyKeys: NewIdempotencyStore(db),
+		Accounts:        NewAccountEraser(db),
+		TelegramLinks:   NewTelegramLinkStore(db),
+		AgentURLs:       NewAgentURLStore(db),
+		Limits:          NewLimitStore(db),
+	}, nil
+}
+
+// NewMemoryBackend keeps everything in process memory (local dev and tests)
+func NewMemoryBackend() *Backend {
+	b := &Backend{
+		Driver:          DriverMemory,
+		Messages:        conversation.NewMemoryRepository(),
+		Users:           user.NewMemoryRepository(),
+		IdempotencyKeys: idempotency.NewMemoryStore(),
+		TelegramLinks:   telegramlink.NewMemoryStore(),
+		AgentURLs:       agenturl.NewMemoryStore(),
+		Limits:          limits.NewMemoryStore(),
+	}
+	b.Accounts = account.NewRepositoryEraser(account.Stores{
+		History:       b.Messages,
+		Users:         b.Users,
+		Keys:          b.IdempotencyKeys,
+		TelegramLinks: b.TelegramLinks,
+		AgentURLs:     b.AgentURLs,
+		Limits:        b.Limits,
+	})
+	return b
+}
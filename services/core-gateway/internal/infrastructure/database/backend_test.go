@@ -0,0 +1,425 @@
+package database_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/agenturl"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/conversation"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/infrastructure/database"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/limits"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/telegramlink"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// forEachBackend runs the shared repository suite against every driver.
+// Postgres joins when TEST_DATABASE_DSN is set.
+func forEachBackend(t *testing.T, test func(t *testing.T, b *database.Backend)) {
+	t.Run(database.DriverMemory, func(t *testing.T) {
+		test(t, database.NewMemoryBackend())
+	})
+	t.Run(database.DriverSQLite, func(t *testing.T) {
+		db, err := database.NewSQLiteDB(filepath.Join(t.TempDir(), "test.db"))
+		require.NoError(t, err)
+		b, err := database.NewGORMBackend(database.DriverSQLite, db)
+		require.NoError(t, err)
+		test(t, b)
+	})
+	t.Run(database.DriverPostgres, func(t *testing.T) {
+		dsn := os.Getenv("TEST_DATABASE_DSN")
+		if dsn == "" {
+			t.Skip("TEST_DATABASE_DSN not set")
+		}
+		db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+		require.NoError(t, err)
+		b, err := database.NewGORMBackend(database.DriverPostgres, db)
+		require.NoError(t, err)
+		test(t, b)
+	})
+}
+
+func TestBackend_Messages(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b *database.Backend) {
+		// Arrange: unique IDs so a shared Postgres can be reused across runs
+		ctx := context.Background()
+		owner := conversation.Scope{TenantID: "t1", UserID: uuid.NewString()}
+		older, newer := uuid.NewString(), uuid.NewString()
+		base := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+		for i, m := range []conversation.Message{
+			{ThreadID: older, Role: conversation.RoleUser, Content: "first", CreatedAt: base},
+			{ThreadID: older, Role: conversation.RoleAssistant, Content: "answer", CreatedAt: base.Add(time.Minute)},
+			{ThreadID: newer, Role: conversation.RoleUser, Content: "later", CreatedAt: base.Add(time.Hour - time.Minute)},
+		} {
+			m.TenantID, m.UserID = owner.TenantID, owner.UserID
+			require.NoError(t, b.Messages.SaveMessage(ctx, &m), i)
+		}
+		require.NoError(t, b.Messages.SaveMessage(ctx, &conversation.Message{
+			TenantID: "t2", UserID: owner.UserID, ThreadID: older, Role: conversation.RoleUser, Content: "other tenant",
+		}))
+
+		// Act
+		threads, err := b.Messages.ListThreads(ctx, owner)
+		require.NoError(t, err)
+		messages, err := b.Messages.ListMessages(ctx, owner, older)
+		require.NoError(t, err)
+
+		// Assert: most recent thread first, tenants isolated, messages in order
+		require.Len(t, threads, 2)
+		assert.Equal(t, newer, threads[0].ID)
+		assert.Equal(t, older, threads[1].ID)
+		assert.Equal(t, 2, threads[1].MessageCount)
+		require.Len(t, messages, 2)
+		assert.Equal(t, "first", messages[0].Content)
+		assert.Equal(t, "answer", messages[1].Content)
+
+		got, err := b.Messages.GetMessage(ctx, older, messages[1].ID)
+		require.NoError(t, err)
+		assert.Equal(t, "answer", got.Content)
+		_, err = b.Messages.GetMessage(ctx, older, uuid.NewString())
+		assert.ErrorIs(t, err, conversation.ErrNotFound)
+	})
+}
+
+func TestBackend_MarkMessageComplete(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b *database.Backend) {
+		// Arrange: a prompt the agent has not answered yet
+		ctx := context.Background()
+		owner := conversation.Scope{UserID: uuid.NewString()}
+		threadID := uuid.NewString()
+		prompt := &conversation.Message{UserID: owner.UserID, ThreadID: threadID, Role: conversation.RoleUser, Content: "plan the release", Incomplete: true}
+		require.NoError(t, b.Messages.SaveMessage(ctx, prompt))
+
+		// Act
+		err := b.Messages.MarkComplete(ctx, prompt.ID)
+
+		// Assert
+		require.NoError(t, err)
+		got, err := b.Messages.GetMessage(ctx, threadID, prompt.ID)
+		require.NoError(t, err)
+		assert.False(t, got.Incomplete)
+	})
+}
+
+func TestBackend_PruneMessages(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b *database.Backend) {
+		// Arrange
+		ctx := context.Background()
+		prunable, ok := b.Messages.(conversation.Prunable)
+		require.True(t, ok, "every backend supports retention")
+		scope := conversation.Scope{UserID: uuid.NewString()}
+		thread := uuid.NewString()
+		old := time.Now().Add(-100 * 365 * 24 * time.Hour)
+		for i := 0; i < 3; i++ {
+			require.NoError(t, b.Messages.SaveMessage(ctx, &conversation.Message{
+				UserID: scope.UserID, ThreadID: thread, Role: conversation.RoleUser, Content: "old", CreatedAt: old,
+			}))
+		}
+		require.NoError(t, b.Messages.SaveMessage(ctx, &conversation.Message{
+			UserID: scope.UserID, ThreadID: thread, Role: conversation.RoleUser, Content: "new",
+		}))
+
+		// Act: batches of 2 until the old rows are gone
+		first, err := prunable.DeleteMessagesBefore(ctx, old.Add(time.Hour), 2)
+		require.NoError(t, err)
+		second, err := prunable.DeleteMessagesBefore(ctx, old.Add(time.Hour), 2)
+		require.NoError(t, err)
+
+		// Assert
+		assert.Equal(t, int64(2), first)
+		assert.Equal(t, int64(1), second)
+		messages, _ := b.Messages.ListMessages(ctx, scope, thread)
+		require.Len(t, messages, 1)
+		assert.Equal(t, "new", messages[0].Content)
+
+		deleted, err := prunable.DeleteThreadMessages(ctx, conversation.OwnedThread{Scope: scope, ThreadID: thread}, 10)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), deleted)
+	})
+}
+
+func TestBackend_Users(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b *database.Backend) {
+		// Arrange
+		ctx := context.Background()
+		u := &user.User{Username: "kim-" + uuid.NewString(), PasswordHash: "hash", Role: "admin"}
+
+		// Act
+		require.NoError(t, b.Users.Create(ctx, u))
+		byID, errByID := b.Users.FindByID(ctx, u.ID)
+		byName, errByName := b.Users.FindByUsername(ctx, u.Username)
+		_, errMissing := b.Users.FindByUsername(ctx, "nobody-"+uuid.NewString())
+		errDuplicate := b.Users.Create(ctx, &user.User{Username: u.Username, PasswordHash: "x"})
+
+		// Assert
+		require.NoError(t, errByID)
+		require.NoError(t, errByName)
+		assert.Equal(t, u.Username, byID.Username)
+		assert.Equal(t, "admin", byName.Role)
+		assert.ErrorIs(t, errMissing, user.ErrNotFound)
+		assert.Error(t, errDuplicate, "usernames are unique")
+	})
+}
+
+func TestBackend_UpdateUser(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b *database.Backend) {
+		// Arrange
+		ctx := context.Background()
+		u := &user.User{Username: "lee-" + uuid.NewString(), PasswordHash: "hash", Role: "user"}
+		require.NoError(t, b.Users.Create(ctx, u))
+		users := b.Users.(user.Updatable)
+
+		// Act
+		u.Role, u.Disabled = "admin", true
+		errUpdate := users.Update(ctx, u)
+		updated, errFind := b.Users.FindByID(ctx, u.ID)
+		errMissing := users.Update(ctx, &user.User{ID: "missing-" + uuid.NewString(), Username: "ghost-" + uuid.NewString()})
+
+		// Assert
+		require.NoError(t, errUpdate)
+		require.NoError(t, errFind)
+		assert.Equal(t, "admin", updated.Role)
+		assert.True(t, updated.Disabled)
+		assert.ErrorIs(t, errMissing, user.ErrNotFound)
+	})
+}
+
+func TestBackend_IdempotencyKeys(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b *database.Backend) {
+		// Arrange
+		ctx := context.Background()
+		key, userID := uuid.NewString(), uuid.NewString()
+
+		// Act
+		_, created, err := b.IdempotencyKeys.Begin(ctx, key, userID, "", time.Hour)
+		require.NoError(t, err)
+		require.NoError(t, b.IdempotencyKeys.Complete(ctx, key, userID, 200, `{"reply":"ok"}`))
+		rec, again, err := b.IdempotencyKeys.Begin(ctx, key, userID, "", time.Hour)
+		require.NoError(t, err)
+
+		// Assert
+		assert.True(t, created)
+		assert.False(t, again)
+		assert.True(t, rec.Completed)
+		assert.Equal(t, 200, rec.StatusCode)
+		assert.JSONEq(t, `{"reply":"ok"}`, rec.ResponseJSON)
+	})
+}
+
+func TestBackend_IdempotencyDeleteExpired(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b *database.Backend) {
+		// Arrange: one key has already expired, the other is live
+		ctx := context.Background()
+		userID := uuid.NewString()
+		expired, live := uuid.NewString(), uuid.NewString()
+		_, _, err := b.IdempotencyKeys.Begin(ctx, expired, userID, "", -time.Minute)
+		require.NoError(t, err)
+		_, _, err = b.IdempotencyKeys.Begin(ctx, live, userID, "", time.Hour)
+		require.NoError(t, err)
+
+		// Act
+		n, err := b.IdempotencyKeys.DeleteExpired(ctx)
+
+		// Assert: only the live key still holds
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, n, int64(1))
+		_, created, err := b.IdempotencyKeys.Begin(ctx, live, userID, "", time.Hour)
+		require.NoError(t, err)
+		assert.False(t, created)
+	})
+}
+
+func TestBackend_IdempotencyForgetThread(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b *database.Backend) {
+		// Arrange: two keys for the deleted thread, one for another thread
+		ctx := context.Background()
+		userID := uuid.NewString()
+		responses := map[string]string{
+			"k1": `{"reply":"a","thread_id":"gone"}`,
+			"k2": `{"reply":"b","thread_id":"gone"}`,
+			"k3": `{"reply":"c","thread_id":"kept"}`,
+		}
+		for key, resp := range responses {
+			_, _, err := b.IdempotencyKeys.Begin(ctx, key, userID, "", time.Hour)
+			require.NoError(t, err)
+			require.NoError(t, b.IdempotencyKeys.Complete(ctx, key, userID, 200, resp))
+		}
+
+		// Act
+		require.NoError(t, b.IdempotencyKeys.ForgetThread(ctx, userID, "gone"))
+
+		// Assert: forgotten keys can be claimed again, the other one still replays
+		for key, wantCreated := range map[string]bool{"k1": true, "k2": true, "k3": false} {
+			_, created, err := b.IdempotencyKeys.Begin(ctx, key, userID, "", time.Hour)
+			require.NoError(t, err)
+			assert.Equal(t, wantCreated, created, key)
+		}
+	})
+}
+
+func TestBackend_EraseUser(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b *database.Backend) {
+		// Arrange: a registered user with two threads and a stored response, plus a neighbour
+		ctx := context.Background()
+		u := &user.User{Username: "erase-" + uuid.NewString(), PasswordHash: "hash"}
+		require.NoError(t, b.Users.Create(ctx, u))
+		other := uuid.NewString()
+		for _, m := range []conversation.Message{
+			{UserID: u.ID, ThreadID: "a", Role: conversation.RoleUser, Content: "1"},
+			{UserID: u.ID, ThreadID: "a", Role: conversation.RoleAssistant, Content: "2"},
+			{UserID: u.ID, ThreadID: "b", Role: conversation.RoleUser, Content: "3"},
+			{UserID: other, ThreadID: "a", Role: conversation.RoleUser, Content: "kept"},
+		} {
+			require.NoError(t, b.Messages.SaveMessage(ctx, &m))
+		}
+		_, _, err := b.IdempotencyKeys.Begin(ctx, "k", u.ID, "", time.Hour)
+		require.NoError(t, err)
+		require.NoError(t, b.IdempotencyKeys.Complete(ctx, "k", u.ID, 200, `{"thread_id":"a"}`))
+
+		// Act
+		erased, err := b.Accounts.EraseUser(ctx, conversation.Scope{UserID: u.ID})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), erased.Messages)
+		assert.Equal(t, 2, erased.Threads)
+
+		threads, err := b.Messages.ListThreads(ctx, conversation.Scope{UserID: u.ID})
+		require.NoError(t, err)
+		assert.Empty(t, threads)
+		kept, err := b.Messages.ListMessages(ctx, conversation.Scope{UserID: other}, "a")
+		require.NoError(t, err)
+		assert.Len(t, kept, 1)
+
+		_, created, err := b.IdempotencyKeys.Begin(ctx, "k", u.ID, "", time.Hour)
+		require.NoError(t, err)
+		assert.True(t, created, "stored responses are gone")
+
+		tomb, err := b.Users.FindByID(ctx, u.ID)
+		require.NoError(t, err)
+		assert.True(t, tomb.Disabled)
+		assert.Empty(t, tomb.PasswordHash)
+		assert.NotEqual(t, u.Username, tomb.Username)
+	})
+}
+
+func TestBackend_EraseUserClearsSettings(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b *database.Backend) {
+		// Arrange: a Telegram link, a pending link code, an agent URL and a limit override
+		ctx := context.Background()
+		userID := "user_" + uuid.NewString()
+		telegramUserID := time.Now().UnixNano()
+		code := uuid.NewString()[:8]
+		rpm := 10
+		require.NoError(t, b.TelegramLinks.SaveLink(ctx, &telegramlink.Link{TelegramUserID: telegramUserID, UserID: userID, CreatedAt: time.Now()}))
+		require.NoError(t, b.TelegramLinks.SaveCode(ctx, &telegramlink.Code{Code: code, UserID: userID, ExpiresAt: time.Now().Add(time.Minute).UTC()}))
+		require.NoError(t, b.AgentURLs.Save(ctx, &agenturl.Setting{UserID: userID, URL: "http://agent.internal", UpdatedAt: time.Now().UTC()}))
+		require.NoError(t, b.Limits.Save(ctx, &limits.Override{SubjectType: limits.SubjectUser, SubjectID: userID, Values: limits.Values{RequestsPerMinute: &rpm}, UpdatedAt: time.Now().UTC()}))
+
+		// Act
+		_, err := b.Accounts.EraseUser(ctx, conversation.Scope{UserID: userID})
+
+		// Assert: every backend erases the same stores
+		require.NoError(t, err)
+		_, err = b.TelegramLinks.GetLink(ctx, telegramUserID)
+		assert.ErrorIs(t, err, telegramlink.ErrNotLinked)
+		_, err = b.TelegramLinks.TakeCode(ctx, code)
+		assert.ErrorIs(t, err, telegramlink.ErrInvalidCode, "a pending code cannot relink the erased account")
+		_, err = b.AgentURLs.Get(ctx, userID)
+		assert.ErrorIs(t, err, agenturl.ErrNotSet)
+		_, err = b.Limits.Get(ctx, limits.SubjectUser, userID)
+		assert.ErrorIs(t, err, limits.ErrNotSet)
+	})
+}
+
+func TestBackend_EraseUserWithoutAccountLeavesTombstone(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b *database.Backend) {
+		// Arrange: a token-only user (e.g. the dev token) has history but no account row
+		ctx := context.Background()
+		id := uuid.NewString()
+		require.NoError(t, b.Messages.SaveMessage(ctx, &conversation.Message{UserID: id, ThreadID: "a", Role: conversation.RoleUser, Content: "hi"}))
+
+		// Act
+		_, err := b.Accounts.EraseUser(ctx, conversation.Scope{UserID: id})
+
+		// Assert: the tombstone disables the ID so its tokens are refused
+		require.NoError(t, err)
+		tomb, err := b.Users.FindByID(ctx, id)
+		require.NoError(t, err)
+		assert.True(t, tomb.Disabled)
+	})
+}
+
+func TestBackend_TelegramLinks(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b *database.Backend) {
+		// Arrange
+		ctx := context.Background()
+		code := &telegramlink.Code{Code: uuid.NewString()[:8], UserID: "user_1", ExpiresAt: time.Now().Add(time.Minute).UTC()}
+		require.NoError(t, b.TelegramLinks.SaveCode(ctx, code))
+		telegramUserID := time.Now().UnixNano()
+
+		// Act
+		taken, err := b.TelegramLinks.TakeCode(ctx, code.Code)
+		require.NoError(t, err)
+		_, again := b.TelegramLinks.TakeCode(ctx, code.Code)
+		require.NoError(t, b.TelegramLinks.SaveLink(ctx, &telegramlink.Link{TelegramUserID: telegramUserID, UserID: "user_1", CreatedAt: time.Now()}))
+		require.NoError(t, b.TelegramLinks.SaveLink(ctx, &telegramlink.Link{TelegramUserID: telegramUserID, UserID: "user_2", CreatedAt: time.Now()}))
+
+		// Assert: codes are single-use, relinking replaces the old link
+		assert.Equal(t, "user_1", taken.UserID)
+		assert.ErrorIs(t, again, telegramlink.ErrInvalidCode)
+		link, err := b.TelegramLinks.GetLink(ctx, telegramUserID)
+		require.NoError(t, err)
+		assert.Equal(t, "user_2", link.UserID)
+		_, err = b.TelegramLinks.GetLink(ctx, telegramUserID+1)
+		assert.ErrorIs(t, err, telegramlink.ErrNotLinked)
+		byUser, err := b.TelegramLinks.GetLinkByUser(ctx, "user_2")
+		require.NoError(t, err)
+		assert.Equal(t, telegramUserID, byUser.TelegramUserID)
+		_, err = b.TelegramLinks.GetLinkByUser(ctx, "unlinked_"+uuid.NewString())
+		assert.ErrorIs(t, err, telegramlink.ErrNotLinked)
+	})
+}
+
+func TestBackend_Limits(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b *database.Backend) {
+		// Arrange
+		ctx := context.Background()
+		userID := "user_" + uuid.NewString()
+		rpm, daily := 120, 0
+		override := &limits.Override{SubjectType: limits.SubjectUser, SubjectID: userID, Values: limits.Values{RequestsPerMinute: &rpm}, UpdatedAt: time.Now().UTC()}
+
+		// Act
+		require.NoError(t, b.Limits.Save(ctx, override))
+		override.Values = limits.Values{DailyChars: &daily}
+		require.NoError(t, b.Limits.Save(ctx, override))
+		got, err := b.Limits.Get(ctx, limits.SubjectUser, userID)
+		require.NoError(t, err)
+		listed, err := b.Limits.List(ctx)
+		require.NoError(t, err)
+		require.NoError(t, b.Limits.Delete(ctx, limits.SubjectUser, userID))
+		_, afterDelete := b.Limits.Get(ctx, limits.SubjectUser, userID)
+
+		// Assert: saving again replaces the whole override, unset values included
+		assert.Nil(t, got.RequestsPerMinute)
+		require.NotNil(t, got.DailyChars)
+		assert.Equal(t, 0, *got.DailyChars)
+		assert.Contains(t, subjects(listed), userID)
+		assert.ErrorIs(t, afterDelete, limits.ErrNotSet)
+	})
+}
+
+func subjects(overrides []limits.Override) []string {
+	out := make([]string, 0, len(overrides))
+	for _, o := range overrides {
+		out = append(out, o.SubjectID)
+	}
+	return out
+}
@@ -0,0 +1,97 @@
+package database
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/clock"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/idempotency"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type idempotencyStore struct {
+	db    *gorm.DB
+	clock clock.Clock
+	// claims counts Begin calls, to sweep expired keys every idempotency.SweepEvery
+	claims atomic.Int64
+}
+
+// NewIdempotencyStore returns a GORM-backed idempotency.Store (table: idempotency_keys).
+// The (key, user_id) primary key makes concurrent claims race-free across replicas.
+func NewIdempotencyStore(db *gorm.DB) idempotency.Store {
+	return NewIdempotencyStoreWithClock(db, nil)
+}
+
+// NewIdempotencyStoreWithClock is NewIdempotencyStore with TTLs measured on c (nil = wall clock)
+func NewIdempotencyStoreWithClock(db *gorm.DB, c clock.Clock) idempotency.Store {
+	return &idempotencyStore{db: db, clock: clock.Or(c)}
+}
+
+func (s *idempotencyStore) Begin(ctx context.Context, key, userID, fingerprint string, ttl time.Duration) (*idempotency.Record, bool, error) {
+	db := s.db.WithContext(ctx)
+	now := s.clock.Now().UTC()
+
+	// An expired record no longer holds the key; every so often all of them go
+	expired := db.Where("key = ? AND user_id = ? AND expires_at <= ?", key, userID, now)
+	if s.claims.Add(1)%idempotency.SweepEvery == 0 {
+		expired = db.Where("expires_at <= ?", now)
+	}
+	if err := expired.Delete(&idempotency.Record{}).Error; err != nil {
+		return nil, false, err
+	}
+
+	// Insert or return existing: only one concurrent claim can win the insert
+	rec := &idempotency.Record{Key: key, UserID: userID, Fingerprint: fingerprint, CreatedAt: now, ExpiresAt: now.Add(ttl)}
+	res := db.Clauses(clause.OnConflict{DoNothing: true}).Create(rec)
+	if res.Error != nil {
+		return nil, false, res.Error
+	}
+	if res.RowsAffected == 1 {
+		return rec, true, nil
+	}
+
+	var existing idempotency.Record
+	if err := db.First(&existing, "key = ? AND user_id = ?", key, userID).Error; err != nil {
+		return nil, false, err
+	}
+	return &existing, false, nil
+}
+
+func (s *idempotencyStore) Complete(ctx context.Context, key, userID string, statusCode int, responseJSON string) error {
+	return s.db.WithContext(ctx).Model(&idempotency.Record{}).
+		Where("key = ? AND user_id = ?", key, userID).
+		Updates(map[string]interface{}{"status_code": statusCode, "response_json": responseJSON, "completed": true}).Error
+}
+
+func (s *idempotencyStore) Release(ctx context.Context, key, userID string) error {
+	return s.db.WithContext(ctx).Where("key = ? AND user_id = ?", key, userID).Delete(&idempotency.Record{}).Error
+}
+
+func (s *idempotencyStore) DeleteExpired(ctx context.Context) (int64, error) {
+	res := s.db.WithContext(ctx).Where("expires_at <= ?", s.clock.Now().UTC()).Delete(&idempotency.Record{})
+	return res.RowsAffected, res.Error
+}
+
+// ForgetThread matches thread IDs in Go, as responses are opaque JSON text. Only
+// unexpired records are read: expired ones are never replayed.
+func (s *idempotencyStore) ForgetThread(ctx context.Context, userID, threadID string) error {
+	var records []idempotency.Record
+	err := s.db.WithContext(ctx).Select("key", "response_json").
+		Where("user_id = ? AND expires_at > ?", userID, s.clock.Now().UTC()).
+		Find(&records).Error
+	if err != nil {
+		return err
+	}
+	var keys []string
+	for _, rec := range records {
+		if idempotency.ResponseThreadID(rec.ResponseJSON) == threadID {
+			keys = append(keys, rec.Key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	return s.db.WithContext(ctx).Where("user_id = ? AND key IN ?", userID, keys).Delete(&idempotency.Record{}).Error
+}
@@ -0,0 +1,56 @@
+package database_test
+
+import (
+	"context"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/idempotency"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/infrastructure/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// openTestDB connects to TEST_DATABASE_DSN; the test is skipped without it
+func openTestDB(t *testing.T) *gorm.DB {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&idempotency.Record{}))
+	return db
+}
+
+func TestIdempotencyStore_ConcurrentBeginSameKey(t *testing.T) {
+	// Arrange
+	store := database.NewIdempotencyStore(openTestDB(t))
+	key := uuid.NewString()
+	var created atomic.Int32
+	var wg sync.WaitGroup
+
+	// Act
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec, ok, err := store.Begin(context.Background(), key, "user_1", "", time.Hour)
+			assert.NoError(t, err)
+			assert.Equal(t, key, rec.Key)
+			if ok {
+				created.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Assert: exactly one claim wins the insert; the rest get the existing record
+	assert.Equal(t, int32(1), created.Load())
+}
@@ -0,0 +1,48 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/limits"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type limitStore struct {
+	db *gorm.DB
+}
+
+// NewLimitStore returns a GORM-backed limits.Store (table: limits)
+func NewLimitStore(db *gorm.DB) limits.Store {
+	return &limitStore{db: db}
+}
+
+func (s *limitStore) Get(ctx context.Context, subjectType, subjectID string) (*limits.Override, error) {
+	var o limits.Override
+	err := s.db.WithContext(ctx).First(&o, "subject_type = ? AND subject_id = ?", subjectType, subjectID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, limits.ErrNotSet
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+func (s *limitStore) List(ctx context.Context) ([]limits.Override, error) {
+	var overrides []limits.Override
+	err := s.db.WithContext(ctx).Order("subject_type DESC, subject_id").Find(&overrides).Error
+	return overrides, err
+}
+
+func (s *limitStore) Save(ctx context.Context, o *limits.Override) error {
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "subject_type"}, {Name: "subject_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"requests_per_minute", "daily_chars", "updated_at"}),
+	}).Create(o).Error
+}
+
+func (s *limitStore) Delete(ctx context.Context, subjectType, subjectID string) error {
+	return s.db.WithContext(ctx).Where("subject_type = ? AND subject_id = ?", subjectType, subjectID).Delete(&limits.Override{}).Error
+}
@@ -0,0 +1,163 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/conversation"
+	"gorm.io/gorm"
+)
+
+type messageRepository struct {
+	db *gorm.DB
+}
+
+// NewMessageRepository returns a GORM-backed conversation.Repository (table: messages)
+func NewMessageRepository(db *gorm.DB) conversation.Repository {
+	return &messageRepository{db: db}
+}
+
+func (r *messageRepository) SaveMessage(ctx context.Context, msg *conversation.Message) error {
+	if msg.ID == "" {
+		msg.ID = uuid.NewString()
+	}
+	return r.db.WithContext(ctx).Create(msg).Error
+}
+
+func (r *messageRepository) GetMessage(ctx context.Context, threadID, messageID string) (*conversation.Message, error) {
+	var msg conversation.Message
+	err := r.db.WithContext(ctx).First(&msg, "id = ? AND thread_id = ?", messageID, threadID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, conversation.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+func (r *messageRepository) ListThreads(ctx context.Context, scope conversation.Scope) ([]conversation.Thread, error) {
+	var rows []struct {
+		ID            string
+		MessageCount  int
+		LastMessageAt aggregateTime
+	}
+	err := r.db.WithContext(ctx).Model(&conversation.Message{}).
+		Select("thread_id AS id, COUNT(*) AS message_count, MAX(created_at) AS last_message_at").
+		Where("tenant_id = ? AND user_id = ?", scope.TenantID, scope.UserID).
+		Group("thread_id").
+		Order("last_message_at DESC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	threads := make([]conversation.Thread, 0, len(rows))
+	for _, row := range rows {
+		threads = append(threads, conversation.Thread{ID: row.ID, MessageCount: row.MessageCount, LastMessageAt: row.LastMessageAt.Time})
+	}
+	return threads, nil
+}
+
+func (r *messageRepository) ListMessages(ctx context.Context, scope conversation.Scope, threadID string) ([]conversation.Message, error) {
+	var messages []conversation.Message
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND user_id = ? AND thread_id = ?", scope.TenantID, scope.UserID, threadID).
+		Order("created_at ASC").
+		Find(&messages).Error
+	return messages, err
+}
+
+func (r *messageRepository) MarkComplete(ctx context.Context, messageID string) error {
+	return r.db.WithContext(ctx).Model(&conversation.Message{}).Where("id = ?", messageID).Update("incomplete", false).Error
+}
+
+// Deletes use "id IN (SELECT ... LIMIT n)" so each statement touches a bounded batch
+
+func (r *messageRepository) DeleteMessagesBefore(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	batch := r.db.Model(&conversation.Message{}).Select("id").Where("created_at < ?", cutoff).Limit(limit)
+	res := r.db.WithContext(ctx).Where("id IN (?)", batch).Delete(&conversation.Message{})
+	return res.RowsAffected, res.Error
+}
+
+func (r *messageRepository) ListAllThreads(ctx context.Context) ([]conversation.OwnedThread, error) {
+	var rows []struct {
+		TenantID      string
+		UserID        string
+		ThreadID      string
+		LastMessageAt aggregateTime
+	}
+	err := r.db.WithContext(ctx).Model(&conversation.Message{}).
+		Select("tenant_id, user_id, thread_id, MAX(created_at) AS last_message_at").
+		Group("tenant_id, user_id, thread_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	threads := make([]conversation.OwnedThread, 0, len(rows))
+	for _, row := range rows {
+		threads = append(threads, conversation.OwnedThread{
+			Scope:         conversation.Scope{TenantID: row.TenantID, UserID: row.UserID},
+			ThreadID:      row.ThreadID,
+			LastMessageAt: row.LastMessageAt.Time,
+		})
+	}
+	return threads, nil
+}
+
+func (r *messageRepository) DeleteThreadMessages(ctx context.Context, t conversation.OwnedThread, limit int) (int64, error) {
+	batch := r.db.Model(&conversation.Message{}).Select("id").
+		Where("tenant_id = ? AND user_id = ? AND thread_id = ?", t.TenantID, t.UserID, t.ThreadID).
+		Limit(limit)
+	res := r.db.WithContext(ctx).Where("id IN (?)", batch).Delete(&conversation.Message{})
+	return res.RowsAffected, res.Error
+}
+
+// aggregateTime scans MAX(created_at). Postgres returns a timestamp, but
+// SQLite loses the column type in aggregates and returns text.
+type aggregateTime struct {
+	time.Time
+}
+
+// sqliteTimeLayouts are the formats SQLite drivers store time.Time as
+var sqliteTimeLayouts = []string{
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	time.RFC3339Nano,
+}
+
+// Value lets GORM treat the field as a column rather than a relation
+func (t aggregateTime) Value() (driver.Value, error) {
+	return t.Time, nil
+}
+
+func (t *aggregateTime) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		t.Time = time.Time{}
+		return nil
+	case time.Time:
+		t.Time = v
+		return nil
+	case []byte:
+		return t.parse(string(v))
+	case string:
+		return t.parse(v)
+	}
+	return fmt.Errorf("cannot scan %T into a time", value)
+}
+
+func (t *aggregateTime) parse(s string) error {
+	for _, layout := range sqliteTimeLayouts {
+		if parsed, err := time.Parse(layout, s); err == nil {
+			t.Time = parsed
+			return nil
+		}
+	}
+	return fmt.Errorf("unrecognized time %q", s)
+}
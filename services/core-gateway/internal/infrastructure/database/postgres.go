@@ -12,12 +12,17 @@ import (
 // NewPostgresDB initializes a connection to PostgreSQL using GORM
 func NewPostgresDB(cfg config.Config) (*gorm.DB, error) {
 	// DSN Format: host=localhost user=gorm password=gorm dbname=gorm port=9920 sslmode=disable TimeZone=Asia/Shanghai
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=UTC",
+	sslMode := cfg.DB.SSLMode
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=UTC",
 		cfg.DB.Host,
 		cfg.DB.User,
 		cfg.DB.Password,
 		cfg.DB.Name,
 		cfg.DB.Port,
+		sslMode,
 	)
 
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
@@ -0,0 +1,37 @@
+package database
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// DefaultSQLitePath is used when db.path is unset
+const DefaultSQLitePath = "woorung.db"
+
+// NewSQLiteDB opens (creating if needed) a SQLite database file. It needs no
+// server, which makes it handy for local dev and tests.
+func NewSQLiteDB(path string) (*gorm.DB, error) {
+	if path == "" {
+		path = DefaultSQLitePath
+	}
+
+	// WAL lets readers proceed while a write is in progress; busy_timeout waits out short locks
+	dsn := path + "?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database: %w", err)
+	}
+
+	// SQLite allows one writer at a time
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	log.Printf("Opened SQLite database %s", path)
+	return db, nil
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/telegramlink"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type telegramLinkStore struct {
+	db *gorm.DB
+}
+
+// NewTelegramLinkStore returns a GORM-backed telegramlink.Store
+// (tables: telegram_links, telegram_link_codes)
+func NewTelegramLinkStore(db *gorm.DB) telegramlink.Store {
+	return &telegramLinkStore{db: db}
+}
+
+func (s *telegramLinkStore) SaveCode(ctx context.Context, code *telegramlink.Code) error {
+	return s.db.WithContext(ctx).Create(code).Error
+}
+
+// TakeCode deletes before returning, so concurrent redemptions of one code can't both win
+func (s *telegramLinkStore) TakeCode(ctx context.Context, code string) (*telegramlink.Code, error) {
+	var taken telegramlink.Code
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&taken, "code = ?", code).Error; err != nil {
+			return err
+		}
+		res := tx.Where("code = ?", code).Delete(&telegramlink.Code{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, telegramlink.ErrInvalidCode
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &taken, nil
+}
+
+func (s *telegramLinkStore) SaveLink(ctx context.Context, link *telegramlink.Link) error {
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "telegram_user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"user_id", "created_at"}),
+	}).Create(link).Error
+}
+
+func (s *telegramLinkStore) GetLink(ctx context.Context, telegramUserID int64) (*telegramlink.Link, error) {
+	var link telegramlink.Link
+	err := s.db.WithContext(ctx).First(&link, "telegram_user_id = ?", telegramUserID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, telegramlink.ErrNotLinked
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+func (s *telegramLinkStore) GetLinkByUser(ctx context.Context, userID string) (*telegramlink.Link, error) {
+	var link telegramlink.Link
+	err := s.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").First(&link).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, telegramlink.ErrNotLinked
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+func (s *telegramLinkStore) DeleteUser(ctx context.Context, userID string) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&telegramlink.Link{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&telegramlink.Code{}).Error
+	})
+}
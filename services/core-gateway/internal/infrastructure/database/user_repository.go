@@ -0,0 +1,76 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/user"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type userRepository struct {
+	db *gorm.DB
+}
+
+// NewUserRepository returns a GORM-backed user.Repository (table: users)
+func NewUserRepository(db *gorm.DB) user.Repository {
+	return &userRepository{db: db}
+}
+
+func (r *userRepository) FindByID(ctx context.Context, id string) (*user.User, error) {
+	var u user.User
+	if err := r.db.WithContext(ctx).First(&u, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, user.ErrNotFound
+		}
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (r *userRepository) FindByUsername(ctx context.Context, username string) (*user.User, error) {
+	var u user.User
+	if err := r.db.WithContext(ctx).First(&u, "username = ?", username).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, user.ErrNotFound
+		}
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (r *userRepository) Create(ctx context.Context, u *user.User) error {
+	if u.ID == "" {
+		u.ID = uuid.NewString()
+	}
+	return r.db.WithContext(ctx).Create(u).Error
+}
+
+func (r *userRepository) Update(ctx context.Context, u *user.User) error {
+	res := r.db.WithContext(ctx).Model(&user.User{}).Where("id = ?", u.ID).Updates(map[string]any{
+		"username":      u.Username,
+		"password_hash": u.PasswordHash,
+		"role":          u.Role,
+		"disabled":      u.Disabled,
+		"tenant_id":     u.TenantID,
+		"updated_at":    time.Now(),
+	})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return user.ErrNotFound
+	}
+	return nil
+}
+
+func (r *userRepository) Erase(ctx context.Context, id string) error {
+	tomb := user.Tombstone(id)
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"username", "password_hash", "disabled", "updated_at"}),
+	}).Create(&tomb).Error
+}
@@ -0,0 +1,85 @@
+package limits
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/httperr"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/validation"
+)
+
+// Handler serves the admin routes /api/v1/admin/limits[/:subject_type/:subject_id]
+type Handler struct {
+	limits *Service
+}
+
+func NewHandler(limits *Service) *Handler {
+	return &Handler{limits: limits}
+}
+
+// List returns every override
+func (h *Handler) List(c *gin.Context) {
+	overrides, err := h.limits.List(c.Request.Context())
+	if err != nil {
+		httperr.Respond(c, http.StatusInternalServerError, "Failed to list limit overrides")
+		return
+	}
+	out := make([]gin.H, 0, len(overrides))
+	for i := range overrides {
+		out = append(out, overrideJSON(&overrides[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"overrides": out})
+}
+
+// Set stores the override of a user or role. Omitted values keep the configured limit.
+func (h *Handler) Set(c *gin.Context) {
+	var req Values
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	subjectType, subjectID := c.Param("subject_type"), c.Param("subject_id")
+	o, err := h.limits.Set(c.Request.Context(), subjectType, subjectID, req)
+	switch {
+	case errors.Is(err, ErrInvalidSubject), errors.Is(err, ErrNegative):
+		httperr.Respond(c, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		log.Printf("[Limits] Failed to save override for %s %s: %v", subjectType, subjectID, err)
+		httperr.Respond(c, http.StatusInternalServerError, "Failed to save limit override")
+		return
+	}
+	c.JSON(http.StatusOK, overrideJSON(o))
+}
+
+// Clear returns a user or role to the configured limits
+func (h *Handler) Clear(c *gin.Context) {
+	err := h.limits.Clear(c.Request.Context(), c.Param("subject_type"), c.Param("subject_id"))
+	switch {
+	case errors.Is(err, ErrInvalidSubject):
+		httperr.Respond(c, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		httperr.Respond(c, http.StatusInternalServerError, "Failed to clear limit override")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func overrideJSON(o *Override) gin.H {
+	out := gin.H{
+		"subject_type": o.SubjectType,
+		"subject_id":   o.SubjectID,
+		"updated_at":   o.UpdatedAt.Format(time.RFC3339),
+	}
+	if o.RequestsPerMinute != nil {
+		out["requests_per_minute"] = *o.RequestsPerMinute
+	}
+	if o.DailyChars != nil {
+		out["daily_chars"] = *o.DailyChars
+	}
+	return out
+}
@@ -0,0 +1,67 @@
+package limits_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/config"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminLimits_OverrideRaisesUserAboveDefault(t *testing.T) {
+	// Arrange: by default one ask per minute and 20 characters a day
+	gw := testutil.NewTestGateway(t, testutil.Options{Configure: func(cfg *config.Config) {
+		cfg.Usage.DailyChars = 20
+		cfg.Server.Limits.Routes = map[string]config.RouteLimit{"/api/v1/ask": {RequestsPerMinute: 1}}
+	}})
+	admin := gw.Token(t, "root", "admin")
+	alice, bob := gw.Token(t, "alice", "user"), gw.Token(t, "bob", "user")
+	ask := func(token string) int {
+		return gw.Do(t, http.MethodPost, "/api/v1/ask", token, gin.H{"message": strings.Repeat("x", 5)}, nil).StatusCode
+	}
+
+	// Act: raise alice's limits only
+	var saved map[string]any
+	resp := gw.Do(t, http.MethodPut, "/api/v1/admin/limits/user/alice", admin, gin.H{"requests_per_minute": 100, "daily_chars": 1000}, &saved)
+	var aliceCodes, bobCodes []int
+	for range 3 {
+		aliceCodes = append(aliceCodes, ask(alice))
+		bobCodes = append(bobCodes, ask(bob))
+	}
+
+	// Assert
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, float64(100), saved["requests_per_minute"])
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusOK}, aliceCodes)
+	assert.Equal(t, http.StatusOK, bobCodes[0])
+	assert.Equal(t, http.StatusTooManyRequests, bobCodes[1])
+}
+
+func TestAdminLimits_ListAndClear(t *testing.T) {
+	// Arrange
+	gw := testutil.NewTestGateway(t, testutil.Options{})
+	admin := gw.Token(t, "root", "admin")
+	gw.Do(t, http.MethodPut, "/api/v1/admin/limits/role/pro", admin, gin.H{"daily_chars": 0}, nil)
+
+	// Act
+	var listed struct {
+		Overrides []map[string]any `json:"overrides"`
+	}
+	gw.Do(t, http.MethodGet, "/api/v1/admin/limits", admin, nil, &listed)
+	cleared := gw.Do(t, http.MethodDelete, "/api/v1/admin/limits/role/pro", admin, nil, nil)
+	badType := gw.Do(t, http.MethodPut, "/api/v1/admin/limits/team/core", admin, gin.H{}, nil)
+	notAdmin := gw.Do(t, http.MethodGet, "/api/v1/admin/limits", gw.Token(t, "alice", "user"), nil, nil)
+
+	// Assert
+	require.Len(t, listed.Overrides, 1)
+	assert.Equal(t, "role", listed.Overrides[0]["subject_type"])
+	assert.Equal(t, float64(0), listed.Overrides[0]["daily_chars"])
+	assert.NotContains(t, listed.Overrides[0], "requests_per_minute")
+	assert.Equal(t, http.StatusNoContent, cleared.StatusCode)
+	assert.Equal(t, http.StatusBadRequest, badType.StatusCode)
+	assert.Equal(t, http.StatusForbidden, notAdmin.StatusCode)
+}
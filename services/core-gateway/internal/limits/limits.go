@@ -0,0 +1,253 @@
+// Package limits holds per-user and per-role exceptions to the configured
+// rate limits and character budgets. Config stays the default; a row in the
+// limits table overrides it for one user or every user with a role.
+package limits
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/usage"
+)
+
+// Subject types an override applies to
+const (
+	SubjectUser = "user"
+	SubjectRole = "role"
+)
+
+// DefaultCacheTTL is how long a lookup is reused; other replicas see changes after this
+const DefaultCacheTTL = 30 * time.Second
+
+var (
+	// ErrNotSet is returned when a subject has no override
+	ErrNotSet = errors.New("no limit override set")
+	// ErrInvalidSubject is returned for subject types other than user and role
+	ErrInvalidSubject = errors.New("subject type must be user or role")
+	// ErrNegative is returned for negative limits (0 = unlimited)
+	ErrNegative = errors.New("limits must not be negative")
+)
+
+// Values are the overridable limits. Nil keeps the configured value; 0 means unlimited.
+type Values struct {
+	// RequestsPerMinute replaces every route's rate limit, counted per user instead of per IP
+	RequestsPerMinute *int `json:"requests_per_minute,omitempty" binding:"omitempty,gte=0"`
+	// DailyChars replaces the daily character budget
+	DailyChars *int `json:"daily_chars,omitempty" binding:"omitempty,gte=0"`
+}
+
+// Override is one subject's limits (table: limits)
+type Override struct {
+	SubjectType string `gorm:"primaryKey"`
+	SubjectID   string `gorm:"primaryKey"`
+	Values      `gorm:"embedded"`
+	UpdatedAt   time.Time `gorm:"not null"`
+}
+
+func (Override) TableName() string { return "limits" }
+
+// Store persists overrides
+type Store interface {
+	// Get returns ErrNotSet when the subject has no override
+	Get(ctx context.Context, subjectType, subjectID string) (*Override, error)
+	List(ctx context.Context) ([]Override, error)
+	// Save creates or replaces the override of o's subject
+	Save(ctx context.Context, o *Override) error
+	// Delete is a no-op when the subject has no override
+	Delete(ctx context.Context, subjectType, subjectID string) error
+}
+
+// Options configures a Service
+type Options struct {
+	// TTL caches lookups (default DefaultCacheTTL, negative disables)
+	TTL time.Duration
+	Now func() time.Time
+}
+
+type cached struct {
+	override  *Override // nil when the subject has none
+	expiresAt time.Time
+}
+
+// Service resolves overrides, caching lookups for TTL. A nil *Service has no
+// overrides, so callers need no storage check.
+type Service struct {
+	store Store
+	opts  Options
+
+	mu    sync.Mutex
+	cache map[[2]string]cached
+}
+
+func NewService(store Store, opts Options) *Service {
+	if opts.TTL == 0 {
+		opts.TTL = DefaultCacheTTL
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &Service{store: store, opts: opts, cache: map[[2]string]cached{}}
+}
+
+// Resolve returns the effective overrides for userID with role: the user's
+// row wins per value, then the role's
+func (s *Service) Resolve(ctx context.Context, userID, role string) Values {
+	if s == nil {
+		return Values{}
+	}
+	var v Values
+	for _, o := range []*Override{s.lookup(ctx, SubjectUser, userID), s.lookup(ctx, SubjectRole, role)} {
+		if o == nil {
+			continue
+		}
+		if v.RequestsPerMinute == nil {
+			v.RequestsPerMinute = o.RequestsPerMinute
+		}
+		if v.DailyChars == nil {
+			v.DailyChars = o.DailyChars
+		}
+	}
+	return v
+}
+
+// Budget returns base with the daily character budget overridden for userID
+func (s *Service) Budget(ctx context.Context, userID, role string, base usage.Budget) usage.Budget {
+	if daily := s.Resolve(ctx, userID, role).DailyChars; daily != nil {
+		base.DailyChars = *daily
+	}
+	return base
+}
+
+// RequestsPerMinute returns userID's rate limit override, if any
+func (s *Service) RequestsPerMinute(ctx context.Context, userID, role string) (int, bool) {
+	if rpm := s.Resolve(ctx, userID, role).RequestsPerMinute; rpm != nil {
+		return *rpm, true
+	}
+	return 0, false
+}
+
+// Set stores the override of a subject
+func (s *Service) Set(ctx context.Context, subjectType, subjectID string, v Values) (*Override, error) {
+	if subjectType != SubjectUser && subjectType != SubjectRole {
+		return nil, ErrInvalidSubject
+	}
+	for _, n := range []*int{v.RequestsPerMinute, v.DailyChars} {
+		if n != nil && *n < 0 {
+			return nil, ErrNegative
+		}
+	}
+	o := &Override{SubjectType: subjectType, SubjectID: subjectID, Values: v, UpdatedAt: s.opts.Now().UTC()}
+	if err := s.store.Save(ctx, o); err != nil {
+		return nil, err
+	}
+	s.forget(subjectType, subjectID)
+	return o, nil
+}
+
+// Clear removes the override of a subject, returning it to the configured limits
+func (s *Service) Clear(ctx context.Context, subjectType, subjectID string) error {
+	if subjectType != SubjectUser && subjectType != SubjectRole {
+		return ErrInvalidSubject
+	}
+	if err := s.store.Delete(ctx, subjectType, subjectID); err != nil {
+		return err
+	}
+	s.forget(subjectType, subjectID)
+	return nil
+}
+
+// List returns every override, users before roles
+func (s *Service) List(ctx context.Context) ([]Override, error) {
+	return s.store.List(ctx)
+}
+
+// lookup returns the subject's override or nil. Store failures are logged and
+// treated as no override, so limits fall back to config.
+func (s *Service) lookup(ctx context.Context, subjectType, subjectID string) *Override {
+	if subjectID == "" {
+		return nil
+	}
+	key := [2]string{subjectType, subjectID}
+	now := s.opts.Now()
+	s.mu.Lock()
+	entry, ok := s.cache[key]
+	s.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.override
+	}
+
+	o, err := s.store.Get(ctx, subjectType, subjectID)
+	if err != nil {
+		if !errors.Is(err, ErrNotSet) {
+			log.Printf("[Limits] Lookup for %s %s failed, using configured limits: %v", subjectType, subjectID, err)
+			return nil
+		}
+		o = nil
+	}
+	if s.opts.TTL > 0 {
+		s.mu.Lock()
+		s.cache[key] = cached{override: o, expiresAt: now.Add(s.opts.TTL)}
+		s.mu.Unlock()
+	}
+	return o
+}
+
+func (s *Service) forget(subjectType, subjectID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.cache, [2]string{subjectType, subjectID})
+}
+
+type memoryStore struct {
+	mu        sync.Mutex
+	overrides map[[2]string]Override
+}
+
+// NewMemoryStore returns a process-local Store (local dev and tests)
+func NewMemoryStore() Store {
+	return &memoryStore{overrides: map[[2]string]Override{}}
+}
+
+func (s *memoryStore) Get(ctx context.Context, subjectType, subjectID string) (*Override, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.overrides[[2]string{subjectType, subjectID}]
+	if !ok {
+		return nil, ErrNotSet
+	}
+	return &o, nil
+}
+
+func (s *memoryStore) List(ctx context.Context) ([]Override, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Override, 0, len(s.overrides))
+	for _, o := range s.overrides {
+		out = append(out, o)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].SubjectType != out[j].SubjectType {
+			return out[i].SubjectType > out[j].SubjectType
+		}
+		return out[i].SubjectID < out[j].SubjectID
+	})
+	return out, nil
+}
+
+func (s *memoryStore) Save(ctx context.Context, o *Override) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.overrides[[2]string{o.SubjectType, o.SubjectID}] = *o
+	return nil
+}
+
+func (s *memoryStore) Delete(ctx context.Context, subjectType, subjectID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.overrides, [2]string{subjectType, subjectID})
+	return nil
+}
@@ -0,0 +1,71 @@
+package limits_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/limits"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/usage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func intPtr(n int) *int { return &n }
+
+func TestService_UserOverrideBeatsRole(t *testing.T) {
+	// Arrange: the role raises both limits, alice's row only the daily budget
+	ctx := context.Background()
+	svc := limits.NewService(limits.NewMemoryStore(), limits.Options{})
+	_, err := svc.Set(ctx, limits.SubjectRole, "pro", limits.Values{RequestsPerMinute: intPtr(120), DailyChars: intPtr(50_000)})
+	require.NoError(t, err)
+	_, err = svc.Set(ctx, limits.SubjectUser, "alice", limits.Values{DailyChars: intPtr(0)})
+	require.NoError(t, err)
+	base := usage.Budget{MaxRequestChars: 4000, DailyChars: 10_000}
+
+	// Act
+	alice := svc.Budget(ctx, "alice", "pro", base)
+	bob := svc.Budget(ctx, "bob", "pro", base)
+	plain := svc.Budget(ctx, "carol", "user", base)
+	aliceRPM, aliceOK := svc.RequestsPerMinute(ctx, "alice", "pro")
+	_, carolOK := svc.RequestsPerMinute(ctx, "carol", "user")
+
+	// Assert
+	assert.Equal(t, usage.Budget{MaxRequestChars: 4000, DailyChars: 0}, alice, "0 lifts the budget entirely")
+	assert.Equal(t, 50_000, bob.DailyChars)
+	assert.Equal(t, base, plain)
+	assert.True(t, aliceOK)
+	assert.Equal(t, 120, aliceRPM, "values alice's row leaves unset come from her role")
+	assert.False(t, carolOK)
+}
+
+func TestService_CachesLookupsForTTL(t *testing.T) {
+	// Arrange: another replica writes to the shared store
+	ctx := context.Background()
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	store := limits.NewMemoryStore()
+	svc := limits.NewService(store, limits.Options{TTL: time.Minute, Now: func() time.Time { return now }})
+	_, cached := svc.RequestsPerMinute(ctx, "alice", "user")
+	require.NoError(t, store.Save(ctx, &limits.Override{SubjectType: limits.SubjectUser, SubjectID: "alice", Values: limits.Values{RequestsPerMinute: intPtr(600)}}))
+
+	// Act
+	_, beforeTTL := svc.RequestsPerMinute(ctx, "alice", "user")
+	now = now.Add(2 * time.Minute)
+	rpm, afterTTL := svc.RequestsPerMinute(ctx, "alice", "user")
+
+	// Assert
+	assert.False(t, cached)
+	assert.False(t, beforeTTL, "the cached miss is reused")
+	assert.True(t, afterTTL)
+	assert.Equal(t, 600, rpm)
+}
+
+func TestService_RejectsInvalidOverrides(t *testing.T) {
+	svc := limits.NewService(limits.NewMemoryStore(), limits.Options{})
+
+	_, badType := svc.Set(context.Background(), "team", "core", limits.Values{})
+	_, negative := svc.Set(context.Background(), limits.SubjectUser, "alice", limits.Values{DailyChars: intPtr(-1)})
+
+	assert.ErrorIs(t, badType, limits.ErrInvalidSubject)
+	assert.ErrorIs(t, negative, limits.ErrNegative)
+}
@@ -0,0 +1,56 @@
+// Package listener opens the gateway's TCP or Unix domain socket listener
+// and serves it over plain HTTP or HTTPS.
+package listener
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"time"
+)
+
+// SocketMode restricts the socket to its owner and group
+const SocketMode fs.FileMode = 0o660
+
+// Listen listens on socketPath when set, otherwise on the TCP addr. A stale
+// socket file left by a crashed process is removed first; one still being
+// served by another process is an error. The socket file is removed again
+// when the listener is closed.
+func Listen(socketPath, addr string) (net.Listener, error) {
+	if socketPath == "" {
+		return net.Listen("tcp", addr)
+	}
+	if err := removeStale(socketPath); err != nil {
+		return nil, err
+	}
+
+	ln, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(socketPath, SocketMode); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("restricting socket %s: %w", socketPath, err)
+	}
+	return ln, nil
+}
+
+func removeStale(socketPath string) error {
+	info, err := os.Lstat(socketPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode()&fs.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket", socketPath)
+	}
+	if conn, err := net.DialTimeout("unix", socketPath, time.Second); err == nil {
+		conn.Close()
+		return fmt.Errorf("socket %s is in use by another process", socketPath)
+	}
+	return os.Remove(socketPath)
+}
@@ -0,0 +1,77 @@
+package listener_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/client"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/listener"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// socketPath keeps the path short: Unix socket paths are limited to ~100 bytes
+func socketPath(t *testing.T) string {
+	dir, err := os.MkdirTemp("", "wg")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return filepath.Join(dir, "gw.sock")
+}
+
+func TestListen_UnixSocketServesRequests(t *testing.T) {
+	// Arrange
+	path := socketPath(t)
+	ln, err := listener.Listen(path, ":0")
+	require.NoError(t, err)
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"reply":"pong","thread_id":"t1"}`))
+	})}
+	go srv.Serve(ln)
+
+	// Act
+	resp, err := client.New(client.UnixScheme+path, client.StaticTokenSource("token"), nil).
+		Ask(context.Background(), client.AskRequest{Message: "ping"})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "pong", resp.Reply)
+
+	require.NoError(t, srv.Shutdown(context.Background()))
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err), "socket file removed on shutdown")
+}
+
+func TestListen_RemovesStaleSocket(t *testing.T) {
+	// Arrange: a socket file nobody serves, as left by a crash
+	path := socketPath(t)
+	stale, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	// Act
+	ln, err := listener.Listen(path, "")
+
+	// Assert
+	require.NoError(t, err)
+	ln.Close()
+}
+
+func TestListen_RefusesSocketInUse(t *testing.T) {
+	// Arrange
+	path := socketPath(t)
+	live, err := listener.Listen(path, "")
+	require.NoError(t, err)
+	defer live.Close()
+
+	// Act
+	_, err = listener.Listen(path, "")
+
+	// Assert
+	assert.ErrorContains(t, err, "in use")
+}
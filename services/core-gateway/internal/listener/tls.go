@@ -0,0 +1,61 @@
+package listener
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+)
+
+// TLSOptions enables HTTPS when both CertFile and KeyFile are set
+type TLSOptions struct {
+	CertFile string
+	KeyFile  string
+}
+
+// Enabled reports whether the gateway should terminate TLS itself
+func (o TLSOptions) Enabled() bool {
+	return o.CertFile != "" && o.KeyFile != ""
+}
+
+// TLSConfig is the gateway's TLS baseline: TLS 1.2+ with forward-secret AEAD
+// suites only (TLS 1.3 suites are not configurable and already strict)
+func TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+		},
+	}
+}
+
+// Serve serves srv on ln, over HTTPS when opts is enabled and plain HTTP otherwise.
+// srv.TLSConfig defaults to TLSConfig().
+func Serve(srv *http.Server, ln net.Listener, opts TLSOptions) error {
+	if !opts.Enabled() {
+		return srv.Serve(ln)
+	}
+	if srv.TLSConfig == nil {
+		srv.TLSConfig = TLSConfig()
+	}
+	return srv.ServeTLS(ln, opts.CertFile, opts.KeyFile)
+}
+
+// RedirectToHTTPS permanently redirects every request to the same host and
+// path over HTTPS; httpsPort is appended unless it is the default 443
+func RedirectToHTTPS(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if httpsPort != "" && httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
@@ -0,0 +1,117 @@
+package listener_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/listener"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// selfSignedCert writes a localhost certificate and key, returning their paths and the cert
+func selfSignedCert(t *testing.T) (string, string, *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile, cert
+}
+
+func TestServe_TLS(t *testing.T) {
+	// Arrange
+	certFile, keyFile, cert := selfSignedCert(t)
+	ln, err := listener.Listen("", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("secure"))
+	})}
+	go listener.Serve(srv, ln, listener.TLSOptions{CertFile: certFile, KeyFile: keyFile})
+	defer srv.Shutdown(context.Background())
+
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	httpsClient := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+
+	// Act
+	resp, err := httpsClient.Get("https://" + ln.Addr().String() + "/health")
+
+	// Assert
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.GreaterOrEqual(t, resp.TLS.Version, uint16(tls.VersionTLS12))
+}
+
+func TestServe_RejectsOldTLS(t *testing.T) {
+	// Arrange
+	certFile, keyFile, _ := selfSignedCert(t)
+	ln, err := listener.Listen("", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := &http.Server{Handler: http.NotFoundHandler()}
+	go listener.Serve(srv, ln, listener.TLSOptions{CertFile: certFile, KeyFile: keyFile})
+	defer srv.Shutdown(context.Background())
+
+	oldClient := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		InsecureSkipVerify: true,
+		MaxVersion:         tls.VersionTLS11,
+	}}}
+
+	// Act
+	_, err = oldClient.Get("https://" + ln.Addr().String() + "/")
+
+	// Assert
+	assert.Error(t, err)
+}
+
+func TestRedirectToHTTPS(t *testing.T) {
+	cases := map[string]string{
+		"443":  "https://api.woorung.dev/api/v1/me?x=1",
+		"8443": "https://api.woorung.dev:8443/api/v1/me?x=1",
+	}
+	for port, want := range cases {
+		// Arrange
+		req := httptest.NewRequest(http.MethodGet, "http://api.woorung.dev:8080/api/v1/me?x=1", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		listener.RedirectToHTTPS(port).ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusPermanentRedirect, w.Code)
+		assert.Equal(t, want, w.Header().Get("Location"))
+	}
+}
@@ -0,0 +1,232 @@
+// Package markdown converts the GitHub-flavored markdown the PM Agent writes
+// into Telegram's MarkdownV2.
+//
+// MarkdownV2 rejects a whole message when a reserved character is left
+// unescaped or an entity is left open, so ToTelegramV2 only maps the common
+// constructs (bold, italics, strikethrough, inline code, code blocks, headings
+// and bullets) and escapes everything else. Text it cannot convert with
+// confidence is escaped as plain text instead.
+package markdown
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// ErrUnbalanced is returned when an emphasis marker, inline code span or code
+// block is opened but never closed
+var ErrUnbalanced = errors.New("markdown: unbalanced markers")
+
+// reserved are the characters MarkdownV2 requires escaping outside entities
+const reserved = "_*[]()~`>#+-=|{}.!\\"
+
+var (
+	headingPattern = regexp.MustCompile(`^#{1,6}\s+(.+?)\s*#*\s*$`)
+	bulletPattern  = regexp.MustCompile(`^(\s*)[-*+]\s+`)
+)
+
+// ToTelegramV2 converts GitHub markdown to MarkdownV2. When the markdown has
+// unbalanced markers it returns the text escaped as plain text, so the result
+// is always safe to send with ParseMode MarkdownV2.
+func ToTelegramV2(githubMarkdown string) string {
+	converted, err := ConvertTelegramV2(githubMarkdown)
+	if err != nil {
+		return EscapeTelegramV2(githubMarkdown)
+	}
+	return converted
+}
+
+// ConvertTelegramV2 converts GitHub markdown to MarkdownV2, or returns
+// ErrUnbalanced when a marker is left open
+func ConvertTelegramV2(githubMarkdown string) (string, error) {
+	var out strings.Builder
+	var spans inline
+	inCode := false
+	for i, line := range strings.Split(githubMarkdown, "\n") {
+		if i > 0 {
+			out.WriteByte('\n')
+		}
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "```") && !inCode:
+			// Emphasis may not run into a code block
+			if spans.open() {
+				return "", ErrUnbalanced
+			}
+			inCode = true
+			out.WriteString("```" + escapeCode(strings.TrimSpace(strings.TrimPrefix(trimmed, "```"))))
+		case trimmed == "```" && inCode:
+			inCode = false
+			out.WriteString("```")
+		case inCode:
+			out.WriteString(escapeCode(line))
+		default:
+			if err := spans.line(&out, line); err != nil {
+				return "", err
+			}
+		}
+	}
+	if inCode || spans.open() {
+		return "", ErrUnbalanced
+	}
+	return out.String(), nil
+}
+
+// EscapeTelegramV2 escapes every reserved character, so text shows as written
+func EscapeTelegramV2(text string) string {
+	var out strings.Builder
+	for _, r := range text {
+		if strings.ContainsRune(reserved, r) {
+			out.WriteByte('\\')
+		}
+		out.WriteRune(r)
+	}
+	return out.String()
+}
+
+// escapeCode escapes the characters MarkdownV2 reserves inside code
+func escapeCode(text string) string {
+	return strings.NewReplacer("\\", "\\\\", "`", "\\`").Replace(text)
+}
+
+// inline tracks the emphasis left open across the lines of a paragraph
+type inline struct {
+	bold, italic, strike bool
+}
+
+func (s *inline) open() bool {
+	return s.bold || s.italic || s.strike
+}
+
+// line converts one line outside code blocks
+func (s *inline) line(out *strings.Builder, line string) error {
+	if m := headingPattern.FindStringSubmatch(line); m != nil {
+		// Headings become bold; markup inside them is shown as written
+		out.WriteString("*" + EscapeTelegramV2(m[1]) + "*")
+		return nil
+	}
+	if loc := bulletPattern.FindStringIndex(line); loc != nil {
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		out.WriteString(indent + "• ")
+		line = line[loc[1]:]
+	}
+
+	runes := []rune(line)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case r == '\\' && i+1 < len(runes) && strings.ContainsRune(reserved, runes[i+1]):
+			// A GitHub escape stays a literal character
+			out.WriteString("\\" + string(runes[i+1]))
+			i += 2
+		case r == '`':
+			n := run(runes, i)
+			end := indexRun(runes, i+n, '`', n)
+			if end < 0 {
+				return ErrUnbalanced
+			}
+			out.WriteString("`" + escapeCode(strings.TrimSpace(string(runes[i+n:end]))) + "`")
+			i = end + n
+		case r == '*' || r == '_':
+			n := run(runes, i)
+			s.emphasis(out, runes, i, n)
+			i += n
+		case r == '~' && run(runes, i) == 2:
+			s.toggle(out, &s.strike, "~", "\\~\\~", canOpen(runes, i, 2), canClose(runes, i))
+			i += 2
+		default:
+			if strings.ContainsRune(reserved, r) {
+				out.WriteByte('\\')
+			}
+			out.WriteRune(r)
+			i++
+		}
+	}
+	return nil
+}
+
+// emphasis converts a run of n '*' or '_' starting at i: one marks italics,
+// two bold and three both. Other runs are literal.
+func (s *inline) emphasis(out *strings.Builder, runes []rune, i, n int) {
+	literal := strings.Repeat("\\"+string(runes[i]), n)
+	opens, closes := canOpen(runes, i, n), canClose(runes, i)
+	// GitHub ignores underscores inside words, as in snake_case
+	if runes[i] == '_' {
+		opens = opens && (i == 0 || !isWord(runes[i-1]))
+		closes = closes && (i+n == len(runes) || !isWord(runes[i+n]))
+	}
+	switch n {
+	case 1:
+		s.toggle(out, &s.italic, "_", literal, opens, closes)
+	case 2:
+		s.toggle(out, &s.bold, "*", literal, opens, closes)
+	case 3:
+		if s.bold && s.italic && closes {
+			s.italic, s.bold = false, false
+			out.WriteString("_*")
+		} else if !s.bold && !s.italic && opens {
+			s.bold, s.italic = true, true
+			out.WriteString("*_")
+		} else {
+			out.WriteString(literal)
+		}
+	default:
+		out.WriteString(literal)
+	}
+}
+
+// toggle opens or closes the entity tracked by state, or writes the marker
+// literally when it can do neither
+func (s *inline) toggle(out *strings.Builder, state *bool, marker, literal string, opens, closes bool) {
+	switch {
+	case *state && closes:
+		*state = false
+		out.WriteString(marker)
+	case !*state && opens:
+		*state = true
+		out.WriteString(marker)
+	default:
+		out.WriteString(literal)
+	}
+}
+
+// canOpen reports whether the marker run at i is followed by text
+func canOpen(runes []rune, i, n int) bool {
+	return i+n < len(runes) && !unicode.IsSpace(runes[i+n])
+}
+
+// canClose reports whether the marker run at i follows text
+func canClose(runes []rune, i int) bool {
+	return i > 0 && !unicode.IsSpace(runes[i-1])
+}
+
+// run counts the repeats of runes[i] starting at i
+func run(runes []rune, i int) int {
+	n := 1
+	for i+n < len(runes) && runes[i+n] == runes[i] {
+		n++
+	}
+	return n
+}
+
+// indexRun finds the next run of exactly n r's at or after start, or -1
+func indexRun(runes []rune, start int, r rune, n int) int {
+	for i := start; i < len(runes); {
+		if runes[i] != r {
+			i++
+			continue
+		}
+		m := run(runes, i)
+		if m == n {
+			return i
+		}
+		i += m
+	}
+	return -1
+}
+
+func isWord(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,61 @@
+package markdown_test
+
+import (
+	"testing"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/markdown"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertTelegramV2(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"plain text is escaped", "Done (v1.2)! See #12 - a+b=c", `Done \(v1\.2\)\! See \#12 \- a\+b\=c`},
+		{"bold", "**Build** and __deploy__", "*Build* and *deploy*"},
+		{"italics", "*now* or _later_", "_now_ or _later_"},
+		{"bold italics", "***both***", "*_both_*"},
+		{"strikethrough", "~~gone~~", "~gone~"},
+		{"inline code keeps its content", "run `make test.unit` now", "run `make test.unit` now"},
+		{"backticks in inline code", "``a `b` c``", "`a \\`b\\` c`"},
+		{"code block", "```go\nfmt.Println(`hi`) // a.b\n```", "```go\nfmt.Println(\\`hi\\`) // a.b\n```"},
+		{"heading", "## Next steps", "*Next steps*"},
+		{"bullets", "- one\n* two\n  + nested", "• one\n• two\n  • nested"},
+		{"underscores inside words", "snake_case_name", `snake\_case\_name`},
+		{"lone asterisk", "2 * 3", `2 \* 3`},
+		{"github escapes", `\*not italic\*`, `\*not italic\*`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			converted, err := markdown.ConvertTelegramV2(tt.input)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, converted)
+		})
+	}
+}
+
+func TestConvertTelegramV2_Unbalanced(t *testing.T) {
+	for _, input := range []string{
+		"**open bold",
+		"an *unclosed italic",
+		"a `dangling code span",
+		"```go\nfmt.Println()",
+		"**bold runs\n```\ninto code\n```\n**",
+	} {
+		_, err := markdown.ConvertTelegramV2(input)
+		assert.ErrorIs(t, err, markdown.ErrUnbalanced, input)
+	}
+}
+
+func TestToTelegramV2_FallsBackToPlainText(t *testing.T) {
+	// Act
+	converted := markdown.ToTelegramV2("**half bold (1.0)")
+
+	// Assert: every marker is escaped, so Telegram shows the text as written
+	assert.Equal(t, `\*\*half bold \(1\.0\)`, converted)
+}
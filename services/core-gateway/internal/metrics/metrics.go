@@ -0,0 +1,80 @@
+// Package metrics holds the gateway's Prometheus collectors and the /metrics handler.
+package metrics
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry is the gateway's private registry (avoids leaking global default collectors)
+var Registry = prometheus.NewRegistry()
+
+var (
+	// AgentChars counts characters sent to / received from the agent.
+	// Labelled by role rather than user_id to keep cardinality bounded;
+	// per-user totals live in the usage tracker.
+	AgentChars = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "woorung_agent_chars_total",
+		Help: "Characters exchanged with the PM Agent.",
+	}, []string{"direction", "role"})
+
+	// AgentMessageChars is the size distribution of prompts and responses
+	AgentMessageChars = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "woorung_agent_message_chars",
+		Help:    "Size in characters of individual prompts and responses.",
+		Buckets: prometheus.ExponentialBuckets(16, 4, 8),
+	}, []string{"direction"})
+
+	// BudgetRejections counts requests rejected by character budgets
+	BudgetRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "woorung_usage_budget_rejections_total",
+		Help: "Requests rejected because a character budget was exceeded.",
+	}, []string{"reason"})
+
+	// AgentRequests counts asks by validated source (cli, telegram, web, api, batch)
+	AgentRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "woorung_agent_requests_total",
+		Help: "Ask requests by source.",
+	}, []string{"source"})
+
+	// AgentFailovers counts switches from the primary to the secondary PM Agent
+	AgentFailovers = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "woorung_agent_failovers_total",
+		Help: "Times the primary PM Agent failed and the secondary took over.",
+	})
+
+	// UserCacheLookups counts user lookups by the cache by result (hit, miss);
+	// the hit rate is hit / (hit + miss)
+	UserCacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "woorung_user_cache_lookups_total",
+		Help: "User lookups served from the cache (hit) or the database (miss).",
+	}, []string{"result"})
+
+	// HistoryPruned counts messages deleted by the retention pruner
+	HistoryPruned = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "woorung_history_pruned_messages_total",
+		Help: "Conversation messages deleted by the retention policy.",
+	}, []string{"reason"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		AgentChars,
+		AgentMessageChars,
+		BudgetRejections,
+		AgentRequests,
+		AgentFailovers,
+		HistoryPruned,
+		UserCacheLookups,
+		stores,
+	)
+}
+
+// Handler serves the Prometheus exposition format
+func Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.HandlerFor(Registry, promhttp.HandlerOpts{}))
+}
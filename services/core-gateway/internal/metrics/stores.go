@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// storeEntriesDesc is woorung_store_entries{store}: entries held by an in-memory
+// store (rate-limit buckets, idempotency keys, ...), read at scrape time
+var storeEntriesDesc = prometheus.NewDesc(
+	"woorung_store_entries",
+	"Entries currently held by an in-memory store; alert on unbounded growth.",
+	[]string{"store"}, nil,
+)
+
+// storeCollector reports registered store sizes. Each size func must be safe
+// for concurrent use (stores take their own lock).
+type storeCollector struct {
+	mu     sync.Mutex
+	stores map[string]func() int
+}
+
+var stores = &storeCollector{stores: map[string]func() int{}}
+
+// RegisterStore reports size() as woorung_store_entries{store=name}.
+// Registering a name again replaces the previous size func.
+func RegisterStore(name string, size func() int) {
+	stores.mu.Lock()
+	defer stores.mu.Unlock()
+	stores.stores[name] = size
+}
+
+func (c *storeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- storeEntriesDesc
+}
+
+func (c *storeCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	names := make([]string, 0, len(c.stores))
+	for name := range c.stores {
+		names = append(names, name)
+	}
+	sizes := make(map[string]func() int, len(c.stores))
+	for name, size := range c.stores {
+		sizes[name] = size
+	}
+	c.mu.Unlock()
+
+	// Sizes are read outside our lock so a slow store can't block registration
+	sort.Strings(names)
+	for _, name := range names {
+		ch <- prometheus.MustNewConstMetric(storeEntriesDesc, prometheus.GaugeValue, float64(sizes[name]()), name)
+	}
+}
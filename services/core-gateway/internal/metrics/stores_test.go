@@ -0,0 +1,74 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/metrics"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/middleware"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/usage"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterStore_GaugesReflectEntries(t *testing.T) {
+	// Arrange: three clients hit a rate-limited route, two users record usage
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.RateLimit(func() middleware.RouteLimits {
+		return middleware.RouteLimits{Default: middleware.Limit{RequestsPerMinute: 60}}
+	}))
+	r.GET("/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+	for _, ip := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.1"} {
+		req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+		req.RemoteAddr = ip + ":1234"
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	tracker := usage.NewTracker(usage.Budget{}, nil)
+	metrics.RegisterStore("usage_users", tracker.Len)
+	tracker.Record("alice", "user", "hi", "hello")
+	tracker.Record("bob", "user", "hi", "hello")
+
+	// Act
+	expected := `
+# HELP woorung_store_entries Entries currently held by an in-memory store; alert on unbounded growth.
+# TYPE woorung_store_entries gauge
+woorung_store_entries{store="rate_limit_buckets"} 3
+woorung_store_entries{store="usage_users"} 2
+`
+	err := testutil.GatherAndCompare(metrics.Registry, strings.NewReader(expected), "woorung_store_entries")
+
+	// Assert
+	require.NoError(t, err)
+}
+
+func TestRegisterStore_ConcurrentScrapes(t *testing.T) {
+	// Arrange
+	tracker := usage.NewTracker(usage.Budget{}, nil)
+	metrics.RegisterStore("usage_users", tracker.Len)
+	var wg sync.WaitGroup
+
+	// Act: record and scrape at the same time (run with -race)
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			tracker.Record(string(rune('a'+i)), "user", "x", "y")
+		}(i)
+		go func() {
+			defer wg.Done()
+			_, err := metrics.Registry.Gather()
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	// Assert
+	assert.Equal(t, 20, tracker.Len())
+}
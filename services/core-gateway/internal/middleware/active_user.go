@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/ctxutil"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/httperr"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/user"
+)
+
+// RejectDisabledUsers refuses tokens of disabled (or erased) accounts with 401,
+// so tokens stop working before they expire. Users only known from their
+// token pass, and a failed lookup is logged and let through rather than
+// taking the API down with the user store. Must run after AuthMiddleware.
+func RejectDisabledUsers(users user.Repository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := ctxutil.UserID(c)
+		if !ok || ctxutil.IsAnonymous(c) {
+			c.Next()
+			return
+		}
+
+		u, err := users.FindByID(c.Request.Context(), userID)
+		switch {
+		case err == nil && u.Disabled:
+			httperr.Abort(c, http.StatusUnauthorized, "Account is disabled")
+			return
+		case err != nil && !errors.Is(err, user.ErrNotFound):
+			log.Printf("[Auth] Failed to check account %s: %v", userID, err)
+		}
+		c.Next()
+	}
+}
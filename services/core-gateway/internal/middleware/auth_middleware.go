@@ -1,39 +1,117 @@
 package middleware
 
 import (
+	"log"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/auth"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/ctxutil"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/httperr"
 )
 
 func AuthMiddleware(jwtService auth.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is missing"})
+		if c.GetHeader("Authorization") == "" {
+			httperr.Abort(c, http.StatusUnauthorized, "Authorization header is missing")
 			return
 		}
+		if authenticate(c, jwtService) {
+			c.Next()
+		}
+	}
+}
 
-		// Expect format: "Bearer <token>"
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token format"})
+// OptionalAuth is AuthMiddleware for routes that also serve anonymous callers:
+// without an Authorization header the request continues as the anonymous user
+// (role "anonymous"). A token that is present but invalid is still rejected.
+func OptionalAuth(jwtService auth.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			ctxutil.SetUserID(c, ctxutil.AnonymousUserID)
+			ctxutil.SetRole(c, ctxutil.RoleAnonymous)
+			c.Next()
 			return
 		}
+		if authenticate(c, jwtService) {
+			c.Next()
+		}
+	}
+}
 
-		tokenString := parts[1]
-		claims, err := jwtService.ValidateToken(tokenString)
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+// RouteAuth applies OptionalAuth to the route paths returned by optionalRoutes
+// (e.g. "/api/v1/ask") and AuthMiddleware to every other route. The list is read
+// per request so config reloads take effect immediately.
+func RouteAuth(jwtService auth.Service, optionalRoutes func() []string) gin.HandlerFunc {
+	required := AuthMiddleware(jwtService)
+	optional := OptionalAuth(jwtService)
+	return func(c *gin.Context) {
+		if slices.Contains(optionalRoutes(), c.FullPath()) {
+			optional(c)
 			return
 		}
+		required(c)
+	}
+}
+
+// BearerClaims returns the claims of a valid bearer token without rejecting the
+// request, for middleware that runs before authentication (e.g. rate limits)
+func BearerClaims(c *gin.Context, jwtService auth.Service) (*auth.Claims, bool) {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return nil, false
+	}
+	claims, err := jwtService.ValidateToken(token)
+	if err != nil {
+		return nil, false
+	}
+	return claims, true
+}
+
+// authenticate validates the bearer token and fills the request context.
+// It aborts with 401 and returns false when the token is unusable.
+func authenticate(c *gin.Context, jwtService auth.Service) bool {
+	// Expect format: "Bearer <token>"
+	parts := strings.Split(c.GetHeader("Authorization"), " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		httperr.Abort(c, http.StatusUnauthorized, "Invalid token format")
+		return false
+	}
+
+	tokenString := parts[1]
+	claims, err := jwtService.ValidateToken(tokenString)
+	if err != nil {
+		httperr.Abort(c, http.StatusUnauthorized, "Invalid or expired token")
+		return false
+	}
+
+	// Service tokens carry an identity instead of a user; they never get a user ID
+	if service := claims.ServiceName(); service != "" {
+		ctxutil.SetService(c, service)
+		if claims.TenantID != "" {
+			ctxutil.SetTenantID(c, claims.TenantID)
+		}
+		return true
+	}
+	if claims.UserID == "" {
+		httperr.Abort(c, http.StatusUnauthorized, "Token has no subject")
+		return false
+	}
+
+	// Inject User ID into Context
+	ctxutil.SetUserID(c, claims.UserID)
+	ctxutil.SetRole(c, claims.Role)
+	if claims.TenantID != "" {
+		ctxutil.SetTenantID(c, claims.TenantID)
+	}
 
-		// Inject User ID into Context
-		c.Set("userID", claims.UserID)
-		c.Set("role", claims.Role)
-		
-		c.Next()
+	// Impersonated sessions are flagged so handlers and logs make them obvious
+	if claims.ImpersonatedBy != "" {
+		ctxutil.SetImpersonatedBy(c, claims.ImpersonatedBy)
+		log.Printf("[Auth] Impersonated request: user=%s impersonated_by=%s %s %s",
+			claims.UserID, claims.ImpersonatedBy, c.Request.Method, c.Request.URL.Path)
 	}
+	return true
 }
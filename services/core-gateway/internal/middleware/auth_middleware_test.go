@@ -7,7 +7,9 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/auth"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/ctxutil"
 	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/middleware"
 	"github.com/stretchr/testify/assert"
 )
@@ -23,8 +25,8 @@ func TestAuthMiddleware(t *testing.T) {
 	
 	// Protected Endpoints
 	r.GET("/protected", func(c *gin.Context) {
-		userID, _ := c.Get("userID")
-		role, _ := c.Get("role")
+		userID, _ := ctxutil.UserID(c)
+		role, _ := ctxutil.Role(c)
 		c.JSON(200, gin.H{"userID": userID, "role": role})
 	})
 
@@ -44,3 +46,99 @@ func TestAuthMiddleware(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w2.Code, "Should allow request with valid token")
 	assert.JSONEq(t, `{"userID":"user_123", "role":"admin"}`, w2.Body.String())
 }
+
+func TestAuthMiddleware_ServiceTokens(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	jwtService := auth.NewJWTService("secret", time.Hour)
+	serviceToken, _ := jwtService.GenerateServiceToken("scheduler", time.Hour)
+	subOnlyToken, _ := jwtService.IssueToken(auth.Claims{RegisteredClaims: jwt.RegisteredClaims{Subject: "indexer"}}, time.Hour)
+	userToken, _ := jwtService.GenerateToken("user_123", "user")
+	anonymousToken, _ := jwtService.IssueToken(auth.Claims{}, time.Hour)
+
+	r := gin.New()
+	r.Use(middleware.AuthMiddleware(jwtService))
+	r.GET("/whoami", func(c *gin.Context) {
+		userID, _ := ctxutil.UserID(c)
+		service, _ := ctxutil.Service(c)
+		c.JSON(200, gin.H{"user_id": userID, "service": service, "is_service": ctxutil.IsService(c)})
+	})
+	r.GET("/conversations", middleware.RequireUser(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	do := func(path, token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// Act & Assert: identities
+	assert.JSONEq(t, `{"user_id":"", "service":"scheduler", "is_service":true}`, do("/whoami", serviceToken).Body.String())
+	assert.JSONEq(t, `{"user_id":"", "service":"indexer", "is_service":true}`, do("/whoami", subOnlyToken).Body.String())
+	assert.JSONEq(t, `{"user_id":"user_123", "service":"", "is_service":false}`, do("/whoami", userToken).Body.String())
+	assert.Equal(t, http.StatusUnauthorized, do("/whoami", anonymousToken).Code, "Tokens need a user or service identity")
+
+	// Act & Assert: user-scoped data
+	assert.Equal(t, http.StatusForbidden, do("/conversations", serviceToken).Code)
+	assert.Equal(t, http.StatusOK, do("/conversations", userToken).Code)
+}
+
+func TestRouteAuth_OptionalRoutes(t *testing.T) {
+	// Arrange: /ask is optional-auth, /me is not
+	gin.SetMode(gin.TestMode)
+	jwtService := auth.NewJWTService("secret", time.Hour)
+	userToken, _ := jwtService.GenerateToken("user_123", "user")
+
+	r := gin.New()
+	api := r.Group("/api/v1", middleware.RouteAuth(jwtService, func() []string { return []string{"/api/v1/ask"} }))
+	whoami := func(c *gin.Context) {
+		userID, _ := ctxutil.UserID(c)
+		role, _ := ctxutil.Role(c)
+		c.JSON(200, gin.H{"user_id": userID, "role": role})
+	}
+	api.POST("/ask", whoami)
+	api.GET("/me", whoami)
+
+	do := func(method, path, token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// Act & Assert: anonymous on an optional route
+	anonymous := do("POST", "/api/v1/ask", "")
+	assert.Equal(t, http.StatusOK, anonymous.Code)
+	assert.JSONEq(t, `{"user_id":"anonymous","role":"anonymous"}`, anonymous.Body.String())
+
+	// Authenticated on an optional route keeps the real identity
+	authenticated := do("POST", "/api/v1/ask", userToken)
+	assert.JSONEq(t, `{"user_id":"user_123","role":"user"}`, authenticated.Body.String())
+
+	// A bad token is still rejected, not downgraded to anonymous
+	assert.Equal(t, http.StatusUnauthorized, do("POST", "/api/v1/ask", "garbage").Code)
+
+	// Other routes still require a token
+	assert.Equal(t, http.StatusUnauthorized, do("GET", "/api/v1/me", "").Code)
+	assert.Equal(t, http.StatusOK, do("GET", "/api/v1/me", userToken).Code)
+}
+
+func TestRequireUser_RejectsAnonymous(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/conversations", middleware.OptionalAuth(auth.NewJWTService("secret", time.Hour)), middleware.RequireUser(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	req, _ := http.NewRequest("GET", "/conversations", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
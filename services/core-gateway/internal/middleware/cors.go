@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OriginAllowed reports whether origin matches the allow-list. "*" allows any origin.
+func OriginAllowed(origin string, allowed []string) bool {
+	for _, o := range allowed {
+		if o == "*" || strings.EqualFold(o, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultCORSExposeHeaders are the custom response headers browsers may read
+var DefaultCORSExposeHeaders = []string{
+	"X-Request-ID",
+	"Retry-After",
+	"X-RateLimit-Limit",
+	"X-RateLimit-Remaining",
+	"X-RateLimit-Reset",
+	"Idempotent-Replayed",
+	"X-Woorung-API-Version",
+	"ETag",
+}
+
+// DefaultCORSMaxAge is how long browsers may cache a preflight response
+const DefaultCORSMaxAge = 10 * time.Minute
+
+// CORSConfig configures the CORS middleware
+type CORSConfig struct {
+	// AllowedOrigins ("*" allows any origin, but without credentials)
+	AllowedOrigins []string
+	// ExposeHeaders defaults to DefaultCORSExposeHeaders
+	ExposeHeaders []string
+	// MaxAge defaults to DefaultCORSMaxAge; negative disables preflight caching
+	MaxAge time.Duration
+}
+
+// CORS sets Access-Control headers for allow-listed origins and answers preflight requests.
+// Origins listed by name are echoed back and may send credentials; one allowed
+// only through "*" gets a literal "*" without credentials, as the CORS spec
+// requires. cfg is read per request so config reloads take effect immediately.
+func CORS(cfg func() CORSConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		conf := cfg()
+		origin := c.GetHeader("Origin")
+		if origin == "" || !OriginAllowed(origin, conf.AllowedOrigins) {
+			c.Next()
+			return
+		}
+
+		h := c.Writer.Header()
+		if OriginAllowed(origin, namedOrigins(conf.AllowedOrigins)) {
+			h.Set("Access-Control-Allow-Origin", origin)
+			h.Add("Vary", "Origin")
+			h.Set("Access-Control-Allow-Credentials", "true")
+		} else {
+			h.Set("Access-Control-Allow-Origin", "*")
+		}
+
+		expose := conf.ExposeHeaders
+		if len(expose) == 0 {
+			expose = DefaultCORSExposeHeaders
+		}
+		h.Set("Access-Control-Expose-Headers", strings.Join(expose, ", "))
+
+		if c.Request.Method == http.MethodOptions {
+			h.Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+			h.Set("Access-Control-Allow-Headers", "Authorization, Content-Type, X-Request-ID, Idempotency-Key, Accept-Version, If-None-Match")
+			maxAge := conf.MaxAge
+			if maxAge == 0 {
+				maxAge = DefaultCORSMaxAge
+			}
+			if maxAge > 0 {
+				h.Set("Access-Control-Max-Age", strconv.Itoa(int(maxAge.Seconds())))
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// namedOrigins drops the "*" wildcard from an allow-list
+func namedOrigins(allowed []string) []string {
+	named := make([]string, 0, len(allowed))
+	for _, o := range allowed {
+		if o != "*" {
+			named = append(named, o)
+		}
+	}
+	return named
+}
@@ -0,0 +1,105 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func corsRouter(cfg middleware.CORSConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.CORS(func() middleware.CORSConfig { return cfg }))
+	r.GET("/api/v1/me", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r
+}
+
+func preflight(r *gin.Engine, origin string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(http.MethodOptions, "/api/v1/me", nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", "GET")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORS_PreflightDefaults(t *testing.T) {
+	// Arrange
+	r := corsRouter(middleware.CORSConfig{AllowedOrigins: []string{"https://app.woorung.dev"}})
+
+	// Act
+	w := preflight(r, "https://app.woorung.dev")
+
+	// Assert
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://app.woorung.dev", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+	assert.Equal(t, "X-Request-ID, Retry-After, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset, Idempotent-Replayed, X-Woorung-API-Version, ETag",
+		w.Header().Get("Access-Control-Expose-Headers"))
+}
+
+func TestCORS_PreflightConfigured(t *testing.T) {
+	r := corsRouter(middleware.CORSConfig{
+		AllowedOrigins: []string{"*"},
+		ExposeHeaders:  []string{"X-Request-ID"},
+		MaxAge:         time.Hour,
+	})
+
+	w := preflight(r, "https://any.example")
+
+	assert.Equal(t, "3600", w.Header().Get("Access-Control-Max-Age"))
+	assert.Equal(t, "X-Request-ID", w.Header().Get("Access-Control-Expose-Headers"))
+}
+
+func TestCORS_WildcardOmitsCredentials(t *testing.T) {
+	tests := []struct {
+		name        string
+		origin      string
+		allowOrigin string
+		credentials string
+	}{
+		{"any origin", "https://any.example", "*", ""},
+		{"named origin keeps credentials", "https://app.woorung.dev", "https://app.woorung.dev", "true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			r := corsRouter(middleware.CORSConfig{AllowedOrigins: []string{"*", "https://app.woorung.dev"}})
+
+			// Act
+			w := preflight(r, tt.origin)
+
+			// Assert
+			assert.Equal(t, tt.allowOrigin, w.Header().Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, tt.credentials, w.Header().Get("Access-Control-Allow-Credentials"))
+		})
+	}
+}
+
+func TestCORS_MaxAgeDisabled(t *testing.T) {
+	r := corsRouter(middleware.CORSConfig{AllowedOrigins: []string{"*"}, MaxAge: -1})
+
+	w := preflight(r, "https://any.example")
+
+	assert.Empty(t, w.Header().Get("Access-Control-Max-Age"))
+}
+
+func TestCORS_DisallowedOrigin(t *testing.T) {
+	r := corsRouter(middleware.CORSConfig{AllowedOrigins: []string{"https://app.woorung.dev"}})
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/me", nil)
+	req.Header.Set("Origin", "https://evil.example")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Get("Access-Control-Expose-Headers"))
+}
@@ -0,0 +1,201 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/ctxutil"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/httperr"
+)
+
+// DefaultDeadlineExemptRoutes stream for as long as the client stays connected
+var DefaultDeadlineExemptRoutes = []string{"/api/v1/ask/stream", "/api/v1/ws"}
+
+// DeadlineConfig configures RequestDeadline
+type DeadlineConfig struct {
+	// Timeout bounds every request (0 = no deadline)
+	Timeout time.Duration
+	// ExemptRoutes are route patterns served without a deadline (default DefaultDeadlineExemptRoutes)
+	ExemptRoutes []string
+}
+
+// RequestDeadline gives every request a hard deadline. The request context is
+// cancelled when it passes, so agent calls, DB queries and retries stop, and
+// the client gets 504 right away even if a handler ignores the cancellation.
+//
+// The rest of the chain runs in its own goroutine and writes to a buffer that
+// is copied to the client once it finishes in time. Register it after the
+// middleware whose headers must reach a 504 (security headers, CORS).
+func RequestDeadline(conf func() DeadlineConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cfg := conf()
+		exempt := cfg.ExemptRoutes
+		if exempt == nil {
+			exempt = DefaultDeadlineExemptRoutes
+		}
+		if cfg.Timeout <= 0 || slices.Contains(exempt, c.FullPath()) {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), cfg.Timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		// Read before the chain runs concurrently with this goroutine
+		requestID, _ := ctxutil.RequestID(c)
+		original := c.Writer
+		w := &deadlineWriter{ResponseWriter: original, header: http.Header{}}
+		c.Writer = w
+
+		done := make(chan struct{})
+		var panicked any
+		go func() {
+			defer close(done)
+			defer func() { panicked = recover() }()
+			c.Next()
+		}()
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				log.Printf("[Deadline] %s %s exceeded %s (request_id=%s)", c.Request.Method, c.Request.URL.Path, cfg.Timeout, requestID)
+				w.timeout(c.Request, requestID, "Request timed out after "+cfg.Timeout.String())
+			}
+			// The gin context is still in use until the chain returns
+			<-done
+		}
+
+		c.Writer = original
+		w.finish()
+		if panicked != nil {
+			panic(panicked)
+		}
+	}
+}
+
+// deadlineWriter buffers a response until the chain returns in time. Once the
+// deadline has passed, everything the handler writes is discarded.
+type deadlineWriter struct {
+	gin.ResponseWriter // the client's writer, only used after the chain or under mu
+
+	mu       sync.Mutex
+	header   http.Header
+	buf      bytes.Buffer
+	status   int
+	written  bool
+	timedOut bool
+}
+
+func (w *deadlineWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *deadlineWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.written {
+		w.status = code
+	}
+}
+
+func (w *deadlineWriter) WriteHeaderNow() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.written = true
+}
+
+func (w *deadlineWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	w.written = true
+	return w.buf.Write(data)
+}
+
+func (w *deadlineWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *deadlineWriter) Status() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (w *deadlineWriter) Size() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.written {
+		return -1
+	}
+	return w.buf.Len()
+}
+
+func (w *deadlineWriter) Written() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.written
+}
+
+// Flush is a no-op: nothing reaches the client before the chain returns
+func (w *deadlineWriter) Flush() {}
+
+func (w *deadlineWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, errors.New("hijacking is not supported under a request deadline; exempt the route")
+}
+
+func (w *deadlineWriter) Pusher() http.Pusher {
+	return nil
+}
+
+// timeout answers 504 on the client's writer. Content-Length is set so the
+// client has the whole response while the handler is still running.
+func (w *deadlineWriter) timeout(r *http.Request, requestID, msg string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.timedOut = true
+	contentType, body := httperr.Render(r, requestID, msg)
+	h := w.ResponseWriter.Header()
+	h.Set("Content-Type", contentType)
+	h.Set("Content-Length", strconv.Itoa(len(body)))
+	w.ResponseWriter.WriteHeader(http.StatusGatewayTimeout)
+	_, _ = w.ResponseWriter.Write(body)
+	w.ResponseWriter.Flush()
+}
+
+// finish copies the buffered response to the client unless it already got a 504
+func (w *deadlineWriter) finish() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return
+	}
+	dst := w.ResponseWriter.Header()
+	for k, v := range w.header {
+		dst[k] = v
+	}
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	if w.written {
+		w.ResponseWriter.WriteHeaderNow()
+		_, _ = w.ResponseWriter.Write(w.buf.Bytes())
+	}
+}
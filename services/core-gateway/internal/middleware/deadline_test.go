@@ -0,0 +1,90 @@
+package middleware_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func deadlineRouter(timeout time.Duration, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.RequestDeadline(func() middleware.DeadlineConfig {
+		return middleware.DeadlineConfig{Timeout: timeout}
+	}))
+	r.GET("/slow", handler)
+	r.GET("/api/v1/ask/stream", handler)
+	return r
+}
+
+func TestRequestDeadline_HandlerIgnoringCancellationStillTimesOut(t *testing.T) {
+	// Arrange: the handler blocks until the test is over, whatever its context says
+	release := make(chan struct{})
+	observed := make(chan error, 1)
+	srv := httptest.NewServer(deadlineRouter(50*time.Millisecond, func(c *gin.Context) {
+		<-release
+		observed <- c.Request.Context().Err()
+		c.String(http.StatusOK, "too late")
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	// Act
+	start := time.Now()
+	resp, err := http.Get(srv.URL + "/slow")
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	elapsed := time.Since(start)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusGatewayTimeout, resp.StatusCode)
+	assert.JSONEq(t, `{"error":"Request timed out after 50ms"}`, string(body))
+	assert.Less(t, elapsed, 2*time.Second)
+
+	release <- struct{}{}
+	assert.ErrorIs(t, <-observed, context.DeadlineExceeded)
+}
+
+func TestRequestDeadline_FastResponsePassesThrough(t *testing.T) {
+	// Arrange
+	r := deadlineRouter(time.Second, func(c *gin.Context) {
+		c.Header("X-Answer", "42")
+		c.JSON(http.StatusCreated, gin.H{"ok": true})
+	})
+	w := httptest.NewRecorder()
+
+	// Act
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "42", w.Header().Get("X-Answer"))
+	assert.JSONEq(t, `{"ok":true}`, w.Body.String())
+}
+
+func TestRequestDeadline_StreamsAreExempt(t *testing.T) {
+	// Arrange
+	r := deadlineRouter(10*time.Millisecond, func(c *gin.Context) {
+		time.Sleep(50 * time.Millisecond)
+		_, hasDeadline := c.Request.Context().Deadline()
+		assert.False(t, hasDeadline)
+		c.Status(http.StatusOK)
+	})
+	w := httptest.NewRecorder()
+
+	// Act
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/ask/stream", nil))
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/httperr"
+)
+
+// EndpointGuardConfig protects operational endpoints such as /metrics.
+// With neither field set the endpoint stays open.
+type EndpointGuardConfig struct {
+	// Token admits requests carrying "Authorization: Bearer <Token>"
+	Token string
+	// AllowedIPs admits peers by IP or CIDR. The connection's address is used,
+	// never X-Forwarded-For, so the list can't be spoofed.
+	AllowedIPs []string
+}
+
+// EndpointGuard admits a request when it presents the configured token or
+// comes from an allowed IP; others get 401 (token configured) or 403.
+// cfg is read per request so config reloads take effect immediately.
+func EndpointGuard(cfg func() EndpointGuardConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		conf := cfg()
+		if conf.Token == "" && len(conf.AllowedIPs) == 0 {
+			c.Next()
+			return
+		}
+		if len(conf.AllowedIPs) > 0 && remoteAddrIn(c.Request.RemoteAddr, conf.AllowedIPs) {
+			c.Next()
+			return
+		}
+		if conf.Token != "" {
+			presented, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+			if ok && subtle.ConstantTimeCompare([]byte(presented), []byte(conf.Token)) == 1 {
+				c.Next()
+				return
+			}
+			c.Header("WWW-Authenticate", `Bearer realm="metrics"`)
+			httperr.Abort(c, http.StatusUnauthorized, "Authentication required")
+			return
+		}
+		httperr.Abort(c, http.StatusForbidden, "Forbidden")
+	}
+}
@@ -0,0 +1,75 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func guardedRouter(cfg middleware.EndpointGuardConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/health", ok)
+	r.GET("/metrics", middleware.EndpointGuard(func() middleware.EndpointGuardConfig { return cfg }), ok)
+	return r
+}
+
+func TestEndpointGuard(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        middleware.EndpointGuardConfig
+		path       string
+		remoteAddr string
+		auth       string
+		status     int
+	}{
+		{"open by default", middleware.EndpointGuardConfig{}, "/metrics", "203.0.113.9:5000", "", http.StatusOK},
+		{"valid token", middleware.EndpointGuardConfig{Token: "s3cret"}, "/metrics", "203.0.113.9:5000", "Bearer s3cret", http.StatusOK},
+		{"wrong token", middleware.EndpointGuardConfig{Token: "s3cret"}, "/metrics", "203.0.113.9:5000", "Bearer nope", http.StatusUnauthorized},
+		{"missing token", middleware.EndpointGuardConfig{Token: "s3cret"}, "/metrics", "203.0.113.9:5000", "", http.StatusUnauthorized},
+		{"allowed CIDR", middleware.EndpointGuardConfig{AllowedIPs: []string{"10.0.0.0/8"}}, "/metrics", "10.1.2.3:5000", "", http.StatusOK},
+		{"allowed single IP", middleware.EndpointGuardConfig{AllowedIPs: []string{"192.168.1.5"}}, "/metrics", "192.168.1.5:5000", "", http.StatusOK},
+		{"IP not allowed", middleware.EndpointGuardConfig{AllowedIPs: []string{"10.0.0.0/8"}}, "/metrics", "203.0.113.9:5000", "", http.StatusForbidden},
+		{"token admits outside the allow-list", middleware.EndpointGuardConfig{Token: "s3cret", AllowedIPs: []string{"10.0.0.0/8"}}, "/metrics", "203.0.113.9:5000", "Bearer s3cret", http.StatusOK},
+		{"liveness stays open", middleware.EndpointGuardConfig{Token: "s3cret"}, "/health", "203.0.113.9:5000", "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			r := guardedRouter(tt.cfg)
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			w := httptest.NewRecorder()
+
+			// Act
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
+
+func TestEndpointGuard_IgnoresForwardedFor(t *testing.T) {
+	// Arrange
+	r := guardedRouter(middleware.EndpointGuardConfig{AllowedIPs: []string{"10.0.0.0/8"}})
+	req, _ := http.NewRequest(http.MethodGet, "/metrics", nil)
+	req.RemoteAddr = "203.0.113.9:5000"
+	req.Header.Set("X-Forwarded-For", "10.1.2.3")
+	w := httptest.NewRecorder()
+
+	// Act
+	r.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/httperr"
+)
+
+// HTTPSConfig configures RequireHTTPS
+type HTTPSConfig struct {
+	// Enabled turns enforcement on (server.require_https)
+	Enabled bool
+	// TrustedProxies are the IPs or CIDRs whose X-Forwarded-Proto is believed.
+	// Requests over a Unix socket always come from a local proxy and are trusted.
+	TrustedProxies []string
+	// ExemptPaths are served over plain HTTP anyway (e.g. "/health" for probes)
+	ExemptPaths []string
+}
+
+// RequireHTTPS refuses requests that did not arrive over HTTPS, so tokens are
+// never accepted over plaintext. A request counts as HTTPS when the gateway
+// terminated TLS itself or a trusted proxy sent X-Forwarded-Proto: https.
+// Plain GET/HEAD requests are redirected (308); anything else is rejected with
+// 403, since its credentials have already been sent. cfg is read per request
+// so config reloads take effect immediately.
+func RequireHTTPS(cfg func() HTTPSConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		conf := cfg()
+		if !conf.Enabled || arrivedOverHTTPS(c.Request, conf.TrustedProxies) {
+			c.Next()
+			return
+		}
+		for _, path := range conf.ExemptPaths {
+			if c.Request.URL.Path == path {
+				c.Next()
+				return
+			}
+		}
+
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			c.Redirect(http.StatusPermanentRedirect, "https://"+c.Request.Host+c.Request.URL.RequestURI())
+			c.Abort()
+			return
+		}
+		httperr.Abort(c, http.StatusForbidden, "HTTPS is required")
+	}
+}
+
+func arrivedOverHTTPS(r *http.Request, trustedProxies []string) bool {
+	if r.TLS != nil {
+		return true
+	}
+	// Only the proxy nearest to us is consulted: the last value it appended
+	proto := r.Header.Values("X-Forwarded-Proto")
+	if len(proto) == 0 || !remoteAddrIn(r.RemoteAddr, trustedProxies) {
+		return false
+	}
+	last := proto[len(proto)-1]
+	if i := strings.LastIndex(last, ","); i >= 0 {
+		last = last[i+1:]
+	}
+	return strings.EqualFold(strings.TrimSpace(last), "https")
+}
+
+// remoteAddrIn reports whether the connection's peer matches one of the IPs
+// or CIDRs in list. Unix socket peers are local and always match.
+func remoteAddrIn(remoteAddr string, list []string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		// Not an IP: the connection came over a Unix socket
+		return host == "" || host == "@"
+	}
+	addr = addr.Unmap()
+	for _, entry := range list {
+		if prefix, err := netip.ParsePrefix(entry); err == nil && prefix.Contains(addr) {
+			return true
+		}
+		if ip, err := netip.ParseAddr(entry); err == nil && ip.Unmap() == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidProxies drops the entries InvalidProxies reports
+func ValidProxies(proxies []string) []string {
+	invalid := InvalidProxies(proxies)
+	valid := make([]string, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !slices.Contains(invalid, proxy) {
+			valid = append(valid, proxy)
+		}
+	}
+	return valid
+}
+
+// InvalidProxies lists TrustedProxies entries that are neither an IP nor a
+// CIDR, so typos can be flagged at startup instead of silently ignored
+func InvalidProxies(proxies []string) []string {
+	var invalid []string
+	for _, proxy := range proxies {
+		if _, err := netip.ParsePrefix(proxy); err == nil {
+			continue
+		}
+		if _, err := netip.ParseAddr(proxy); err == nil {
+			continue
+		}
+		invalid = append(invalid, proxy)
+	}
+	return invalid
+}
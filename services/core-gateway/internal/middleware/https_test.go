@@ -0,0 +1,85 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func httpsRouter(cfg middleware.HTTPSConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.RequireHTTPS(func() middleware.HTTPSConfig { return cfg }))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/health", ok)
+	r.GET("/api/v1/me", ok)
+	r.POST("/api/v1/ask", ok)
+	return r
+}
+
+func TestRequireHTTPS_ForwardedProto(t *testing.T) {
+	cfg := middleware.HTTPSConfig{
+		Enabled:        true,
+		TrustedProxies: []string{"10.0.0.0/8", "192.168.1.5"},
+		ExemptPaths:    []string{"/health"},
+	}
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		remoteAddr string
+		proto      string
+		status     int
+	}{
+		{"https from trusted proxy", http.MethodPost, "/api/v1/ask", "10.1.2.3:5000", "https", http.StatusOK},
+		{"https from trusted single IP", http.MethodGet, "/api/v1/me", "192.168.1.5:5000", "HTTPS", http.StatusOK},
+		{"http from trusted proxy is rejected", http.MethodPost, "/api/v1/ask", "10.1.2.3:5000", "http", http.StatusForbidden},
+		{"http GET is redirected", http.MethodGet, "/api/v1/me?x=1", "10.1.2.3:5000", "http", http.StatusPermanentRedirect},
+		{"spoofed header from untrusted client", http.MethodPost, "/api/v1/ask", "203.0.113.9:5000", "https", http.StatusForbidden},
+		{"nearest proxy wins in a chain", http.MethodPost, "/api/v1/ask", "10.1.2.3:5000", "https, http", http.StatusForbidden},
+		{"no header", http.MethodPost, "/api/v1/ask", "10.1.2.3:5000", "", http.StatusForbidden},
+		{"health checks are exempt", http.MethodGet, "/health", "10.1.2.3:5000", "http", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			r := httpsRouter(cfg)
+			req, _ := http.NewRequest(tt.method, tt.path, nil)
+			req.Host = "gateway.example.com"
+			req.RemoteAddr = tt.remoteAddr
+			if tt.proto != "" {
+				req.Header.Set("X-Forwarded-Proto", tt.proto)
+			}
+			w := httptest.NewRecorder()
+
+			// Act
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusPermanentRedirect {
+				assert.Equal(t, "https://gateway.example.com/api/v1/me?x=1", w.Header().Get("Location"))
+			}
+		})
+	}
+}
+
+func TestRequireHTTPS_Disabled(t *testing.T) {
+	r := httpsRouter(middleware.HTTPSConfig{})
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/ask", nil)
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestInvalidProxies(t *testing.T) {
+	assert.Equal(t, []string{"10.0.0.0/33", "proxy.local"},
+		middleware.InvalidProxies([]string{"10.0.0.0/8", "10.0.0.0/33", "::1", "proxy.local"}))
+}
@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/ctxutil"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/httperr"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/idempotency"
+)
+
+// IdempotencyKeyHeader lets clients safely retry non-idempotent requests
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// captureWriter tees the response body so it can be stored for replay
+type captureWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// Idempotency replays the stored response for a repeated Idempotency-Key
+// instead of running the handler again. Requests without the header pass
+// through. Only 2xx and 4xx responses are stored, except 408 and 429: after
+// those and server errors (5xx) the client may retry. A key reused for a
+// different request (method, path or body) is refused with 422.
+// Must run after AuthMiddleware: keys are scoped per caller, and anonymous
+// callers, who share one user ID, per client IP.
+func Idempotency(store idempotency.Store, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		caller, ok := ctxutil.UserID(c)
+		if !ok {
+			caller, _ = ctxutil.Service(c)
+		}
+		if ctxutil.IsAnonymous(c) {
+			caller = ctxutil.AnonymousUserID + "@" + c.ClientIP()
+		}
+		tenantID, _ := ctxutil.TenantID(c)
+		caller = idempotency.Owner(tenantID, caller)
+
+		var body []byte
+		if c.Request.Body != nil {
+			var err error
+			if body, err = io.ReadAll(c.Request.Body); err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					httperr.Abort(c, http.StatusRequestEntityTooLarge, "Request body too large")
+					return
+				}
+				httperr.Abort(c, http.StatusBadRequest, "Failed to read request body")
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		fingerprint := idempotency.Fingerprint(c.Request.Method, c.Request.URL.Path, body)
+
+		ctx := c.Request.Context()
+		rec, created, err := store.Begin(ctx, key, caller, fingerprint, ttl)
+		if err != nil {
+			log.Printf("[Idempotency] Failed to claim key: %v", err)
+			httperr.Abort(c, http.StatusInternalServerError, "Failed to check idempotency key")
+			return
+		}
+		// Records stored before fingerprints were kept match any request
+		if !created && rec.Fingerprint != "" && rec.Fingerprint != fingerprint {
+			httperr.Abort(c, http.StatusUnprocessableEntity, "This Idempotency-Key was already used for a different request")
+			return
+		}
+		if !created {
+			if !rec.Completed {
+				httperr.Abort(c, http.StatusConflict, "A request with this Idempotency-Key is still in progress")
+				return
+			}
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(rec.StatusCode, "application/json; charset=utf-8", []byte(rec.ResponseJSON))
+			c.Abort()
+			return
+		}
+
+		w := &captureWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+
+		// The outcome must be recorded even if the client has gone away
+		ctx = context.WithoutCancel(ctx)
+		if !replayable(c.Writer.Status()) {
+			if err := store.Release(ctx, key, caller); err != nil {
+				log.Printf("[Idempotency] Failed to release key: %v", err)
+			}
+			return
+		}
+		if err := store.Complete(ctx, key, caller, c.Writer.Status(), w.body.String()); err != nil {
+			log.Printf("[Idempotency] Failed to store response: %v", err)
+		}
+	}
+}
+
+// replayable reports whether a response is stored for replay. 408 and 429 are
+// not: a retry may well get through once the client has waited.
+func replayable(status int) bool {
+	if status == http.StatusRequestTimeout || status == http.StatusTooManyRequests {
+		return false
+	}
+	return (status >= 200 && status < 300) || (status >= 400 && status < 500)
+}
@@ -0,0 +1,158 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/ctxutil"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/idempotency"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupIdempotentRouter(status int) (*gin.Engine, *atomic.Int32) {
+	gin.SetMode(gin.TestMode)
+	var calls atomic.Int32
+	r := gin.New()
+	r.POST("/ask", func(c *gin.Context) {
+		ctxutil.SetUserID(c, c.GetHeader("X-User"))
+	}, middleware.Idempotency(idempotency.NewMemoryStore(), time.Hour), func(c *gin.Context) {
+		n := calls.Add(1)
+		c.JSON(status, gin.H{"call": n})
+	})
+	return r, &calls
+}
+
+func postKeyed(r *gin.Engine, user, key string) *httptest.ResponseRecorder {
+	return postKeyedFrom(r, user, key, "192.0.2.1:1234")
+}
+
+func postKeyedFrom(r *gin.Engine, user, key, remoteAddr string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "/ask", strings.NewReader(`{}`))
+	req.RemoteAddr = remoteAddr
+	req.Header.Set("X-User", user)
+	if key != "" {
+		req.Header.Set(middleware.IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotency_ReplaysStoredResponse(t *testing.T) {
+	// Arrange
+	r, calls := setupIdempotentRouter(http.StatusOK)
+
+	// Act
+	first := postKeyed(r, "user_1", "key-1")
+	second := postKeyed(r, "user_1", "key-1")
+	otherUser := postKeyed(r, "user_2", "key-1")
+	unkeyed := postKeyed(r, "user_1", "")
+
+	// Assert
+	assert.JSONEq(t, `{"call":1}`, first.Body.String())
+	assert.JSONEq(t, `{"call":1}`, second.Body.String(), "Repeated key replays the first response")
+	assert.Equal(t, "true", second.Header().Get("Idempotent-Replayed"))
+	assert.JSONEq(t, `{"call":2}`, otherUser.Body.String(), "Keys are scoped per user")
+	assert.JSONEq(t, `{"call":3}`, unkeyed.Body.String())
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestIdempotency_AnonymousCallersScopedByIP(t *testing.T) {
+	// Arrange
+	r, calls := setupIdempotentRouter(http.StatusOK)
+
+	// Act
+	first := postKeyedFrom(r, ctxutil.AnonymousUserID, "key-1", "192.0.2.1:1234")
+	retry := postKeyedFrom(r, ctxutil.AnonymousUserID, "key-1", "192.0.2.1:5678")
+	stranger := postKeyedFrom(r, ctxutil.AnonymousUserID, "key-1", "198.51.100.7:1234")
+
+	// Assert: another anonymous client never sees the first one's response
+	assert.JSONEq(t, `{"call":1}`, first.Body.String())
+	assert.JSONEq(t, `{"call":1}`, retry.Body.String())
+	assert.JSONEq(t, `{"call":2}`, stranger.Body.String())
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestIdempotency_ServerErrorsAreNotStored(t *testing.T) {
+	r, calls := setupIdempotentRouter(http.StatusBadGateway)
+
+	postKeyed(r, "user_1", "key-1")
+	postKeyed(r, "user_1", "key-1")
+
+	assert.Equal(t, int32(2), calls.Load(), "A 5xx releases the key so the retry runs")
+}
+
+func TestIdempotency_OnlyFinalClientErrorsAreStored(t *testing.T) {
+	tests := []struct {
+		status   int
+		expected int32
+	}{
+		{http.StatusBadRequest, 1},
+		{http.StatusNotFound, 1},
+		{http.StatusRequestTimeout, 2},
+		{http.StatusTooManyRequests, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			r, calls := setupIdempotentRouter(tt.status)
+
+			postKeyed(r, "user_1", "key-1")
+			retry := postKeyed(r, "user_1", "key-1")
+
+			assert.Equal(t, tt.status, retry.Code)
+			assert.Equal(t, tt.expected, calls.Load())
+		})
+	}
+}
+
+func TestIdempotency_KeyReusedForDifferentBody(t *testing.T) {
+	// Arrange
+	r, calls := setupIdempotentRouter(http.StatusOK)
+	post := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/ask", strings.NewReader(body))
+		req.Header.Set("X-User", "user_1")
+		req.Header.Set(middleware.IdempotencyKeyHeader, "key-1")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// Act
+	first := post(`{"message":"deploy staging"}`)
+	retry := post(`{"message":"deploy staging"}`)
+	reused := post(`{"message":"deploy production"}`)
+
+	// Assert: a retry is replayed, a different request under the same key is refused
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, http.StatusUnprocessableEntity, reused.Code)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestIdempotency_InProgress(t *testing.T) {
+	// Arrange: the first request holds the key while the second arrives
+	store := idempotency.NewMemoryStore()
+	_, _, err := store.Begin(context.Background(), "key-1", "user_1", "", time.Hour)
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/ask", func(c *gin.Context) { ctxutil.SetUserID(c, "user_1") },
+		middleware.Idempotency(store, time.Hour),
+		func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{}) })
+
+	// Act
+	w := postKeyed(r, "user_1", "key-1")
+
+	// Assert
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
@@ -0,0 +1,188 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/httperr"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/metrics"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/ratelimit"
+)
+
+// rateLimitIdleTTL evicts per-client buckets nobody has used for this long
+const rateLimitIdleTTL = 10 * time.Minute
+
+// Limit caps one route's request body and request rate (0 = unlimited)
+type Limit struct {
+	MaxBodyBytes      int64
+	RequestsPerMinute int
+	// Burst is how many requests may arrive at once (default RequestsPerMinute)
+	Burst int
+}
+
+// RouteLimits holds per-route limits keyed by route pattern (e.g.
+// "/api/v1/conversations/:thread_id/messages"), falling back to Default.
+// MaxJSONBytes and MaxMultipartBytes cap bodies by content type on every
+// route (0 = unlimited); the smallest applicable limit wins.
+type RouteLimits struct {
+	Default           Limit
+	Routes            map[string]Limit
+	MaxJSONBytes      int64
+	MaxMultipartBytes int64
+}
+
+// For returns the limit for a route pattern
+func (l RouteLimits) For(route string) Limit {
+	if limit, ok := l.Routes[route]; ok {
+		return limit
+	}
+	return l.Default
+}
+
+// UnknownLimitRoutes lists configured route patterns that match no registered
+// route, so typos can be flagged at startup instead of silently ignored
+func UnknownLimitRoutes(limits RouteLimits, routes gin.RoutesInfo) []string {
+	known := make(map[string]bool, len(routes))
+	for _, r := range routes {
+		known[r.Path] = true
+	}
+	var unknown []string
+	for route := range limits.Routes {
+		if !known[route] {
+			unknown = append(unknown, route)
+		}
+	}
+	return unknown
+}
+
+// JSONLimit is the body limit for a JSON body on route (0 = unlimited)
+func (l RouteLimits) JSONLimit(route string) int64 {
+	max, _ := l.bodyLimit(route, "application/json")
+	return max
+}
+
+// bodyLimit returns the body limit for a route and content type, with a
+// description of it for the 413 message (0 = unlimited)
+func (l RouteLimits) bodyLimit(route, contentType string) (int64, string) {
+	max, which := l.For(route).MaxBodyBytes, "request bodies on this route are"
+	byType, kind := int64(0), ""
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		byType, kind = l.MaxJSONBytes, "JSON bodies are"
+	case strings.HasPrefix(mediaType, "multipart/"):
+		byType, kind = l.MaxMultipartBytes, "multipart bodies are"
+	}
+	if byType > 0 && (max <= 0 || byType < max) {
+		max, which = byType, kind
+	}
+	if max <= 0 {
+		return 0, ""
+	}
+	return max, fmt.Sprintf("%s limited to %d bytes", which, max)
+}
+
+// BodyLimit rejects bodies larger than the route's MaxBodyBytes, or the limit
+// for their content type, with 413 naming the limit that was hit.
+// A declared Content-Length is checked up front; otherwise reads past the
+// limit fail and the handler's bind reports the error.
+func BodyLimit(limits func() RouteLimits) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		max, which := limits().bodyLimit(c.FullPath(), c.GetHeader("Content-Type"))
+		if max > 0 && c.Request.Body != nil {
+			if c.Request.ContentLength > max {
+				httperr.Abort(c, http.StatusRequestEntityTooLarge, "Request body too large: "+which)
+				return
+			}
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, max)
+		}
+		c.Next()
+	}
+}
+
+// routeLimiter is a route's bucket set, rebuilt when its limit changes
+type routeLimiter struct {
+	limit   Limit
+	limiter *ratelimit.Limiter
+}
+
+// RateLimit enforces each route's RequestsPerMinute per client IP, answering
+// 429 with Retry-After. limits is read per request so reloads take effect.
+func RateLimit(limits func() RouteLimits) gin.HandlerFunc {
+	return RateLimitWithOverrides(limits, nil)
+}
+
+// RateOverride returns a per-caller limit that replaces the route's
+// RequestsPerMinute (0 = unlimited) and the subject it is counted for
+// instead of the client IP. ok is false when the caller has none.
+type RateOverride func(c *gin.Context) (subject string, requestsPerMinute int, ok bool)
+
+// RateLimitWithOverrides is RateLimit where override (nil = none) can replace
+// the limit for individual callers, e.g. from per-user overrides in the database
+func RateLimitWithOverrides(limits func() RouteLimits, override RateOverride) gin.HandlerFunc {
+	var mu sync.Mutex
+	limiters := make(map[string]*routeLimiter)
+
+	limiterFor := func(route string, limit Limit) *ratelimit.Limiter {
+		mu.Lock()
+		defer mu.Unlock()
+		if rl, ok := limiters[route]; ok && rl.limit == limit {
+			return rl.limiter
+		}
+		if rl, ok := limiters[route]; ok {
+			rl.limiter.Stop()
+		}
+		burst := limit.Burst
+		if burst <= 0 {
+			burst = limit.RequestsPerMinute
+		}
+		rl := &routeLimiter{limit: limit, limiter: ratelimit.New(ratelimit.Config{
+			Rate:    float64(limit.RequestsPerMinute) / 60,
+			Burst:   burst,
+			IdleTTL: rateLimitIdleTTL,
+		})}
+		limiters[route] = rl
+		return rl.limiter
+	}
+	metrics.RegisterStore("rate_limit_buckets", func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		n := 0
+		for _, rl := range limiters {
+			n += rl.limiter.Len()
+		}
+		return n
+	})
+
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		limit := limits().For(route)
+		bucket, key := route, c.ClientIP()
+		if override != nil {
+			if subject, rpm, ok := override(c); ok {
+				// Buckets per distinct override, so callers with different limits don't rebuild each other's
+				limit = Limit{RequestsPerMinute: rpm}
+				bucket, key = route+"|"+strconv.Itoa(rpm), subject
+			}
+		}
+		if limit.RequestsPerMinute <= 0 {
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(limit.RequestsPerMinute))
+		if ok, wait := limiterFor(bucket, limit).Allow(key); !ok {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			httperr.Abort(c, http.StatusTooManyRequests, "Rate limit exceeded")
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,158 @@
+package middleware_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func limitsRouter(limits middleware.RouteLimits) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	current := func() middleware.RouteLimits { return limits }
+	r.Use(middleware.RateLimit(current), middleware.BodyLimit(current))
+	echo := func(c *gin.Context) {
+		if _, err := io.ReadAll(c.Request.Body); err != nil {
+			c.Status(http.StatusRequestEntityTooLarge)
+			return
+		}
+		c.Status(http.StatusOK)
+	}
+	r.POST("/ask", echo)
+	r.POST("/batch", echo)
+	return r
+}
+
+func post(r *gin.Engine, path, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+var askAndBatchLimits = middleware.RouteLimits{
+	Default: middleware.Limit{MaxBodyBytes: 1 << 20},
+	Routes: map[string]middleware.Limit{
+		"/ask":   {MaxBodyBytes: 16, RequestsPerMinute: 2},
+		"/batch": {MaxBodyBytes: 1024, RequestsPerMinute: 5},
+	},
+}
+
+func TestBodyLimit_PerRoute(t *testing.T) {
+	// Arrange
+	r := limitsRouter(askAndBatchLimits)
+	body := strings.Repeat("x", 100)
+
+	// Act
+	ask := post(r, "/ask", body)
+	batch := post(r, "/batch", body)
+
+	// Assert
+	assert.Equal(t, http.StatusRequestEntityTooLarge, ask.Code)
+	assert.Equal(t, http.StatusOK, batch.Code)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, post(r, "/batch", strings.Repeat("x", 2000)).Code)
+}
+
+func TestBodyLimit_UnknownLengthStillCapped(t *testing.T) {
+	// Arrange
+	r := limitsRouter(askAndBatchLimits)
+	req, _ := http.NewRequest(http.MethodPost, "/ask", io.NopCloser(strings.NewReader(strings.Repeat("x", 100))))
+	req.ContentLength = -1
+	w := httptest.NewRecorder()
+
+	// Act
+	r.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
+
+func TestBodyLimit_ByContentType(t *testing.T) {
+	// Arrange: small JSON bodies, larger uploads, and a route cap above both
+	r := limitsRouter(middleware.RouteLimits{
+		Default:           middleware.Limit{MaxBodyBytes: 1 << 20},
+		MaxJSONBytes:      64,
+		MaxMultipartBytes: 512,
+	})
+	send := func(contentType string, size int) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, "/batch", strings.NewReader(strings.Repeat("x", size)))
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// Act
+	bigJSON := send("application/json; charset=utf-8", 100)
+	bigMultipart := send("multipart/form-data; boundary=xyz", 1000)
+	uploadAboveJSONLimit := send("multipart/form-data; boundary=xyz", 100)
+	smallJSON := send("application/merge-patch+json", 64)
+	plainText := send("text/plain", 1000)
+
+	// Assert: each 413 names the limit that was hit
+	assert.Equal(t, http.StatusRequestEntityTooLarge, bigJSON.Code)
+	assert.Contains(t, bigJSON.Body.String(), "JSON bodies are limited to 64 bytes")
+	assert.Equal(t, http.StatusRequestEntityTooLarge, bigMultipart.Code)
+	assert.Contains(t, bigMultipart.Body.String(), "multipart bodies are limited to 512 bytes")
+	assert.Equal(t, http.StatusOK, uploadAboveJSONLimit.Code)
+	assert.Equal(t, http.StatusOK, smallJSON.Code)
+	assert.Equal(t, http.StatusOK, plainText.Code)
+}
+
+func TestBodyLimit_RouteLimitBelowContentTypeLimit(t *testing.T) {
+	// Arrange
+	limits := askAndBatchLimits
+	limits.MaxJSONBytes = 1024
+	r := limitsRouter(limits)
+	req, _ := http.NewRequest(http.MethodPost, "/ask", strings.NewReader(strings.Repeat("x", 100)))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	// Act
+	r.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Contains(t, w.Body.String(), "request bodies on this route are limited to 16 bytes")
+}
+
+func TestRateLimit_PerRoute(t *testing.T) {
+	// Arrange
+	r := limitsRouter(askAndBatchLimits)
+
+	// Act
+	var askCodes, batchCodes []int
+	for i := 0; i < 5; i++ {
+		askCodes = append(askCodes, post(r, "/ask", "hi").Code)
+		batchCodes = append(batchCodes, post(r, "/batch", "hi").Code)
+	}
+	limited := post(r, "/ask", "hi")
+
+	// Assert
+	assert.Equal(t, []int{200, 200, 429, 429, 429}, askCodes)
+	assert.Equal(t, []int{200, 200, 200, 200, 200}, batchCodes)
+	assert.Equal(t, http.StatusTooManyRequests, post(r, "/batch", "hi").Code)
+	assert.Equal(t, "2", limited.Header().Get("X-RateLimit-Limit"))
+	assert.NotEmpty(t, limited.Header().Get("Retry-After"))
+}
+
+func TestUnknownLimitRoutes(t *testing.T) {
+	// Arrange
+	r := limitsRouter(middleware.RouteLimits{})
+	limits := middleware.RouteLimits{Routes: map[string]middleware.Limit{
+		"/ask": {RequestsPerMinute: 1},
+		"/aks": {RequestsPerMinute: 1},
+	}}
+
+	// Act
+	unknown := middleware.UnknownLimitRoutes(limits, r.Routes())
+
+	// Assert
+	assert.Equal(t, []string{"/aks"}, unknown)
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"regexp"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/ctxutil"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/httperr"
+)
+
+// RequestIDHeader carries the correlation ID in both directions
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDPattern accepts IDs from proxies and clients that are safe to echo and log
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID gives every request a correlation ID: the caller's X-Request-ID
+// when it is well-formed, else a new UUID. It is echoed in the response,
+// included in error bodies and log lines, and forwarded to the agent.
+// Register it first so everything after it can read the ID.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !requestIDPattern.MatchString(id) {
+			id = uuid.NewString()
+		}
+		ctxutil.SetRequestID(c, id)
+		c.Request.Header.Set(RequestIDHeader, id)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// Recovery turns a panic into a 500 with the standard error envelope. The
+// panic is logged with the request ID the client sees, and the stack.
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			// The handler asked to abort the connection; let net/http do it
+			if p == http.ErrAbortHandler {
+				panic(p)
+			}
+			requestID, _ := ctxutil.RequestID(c)
+			log.Printf("[Recovery] Panic serving %s %s (request_id=%s): %v\n%s", c.Request.Method, c.Request.URL.Path, requestID, p, debug.Stack())
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+			httperr.Abort(c, http.StatusInternalServerError, "Internal server error")
+		}()
+		c.Next()
+	}
+}
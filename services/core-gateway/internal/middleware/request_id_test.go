@@ -0,0 +1,86 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/httperr"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func requestIDRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.RequestID(), middleware.Recovery())
+	r.GET("/panic", func(c *gin.Context) { panic("boom") })
+	r.GET("/fail", func(c *gin.Context) { httperr.Respond(c, http.StatusBadGateway, "Agent unavailable") })
+	return r
+}
+
+// captureLog redirects the standard logger for the rest of the test
+func captureLog(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	return &buf
+}
+
+func TestRecovery_RequestIDMatchesBodyAndLog(t *testing.T) {
+	for _, path := range []string{"/panic", "/fail"} {
+		t.Run(path, func(t *testing.T) {
+			// Arrange
+			logged := captureLog(t)
+			w := httptest.NewRecorder()
+
+			// Act
+			requestIDRouter().ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+			// Assert
+			require.GreaterOrEqual(t, w.Code, http.StatusInternalServerError)
+			var body struct {
+				Error     string `json:"error"`
+				RequestID string `json:"request_id"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			require.NotEmpty(t, body.RequestID)
+			assert.Equal(t, body.RequestID, w.Header().Get(middleware.RequestIDHeader))
+			match := regexp.MustCompile(`request_id=(\S+)\)`).FindStringSubmatch(logged.String())
+			require.Len(t, match, 2, logged.String())
+			assert.Equal(t, body.RequestID, match[1])
+		})
+	}
+}
+
+func TestRequestID_KeepsWellFormedCallerID(t *testing.T) {
+	for header, kept := range map[string]bool{
+		"req-2026.10.16:42":         true,
+		"has spaces":                false,
+		"<script>alert(1)</script>": false,
+	} {
+		// Arrange
+		var seen string
+		r := gin.New()
+		r.Use(middleware.RequestID())
+		r.GET("/", func(c *gin.Context) { seen = c.GetHeader(middleware.RequestIDHeader) })
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(middleware.RequestIDHeader, header)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert: handlers (and the agent call) see the same ID as the client
+		echoed := w.Header().Get(middleware.RequestIDHeader)
+		assert.Equal(t, kept, echoed == header, header)
+		assert.Equal(t, echoed, seen, header)
+	}
+}
@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/ctxutil"
+)
+
+// Access log formats (server.access_log_format)
+const (
+	AccessLogJSON     = "json"     // one structured JSON object per request (default)
+	AccessLogCombined = "combined" // NCSA combined log format
+	AccessLogBoth     = "both"     // JSON and combined lines
+	AccessLogText     = "text"     // alias for AccessLogCombined
+)
+
+// DefaultSlowRequest is the duration from which a request is always logged when sampling
+const DefaultSlowRequest = time.Second
+
+// AccessLogConfig controls the access log
+type AccessLogConfig struct {
+	// Format is AccessLogJSON (default), AccessLogCombined (or AccessLogText) or AccessLogBoth
+	Format string
+	// SampleRate logs 1 in N successful requests (0 or 1 = all). Failed
+	// requests (status >= 400) and slow ones are always logged.
+	SampleRate int
+	// SlowThreshold marks a request as slow (0 = DefaultSlowRequest)
+	SlowThreshold time.Duration
+}
+
+// accessLogEntry is the JSON access log line
+type accessLogEntry struct {
+	Time       string `json:"time"`
+	Method     string `json:"method"`
+	Path       string `json:"path"`
+	Status     int    `json:"status"`
+	DurationMS int64  `json:"duration_ms"`
+	ClientIP   string `json:"client_ip"`
+	UserID     string `json:"user_id,omitempty"`
+	RequestID  string `json:"request_id,omitempty"`
+}
+
+// RequestLogger writes one access log line per request to out. cfg is read
+// per request so config reloads take effect immediately. User and request IDs
+// are read after the handler chain, so it may be registered before AuthMiddleware.
+func RequestLogger(out io.Writer, cfg func() AccessLogConfig) gin.HandlerFunc {
+	var successes atomic.Uint64
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		conf := cfg()
+		if !logged(conf, c.Writer.Status(), time.Since(start), &successes) {
+			return
+		}
+		f := conf.Format
+		if f == AccessLogText {
+			f = AccessLogCombined
+		}
+		if f != AccessLogCombined {
+			writeJSONAccessLog(out, c, start)
+		}
+		if f == AccessLogCombined || f == AccessLogBoth {
+			writeCombinedAccessLog(out, c, start)
+		}
+	}
+}
+
+// logged decides whether a request makes it into the access log: every
+// problem does, successful requests 1 in SampleRate
+func logged(conf AccessLogConfig, status int, elapsed time.Duration, successes *atomic.Uint64) bool {
+	if conf.SampleRate <= 1 || status >= http.StatusBadRequest {
+		return true
+	}
+	slow := conf.SlowThreshold
+	if slow <= 0 {
+		slow = DefaultSlowRequest
+	}
+	if elapsed >= slow {
+		return true
+	}
+	return (successes.Add(1)-1)%uint64(conf.SampleRate) == 0
+}
+
+func writeJSONAccessLog(out io.Writer, c *gin.Context, start time.Time) {
+	entry := accessLogEntry{
+		Time:       start.UTC().Format(time.RFC3339),
+		Method:     c.Request.Method,
+		Path:       c.Request.URL.Path,
+		Status:     c.Writer.Status(),
+		DurationMS: time.Since(start).Milliseconds(),
+		ClientIP:   c.ClientIP(),
+	}
+	entry.UserID, _ = ctxutil.UserID(c)
+	entry.RequestID, _ = ctxutil.RequestID(c)
+
+	line, _ := json.Marshal(entry)
+	fmt.Fprintf(out, "%s\n", line)
+}
+
+// writeCombinedAccessLog emits:
+// host ident authuser [date] "request" status bytes "referer" "user-agent"
+func writeCombinedAccessLog(out io.Writer, c *gin.Context, start time.Time) {
+	user, ok := ctxutil.UserID(c)
+	if !ok || user == "" {
+		user = "-"
+	}
+	size := c.Writer.Size()
+	if size < 0 {
+		size = 0
+	}
+
+	fmt.Fprintf(out, "%s - %s [%s] \"%s %s %s\" %d %d \"%s\" \"%s\"\n",
+		c.ClientIP(),
+		user,
+		start.Format("02/Jan/2006:15:04:05 -0700"),
+		c.Request.Method,
+		c.Request.URL.RequestURI(),
+		c.Request.Proto,
+		c.Writer.Status(),
+		size,
+		orDash(c.Request.Referer()),
+		orDash(c.Request.UserAgent()),
+	)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return strings.ReplaceAll(s, `"`, `\"`)
+}
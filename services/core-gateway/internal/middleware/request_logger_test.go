@@ -0,0 +1,115 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/ctxutil"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serveLogged(format string) string {
+	gin.SetMode(gin.TestMode)
+	var out bytes.Buffer
+	r := gin.New()
+	r.Use(middleware.RequestLogger(&out, func() middleware.AccessLogConfig { return middleware.AccessLogConfig{Format: format} }))
+	r.GET("/api/v1/me", func(c *gin.Context) {
+		ctxutil.SetUserID(c, "user_1")
+		c.String(http.StatusOK, "hello")
+	})
+
+	req, _ := http.NewRequest("GET", "/api/v1/me?x=1", nil)
+	req.RemoteAddr = "203.0.113.7:5555"
+	req.Header.Set("User-Agent", "curl/8.0")
+	req.Header.Set("Referer", "https://app.woorung.dev/")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	return out.String()
+}
+
+func TestRequestLogger_Combined(t *testing.T) {
+	// Act
+	line := serveLogged(middleware.AccessLogCombined)
+
+	// Assert
+	assert.Regexp(t,
+		`^203\.0\.113\.7 - user_1 \[\d{2}/[A-Z][a-z]{2}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] "GET /api/v1/me\?x=1 HTTP/1\.1" 200 5 "https://app\.woorung\.dev/" "curl/8\.0"\n$`,
+		line)
+}
+
+func TestRequestLogger_TextIsCombined(t *testing.T) {
+	// Act
+	line := serveLogged(middleware.AccessLogText)
+
+	// Assert
+	assert.Regexp(t, `^203\.0\.113\.7 - user_1 \[.+\] "GET /api/v1/me\?x=1 HTTP/1\.1" 200 5 `, line)
+	assert.Equal(t, 1, strings.Count(line, "\n"))
+}
+
+func TestRequestLogger_JSON(t *testing.T) {
+	// Act
+	line := serveLogged(middleware.AccessLogJSON)
+
+	// Assert
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal([]byte(line), &entry))
+	assert.Equal(t, "GET", entry["method"])
+	assert.Equal(t, "/api/v1/me", entry["path"])
+	assert.Equal(t, float64(200), entry["status"])
+	assert.Equal(t, "user_1", entry["user_id"])
+	assert.Contains(t, entry, "duration_ms")
+}
+
+func TestRequestLogger_Both(t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(serveLogged(middleware.AccessLogBoth)), "\n")
+
+	require.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], "{"))
+	assert.True(t, strings.HasPrefix(lines[1], "203.0.113.7 - user_1 ["))
+}
+
+func TestRequestLogger_Sampling(t *testing.T) {
+	// Arrange: 1 in 5 successes; /slow exceeds the slow threshold
+	gin.SetMode(gin.TestMode)
+	var out bytes.Buffer
+	r := gin.New()
+	r.Use(middleware.RequestLogger(&out, func() middleware.AccessLogConfig {
+		return middleware.AccessLogConfig{SampleRate: 5, SlowThreshold: 20 * time.Millisecond}
+	}))
+	r.GET("/ok", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/fail", func(c *gin.Context) { c.Status(http.StatusInternalServerError) })
+	r.GET("/slow", func(c *gin.Context) {
+		time.Sleep(25 * time.Millisecond)
+		c.Status(http.StatusOK)
+	})
+	serve := func(path string, times int) {
+		for range times {
+			req, _ := http.NewRequest("GET", path, nil)
+			r.ServeHTTP(httptest.NewRecorder(), req)
+		}
+	}
+
+	// Act
+	serve("/ok", 20)
+	serve("/fail", 3)
+	serve("/slow", 2)
+	serve("/missing", 2)
+
+	// Assert
+	counts := map[string]int{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var entry struct {
+			Path string `json:"path"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		counts[entry.Path]++
+	}
+	assert.Equal(t, map[string]int{"/ok": 4, "/fail": 3, "/slow": 2, "/missing": 2}, counts)
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/ctxutil"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/httperr"
+)
+
+// RequireRole rejects requests whose token role is not one of roles.
+// Must run after AuthMiddleware.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(roles))
+	for _, role := range roles {
+		allowed[role] = true
+	}
+
+	return func(c *gin.Context) {
+		if role, _ := ctxutil.Role(c); !allowed[role] {
+			httperr.Abort(c, http.StatusForbidden, "Insufficient permissions")
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireTenant rejects tokens without a tenant_id claim while enabled() reports
+// multi-tenancy is on. Anonymous callers (no token at all) are let through.
+// Must run after AuthMiddleware.
+func RequireTenant(enabled func() bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if tenantID, _ := ctxutil.TenantID(c); enabled() && tenantID == "" && !ctxutil.IsAnonymous(c) {
+			httperr.Abort(c, http.StatusForbidden, "Token is missing a tenant")
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireUser rejects service tokens and anonymous callers on user-scoped data endpoints.
+// Must run after AuthMiddleware.
+func RequireUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if ctxutil.IsService(c) {
+			httperr.Abort(c, http.StatusForbidden, "Service tokens cannot access user data")
+			return
+		}
+		if ctxutil.IsAnonymous(c) {
+			httperr.Abort(c, http.StatusUnauthorized, "Authorization header is missing")
+			return
+		}
+		c.Next()
+	}
+}
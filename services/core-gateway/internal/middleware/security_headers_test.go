@@ -0,0 +1,54 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func serveSecured(cfg middleware.SecurityHeadersConfig, forwardedProto string) http.Header {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.SecurityHeaders(func() middleware.SecurityHeadersConfig { return cfg }))
+	r.POST("/auth/login", middleware.NoStore(), func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"token": "t"}) })
+
+	req, _ := http.NewRequest("POST", "/auth/login", nil)
+	if forwardedProto != "" {
+		req.Header.Set("X-Forwarded-Proto", forwardedProto)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Header()
+}
+
+func TestSecurityHeaders_Defaults(t *testing.T) {
+	h := serveSecured(middleware.SecurityHeadersConfig{}, "")
+
+	assert.Equal(t, "nosniff", h.Get("X-Content-Type-Options"))
+	assert.Equal(t, "DENY", h.Get("X-Frame-Options"))
+	assert.Equal(t, "no-referrer", h.Get("Referrer-Policy"))
+	assert.Empty(t, h.Get("Strict-Transport-Security"), "HSTS is off by default")
+	assert.Equal(t, "no-store", h.Get("Cache-Control"))
+}
+
+func TestSecurityHeaders_Configured(t *testing.T) {
+	cfg := middleware.SecurityHeadersConfig{
+		FrameOptions:          "SAMEORIGIN",
+		ReferrerPolicy:        "strict-origin-when-cross-origin",
+		HSTSMaxAge:            365 * 24 * time.Hour,
+		HSTSIncludeSubdomains: true,
+	}
+
+	overTLS := serveSecured(cfg, "https")
+	plain := serveSecured(cfg, "")
+
+	assert.Equal(t, "SAMEORIGIN", overTLS.Get("X-Frame-Options"))
+	assert.Equal(t, "strict-origin-when-cross-origin", overTLS.Get("Referrer-Policy"))
+	assert.Equal(t, "max-age=31536000; includeSubDomains", overTLS.Get("Strict-Transport-Security"))
+	assert.Empty(t, plain.Get("Strict-Transport-Security"), "HSTS is only sent over TLS")
+}
@@ -0,0 +1,69 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/smtp"
+	"strings"
+)
+
+// ErrNoRecipient is returned when a user has no email address configured
+var ErrNoRecipient = errors.New("no email address for user")
+
+// EmailOptions configures the email channel
+type EmailOptions struct {
+	// SMTPAddr is host:port of the mail server
+	SMTPAddr string
+	From     string
+	// Username and Password enable PLAIN auth (empty = no auth)
+	Username string
+	Password string
+	// Recipients maps user IDs to email addresses; read per message so reloads apply
+	Recipients func() map[string]string
+	// Subject of every notification (default "Woorung-Gaksi notification")
+	Subject string
+	// SendMail defaults to smtp.SendMail (tests pass a fake)
+	SendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// Email sends notifications as plain-text mail
+type Email struct {
+	opts EmailOptions
+}
+
+func NewEmail(opts EmailOptions) *Email {
+	if opts.Recipients == nil {
+		opts.Recipients = func() map[string]string { return nil }
+	}
+	if opts.Subject == "" {
+		opts.Subject = "Woorung-Gaksi notification"
+	}
+	if opts.SendMail == nil {
+		opts.SendMail = smtp.SendMail
+	}
+	return &Email{opts: opts}
+}
+
+func (e *Email) Notify(ctx context.Context, userID, message string) error {
+	to := e.opts.Recipients()[userID]
+	if to == "" {
+		return ErrNoRecipient
+	}
+	// Header values come from config, but refuse line breaks all the same
+	if strings.ContainsAny(to+e.opts.From, "\r\n") {
+		return fmt.Errorf("invalid email address for %s", userID)
+	}
+
+	var auth smtp.Auth
+	if e.opts.Username != "" {
+		host, _, _ := strings.Cut(e.opts.SMTPAddr, ":")
+		auth = smtp.PlainAuth("", e.opts.Username, e.opts.Password, host)
+	}
+	msg := "From: " + e.opts.From + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + e.opts.Subject + "\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" + message + "\r\n"
+	return e.opts.SendMail(e.opts.SMTPAddr, auth, e.opts.From, []string{to}, []byte(msg))
+}
@@ -0,0 +1,135 @@
+// Package notify reaches users outside the request they are making, e.g.
+// when their quota runs out or an async task finishes. Each event is sent
+// over one channel (Telegram, email, ...) chosen per user or per event.
+package notify
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Events users can be notified about
+const (
+	// EventQuotaExceeded fires when a user's daily budget rejects a request
+	EventQuotaExceeded = "quota_exceeded"
+	// EventTaskDone fires when an async agent result couldn't be pushed to a live channel
+	EventTaskDone = "task_done"
+)
+
+// Channel names used in Routes
+const (
+	ChannelNone     = "none"
+	ChannelTelegram = "telegram"
+	ChannelEmail    = "email"
+)
+
+// DefaultCooldown is how long an event is not repeated to the same user
+const DefaultCooldown = time.Hour
+
+// Notifier delivers a message to a user over one channel
+type Notifier interface {
+	Notify(ctx context.Context, userID, message string) error
+}
+
+// Noop discards every message
+type Noop struct{}
+
+func (Noop) Notify(context.Context, string, string) error { return nil }
+
+// Routes picks the channel for an event: the user's channel, else the
+// event's, else Default ("" = ChannelNone)
+type Routes struct {
+	Default string
+	Events  map[string]string
+	Users   map[string]string
+}
+
+// channel returns the channel name for event and userID
+func (r Routes) channel(event, userID string) string {
+	if ch := r.Users[userID]; ch != "" {
+		return ch
+	}
+	if ch := r.Events[event]; ch != "" {
+		return ch
+	}
+	if r.Default != "" {
+		return r.Default
+	}
+	return ChannelNone
+}
+
+// Options configures a Router
+type Options struct {
+	// Routes is read per event so config reloads apply (nil = nothing is sent)
+	Routes func() Routes
+	// Cooldown suppresses repeats of one event to one user (0 = DefaultCooldown)
+	Cooldown time.Duration
+	Now      func() time.Time
+}
+
+// Router sends events over the channel Routes selects
+type Router struct {
+	channels map[string]Notifier
+	opts     Options
+
+	mu   sync.Mutex
+	sent map[string]time.Time
+}
+
+// NewRouter routes to channels by name; unknown names are logged and dropped
+func NewRouter(channels map[string]Notifier, opts Options) *Router {
+	if opts.Routes == nil {
+		opts.Routes = func() Routes { return Routes{} }
+	}
+	if opts.Cooldown <= 0 {
+		opts.Cooldown = DefaultCooldown
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &Router{channels: channels, opts: opts, sent: map[string]time.Time{}}
+}
+
+// Send notifies userID of event unless it already did within the cooldown.
+// Delivery failures are logged, never returned: notifications are best effort.
+// It is safe on a nil Router.
+func (r *Router) Send(ctx context.Context, event, userID, message string) {
+	if r == nil || userID == "" {
+		return
+	}
+	name := r.opts.Routes().channel(event, userID)
+	if name == ChannelNone {
+		return
+	}
+	n, ok := r.channels[name]
+	if !ok {
+		log.Printf("[Notify] Unknown channel %q for %s; dropping notification to %s", name, event, userID)
+		return
+	}
+	if !r.admit(event, userID) {
+		return
+	}
+	if err := n.Notify(ctx, userID, message); err != nil {
+		log.Printf("[Notify] %s to %s via %s failed: %v", event, userID, name, err)
+	}
+}
+
+// admit records event for userID, refusing repeats within the cooldown
+func (r *Router) admit(event, userID string) bool {
+	key := event + "\x00" + userID
+	now := r.opts.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if last, ok := r.sent[key]; ok && now.Sub(last) < r.opts.Cooldown {
+		return false
+	}
+	for k, t := range r.sent {
+		if now.Sub(t) >= r.opts.Cooldown {
+			delete(r.sent, k)
+		}
+	}
+	r.sent[key] = now
+	return true
+}
@@ -0,0 +1,119 @@
+package notify_test
+
+import (
+	"context"
+	"net/smtp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/notify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeNotifier captures what it was asked to deliver
+type fakeNotifier struct {
+	mu   sync.Mutex
+	sent []string
+}
+
+func (f *fakeNotifier) Notify(_ context.Context, userID, message string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, userID+": "+message)
+	return nil
+}
+
+func (f *fakeNotifier) messages() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.sent...)
+}
+
+func TestRouter_SelectsChannel(t *testing.T) {
+	// Arrange
+	telegram, email := &fakeNotifier{}, &fakeNotifier{}
+	router := notify.NewRouter(map[string]notify.Notifier{
+		notify.ChannelTelegram: telegram,
+		notify.ChannelEmail:    email,
+	}, notify.Options{Routes: func() notify.Routes {
+		return notify.Routes{
+			Default: notify.ChannelTelegram,
+			Events:  map[string]string{notify.EventTaskDone: notify.ChannelEmail},
+			Users:   map[string]string{"alice": notify.ChannelTelegram, "bob": notify.ChannelNone},
+		}
+	}})
+	ctx := context.Background()
+
+	// Act
+	router.Send(ctx, notify.EventQuotaExceeded, "carol", "quota")
+	router.Send(ctx, notify.EventTaskDone, "carol", "done")
+	router.Send(ctx, notify.EventTaskDone, "alice", "done")
+	router.Send(ctx, notify.EventTaskDone, "bob", "done")
+
+	// Assert: user beats event beats default; "none" sends nothing
+	assert.Equal(t, []string{"carol: quota", "alice: done"}, telegram.messages())
+	assert.Equal(t, []string{"carol: done"}, email.messages())
+}
+
+func TestRouter_Cooldown(t *testing.T) {
+	// Arrange
+	fake := &fakeNotifier{}
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	router := notify.NewRouter(map[string]notify.Notifier{notify.ChannelEmail: fake}, notify.Options{
+		Routes:   func() notify.Routes { return notify.Routes{Default: notify.ChannelEmail} },
+		Cooldown: time.Hour,
+		Now:      func() time.Time { return now },
+	})
+	ctx := context.Background()
+
+	// Act
+	router.Send(ctx, notify.EventQuotaExceeded, "alice", "first")
+	router.Send(ctx, notify.EventQuotaExceeded, "alice", "repeat")
+	router.Send(ctx, notify.EventQuotaExceeded, "bob", "other user")
+	router.Send(ctx, notify.EventTaskDone, "alice", "other event")
+	now = now.Add(time.Hour)
+	router.Send(ctx, notify.EventQuotaExceeded, "alice", "after cooldown")
+
+	// Assert
+	assert.Equal(t, []string{"alice: first", "bob: other user", "alice: other event", "alice: after cooldown"}, fake.messages())
+}
+
+func TestRouter_NilAndUnknownChannel(t *testing.T) {
+	var none *notify.Router
+	none.Send(context.Background(), notify.EventTaskDone, "alice", "ignored")
+
+	router := notify.NewRouter(nil, notify.Options{Routes: func() notify.Routes { return notify.Routes{Default: "pager"} }})
+	router.Send(context.Background(), notify.EventTaskDone, "alice", "dropped")
+}
+
+func TestEmail_Notify(t *testing.T) {
+	// Arrange
+	var gotAddr, gotFrom string
+	var gotTo []string
+	var gotMsg string
+	email := notify.NewEmail(notify.EmailOptions{
+		SMTPAddr:   "smtp.example.com:587",
+		From:       "bot@example.com",
+		Recipients: func() map[string]string { return map[string]string{"alice": "alice@example.com"} },
+		SendMail: func(addr string, _ smtp.Auth, from string, to []string, msg []byte) error {
+			gotAddr, gotFrom, gotTo, gotMsg = addr, from, to, string(msg)
+			return nil
+		},
+	})
+
+	// Act
+	err := email.Notify(context.Background(), "alice", "Your report is ready")
+	unknown := email.Notify(context.Background(), "bob", "hi")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "smtp.example.com:587", gotAddr)
+	assert.Equal(t, "bot@example.com", gotFrom)
+	assert.Equal(t, []string{"alice@example.com"}, gotTo)
+	assert.True(t, strings.HasPrefix(gotMsg, "From: bot@example.com\r\nTo: alice@example.com\r\nSubject: Woorung-Gaksi notification\r\n"))
+	assert.Contains(t, gotMsg, "\r\n\r\nYour report is ready\r\n")
+	assert.ErrorIs(t, unknown, notify.ErrNoRecipient)
+}
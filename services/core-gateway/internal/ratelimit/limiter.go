@@ -0,0 +1,137 @@
+// Package ratelimit provides a keyed, concurrency-safe token-bucket limiter.
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+type Config struct {
+	// Rate is the number of tokens added per second
+	Rate float64
+	// Burst is the bucket capacity (max requests allowed at once)
+	Burst int
+	// IdleTTL evicts buckets not used for this long. 0 disables eviction.
+	IdleTTL time.Duration
+	// Now overrides the clock (tests). Defaults to time.Now.
+	Now func() time.Time
+}
+
+type bucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// Limiter keeps one token bucket per key
+type Limiter struct {
+	cfg     Config
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	stop    chan struct{}
+	once    sync.Once
+}
+
+// New creates a limiter. When IdleTTL is set a background janitor evicts idle
+// keys until Stop is called.
+func New(cfg Config) *Limiter {
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	if cfg.Burst < 1 {
+		cfg.Burst = 1
+	}
+
+	l := &Limiter{
+		cfg:     cfg,
+		buckets: make(map[string]*bucket),
+		stop:    make(chan struct{}),
+	}
+
+	if cfg.IdleTTL > 0 {
+		go l.janitor(cfg.IdleTTL / 2)
+	}
+	return l
+}
+
+// Allow consumes a token for key. When the bucket is empty it returns false and
+// how long the caller should wait before a token becomes available.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	now := l.cfg.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.cfg.Burst), lastSeen: now}
+		l.buckets[key] = b
+	}
+
+	// Refill based on elapsed time
+	elapsed := now.Sub(b.lastSeen).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(float64(l.cfg.Burst), b.tokens+elapsed*l.cfg.Rate)
+	}
+	b.lastSeen = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	if l.cfg.Rate <= 0 {
+		return false, time.Duration(math.MaxInt64)
+	}
+	wait := time.Duration((1 - b.tokens) / l.cfg.Rate * float64(time.Second))
+	return false, wait
+}
+
+// Len returns the number of tracked keys
+func (l *Limiter) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.buckets)
+}
+
+// Sweep evicts keys idle for longer than IdleTTL and returns how many were removed
+func (l *Limiter) Sweep() int {
+	if l.cfg.IdleTTL <= 0 {
+		return 0
+	}
+	cutoff := l.cfg.Now().Add(-l.cfg.IdleTTL)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	removed := 0
+	for key, b := range l.buckets {
+		if b.lastSeen.Before(cutoff) {
+			delete(l.buckets, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+// Stop terminates the background janitor
+func (l *Limiter) Stop() {
+	l.once.Do(func() { close(l.stop) })
+}
+
+func (l *Limiter) janitor(interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			l.Sweep()
+		case <-l.stop:
+			return
+		}
+	}
+}
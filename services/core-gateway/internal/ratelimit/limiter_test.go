@@ -0,0 +1,121 @@
+package ratelimit_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *fakeClock) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
+
+func TestLimiter_BurstThenRefill(t *testing.T) {
+	// Arrange
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	l := ratelimit.New(ratelimit.Config{Rate: 2, Burst: 3, Now: clock.Now})
+	defer l.Stop()
+
+	// Act & Assert: burst of 3 allowed, 4th rejected
+	for i := 0; i < 3; i++ {
+		ok, _ := l.Allow("user_1")
+		assert.True(t, ok, "request %d within burst", i)
+	}
+	ok, retryAfter := l.Allow("user_1")
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, retryAfter, "One token takes 1/rate seconds")
+
+	// Keys are independent
+	ok, _ = l.Allow("user_2")
+	assert.True(t, ok)
+
+	// After refill, one more request is allowed
+	clock.Advance(500 * time.Millisecond)
+	ok, _ = l.Allow("user_1")
+	assert.True(t, ok)
+	ok, _ = l.Allow("user_1")
+	assert.False(t, ok)
+
+	// Refill never exceeds burst
+	clock.Advance(time.Hour)
+	for i := 0; i < 3; i++ {
+		ok, _ = l.Allow("user_1")
+		assert.True(t, ok)
+	}
+	ok, _ = l.Allow("user_1")
+	assert.False(t, ok)
+}
+
+func TestLimiter_Concurrency(t *testing.T) {
+	// Arrange: no refill, so exactly Burst requests may succeed per key
+	l := ratelimit.New(ratelimit.Config{Rate: 0, Burst: 50})
+	defer l.Stop()
+
+	var allowed atomic.Int64
+	var wg sync.WaitGroup
+
+	// Act: 20 goroutines x 10 requests on the same key, plus noise on other keys
+	for g := 0; g < 20; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 10; i++ {
+				if ok, _ := l.Allow("shared"); ok {
+					allowed.Add(1)
+				}
+				l.Allow(string(rune('a' + g)))
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	// Assert
+	assert.Equal(t, int64(50), allowed.Load())
+	assert.Equal(t, 21, l.Len())
+}
+
+func TestLimiter_EvictsIdleKeys(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	l := ratelimit.New(ratelimit.Config{Rate: 1, Burst: 1, IdleTTL: time.Minute, Now: clock.Now})
+	defer l.Stop()
+
+	l.Allow("old")
+	clock.Advance(45 * time.Second)
+	l.Allow("recent")
+	assert.Equal(t, 2, l.Len())
+
+	clock.Advance(30 * time.Second)
+	removed := l.Sweep()
+
+	assert.Equal(t, 1, removed)
+	assert.Equal(t, 1, l.Len())
+
+	// An evicted key starts with a full bucket again
+	ok, _ := l.Allow("old")
+	assert.True(t, ok)
+}
+
+func TestLimiter_JanitorRunsInBackground(t *testing.T) {
+	l := ratelimit.New(ratelimit.Config{Rate: 1, Burst: 1, IdleTTL: 20 * time.Millisecond})
+	defer l.Stop()
+
+	l.Allow("key")
+	assert.Eventually(t, func() bool { return l.Len() == 0 }, time.Second, 5*time.Millisecond)
+}
@@ -0,0 +1,120 @@
+// Package safeurl guards outbound requests to user-provided URLs against
+// server-side request forgery (SSRF).
+//
+// Check validates a URL before it is stored or used: http(s) only, no
+// credentials, a host on the operator's allow-list and never a private,
+// loopback or link-local address. Because an allowed name can still resolve
+// to an internal address, requests must also go through NewTransport, which
+// re-checks every address it actually connects to.
+package safeurl
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrNotAllowed is returned for URLs and addresses the guard refuses
+var ErrNotAllowed = errors.New("URL is not allowed")
+
+// blockedPrefixes are non-public ranges not covered by netip's predicates
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // "this network"
+	netip.MustParsePrefix("100.64.0.0/10"), // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),  // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"), // benchmarking
+	netip.MustParsePrefix("64:ff9b::/96"),  // NAT64, can embed any IPv4 address
+	netip.MustParsePrefix("fec0::/10"),     // deprecated site-local
+}
+
+// PublicIP reports whether addr is a publicly routable unicast address.
+// Loopback, private, link-local (incl. 169.254.169.254 metadata), multicast
+// and unspecified addresses are not.
+func PublicIP(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// Check parses raw and returns it if it may be requested.
+//
+// Allow-list entries are origins such as "https://agents.example.com" or
+// "https://*.agents.example.com" (any subdomain, not the apex). Scheme, host
+// and port must match an entry exactly. IP literals and localhost names are
+// refused even when allow-listed.
+func Check(raw string, allowed []string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Opaque != "" {
+		return nil, fmt.Errorf("%w: must be an absolute http(s) URL", ErrNotAllowed)
+	}
+	if u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+		return nil, fmt.Errorf("%w: credentials, query and fragment are not allowed", ErrNotAllowed)
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if addr, err := netip.ParseAddr(host); err == nil && !PublicIP(addr) {
+		return nil, fmt.Errorf("%w: %s is not a public address", ErrNotAllowed, host)
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return nil, fmt.Errorf("%w: %s is not a public address", ErrNotAllowed, host)
+	}
+	for _, entry := range allowed {
+		if originAllowed(u, entry) {
+			return u, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s is not in the allow-list", ErrNotAllowed, u.Host)
+}
+
+// originAllowed reports whether u's scheme and host[:port] match entry
+func originAllowed(u *url.URL, entry string) bool {
+	e, err := url.Parse(strings.TrimSpace(entry))
+	if err != nil || e.Host == "" || !strings.EqualFold(e.Scheme, u.Scheme) {
+		return false
+	}
+	host, pattern := strings.ToLower(u.Host), strings.ToLower(e.Host)
+	if suffix, ok := strings.CutPrefix(pattern, "*"); ok && strings.HasPrefix(suffix, ".") {
+		return strings.HasSuffix(host, suffix) && len(host) > len(suffix)
+	}
+	return host == pattern
+}
+
+// Control refuses connections to non-public addresses. It runs after DNS
+// resolution, so names that resolve (or rebind) to internal hosts are caught.
+func Control(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrNotAllowed, address)
+	}
+	if !PublicIP(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s is not a public address", ErrNotAllowed, addrPort.Addr())
+	}
+	return nil
+}
+
+// NewTransport returns a transport for user-provided URLs: it only connects
+// to public addresses, ignores proxy settings (a proxy would hide the real
+// destination from the guard) and doesn't reuse the default transport's pool.
+func NewTransport() *http.Transport {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: Control}
+	return &http.Transport{
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+}
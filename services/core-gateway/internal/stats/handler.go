@@ -0,0 +1,138 @@
+// Package stats serves the caller's own usage figures at GET /api/v1/stats.
+package stats
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/conversation"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/ctxutil"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/httperr"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/usage"
+)
+
+// DefaultCacheTTL is how long history counts are reused before being recomputed
+const DefaultCacheTTL = 30 * time.Second
+
+// Options configures the stats handler; nil sources report zeros
+type Options struct {
+	// History provides message and thread counts (requires a database)
+	History conversation.Repository
+	// Usage provides today's characters and quota
+	Usage *usage.Tracker
+	// Budget returns a user's effective budget (nil = Usage.BudgetFor(role))
+	Budget func(ctx context.Context, userID, role string) usage.Budget
+	// CacheTTL for history counts (default 30s)
+	CacheTTL time.Duration
+}
+
+type Handler struct {
+	opts Options
+	now  func() time.Time
+
+	mu    sync.Mutex
+	cache map[conversation.Scope]historyCounts
+}
+
+type historyCounts struct {
+	messages  int
+	threads   int
+	expiresAt time.Time
+}
+
+func NewHandler(opts Options) *Handler {
+	if opts.CacheTTL <= 0 {
+		opts.CacheTTL = DefaultCacheTTL
+	}
+	return &Handler{opts: opts, now: time.Now, cache: map[conversation.Scope]historyCounts{}}
+}
+
+// Response is the body of GET /api/v1/stats
+type Response struct {
+	Messages   int        `json:"messages"`
+	Threads    int        `json:"threads"`
+	Characters Characters `json:"characters"`
+	Quota      Quota      `json:"quota"`
+}
+
+// Characters consumed today (prompt + response)
+type Characters struct {
+	Today    int `json:"today"`
+	Prompt   int `json:"prompt"`
+	Response int `json:"response"`
+}
+
+// Quota is today's character budget. DailyChars 0 means unlimited, and
+// Remaining is then omitted.
+type Quota struct {
+	DailyChars int       `json:"daily_chars"`
+	Remaining  *int      `json:"remaining,omitempty"`
+	ResetsAt   time.Time `json:"resets_at"`
+}
+
+// Get returns the caller's stats. New users get zeros.
+func (h *Handler) Get(c *gin.Context) {
+	scope := conversation.ScopeFrom(c)
+
+	counts, err := h.historyCounts(c, scope)
+	if err != nil {
+		log.Printf("[Stats] Failed to count history for %s: %v", scope.UserID, err)
+		httperr.Respond(c, http.StatusInternalServerError, "Failed to load stats")
+		return
+	}
+
+	resp := Response{Messages: counts.messages, Threads: counts.threads}
+	if h.opts.Usage != nil {
+		snap := h.opts.Usage.Usage(usage.Key(scope.TenantID, scope.UserID))
+		role, _ := ctxutil.Role(c)
+		budget := h.opts.Usage.BudgetFor(role)
+		if h.opts.Budget != nil {
+			budget = h.opts.Budget(c.Request.Context(), scope.UserID, role)
+		}
+
+		resp.Characters = Characters{Today: snap.Chars, Prompt: snap.PromptChars, Response: snap.ResponseChars}
+		resp.Quota = Quota{DailyChars: budget.DailyChars, ResetsAt: snap.ResetsAt}
+		if budget.DailyChars > 0 {
+			remaining := max(budget.DailyChars-snap.Chars, 0)
+			resp.Quota.Remaining = &remaining
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// historyCounts aggregates the user's threads, reusing a recent result
+func (h *Handler) historyCounts(c *gin.Context, scope conversation.Scope) (historyCounts, error) {
+	if h.opts.History == nil {
+		return historyCounts{}, nil
+	}
+
+	h.mu.Lock()
+	cached, ok := h.cache[scope]
+	h.mu.Unlock()
+	if ok && h.now().Before(cached.expiresAt) {
+		return cached, nil
+	}
+
+	threads, err := h.opts.History.ListThreads(c.Request.Context(), scope)
+	if err != nil {
+		return historyCounts{}, err
+	}
+	counts := historyCounts{threads: len(threads), expiresAt: h.now().Add(h.opts.CacheTTL)}
+	for _, t := range threads {
+		counts.messages += t.MessageCount
+	}
+
+	h.mu.Lock()
+	for s, old := range h.cache {
+		if !h.now().Before(old.expiresAt) {
+			delete(h.cache, s)
+		}
+	}
+	h.cache[scope] = counts
+	h.mu.Unlock()
+	return counts, nil
+}
@@ -0,0 +1,93 @@
+package stats_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/conversation"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/ctxutil"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/stats"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/usage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeHistory returns fixed threads per user and counts ListThreads calls
+type fakeHistory struct {
+	conversation.Repository
+	threads map[string][]conversation.Thread
+	calls   int
+}
+
+func (f *fakeHistory) ListThreads(ctx context.Context, scope conversation.Scope) ([]conversation.Thread, error) {
+	f.calls++
+	return f.threads[scope.UserID], nil
+}
+
+func statsRouter(h *stats.Handler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/stats", func(c *gin.Context) {
+		ctxutil.SetUserID(c, c.GetHeader("X-User"))
+		ctxutil.SetRole(c, "user")
+		h.Get(c)
+	})
+	return r
+}
+
+func getStats(t *testing.T, r *gin.Engine, userID string) stats.Response {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, "/stats", nil)
+	req.Header.Set("X-User", userID)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp stats.Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp
+}
+
+func TestStats_AggregatesUserData(t *testing.T) {
+	// Arrange
+	history := &fakeHistory{threads: map[string][]conversation.Thread{
+		"user_1": {{ID: "a", MessageCount: 4}, {ID: "b", MessageCount: 6}},
+	}}
+	tracker := usage.NewTracker(usage.Budget{DailyChars: 100}, nil)
+	tracker.Record("user_1", "user", "hello", "world!!")
+	r := statsRouter(stats.NewHandler(stats.Options{History: history, Usage: tracker}))
+
+	// Act
+	resp := getStats(t, r, "user_1")
+	again := getStats(t, r, "user_1")
+
+	// Assert
+	assert.Equal(t, 10, resp.Messages)
+	assert.Equal(t, 2, resp.Threads)
+	assert.Equal(t, stats.Characters{Today: 12, Prompt: 5, Response: 7}, resp.Characters)
+	assert.Equal(t, 100, resp.Quota.DailyChars)
+	require.NotNil(t, resp.Quota.Remaining)
+	assert.Equal(t, 88, *resp.Quota.Remaining)
+	assert.Equal(t, resp, again)
+	assert.Equal(t, 1, history.calls, "second request is served from the cache")
+}
+
+func TestStats_ZerosForNewUser(t *testing.T) {
+	// Arrange
+	history := &fakeHistory{}
+	tracker := usage.NewTracker(usage.Budget{}, nil)
+	r := statsRouter(stats.NewHandler(stats.Options{History: history, Usage: tracker}))
+
+	// Act
+	resp := getStats(t, r, "newcomer")
+
+	// Assert: unlimited quota has no remaining figure
+	assert.Zero(t, resp.Messages)
+	assert.Zero(t, resp.Threads)
+	assert.Zero(t, resp.Characters)
+	assert.Zero(t, resp.Quota.DailyChars)
+	assert.Nil(t, resp.Quota.Remaining)
+}
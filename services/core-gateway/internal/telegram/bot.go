@@ -1,66 +1,211 @@
 package telegram
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/google/uuid"
 	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/agent"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/conversation"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/telegramlink"
 )
 
+// AccessDeniedMessage is sent to chats outside the whitelist when Options.ReplyAccessDenied is set
+const AccessDeniedMessage = "🚫 Access Denied. You are not authorized to use Woorung-Gaksi."
+
+// PartialReplyNotice is appended to replies the agent gave despite an error
+const PartialReplyNotice = "⚠️ The agent hit an error, so this reply may be incomplete."
+
+// UserID owns the conversations of unlinked Telegram users; each chat ID is a thread
+const UserID = "telegram_user"
+
+// API is the part of the Bot API the bot uses
+// (*tgbotapi.BotAPI satisfies it; tests pass a fake)
+type API interface {
+	Sender
+	Poller
+}
+
 type Bot struct {
-	api     *tgbotapi.BotAPI
-	service agent.Service
-	allowedChatID int64
+	api       API
+	service   agent.Service
+	allowed   Whitelist
+	opts      Options
+	outage    *OutageGate
+	receiver  *Receiver
+	coalescer *Coalescer
+	inflight  Inflight
+}
+
+// Options configures optional bot behavior
+type Options struct {
+	// TruncateThreshold (characters) above which replies are truncated with a link
+	// to the full reply. 0 disables truncation. Requires Replies and PublicURL.
+	TruncateThreshold int
+	// PublicURL is the externally reachable gateway base URL used to build reply links
+	PublicURL string
+	// Replies persists full replies so truncated messages can link to them
+	Replies conversation.Repository
+	// AgentHealthy, when set, pauses prompts while it reports false: the chat gets
+	// one "temporarily unavailable" reply and later prompts are ignored until
+	// recovery. Commands (/status, ...) are still handled.
+	AgentHealthy func() bool
+	// EmptyReplyFallback is sent when the agent replies with no text, which
+	// Telegram would reject ("" = agent.DefaultEmptyReplyFallback)
+	EmptyReplyFallback string
+	// StreamReplies shows replies building up by editing one message as the
+	// agent streams, at most once per EditInterval (0 = DefaultEditInterval)
+	StreamReplies bool
+	EditInterval  time.Duration
+	// Links attributes messages from linked Telegram users to their gateway
+	// account and enables /link (nil = everyone is UserID)
+	Links *telegramlink.Service
+	// PollTimeout is the long-poll timeout in seconds (0 = DefaultPollTimeout);
+	// shutdown may wait this long for the poll in flight
+	PollTimeout int
+	// CoalesceWindow joins messages one sender sends less than this apart in a
+	// chat into one prompt, sent once they are quiet (0 = every message is its
+	// own prompt). A command answers the chat's held prompts first.
+	CoalesceWindow time.Duration
+	// History stores /reset markers; asks then carry the chat's last reset so
+	// the agent drops earlier turns (nil = /reset is unavailable)
+	History conversation.Repository
+	// ReplyAccessDenied answers chats outside the whitelist with AccessDeniedMessage
+	ReplyAccessDenied bool
+	// AskTimeout bounds one agent call, including its wait for a pool slot and,
+	// with StreamReplies, the whole stream (0 = DefaultAskTimeout)
+	AskTimeout time.Duration
+}
+
+// DefaultAskTimeout bounds agent calls when Options.AskTimeout is unset
+const DefaultAskTimeout = 3 * time.Minute
+
+func (b *Bot) askTimeout() time.Duration {
+	if b.opts.AskTimeout > 0 {
+		return b.opts.AskTimeout
+	}
+	return DefaultAskTimeout
 }
 
 // NewBot creates a new Telegram Bot instance
-func NewBot(token string, allowedChatID int64, service agent.Service) (*Bot, error) {
+func NewBot(token string, allowed Whitelist, service agent.Service, opts Options) (*Bot, error) {
 	api, err := tgbotapi.NewBotAPI(token)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create bot API: %w", err)
 	}
 
 	log.Printf("Authorized on account %s", api.Self.UserName)
+	return NewBotWithAPI(api, allowed, service, opts), nil
+}
 
-	return &Bot{
-		api:           api,
-		service:       service,
-		allowedChatID: allowedChatID,
-	}, nil
+// NewBotWithAPI creates a Bot that talks to Telegram through api
+func NewBotWithAPI(api API, allowed Whitelist, service agent.Service, opts Options) *Bot {
+	if len(allowed) == 0 {
+		log.Println("⚠️ ==============================================================")
+		log.Println("⚠️ [Telegram] OPEN ACCESS: no allowed chat ID set, ANY chat can")
+		log.Println("⚠️ talk to the agent. Set TELEGRAM_ALLOWED_ID before deploying.")
+		log.Println("⚠️ ==============================================================")
+	}
+
+	bot := &Bot{
+		api:      api,
+		service:  service,
+		allowed:  allowed,
+		opts:     opts,
+		receiver: NewReceiver(api, opts.PollTimeout),
+	}
+	if opts.AgentHealthy != nil {
+		bot.outage = NewOutageGate(opts.AgentHealthy)
+	}
+	if opts.CoalesceWindow > 0 {
+		// The coalescer flushes on its own goroutine; run the reply under inflight all the same
+		bot.coalescer = NewCoalescer(opts.CoalesceWindow, func(msg *tgbotapi.Message) {
+			bot.handle(msg, bot.handleMessage)
+		})
+	}
+	return bot
 }
 
 // Start polling for updates
 func (b *Bot) Start() {
-	u := tgbotapi.NewUpdate(0)
-	u.Timeout = 60
+	b.receiver.Start(b.handleUpdate)
+}
 
-	updates := b.api.GetUpdatesChan(u)
+// Stop stops polling for updates and waits for the poll and the replies in
+// flight, at most until ctx is done. Messages still held by the coalescer are
+// dropped. It is safe on a nil Bot.
+func (b *Bot) Stop(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+	return errors.Join(b.receiver.Stop(ctx), b.inflight.Wait(ctx))
+}
 
-	// Process updates in background
-	go func() {
-		for update := range updates {
-			if update.Message == nil { // ignore any non-Message updates
-				continue
-			}
+// handle runs fn for msg in the background unless the bot is stopping
+func (b *Bot) handle(msg *tgbotapi.Message, fn func(*tgbotapi.Message)) {
+	if !b.inflight.Go(func() { fn(msg) }) {
+		log.Printf("[Telegram] Shutting down, dropped message from ChatID: %d", msg.Chat.ID)
+	}
+}
 
-			// Security Check: Whitelist
-			if b.allowedChatID != 0 && update.Message.Chat.ID != b.allowedChatID {
-				log.Printf("[Telegram] Unauthorized access attempt from ChatID: %d (User: %s)", update.Message.Chat.ID, update.Message.From.UserName)
-				// msg := tgbotapi.NewMessage(update.Message.Chat.ID, "🚫 Access Denied. You are not authorized to use Woorung-Gaksi.")
-				// b.api.Send(msg)
-				continue
-			}
+func (b *Bot) handleUpdate(update tgbotapi.Update) {
+	if update.Message == nil { // ignore any non-Message updates
+		return
+	}
+
+	// Security Check: Whitelist
+	if !b.allowed.Allows(update.Message.Chat.ID) {
+		log.Printf("[Telegram] Unauthorized access attempt from ChatID: %d (User: %s)", update.Message.Chat.ID, update.Message.From.UserName)
+		if b.opts.ReplyAccessDenied {
+			b.api.Send(tgbotapi.NewMessage(update.Message.Chat.ID, AccessDeniedMessage))
+		}
+		return
+	}
 
-			// Handle message
-			go b.handleMessage(update.Message)
+	// Commands are answered by the bot itself, even during an agent outage.
+	// Prompts still held by the coalescer go to the agent first, on the same
+	// goroutine, so e.g. /reset cannot overtake them.
+	if update.Message.IsCommand() {
+		var held []*tgbotapi.Message
+		if b.coalescer != nil {
+			held = b.coalescer.Flush(update.Message.Chat.ID)
 		}
-	}()
+		b.handle(update.Message, func(cmd *tgbotapi.Message) {
+			for _, prompt := range held {
+				b.handleMessage(prompt)
+			}
+			b.handleCommand(cmd)
+		})
+		return
+	}
+
+	admit, notify := b.outage.Admit(update.Message.Chat.ID, false)
+	if notify {
+		b.api.Send(tgbotapi.NewMessage(update.Message.Chat.ID, UnavailableMessage))
+	}
+	if !admit {
+		log.Printf("[Telegram] Agent unavailable, ignoring message from ChatID: %d", update.Message.Chat.ID)
+		return
+	}
+
+	// Handle message
+	if b.coalescer != nil {
+		b.coalescer.Add(update.Message)
+		return
+	}
+	b.handle(update.Message, b.handleMessage)
 }
 
 func (b *Bot) handleMessage(msg *tgbotapi.Message) {
-	log.Printf("[Telegram] Received: %s", msg.Text)
+	ctx, requestID := agentContext()
+	log.Printf("[Telegram] Received (request_id=%s): %s", requestID, msg.Text)
 
 	// Send "Typing..." action
 	action := tgbotapi.NewChatAction(msg.Chat.ID, tgbotapi.ChatTyping)
@@ -68,25 +213,167 @@ func (b *Bot) handleMessage(msg *tgbotapi.Message) {
 
 	// Use ChatID as ThreadID to maintain persistent conversation for this chat
 	threadID := strconv.FormatInt(msg.Chat.ID, 10)
-	
-	// Create context/timeout if needed in service, but for now just call
-	response, _, err := b.service.Ask(msg.Text, "telegram_user", threadID)
-	
+	if err := conversation.ValidateThreadID(threadID); err != nil {
+		log.Printf("[Telegram] Rejected chat %d: %v", msg.Chat.ID, err)
+		return
+	}
+
+	userID := b.userID(msg)
+	agentThreadID, err := agent.AnchoredThreadID(ctx, b.opts.History, conversation.Scope{UserID: userID}, threadID)
 	if err != nil {
-		log.Printf("[Telegram] Error calling agent: %v", err)
+		log.Printf("[Telegram] Failed to look up the context anchor of chat %d (request_id=%s): %v", msg.Chat.ID, requestID, err)
+		b.api.Send(tgbotapi.NewMessage(msg.Chat.ID, "⚠️ Error: failed to load the conversation"))
+		return
+	}
+	// A stalled agent must not hold a streamed reply open either
+	ctx, cancel := context.WithTimeout(ctx, b.askTimeout())
+	defer cancel()
+	if b.opts.StreamReplies {
+		b.streamReply(ctx, requestID, msg.Chat.ID, msg.Text, userID, agentThreadID)
+		return
+	}
+
+	result, err := b.service.Ask(ctx, msg.Text, userID, agentThreadID)
+
+	if err != nil {
+		log.Printf("[Telegram] Error calling agent (request_id=%s): %v", requestID, err)
 		errMsg := tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("⚠️ Error: %v", err))
 		b.api.Send(errMsg)
 		return
 	}
 
-	// Send Response
-	reply := tgbotapi.NewMessage(msg.Chat.ID, response)
-	
-	// Enable Markdown parsing if response contains markdown (Agent usually does)
-	// But Telegram MarkdownV2 is strict. Markdown 'legacy' is safer or just text.
-	// PM Agent returns Github-style markdown which might conflict with V2.
-	// Let's try basic Markdown or just plain text for reliability first.
-	// reply.ParseMode = tgbotapi.ModeMarkdown 
+	response := result.Text
+	if result.Empty() {
+		log.Printf("[Telegram] Empty reply for ChatID %d; sending fallback", msg.Chat.ID)
+		response = agent.EmptyReplyFallback(b.opts.EmptyReplyFallback)
+	}
+	if result.Partial() {
+		log.Printf("[Telegram] Partial reply for ChatID %d: %s", msg.Chat.ID, result.AgentError)
+		response += "\n\n" + PartialReplyNotice
+	}
+
+	// Long replies are truncated with a link to the full text on the web
+	if b.canTruncate() && ShouldTruncate(response, b.opts.TruncateThreshold) {
+		response = b.truncateReply(userID, threadID, response)
+	}
+
+	// Send Response; Telegram rejects messages over MaxMessageLength, so long
+	// replies go out as several messages in order
+	if err := SendReply(b.api, msg.Chat.ID, response, result.Suggestions); err != nil {
+		log.Printf("[Telegram] Failed to send reply to ChatID %d: %v", msg.Chat.ID, err)
+	}
+}
+
+// agentContext tags one message's agent call with a fresh X-Request-ID, so it can
+// be found in the PM Agent's logs like a gateway request
+func agentContext() (context.Context, string) {
+	requestID := uuid.NewString()
+	headers := http.Header{}
+	headers.Set("X-Request-ID", requestID)
+	return agent.WithForwardedHeaders(context.Background(), headers), requestID
+}
+
+// streamReply edits the reply into place as the agent streams it
+func (b *Bot) streamReply(ctx context.Context, requestID string, chatID int64, text, userID, agentThreadID string) {
+	editor := NewStreamEditor(b.api, chatID, b.opts.EditInterval, nil)
+	if err := editor.Start(); err != nil {
+		log.Printf("[Telegram] Failed to post placeholder for ChatID %d: %v", chatID, err)
+		return
+	}
+
+	events, err := b.service.AskStream(ctx, text, userID, agentThreadID)
+	if err != nil {
+		log.Printf("[Telegram] Error calling agent (request_id=%s): %v", requestID, err)
+		editor.Append(fmt.Sprintf("⚠️ Error: %v", err))
+		editor.Finish()
+		return
+	}
+
+	for ev := range events {
+		if ev.Err != nil {
+			log.Printf("[Telegram] Stream for ChatID %d ended early (request_id=%s): %v", chatID, requestID, ev.Err)
+			editor.Append(fmt.Sprintf("\n\n⚠️ Error: %v", ev.Err))
+			break
+		}
+		switch ev.Type {
+		case agent.EventThinking, agent.EventTool, agent.EventResult, agent.EventDone:
+			continue
+		}
+		if err := editor.Append(ev.Data); err != nil {
+			log.Printf("[Telegram] Failed to edit streamed reply for ChatID %d: %v", chatID, err)
+		}
+	}
+	if strings.TrimSpace(editor.Text()) == "" {
+		log.Printf("[Telegram] Empty reply for ChatID %d; sending fallback", chatID)
+		editor.Append(agent.EmptyReplyFallback(b.opts.EmptyReplyFallback))
+	}
+	if err := editor.Finish(); err != nil {
+		log.Printf("[Telegram] Failed to finalize streamed reply for ChatID %d: %v", chatID, err)
+	}
+}
+
+// userID is the gateway user msg is attributed to: the sender's linked account, else UserID
+func (b *Bot) userID(msg *tgbotapi.Message) string {
+	if msg.From == nil {
+		return UserID
+	}
+	return b.opts.Links.UserID(context.Background(), msg.From.ID, UserID)
+}
+
+// handleLink links the sender to the gateway account that issued the code
+func (b *Bot) handleLink(msg *tgbotapi.Message) {
+	if msg.From == nil {
+		return
+	}
+	text := LinkReply(context.Background(), b.opts.Links, strings.TrimSpace(msg.CommandArguments()), msg.From.ID)
+	b.api.Send(tgbotapi.NewMessage(msg.Chat.ID, text))
+}
+
+// handleStatus answers /status locally, so it works during an agent outage
+func (b *Bot) handleStatus(msg *tgbotapi.Message) {
+	text := "✅ The agent is available."
+	if b.opts.AgentHealthy != nil && !b.opts.AgentHealthy() {
+		text = "⏸ The agent is temporarily unavailable."
+	}
+	b.api.Send(tgbotapi.NewMessage(msg.Chat.ID, text))
+}
+
+// Notify delivers an async agent result to the chat it belongs to. Results of
+// linked users are delivered to their private chat (chat ID = Telegram user ID).
+func (b *Bot) Notify(result agent.CallbackResult) bool {
+	chatID, err := strconv.ParseInt(result.ThreadID, 10, 64)
+	if err != nil || !b.allowed.Allows(chatID) {
+		return false
+	}
+	if result.UserID != UserID && b.opts.Links.UserID(context.Background(), chatID, UserID) != result.UserID {
+		return false
+	}
+
+	if err := SendReply(b.api, chatID, result.Reply, result.Suggestions); err != nil {
+		log.Printf("[Telegram] Failed to deliver callback to ChatID %d: %v", chatID, err)
+		return false
+	}
+	return true
+}
+
+func (b *Bot) canTruncate() bool {
+	return b.opts.TruncateThreshold > 0 && b.opts.Replies != nil && b.opts.PublicURL != ""
+}
+
+// truncateReply stores the full reply and returns the truncated text with a link.
+// Falls back to the full reply if it cannot be stored.
+func (b *Bot) truncateReply(userID, threadID, response string) string {
+	stored := &conversation.Message{
+		ThreadID: threadID,
+		UserID:   userID,
+		Role:     conversation.RoleAssistant,
+		Content:  response,
+	}
+	if err := b.opts.Replies.SaveMessage(context.Background(), stored); err != nil {
+		log.Printf("[Telegram] Failed to store full reply, sending untruncated: %v", err)
+		return response
+	}
 
-	b.api.Send(reply)
+	link := strings.TrimRight(b.opts.PublicURL, "/") + "/replies/" + threadID + "/" + stored.ID
+	return TruncateWithLink(response, b.opts.TruncateThreshold, link)
 }
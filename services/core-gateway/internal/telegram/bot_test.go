@@ -0,0 +1,157 @@
+package telegram_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/google/uuid"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/agent"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/conversation"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/telegram"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBotAPI delivers queued updates and records the messages the bot sends
+type fakeBotAPI struct {
+	updates chan tgbotapi.Update
+
+	mu   sync.Mutex
+	sent []tgbotapi.MessageConfig
+}
+
+func newFakeBotAPI(updates ...tgbotapi.Update) *fakeBotAPI {
+	api := &fakeBotAPI{updates: make(chan tgbotapi.Update, len(updates))}
+	for _, u := range updates {
+		api.updates <- u
+	}
+	return api
+}
+
+func (f *fakeBotAPI) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if msg, ok := c.(tgbotapi.MessageConfig); ok {
+		f.sent = append(f.sent, msg)
+	}
+	return tgbotapi.Message{MessageID: len(f.sent)}, nil
+}
+
+func (f *fakeBotAPI) GetUpdatesChan(tgbotapi.UpdateConfig) tgbotapi.UpdatesChannel {
+	return f.updates
+}
+
+func (f *fakeBotAPI) StopReceivingUpdates() {
+	close(f.updates)
+}
+
+func (f *fakeBotAPI) Sent() []tgbotapi.MessageConfig {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]tgbotapi.MessageConfig(nil), f.sent...)
+}
+
+// runBot lets the bot handle every update queued on api and waits for its replies
+func runBot(t *testing.T, api *fakeBotAPI, allowed telegram.Whitelist, service agent.Service, opts telegram.Options) {
+	t.Helper()
+	bot := telegram.NewBotWithAPI(api, allowed, service, opts)
+	bot.Start()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, bot.Stop(ctx))
+}
+
+// textUpdate is a message from a private chat, whose ID is the sender's ID
+func textUpdate(chatID int64, text string) tgbotapi.Update {
+	return tgbotapi.Update{Message: &tgbotapi.Message{
+		Text: text,
+		Chat: &tgbotapi.Chat{ID: chatID},
+		From: &tgbotapi.User{ID: chatID, UserName: "someone"},
+	}}
+}
+
+func TestBot_AccessDenied(t *testing.T) {
+	tests := []struct {
+		name     string
+		reply    bool
+		expected []string
+	}{
+		{"replies when enabled", true, []string{telegram.AccessDeniedMessage}},
+		{"silent by default", false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange: chat 7 is outside the whitelist
+			api := newFakeBotAPI(textUpdate(7, "hello"))
+
+			// Act
+			runBot(t, api, telegram.Whitelist{42}, agent.NewEchoService(), telegram.Options{ReplyAccessDenied: tt.reply})
+
+			// Assert: the agent is never asked, so nothing but the notice is sent
+			var texts []string
+			for _, msg := range api.Sent() {
+				assert.Equal(t, int64(7), msg.ChatID)
+				texts = append(texts, msg.Text)
+			}
+			assert.Equal(t, tt.expected, texts)
+		})
+	}
+}
+
+func TestBot_AsksCarryRequestID(t *testing.T) {
+	// Arrange: the agent records the X-Request-ID of every ask
+	var mu sync.Mutex
+	var requestIDs []string
+	agentServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requestIDs = append(requestIDs, r.Header.Get("X-Request-ID"))
+		mu.Unlock()
+		w.Write([]byte(`{"reply":"ok"}`))
+	}))
+	defer agentServer.Close()
+	api := newFakeBotAPI(textUpdate(42, "first"), textUpdate(42, "second"))
+
+	// Act
+	runBot(t, api, telegram.Whitelist{42}, agent.NewAgentClient(agentServer.URL, agent.ClientOptions{}), telegram.Options{})
+
+	// Assert: Telegram sends none, so each ask gets its own
+	require.Len(t, requestIDs, 2)
+	for _, id := range requestIDs {
+		_, err := uuid.Parse(id)
+		assert.NoError(t, err, "request ID %q", id)
+	}
+	assert.NotEqual(t, requestIDs[0], requestIDs[1])
+}
+
+// slowAgent takes a moment to answer, so a reply racing it would overtake it
+type slowAgent struct{ agent.Service }
+
+func (s slowAgent) Ask(ctx context.Context, message, userID, threadID string) (*agent.Reply, error) {
+	time.Sleep(20 * time.Millisecond)
+	return s.Service.Ask(ctx, message, userID, threadID)
+}
+
+func TestBot_HeldPromptIsAnsweredBeforeCommand(t *testing.T) {
+	// Arrange: a prompt is held by the coalescer when /reset arrives
+	reset := textUpdate(42, "/reset")
+	reset.Message.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len("/reset")}}
+	api := newFakeBotAPI(textUpdate(42, "wrap up the release"), reset)
+
+	// Act
+	runBot(t, api, telegram.Whitelist{42}, slowAgent{agent.NewEchoService()}, telegram.Options{
+		CoalesceWindow: time.Hour,
+		History:        conversation.NewMemoryRepository(),
+	})
+
+	// Assert: the prompt reached the agent in its old context, then the reset ran
+	sent := api.Sent()
+	require.Len(t, sent, 2)
+	assert.Contains(t, sent[0].Text, "wrap up the release")
+	assert.Contains(t, sent[1].Text, "Conversation reset")
+}
@@ -0,0 +1,105 @@
+package telegram
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Coalescer joins rapid-fire messages from one sender in a chat into a single
+// prompt. A sender's messages are held until they have been quiet for the
+// window, then handed to flush as one message whose text is the texts joined
+// by newlines. Senders are kept apart, so in a group chat each prompt is still
+// attributed to the person who wrote it.
+type Coalescer struct {
+	window time.Duration
+	flush  func(*tgbotapi.Message)
+
+	mu      sync.Mutex
+	pending map[burstKey]*pendingPrompt
+	seq     int
+}
+
+// burstKey identifies one sender's burst in a chat
+type burstKey struct {
+	chatID, senderID int64
+}
+
+type pendingPrompt struct {
+	seq   int
+	last  *tgbotapi.Message
+	parts []string
+	timer *time.Timer
+}
+
+// NewCoalescer calls flush (on its own goroutine) once per burst of messages
+func NewCoalescer(window time.Duration, flush func(*tgbotapi.Message)) *Coalescer {
+	return &Coalescer{window: window, flush: flush, pending: map[burstKey]*pendingPrompt{}}
+}
+
+// Add holds msg and restarts its sender's quiet window
+func (c *Coalescer) Add(msg *tgbotapi.Message) {
+	key := burstKey{chatID: msg.Chat.ID}
+	if msg.From != nil {
+		key.senderID = msg.From.ID
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p, ok := c.pending[key]
+	if !ok {
+		c.seq++
+		p = &pendingPrompt{seq: c.seq}
+		c.pending[key] = p
+		p.timer = time.AfterFunc(c.window, func() { c.send(key, p) })
+	} else {
+		p.timer.Reset(c.window)
+	}
+	p.last = msg
+	p.parts = append(p.parts, msg.Text)
+}
+
+// Flush takes the chat's held prompts, one per sender in the order their
+// bursts began, instead of waiting for their windows. The caller handles
+// them itself, e.g. before a command so a /reset cannot overtake them.
+func (c *Coalescer) Flush(chatID int64) []*tgbotapi.Message {
+	c.mu.Lock()
+	var held []*pendingPrompt
+	for key, p := range c.pending {
+		if key.chatID == chatID {
+			delete(c.pending, key)
+			p.timer.Stop()
+			held = append(held, p)
+		}
+	}
+	c.mu.Unlock()
+
+	sort.Slice(held, func(i, j int) bool { return held[i].seq < held[j].seq })
+	prompts := make([]*tgbotapi.Message, 0, len(held))
+	for _, p := range held {
+		prompts = append(prompts, p.joined())
+	}
+	return prompts
+}
+
+// send flushes the burst's pending prompt if it is still p (a timer that
+// fired late must not cut the next burst short)
+func (c *Coalescer) send(key burstKey, p *pendingPrompt) {
+	c.mu.Lock()
+	if c.pending[key] != p {
+		c.mu.Unlock()
+		return
+	}
+	delete(c.pending, key)
+	c.mu.Unlock()
+	c.flush(p.joined())
+}
+
+// joined is the burst as one message, replying to its last part
+func (p *pendingPrompt) joined() *tgbotapi.Message {
+	joined := *p.last
+	joined.Text = strings.Join(p.parts, "\n")
+	return &joined
+}
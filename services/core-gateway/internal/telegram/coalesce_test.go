@@ -0,0 +1,87 @@
+package telegram_test
+
+import (
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/telegram"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func chatMessage(chatID int64, id int, text string) *tgbotapi.Message {
+	return &tgbotapi.Message{MessageID: id, Chat: &tgbotapi.Chat{ID: chatID}, Text: text}
+}
+
+func TestCoalescer_JoinsRapidMessages(t *testing.T) {
+	// Arrange
+	asks := make(chan *tgbotapi.Message, 10)
+	c := telegram.NewCoalescer(50*time.Millisecond, func(m *tgbotapi.Message) { asks <- m })
+
+	// Act: three messages, each well inside the window of the previous one
+	c.Add(chatMessage(1, 1, "Can you check"))
+	time.Sleep(10 * time.Millisecond)
+	c.Add(chatMessage(1, 2, "the staging deploy"))
+	time.Sleep(10 * time.Millisecond)
+	c.Add(chatMessage(1, 3, "from this morning?"))
+
+	// Assert: one ask with the texts joined, replying to the last message
+	select {
+	case m := <-asks:
+		assert.Equal(t, "Can you check\nthe staging deploy\nfrom this morning?", m.Text)
+		assert.Equal(t, 3, m.MessageID)
+	case <-time.After(time.Second):
+		t.Fatal("no coalesced ask")
+	}
+	select {
+	case m := <-asks:
+		t.Fatalf("unexpected second ask %q", m.Text)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestCoalescer_FlushAndSeparateChats(t *testing.T) {
+	// Arrange
+	asks := make(chan *tgbotapi.Message, 10)
+	c := telegram.NewCoalescer(time.Hour, func(m *tgbotapi.Message) { asks <- m })
+	c.Add(chatMessage(1, 1, "first"))
+	c.Add(chatMessage(2, 2, "other chat"))
+	c.Add(chatMessage(1, 3, "second"))
+
+	// Act: a command in chat 1 takes its held messages right away
+	flushed := c.Flush(1)
+	again := c.Flush(1)
+
+	// Assert: the caller handles them; the timer no longer will
+	require.Len(t, flushed, 1)
+	assert.Empty(t, again)
+	assert.Equal(t, "first\nsecond", flushed[0].Text)
+	assert.Equal(t, int64(1), flushed[0].Chat.ID)
+	other := c.Flush(2)
+	require.Len(t, other, 1)
+	assert.Equal(t, "other chat", other[0].Text)
+	assert.Empty(t, asks)
+}
+
+func TestCoalescer_KeepsSendersApart(t *testing.T) {
+	// Arrange: two people typing in the same group chat
+	c := telegram.NewCoalescer(time.Hour, func(*tgbotapi.Message) {})
+	from := func(m *tgbotapi.Message, senderID int64) *tgbotapi.Message {
+		m.From = &tgbotapi.User{ID: senderID}
+		return m
+	}
+	c.Add(from(chatMessage(-100, 1, "alice one"), 7))
+	c.Add(from(chatMessage(-100, 2, "bob one"), 8))
+	c.Add(from(chatMessage(-100, 3, "alice two"), 7))
+
+	// Act
+	prompts := c.Flush(-100)
+
+	// Assert: one prompt per sender, attributed to them, in the order they began
+	require.Len(t, prompts, 2)
+	assert.Equal(t, "alice one\nalice two", prompts[0].Text)
+	assert.Equal(t, int64(7), prompts[0].From.ID)
+	assert.Equal(t, "bob one", prompts[1].Text)
+	assert.Equal(t, int64(8), prompts[1].From.ID)
+}
@@ -0,0 +1,66 @@
+package telegram
+
+import (
+	"context"
+	"log"
+	"strconv"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/conversation"
+)
+
+// StartMessage greets a chat that sends /start
+const StartMessage = "👋 Hi! Send me a message and I'll pass it to the PM agent. Send /help to see the commands."
+
+// HelpMessage lists the commands the bot answers itself
+const HelpMessage = `Commands:
+/start - greeting
+/help - this list
+/reset - start a fresh conversation; earlier messages are no longer sent to the agent
+/status - whether the agent is available
+/link <code> - attribute your messages to your gateway account
+
+Anything else is sent to the agent.`
+
+// CommandReply is the reply to a command that needs no state; ok is false for
+// commands handled elsewhere
+func CommandReply(command string) (reply string, ok bool) {
+	switch command {
+	case "start":
+		return StartMessage, true
+	case "help":
+		return HelpMessage, true
+	case "reset", "status", "link":
+		return "", false
+	}
+	return "Unknown command /" + command + ". Send /help to see the commands.", true
+}
+
+// ResetReply marks a fresh start of the agent's context in the user's chat
+// thread and returns the chat reply
+func ResetReply(ctx context.Context, history conversation.Repository, userID, threadID string) string {
+	if history == nil {
+		return "Resetting the conversation is not enabled on this gateway."
+	}
+	if err := history.SaveMessage(ctx, conversation.ResetMarker(conversation.Scope{UserID: userID}, threadID)); err != nil {
+		log.Printf("[Telegram] Failed to reset thread %s of %s: %v", threadID, userID, err)
+		return "⚠️ Resetting failed, please try again later."
+	}
+	return "🧹 Conversation reset. The agent starts fresh from your next message."
+}
+
+// handleCommand answers a command locally; commands never reach the agent
+func (b *Bot) handleCommand(msg *tgbotapi.Message) {
+	switch msg.Command() {
+	case "status":
+		b.handleStatus(msg)
+	case "link":
+		b.handleLink(msg)
+	case "reset":
+		text := ResetReply(context.Background(), b.opts.History, b.userID(msg), strconv.FormatInt(msg.Chat.ID, 10))
+		b.api.Send(tgbotapi.NewMessage(msg.Chat.ID, text))
+	default:
+		text, _ := CommandReply(msg.Command())
+		b.api.Send(tgbotapi.NewMessage(msg.Chat.ID, text))
+	}
+}
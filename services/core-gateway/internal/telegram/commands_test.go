@@ -0,0 +1,58 @@
+package telegram_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/conversation"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/telegram"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandReply(t *testing.T) {
+	tests := []struct {
+		command  string
+		expected string
+		ok       bool
+	}{
+		{"start", telegram.StartMessage, true},
+		{"help", telegram.HelpMessage, true},
+		{"reset", "", false},
+		{"status", "", false},
+		{"link", "", false},
+		{"deploy", "Unknown command /deploy. Send /help to see the commands.", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			reply, ok := telegram.CommandReply(tt.command)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, reply)
+		})
+	}
+}
+
+func TestResetReply(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	history := conversation.NewMemoryRepository()
+	scope := conversation.Scope{UserID: "user_1"}
+
+	// Act
+	reply := telegram.ResetReply(ctx, history, "user_1", "42")
+
+	// Assert: the chat's thread got a context reset marker
+	assert.Contains(t, reply, "Conversation reset")
+	messages, err := history.ListMessages(ctx, scope, "42")
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, conversation.RoleContextReset, messages[0].Role)
+	assert.Equal(t, messages[0].ID, conversation.ContextAnchor(messages))
+}
+
+func TestResetReply_NoHistory(t *testing.T) {
+	reply := telegram.ResetReply(context.Background(), nil, "user_1", "42")
+
+	assert.Equal(t, "Resetting the conversation is not enabled on this gateway.", reply)
+}
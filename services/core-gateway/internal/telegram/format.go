@@ -0,0 +1,49 @@
+package telegram
+
+import (
+	"fmt"
+	"log"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/markdown"
+)
+
+// SendMarkdown sends msg, whose text is the agent's GitHub markdown, rendered as
+// MarkdownV2. The text goes out unformatted when escaping would push it past
+// MaxMessageLength or Telegram still rejects the formatting, so a reply is
+// never lost to its markup.
+func SendMarkdown(api Sender, msg tgbotapi.MessageConfig) (tgbotapi.Message, error) {
+	plain := msg.Text
+	formatted := markdown.ToTelegramV2(plain)
+	if len([]rune(formatted)) > MaxMessageLength {
+		return api.Send(msg)
+	}
+
+	msg.Text, msg.ParseMode = formatted, tgbotapi.ModeMarkdownV2
+	sent, err := api.Send(msg)
+	if err == nil {
+		return sent, nil
+	}
+	log.Printf("[Telegram] MarkdownV2 reply to ChatID %d rejected, resending as plain text: %v", msg.ChatID, err)
+	msg.Text, msg.ParseMode = plain, ""
+	return api.Send(msg)
+}
+
+// SendReply sends the agent's reply to chatID as MarkdownV2, split into as many
+// messages as Telegram's length limit requires. Follow-up suggestions become
+// reply buttons under the last message. Sending stops at the first failure.
+func SendReply(api Sender, chatID int64, text string, suggestions []string) error {
+	chunks := SplitMessage(text, MaxMessageLength)
+	for i, chunk := range chunks {
+		msg := tgbotapi.NewMessage(chatID, chunk)
+		if i == len(chunks)-1 {
+			if keyboard := SuggestionKeyboard(suggestions); keyboard != nil {
+				msg.ReplyMarkup = keyboard
+			}
+		}
+		if _, err := SendMarkdown(api, msg); err != nil {
+			return fmt.Errorf("send part %d/%d: %w", i+1, len(chunks), err)
+		}
+	}
+	return nil
+}
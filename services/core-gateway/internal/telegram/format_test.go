@@ -0,0 +1,85 @@
+package telegram_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/telegram"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// strictAPI rejects every formatted message, like Telegram does for markup it cannot parse
+type strictAPI struct{ fakeAPI }
+
+func (f *strictAPI) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	if msg, ok := c.(tgbotapi.MessageConfig); ok && msg.ParseMode != "" {
+		return tgbotapi.Message{}, errors.New("Bad Request: can't parse entities")
+	}
+	return f.fakeAPI.Send(c)
+}
+
+func TestSendMarkdown(t *testing.T) {
+	// Arrange
+	api := &fakeAPI{}
+
+	// Act
+	_, err := telegram.SendMarkdown(api, tgbotapi.NewMessage(42, "**Done** (v1.2)"))
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, api.sent, 1)
+	assert.Equal(t, `*Done* \(v1\.2\)`, api.sent[0].Text)
+	assert.Equal(t, tgbotapi.ModeMarkdownV2, api.sent[0].ParseMode)
+}
+
+func TestSendMarkdown_FallsBackToPlainText(t *testing.T) {
+	// Arrange
+	api := &strictAPI{}
+
+	// Act
+	_, err := telegram.SendMarkdown(api, tgbotapi.NewMessage(42, "**Done** (v1.2)"))
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, api.sent, 1)
+	assert.Equal(t, "**Done** (v1.2)", api.sent[0].Text)
+	assert.Empty(t, api.sent[0].ParseMode)
+}
+
+func TestSendMarkdown_TooLongOnceEscaped(t *testing.T) {
+	// Arrange: fits as plain text, but escaping every dot doubles it
+	api := &fakeAPI{}
+	text := strings.Repeat(".", telegram.MaxMessageLength)
+
+	// Act
+	_, err := telegram.SendMarkdown(api, tgbotapi.NewMessage(42, text))
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, api.sent, 1)
+	assert.Equal(t, text, api.sent[0].Text)
+	assert.Empty(t, api.sent[0].ParseMode)
+}
+
+func TestSendReply(t *testing.T) {
+	// Arrange: a reply longer than one message
+	api := &fakeAPI{}
+	text := strings.Repeat("word ", telegram.MaxMessageLength/5-1) + "\n\n**Done**"
+
+	// Act
+	err := telegram.SendReply(api, 42, text, []string{"Next"})
+
+	// Assert: every part is formatted, the keyboard sits under the last one
+	require.NoError(t, err)
+	require.Len(t, api.sent, 2)
+	for _, msg := range api.sent {
+		assert.Equal(t, int64(42), msg.ChatID)
+		assert.Equal(t, tgbotapi.ModeMarkdownV2, msg.ParseMode)
+	}
+	assert.Nil(t, api.sent[0].ReplyMarkup)
+	assert.NotNil(t, api.sent[1].ReplyMarkup)
+	assert.Equal(t, "*Done*", api.sent[1].Text)
+}
@@ -0,0 +1,51 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Inflight runs message handlers on their own goroutines so shutdown can wait
+// for replies that are still being generated
+type Inflight struct {
+	mu     sync.Mutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// Go runs fn in the background, or reports false once Wait has been called
+func (f *Inflight) Go(fn func()) bool {
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return false
+	}
+	f.wg.Add(1)
+	f.mu.Unlock()
+
+	go func() {
+		defer f.wg.Done()
+		fn()
+	}()
+	return true
+}
+
+// Wait refuses new handlers and waits for the running ones, at most until ctx is done
+func (f *Inflight) Wait(ctx context.Context) error {
+	f.mu.Lock()
+	f.closed = true
+	f.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		f.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("telegram replies still in flight: %w", ctx.Err())
+	}
+}
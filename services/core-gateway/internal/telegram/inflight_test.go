@@ -0,0 +1,35 @@
+package telegram_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/telegram"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInflight_WaitsForRunningHandlers(t *testing.T) {
+	// Arrange: one reply is still being generated
+	var inflight telegram.Inflight
+	release := make(chan struct{})
+	finished := false
+	require.True(t, inflight.Go(func() {
+		<-release
+		finished = true
+	}))
+
+	// Act
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	timedOut := inflight.Wait(ctx)
+	close(release)
+	waited := inflight.Wait(context.Background())
+
+	// Assert
+	assert.ErrorIs(t, timedOut, context.DeadlineExceeded)
+	assert.NoError(t, waited)
+	assert.True(t, finished)
+	assert.False(t, inflight.Go(func() {}), "no new handlers once shutdown began")
+}
@@ -0,0 +1,26 @@
+package telegram
+
+import tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+// maxSuggestionButtons keeps the reply keyboard compact on small screens
+const maxSuggestionButtons = 4
+
+// SuggestionKeyboard renders agent follow-up suggestions as a one-time reply
+// keyboard (one button per row). Returns nil when there are no suggestions.
+func SuggestionKeyboard(suggestions []string) *tgbotapi.ReplyKeyboardMarkup {
+	if len(suggestions) == 0 {
+		return nil
+	}
+	if len(suggestions) > maxSuggestionButtons {
+		suggestions = suggestions[:maxSuggestionButtons]
+	}
+
+	rows := make([][]tgbotapi.KeyboardButton, 0, len(suggestions))
+	for _, s := range suggestions {
+		rows = append(rows, tgbotapi.NewKeyboardButtonRow(tgbotapi.NewKeyboardButton(s)))
+	}
+
+	keyboard := tgbotapi.NewOneTimeReplyKeyboard(rows...)
+	keyboard.ResizeKeyboard = true
+	return &keyboard
+}
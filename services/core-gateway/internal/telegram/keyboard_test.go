@@ -0,0 +1,20 @@
+package telegram_test
+
+import (
+	"testing"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/telegram"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuggestionKeyboard(t *testing.T) {
+	assert.Nil(t, telegram.SuggestionKeyboard(nil), "No suggestions means no keyboard")
+
+	keyboard := telegram.SuggestionKeyboard([]string{"a", "b", "c", "d", "e"})
+	require.NotNil(t, keyboard)
+	assert.Len(t, keyboard.Keyboard, 4, "Extra suggestions are dropped")
+	assert.Equal(t, "a", keyboard.Keyboard[0][0].Text)
+	assert.True(t, keyboard.OneTimeKeyboard)
+	assert.True(t, keyboard.ResizeKeyboard)
+}
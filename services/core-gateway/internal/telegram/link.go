@@ -0,0 +1,32 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/telegramlink"
+)
+
+// LinkUsage explains how to get a code for /link
+const LinkUsage = "Usage: /link <code>. Get a code from the gateway with POST /api/v1/me/telegram-link."
+
+// LinkReply redeems a /link code for the sending Telegram user and returns the chat reply
+func LinkReply(ctx context.Context, links *telegramlink.Service, code string, telegramUserID int64) string {
+	if links == nil {
+		return "Account linking is not enabled on this gateway."
+	}
+	if code == "" {
+		return LinkUsage
+	}
+	userID, err := links.Redeem(ctx, code, telegramUserID)
+	if errors.Is(err, telegramlink.ErrInvalidCode) {
+		return "❌ That code is invalid or has expired. Request a new one and try again."
+	}
+	if err != nil {
+		log.Printf("[Telegram] Failed to link Telegram user %d: %v", telegramUserID, err)
+		return "⚠️ Linking failed, please try again later."
+	}
+	log.Printf("[Telegram] Linked Telegram user %d to %s", telegramUserID, userID)
+	return "✅ Linked! Your messages are now attributed to your gateway account."
+}
@@ -0,0 +1,37 @@
+package telegram_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/telegram"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/telegramlink"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinkReply_LinksAndAttributes(t *testing.T) {
+	// Arrange: user_1 requested a code on the gateway
+	ctx := context.Background()
+	links := telegramlink.NewService(telegramlink.NewMemoryStore(), telegramlink.Options{})
+	code, err := links.NewCode(ctx, "user_1")
+	require.NoError(t, err)
+	const sender = int64(4242)
+
+	// Act
+	bad := telegram.LinkReply(ctx, links, "WRONGCODE", sender)
+	before := links.UserID(ctx, sender, telegram.UserID)
+	linked := telegram.LinkReply(ctx, links, code.Code, sender)
+
+	// Assert: only a valid code attributes the sender to user_1
+	assert.Contains(t, bad, "invalid or has expired")
+	assert.Equal(t, telegram.UserID, before)
+	assert.Contains(t, linked, "Linked")
+	assert.Equal(t, "user_1", links.UserID(ctx, sender, telegram.UserID))
+}
+
+func TestLinkReply_UsageAndDisabled(t *testing.T) {
+	links := telegramlink.NewService(telegramlink.NewMemoryStore(), telegramlink.Options{})
+	assert.Equal(t, telegram.LinkUsage, telegram.LinkReply(context.Background(), links, "", 1))
+	assert.Contains(t, telegram.LinkReply(context.Background(), nil, "ABCD2345", 1), "not enabled")
+}
@@ -0,0 +1,49 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/notify"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/telegramlink"
+)
+
+// ErrNoChat is returned when a user can't be reached on Telegram
+var ErrNoChat = errors.New("no Telegram chat for user")
+
+// userNotifier is the Telegram notify channel
+type userNotifier struct {
+	api     Sender
+	allowed Whitelist
+	links   *telegramlink.Service
+}
+
+// NewUserNotifier messages linked users in their private chat (chat ID =
+// Telegram user ID) and UserID in the allowed chat. Chats outside the
+// whitelist are never messaged.
+func NewUserNotifier(api Sender, allowed Whitelist, links *telegramlink.Service) notify.Notifier {
+	return &userNotifier{api: api, allowed: allowed, links: links}
+}
+
+// UserNotifier is the bot as a notify channel (see NewUserNotifier)
+func (b *Bot) UserNotifier() notify.Notifier {
+	return NewUserNotifier(b.api, b.allowed, b.opts.Links)
+}
+
+func (n *userNotifier) Notify(ctx context.Context, userID, message string) error {
+	chatID := n.allowed.Primary()
+	if userID != UserID {
+		linked, err := n.links.TelegramUserID(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("%w %s: %v", ErrNoChat, userID, err)
+		}
+		chatID = linked
+	}
+	if chatID == 0 || !n.allowed.Allows(chatID) {
+		return fmt.Errorf("%w %s", ErrNoChat, userID)
+	}
+	_, err := n.api.Send(tgbotapi.NewMessage(chatID, message))
+	return err
+}
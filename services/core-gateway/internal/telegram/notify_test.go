@@ -0,0 +1,39 @@
+package telegram_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/telegram"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/telegramlink"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserNotifier(t *testing.T) {
+	// Arrange: alice is linked to Telegram user 42
+	ctx := context.Background()
+	store := telegramlink.NewMemoryStore()
+	require.NoError(t, store.SaveLink(ctx, &telegramlink.Link{TelegramUserID: 42, UserID: "alice", CreatedAt: time.Now()}))
+	links := telegramlink.NewService(store, telegramlink.Options{})
+	api := &fakeAPI{}
+	open := telegram.NewUserNotifier(api, nil, links)
+	whitelisted := telegram.NewUserNotifier(api, telegram.Whitelist{7}, links)
+
+	// Act
+	linked := open.Notify(ctx, "alice", "Quota reached")
+	unlinked := open.Notify(ctx, "bob", "Quota reached")
+	shared := whitelisted.Notify(ctx, telegram.UserID, "Task done")
+	outsideWhitelist := whitelisted.Notify(ctx, "alice", "Quota reached")
+
+	// Assert
+	require.NoError(t, linked)
+	require.NoError(t, shared)
+	assert.ErrorIs(t, unlinked, telegram.ErrNoChat)
+	assert.ErrorIs(t, outsideWhitelist, telegram.ErrNoChat)
+	require.Len(t, api.sent, 2)
+	assert.Equal(t, int64(42), api.sent[0].ChatID)
+	assert.Equal(t, "Quota reached", api.sent[0].Text)
+	assert.Equal(t, int64(7), api.sent[1].ChatID)
+}
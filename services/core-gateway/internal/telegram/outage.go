@@ -0,0 +1,40 @@
+package telegram
+
+import "sync"
+
+// UnavailableMessage is sent once per chat when prompts are paused during an agent outage
+const UnavailableMessage = "⏸ The agent is temporarily unavailable. I'll pick up new messages once it's back."
+
+// OutageGate pauses prompts while the agent is down. Each chat is told once,
+// further prompts are dropped, and commands always go through.
+type OutageGate struct {
+	agentHealthy func() bool
+
+	mu       sync.Mutex
+	notified map[int64]bool
+}
+
+func NewOutageGate(agentHealthy func() bool) *OutageGate {
+	return &OutageGate{agentHealthy: agentHealthy, notified: map[int64]bool{}}
+}
+
+// Admit reports whether a message should be processed, and whether the chat
+// should now be told the agent is unavailable.
+func (g *OutageGate) Admit(chatID int64, isCommand bool) (admit, notify bool) {
+	if g == nil || isCommand {
+		return true, false
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.agentHealthy() {
+		// Recovered: the next outage is announced again
+		delete(g.notified, chatID)
+		return true, false
+	}
+	if g.notified[chatID] {
+		return false, false
+	}
+	g.notified[chatID] = true
+	return false, true
+}
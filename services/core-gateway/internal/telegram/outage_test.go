@@ -0,0 +1,51 @@
+package telegram_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/health"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/telegram"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutageGate_AgentDownThenRecovered(t *testing.T) {
+	// Arrange: the gate follows the registry, as wired in main
+	registry := health.NewRegistry()
+	gate := telegram.NewOutageGate(func() bool { return registry.Healthy("pm_agent") })
+	const chat = int64(42)
+
+	// Act & Assert: not probed yet counts as healthy
+	admit, notify := gate.Admit(chat, false)
+	assert.True(t, admit)
+	assert.False(t, notify)
+
+	// Agent goes down: one notice, then further prompts are dropped
+	registry.Set("pm_agent", errors.New("connection refused"))
+	admit, notify = gate.Admit(chat, false)
+	assert.False(t, admit)
+	assert.True(t, notify)
+	admit, notify = gate.Admit(chat, false)
+	assert.False(t, admit)
+	assert.False(t, notify)
+
+	// Commands are still accepted during the outage
+	admit, notify = gate.Admit(chat, true)
+	assert.True(t, admit)
+	assert.False(t, notify)
+
+	// Recovery resumes prompts; a later outage is announced again
+	registry.Set("pm_agent", nil)
+	admit, _ = gate.Admit(chat, false)
+	assert.True(t, admit)
+	registry.Set("pm_agent", errors.New("503"))
+	_, notify = gate.Admit(chat, false)
+	assert.True(t, notify)
+}
+
+func TestOutageGate_DisabledWhenNil(t *testing.T) {
+	var gate *telegram.OutageGate
+	admit, notify := gate.Admit(1, false)
+	assert.True(t, admit)
+	assert.False(t, notify)
+}
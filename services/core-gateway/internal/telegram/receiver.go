@@ -0,0 +1,65 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// DefaultPollTimeout is the long-poll timeout in seconds. A poll in flight
+// can't be cancelled, so this is how long shutdown may wait on Telegram;
+// it is kept well below the 60s Telegram allows.
+const DefaultPollTimeout = 10
+
+// Poller is the part of the Bot API that long-polls for updates
+// (*tgbotapi.BotAPI satisfies it; tests pass a fake)
+type Poller interface {
+	GetUpdatesChan(config tgbotapi.UpdateConfig) tgbotapi.UpdatesChannel
+	StopReceivingUpdates()
+}
+
+// Receiver runs the update loop and stops it on shutdown
+type Receiver struct {
+	poller  Poller
+	timeout int
+
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// NewReceiver polls with a timeout of pollTimeout seconds (0 = DefaultPollTimeout)
+func NewReceiver(poller Poller, pollTimeout int) *Receiver {
+	if pollTimeout <= 0 {
+		pollTimeout = DefaultPollTimeout
+	}
+	return &Receiver{poller: poller, timeout: pollTimeout, done: make(chan struct{})}
+}
+
+// Start polls in the background, calling handle for each update in order
+func (r *Receiver) Start(handle func(tgbotapi.Update)) {
+	u := tgbotapi.NewUpdate(0)
+	u.Timeout = r.timeout
+	updates := r.poller.GetUpdatesChan(u)
+
+	go func() {
+		defer close(r.done)
+		for update := range updates {
+			handle(update)
+		}
+	}()
+}
+
+// Stop ends polling and waits for the update loop to exit. The loop exits
+// once the poll in flight returns; if that outlasts ctx, Stop gives up and
+// returns an error, leaving the loop to exit with the process.
+func (r *Receiver) Stop(ctx context.Context) error {
+	r.stopOnce.Do(r.poller.StopReceivingUpdates)
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("telegram poll still in flight: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,95 @@
+package telegram_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/telegram"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stalledPoller mimics tgbotapi: after StopReceivingUpdates the channel only
+// closes once the poll in flight returns, which takes pollDuration
+type stalledPoller struct {
+	pollDuration time.Duration
+	updates      []tgbotapi.Update
+
+	mu      sync.Mutex
+	timeout int
+	stop    chan struct{}
+	stops   int
+}
+
+func (p *stalledPoller) GetUpdatesChan(config tgbotapi.UpdateConfig) tgbotapi.UpdatesChannel {
+	p.mu.Lock()
+	p.timeout = config.Timeout
+	p.stop = make(chan struct{})
+	p.mu.Unlock()
+
+	ch := make(chan tgbotapi.Update, len(p.updates))
+	for _, u := range p.updates {
+		ch <- u
+	}
+	go func() {
+		<-p.stop
+		time.Sleep(p.pollDuration)
+		close(ch)
+	}()
+	return ch
+}
+
+func (p *stalledPoller) StopReceivingUpdates() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stops++
+	close(p.stop)
+}
+
+func TestReceiver_StopWaitsForShortPoll(t *testing.T) {
+	// Arrange
+	poller := &stalledPoller{pollDuration: 20 * time.Millisecond, updates: []tgbotapi.Update{{UpdateID: 1}, {UpdateID: 2}}}
+	receiver := telegram.NewReceiver(poller, 0)
+	var mu sync.Mutex
+	var handled []int
+	receiver.Start(func(u tgbotapi.Update) {
+		mu.Lock()
+		defer mu.Unlock()
+		handled = append(handled, u.UpdateID)
+	})
+
+	// Act
+	err := receiver.Stop(context.Background())
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, telegram.DefaultPollTimeout, poller.timeout)
+	assert.Equal(t, []int{1, 2}, handled)
+}
+
+func TestReceiver_StopIsBoundedByGrace(t *testing.T) {
+	// Arrange: Telegram never answers the poll in flight
+	poller := &stalledPoller{pollDuration: time.Hour}
+	receiver := telegram.NewReceiver(poller, 5)
+	receiver.Start(func(tgbotapi.Update) {})
+	grace := 50 * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+
+	// Act
+	start := time.Now()
+	err := receiver.Stop(ctx)
+	elapsed := time.Since(start)
+
+	// Assert
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, elapsed, grace+time.Second)
+	assert.Equal(t, 5, poller.timeout)
+
+	// Stopping again doesn't close the poller twice
+	receiver.Stop(ctx)
+	assert.Equal(t, 1, poller.stops)
+}
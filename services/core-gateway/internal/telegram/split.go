@@ -0,0 +1,81 @@
+package telegram
+
+import "strings"
+
+// fenceClose ends a code block that a split would otherwise leave open
+const fenceClose = "\n```"
+
+// SplitMessage breaks text into chunks of at most limit characters
+// (limit <= 0 = MaxMessageLength), preferring paragraph, then line, then word
+// boundaries. A code block cut by a split is closed at the end of its chunk and
+// reopened, with the same language tag, at the start of the next.
+func SplitMessage(text string, limit int) []string {
+	if limit <= 0 {
+		limit = MaxMessageLength
+	}
+	var chunks []string
+	rest := []rune(text)
+	fence := ""
+	for {
+		// Carry the open code block over only while its opening line fills less
+		// than half a chunk. Cuts land in the second half of the window, so every
+		// chunk then takes text past the reopened fence; a longer opening line is
+		// dropped instead of being repeated forever.
+		if fence != "" && len([]rune(fence))+1+len(fenceClose) <= limit/2 {
+			rest = append([]rune(fence+"\n"), rest...)
+		}
+		if len(rest) <= limit {
+			return append(chunks, string(rest))
+		}
+
+		cut, skip := breakPoint(rest, limit)
+		fence = openFence(string(rest[:cut]))
+		if fence != "" {
+			// Leave room to close the code block
+			cut, skip = breakPoint(rest, max(limit-len(fenceClose), 1))
+			fence = openFence(string(rest[:cut]))
+		}
+		chunk := string(rest[:cut])
+		if fence != "" {
+			chunk += fenceClose
+		}
+		chunks = append(chunks, chunk)
+
+		rest = rest[cut+skip:]
+		if len(rest) == 0 {
+			return chunks
+		}
+	}
+}
+
+// breakPoint picks where to end a chunk of at most n characters, preferring a
+// blank line, then a line break, then a space in the second half of the window.
+// skip is the length of the separator dropped between the chunks. It is shared
+// by SplitMessage and the StreamEditor.
+func breakPoint(text []rune, n int) (cut, skip int) {
+	window := string(text[:n])
+	for _, sep := range []string{"\n\n", "\n", " "} {
+		if idx := strings.LastIndex(window, sep); idx > len(window)/2 {
+			return len([]rune(window[:idx])), len(sep)
+		}
+	}
+	return n, 0
+}
+
+// openFence returns the opening line of a code block left open at the end of
+// text, or "" when every block is closed
+func openFence(text string) string {
+	open := ""
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "```") {
+			continue
+		}
+		if open == "" {
+			open = line
+		} else {
+			open = ""
+		}
+	}
+	return open
+}
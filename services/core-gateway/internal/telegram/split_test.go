@@ -0,0 +1,92 @@
+package telegram_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/telegram"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitMessage_LongReply(t *testing.T) {
+	// Arrange: ~10k characters of prose with a code block spanning a split
+	var paragraphs, codeLines []string
+	for i := 0; len(strings.Join(paragraphs, "\n\n")) < 4000; i++ {
+		paragraphs = append(paragraphs, fmt.Sprintf("Paragraph %d. %s", i, strings.Repeat("lorem ipsum ", 20)))
+	}
+	for i := 0; i < 150; i++ {
+		codeLines = append(codeLines, fmt.Sprintf("    fmt.Println(%d) // step %d of the rollout", i, i))
+	}
+	paragraphs = append(paragraphs, "```go\n"+strings.Join(codeLines, "\n")+"\n```")
+	for i := 100; len(strings.Join(paragraphs, "\n\n")) < 10000; i++ {
+		paragraphs = append(paragraphs, fmt.Sprintf("Paragraph %d. %s", i, strings.Repeat("dolor sit ", 25)))
+	}
+	text := strings.Join(paragraphs, "\n\n")
+	require.GreaterOrEqual(t, len(text), 10000)
+
+	// Act
+	chunks := telegram.SplitMessage(text, telegram.MaxMessageLength)
+
+	// Assert
+	require.GreaterOrEqual(t, len(chunks), 3)
+	joined := strings.Join(chunks, "\n")
+	for i, chunk := range chunks {
+		assert.LessOrEqual(t, len([]rune(chunk)), telegram.MaxMessageLength, "chunk %d", i)
+		assert.Zero(t, strings.Count(chunk, "```")%2, "chunk %d leaves a code block open", i)
+	}
+	for _, paragraph := range paragraphs[:len(paragraphs)-1] {
+		if !strings.HasPrefix(paragraph, "```") {
+			assert.Contains(t, joined, paragraph, "paragraphs are never cut")
+		}
+	}
+	for _, line := range codeLines {
+		assert.Contains(t, joined, line, "code lines are never cut")
+	}
+	assert.True(t, strings.Contains(joined, "```\n```go\n"), "the code block is closed and reopened across the split")
+}
+
+func TestSplitMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		limit    int
+		expected []string
+	}{
+		{"fits", "hello", 10, []string{"hello"}},
+		{"paragraph boundary", "first para\n\nsecond para", 16, []string{"first para", "second para"}},
+		{"word boundary", "aaaa bbbb cccc", 12, []string{"aaaa bbbb", "cccc"}},
+		{"no boundary", strings.Repeat("x", 25), 12, []string{strings.Repeat("x", 12), strings.Repeat("x", 12), "x"}},
+		{"code block", "```sh\nmake a\nmake b\n```", 20, []string{"```sh\nmake a\n```", "```sh\nmake b\n```"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, telegram.SplitMessage(tt.text, tt.limit))
+		})
+	}
+}
+
+func TestSplitMessage_LongFenceLine(t *testing.T) {
+	// Arrange: an opening fence line longer than half a message
+	text := "```" + strings.Repeat("a", 2100) + "\n" + strings.Repeat("b", 5000) + "\n```"
+
+	// Act
+	done := make(chan []string, 1)
+	go func() { done <- telegram.SplitMessage(text, telegram.MaxMessageLength) }()
+	var chunks []string
+	select {
+	case chunks = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("SplitMessage did not return")
+	}
+
+	// Assert: the fence is not carried over, so every chunk makes progress
+	require.NotEmpty(t, chunks)
+	for i, chunk := range chunks {
+		assert.LessOrEqual(t, len([]rune(chunk)), telegram.MaxMessageLength, "chunk %d", i)
+	}
+	assert.Equal(t, 5000, strings.Count(strings.Join(chunks, ""), "b"), "no text is lost or repeated")
+}
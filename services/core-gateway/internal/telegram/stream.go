@@ -0,0 +1,110 @@
+package telegram
+
+import (
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// MaxMessageLength is Telegram's limit on one message's text, in characters
+const MaxMessageLength = 4096
+
+// DefaultEditInterval keeps streaming edits within Telegram's rate limits
+// (roughly one edit per second per chat)
+const DefaultEditInterval = 1500 * time.Millisecond
+
+// StreamPlaceholder is shown until the first partial reply arrives
+const StreamPlaceholder = "…"
+
+// Sender is the part of the Bot API used to post and edit messages
+// (*tgbotapi.BotAPI satisfies it; tests pass a fake)
+type Sender interface {
+	Send(c tgbotapi.Chattable) (tgbotapi.Message, error)
+}
+
+// StreamEditor shows a streamed reply building up by editing a placeholder
+// message in place, at most once per interval. Text beyond MaxMessageLength
+// continues in a new message.
+type StreamEditor struct {
+	api      Sender
+	chatID   int64
+	interval time.Duration
+	now      func() time.Time
+
+	messageID int
+	// current is the text of the message being edited; shown is what Telegram has
+	current  []rune
+	shown    string
+	lastEdit time.Time
+}
+
+// NewStreamEditor edits messages in chatID (interval <= 0 = DefaultEditInterval, nil now = time.Now)
+func NewStreamEditor(api Sender, chatID int64, interval time.Duration, now func() time.Time) *StreamEditor {
+	if interval <= 0 {
+		interval = DefaultEditInterval
+	}
+	if now == nil {
+		now = time.Now
+	}
+	return &StreamEditor{api: api, chatID: chatID, interval: interval, now: now}
+}
+
+// Start posts the placeholder message that later text is edited into
+func (e *StreamEditor) Start() error {
+	return e.post(StreamPlaceholder)
+}
+
+// Append adds streamed text, editing the message if the interval has passed
+// and splitting into a new message when it would exceed MaxMessageLength
+func (e *StreamEditor) Append(text string) error {
+	e.current = append(e.current, []rune(text)...)
+	for len(e.current) > MaxMessageLength {
+		cut, skip := breakPoint(e.current, MaxMessageLength)
+		rest := append([]rune(nil), e.current[cut+skip:]...)
+		e.current = e.current[:cut]
+		if err := e.edit(); err != nil {
+			return err
+		}
+		e.current = rest
+		if err := e.post(string(e.current[:min(len(e.current), MaxMessageLength)])); err != nil {
+			return err
+		}
+	}
+	if e.now().Sub(e.lastEdit) >= e.interval {
+		return e.edit()
+	}
+	return nil
+}
+
+// Finish writes the final text of the current message, ignoring the interval
+func (e *StreamEditor) Finish() error {
+	return e.edit()
+}
+
+// Text returns the text of the message currently being edited
+func (e *StreamEditor) Text() string {
+	return string(e.current)
+}
+
+func (e *StreamEditor) post(text string) error {
+	sent, err := e.api.Send(tgbotapi.NewMessage(e.chatID, text))
+	if err != nil {
+		return err
+	}
+	e.messageID, e.shown, e.lastEdit = sent.MessageID, text, e.now()
+	return nil
+}
+
+// edit updates the message unless nothing changed (Telegram rejects no-op edits)
+func (e *StreamEditor) edit() error {
+	text := string(e.current)
+	if strings.TrimSpace(text) == "" || text == e.shown {
+		return nil
+	}
+	if _, err := e.api.Send(tgbotapi.NewEditMessageText(e.chatID, e.messageID, text)); err != nil {
+		return err
+	}
+	e.shown, e.lastEdit = text, e.now()
+	return nil
+}
@@ -0,0 +1,79 @@
+package telegram_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/telegram"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAPI records sent messages and edits, numbering messages from 1
+type fakeAPI struct {
+	sent  []tgbotapi.MessageConfig
+	edits []tgbotapi.EditMessageTextConfig
+}
+
+func (f *fakeAPI) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	switch msg := c.(type) {
+	case tgbotapi.MessageConfig:
+		f.sent = append(f.sent, msg)
+		return tgbotapi.Message{MessageID: len(f.sent)}, nil
+	case tgbotapi.EditMessageTextConfig:
+		f.edits = append(f.edits, msg)
+		return tgbotapi.Message{MessageID: msg.MessageID}, nil
+	}
+	return tgbotapi.Message{}, nil
+}
+
+func TestStreamEditor_ThrottlesEdits(t *testing.T) {
+	// Arrange
+	api := &fakeAPI{}
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	editor := telegram.NewStreamEditor(api, 42, time.Second, func() time.Time { return now })
+	require.NoError(t, editor.Start())
+
+	// Act: tokens arrive every 300ms
+	for _, token := range []string{"The ", "build ", "is ", "green ", "and ", "deployed."} {
+		now = now.Add(300 * time.Millisecond)
+		require.NoError(t, editor.Append(token))
+	}
+	require.NoError(t, editor.Finish())
+
+	// Assert: one placeholder, an edit at most once a second, then the final text
+	require.Len(t, api.sent, 1)
+	assert.Equal(t, telegram.StreamPlaceholder, api.sent[0].Text)
+	var texts []string
+	for _, edit := range api.edits {
+		assert.Equal(t, 1, edit.MessageID)
+		texts = append(texts, edit.Text)
+	}
+	assert.Equal(t, []string{"The build is green ", "The build is green and deployed."}, texts)
+}
+
+func TestStreamEditor_SplitsLongReplies(t *testing.T) {
+	// Arrange
+	api := &fakeAPI{}
+	editor := telegram.NewStreamEditor(api, 42, time.Hour, nil)
+	require.NoError(t, editor.Start())
+	first := strings.Repeat("a", 3000) + "\n"
+	second := strings.Repeat("b", 2000)
+
+	// Act
+	require.NoError(t, editor.Append(first))
+	require.NoError(t, editor.Append(second))
+	require.NoError(t, editor.Finish())
+
+	// Assert: the first message ends at the line break, the rest continues in a new one
+	require.Len(t, api.sent, 2)
+	require.NotEmpty(t, api.edits)
+	assert.Equal(t, strings.Repeat("a", 3000), api.edits[0].Text)
+	assert.Equal(t, 1, api.edits[0].MessageID)
+	assert.Equal(t, second, api.sent[1].Text)
+	for _, edit := range api.edits {
+		assert.LessOrEqual(t, len([]rune(edit.Text)), telegram.MaxMessageLength)
+	}
+}
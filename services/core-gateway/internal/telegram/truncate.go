@@ -0,0 +1,29 @@
+package telegram
+
+import "strings"
+
+// DefaultTruncateThreshold leaves headroom under Telegram's 4096-char limit for the link footer
+const DefaultTruncateThreshold = 3500
+
+// ShouldTruncate reports whether a reply exceeds the threshold (in characters).
+// A non-positive threshold disables truncation.
+func ShouldTruncate(text string, threshold int) bool {
+	return threshold > 0 && len([]rune(text)) > threshold
+}
+
+// TruncateWithLink cuts text to at most threshold characters, preferring a line
+// boundary, and appends a link to the full reply.
+func TruncateWithLink(text string, threshold int, link string) string {
+	runes := []rune(text)
+	if len(runes) <= threshold {
+		return text
+	}
+
+	cut := string(runes[:threshold])
+	// Prefer breaking on a newline if one exists in the last half of the window
+	if idx := strings.LastIndex(cut, "\n"); idx > len(cut)/2 {
+		cut = cut[:idx]
+	}
+
+	return strings.TrimRight(cut, " \n") + "\n\n… (truncated) Full reply: " + link
+}
@@ -0,0 +1,40 @@
+package telegram_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/telegram"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShouldTruncate(t *testing.T) {
+	assert.False(t, telegram.ShouldTruncate("short", 10))
+	assert.False(t, telegram.ShouldTruncate(strings.Repeat("a", 10), 10), "Exactly at threshold is kept")
+	assert.True(t, telegram.ShouldTruncate(strings.Repeat("a", 11), 10))
+	assert.False(t, telegram.ShouldTruncate(strings.Repeat("a", 10000), 0), "Zero threshold disables truncation")
+	// Thresholds count characters, not bytes
+	assert.False(t, telegram.ShouldTruncate(strings.Repeat("우", 10), 10))
+}
+
+func TestTruncateWithLink(t *testing.T) {
+	link := "https://gw.example.com/replies/t1/m1"
+
+	// Arrange: two paragraphs, the second crossing the threshold
+	text := strings.Repeat("a", 60) + "\n" + strings.Repeat("b", 60)
+
+	// Act
+	out := telegram.TruncateWithLink(text, 100, link)
+
+	// Assert: cut on the line boundary and link appended
+	assert.True(t, strings.HasPrefix(out, strings.Repeat("a", 60)+"\n\n"))
+	assert.NotContains(t, out, "b")
+	assert.True(t, strings.HasSuffix(out, link))
+
+	// Multi-byte text is cut on rune boundaries
+	out = telegram.TruncateWithLink(strings.Repeat("우", 50), 20, link)
+	assert.True(t, strings.HasPrefix(out, strings.Repeat("우", 20)+"\n"))
+
+	// Under the threshold text is returned untouched
+	assert.Equal(t, "hi", telegram.TruncateWithLink("hi", 100, link))
+}
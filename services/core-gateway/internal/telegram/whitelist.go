@@ -0,0 +1,38 @@
+package telegram
+
+import (
+	"errors"
+	"slices"
+)
+
+// ErrOpenWhitelist is returned when the bot would accept every chat outside a local setup
+var ErrOpenWhitelist = errors.New("telegram: allowed chat ID is not set; open access is only permitted in local/debug (set TELEGRAM_ALLOWED_ID)")
+
+// Whitelist is the chats the bot answers; empty allows every chat
+type Whitelist []int64
+
+// Allows reports whether the bot may answer chatID
+func (w Whitelist) Allows(chatID int64) bool {
+	return len(w) == 0 || slices.Contains(w, chatID)
+}
+
+// Primary is the chat that gets notifications not tied to a linked user (0 when empty)
+func (w Whitelist) Primary() int64 {
+	if len(w) == 0 {
+		return 0
+	}
+	return w[0]
+}
+
+// CheckWhitelist decides whether the bot may start with the given allowed chats.
+// An empty whitelist means "allow all", which is only accepted when allowOpen
+// is set and the gateway runs in the local env or debug mode.
+func CheckWhitelist(env, mode string, allowed Whitelist, allowOpen bool) error {
+	if len(allowed) > 0 {
+		return nil
+	}
+	if allowOpen && (env == "local" || mode == "debug") {
+		return nil
+	}
+	return ErrOpenWhitelist
+}
@@ -0,0 +1,44 @@
+package telegram_test
+
+import (
+	"testing"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/telegram"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckWhitelist(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       string
+		mode      string
+		allowed   telegram.Whitelist
+		allowOpen bool
+		expected  error
+	}{
+		{"whitelist set in prod", "prod", "release", telegram.Whitelist{42}, false, nil},
+		{"empty whitelist in prod", "prod", "release", nil, false, telegram.ErrOpenWhitelist},
+		{"open access not honored in prod", "prod", "release", nil, true, telegram.ErrOpenWhitelist},
+		{"open access not honored in dev release", "dev", "release", nil, true, telegram.ErrOpenWhitelist},
+		{"open access in local", "local", "release", nil, true, nil},
+		{"open access in debug mode", "dev", "debug", nil, true, nil},
+		{"local still requires opt-in", "local", "debug", nil, false, telegram.ErrOpenWhitelist},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := telegram.CheckWhitelist(tt.env, tt.mode, tt.allowed, tt.allowOpen)
+			assert.ErrorIs(t, err, tt.expected)
+		})
+	}
+}
+
+func TestWhitelist_Allows(t *testing.T) {
+	allowed := telegram.Whitelist{7, 42}
+
+	assert.True(t, allowed.Allows(42))
+	assert.False(t, allowed.Allows(8))
+	assert.Equal(t, int64(7), allowed.Primary())
+	assert.True(t, telegram.Whitelist(nil).Allows(8), "an empty whitelist allows every chat")
+	assert.Zero(t, telegram.Whitelist(nil).Primary())
+}
@@ -0,0 +1,42 @@
+package telegramlink
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/ctxutil"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/httperr"
+)
+
+type Handler struct {
+	links *Service
+}
+
+func NewHandler(links *Service) *Handler {
+	return &Handler{links: links}
+}
+
+// CreateCode issues a link code for the caller (POST /api/v1/me/telegram-link)
+func (h *Handler) CreateCode(c *gin.Context) {
+	// Linking decides whose account chat usage is billed to: only the user themselves
+	if _, ok := ctxutil.ImpersonatedBy(c); ok {
+		httperr.Respond(c, http.StatusForbidden, "Telegram accounts cannot be linked while impersonating")
+		return
+	}
+	userID, _ := ctxutil.UserID(c)
+
+	code, err := h.links.NewCode(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("[TelegramLink] Failed to issue code for %s: %v", userID, err)
+		httperr.Respond(c, http.StatusInternalServerError, "Failed to issue link code")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"code":       code.Code,
+		"expires_at": code.ExpiresAt.Format(time.RFC3339),
+		"command":    "/link " + code.Code,
+	})
+}
@@ -0,0 +1,57 @@
+package telegramlink_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/ctxutil"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/telegramlink"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_CreateCode(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	links := telegramlink.NewService(telegramlink.NewMemoryStore(), telegramlink.Options{})
+	h := telegramlink.NewHandler(links)
+	r := gin.New()
+	r.POST("/me/telegram-link", func(c *gin.Context) {
+		ctxutil.SetUserID(c, "user_1")
+		if admin := c.GetHeader("X-Impersonator"); admin != "" {
+			ctxutil.SetImpersonatedBy(c, admin)
+		}
+		h.CreateCode(c)
+	})
+	create := func(impersonator string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, "/me/telegram-link", nil)
+		if impersonator != "" {
+			req.Header.Set("X-Impersonator", impersonator)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// Act
+	w := create("")
+	impersonated := create("admin_1")
+
+	// Assert: the code links a Telegram user to the caller
+	require.Equal(t, http.StatusCreated, w.Code)
+	var body struct {
+		Code    string `json:"code"`
+		Command string `json:"command"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "/link "+body.Code, body.Command)
+	userID, err := links.Redeem(context.Background(), body.Code, 4242)
+	require.NoError(t, err)
+	assert.Equal(t, "user_1", userID)
+
+	assert.Equal(t, http.StatusForbidden, impersonated.Code)
+}
@@ -0,0 +1,232 @@
+// Package telegramlink maps Telegram users to gateway accounts, so bot usage
+// is attributed to real users instead of one shared identity.
+//
+// A logged-in user asks the gateway for a short-lived code and sends
+// "/link <code>" to the bot; the sender's Telegram user ID is then linked.
+package telegramlink
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCodeTTL is how long a link code can be redeemed
+const DefaultCodeTTL = 10 * time.Minute
+
+// codeAlphabet avoids look-alike characters (0/O, 1/I) since codes are typed by hand
+const codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+const codeLength = 8
+
+var (
+	// ErrInvalidCode is returned for unknown, expired or already used codes
+	ErrInvalidCode = errors.New("link code is invalid or expired")
+	// ErrNotLinked is returned when a Telegram user has no linked account
+	ErrNotLinked = errors.New("telegram user is not linked")
+)
+
+// Link attributes a Telegram user to a gateway user (table: telegram_links)
+type Link struct {
+	TelegramUserID int64     `gorm:"primaryKey;autoIncrement:false"`
+	UserID         string    `gorm:"index;not null"`
+	CreatedAt      time.Time `gorm:"not null"`
+}
+
+func (Link) TableName() string { return "telegram_links" }
+
+// Code is a pending, single-use link code (table: telegram_link_codes)
+type Code struct {
+	Code      string    `gorm:"primaryKey"`
+	UserID    string    `gorm:"not null"`
+	ExpiresAt time.Time `gorm:"index;not null"`
+}
+
+func (Code) TableName() string { return "telegram_link_codes" }
+
+// Store persists links and pending codes
+type Store interface {
+	SaveCode(ctx context.Context, code *Code) error
+	// TakeCode deletes and returns a code so it can be used only once
+	// (ErrInvalidCode when there is no such code)
+	TakeCode(ctx context.Context, code string) (*Code, error)
+	// SaveLink creates or replaces the link of link.TelegramUserID
+	SaveLink(ctx context.Context, link *Link) error
+	// GetLink returns ErrNotLinked when telegramUserID has no link
+	GetLink(ctx context.Context, telegramUserID int64) (*Link, error)
+	// GetLinkByUser returns userID's most recent link, or ErrNotLinked
+	GetLinkByUser(ctx context.Context, userID string) (*Link, error)
+	// DeleteUser removes userID's links and pending codes (account deletion)
+	DeleteUser(ctx context.Context, userID string) error
+}
+
+// Options configures a Service
+type Options struct {
+	// CodeTTL is how long codes stay valid (default DefaultCodeTTL)
+	CodeTTL time.Duration
+	Now     func() time.Time
+}
+
+// Service issues link codes and resolves Telegram users to gateway users
+type Service struct {
+	store Store
+	opts  Options
+}
+
+func NewService(store Store, opts Options) *Service {
+	if opts.CodeTTL <= 0 {
+		opts.CodeTTL = DefaultCodeTTL
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &Service{store: store, opts: opts}
+}
+
+// NewCode issues a code that links the Telegram account redeeming it to userID
+func (s *Service) NewCode(ctx context.Context, userID string) (*Code, error) {
+	value, err := randomCode()
+	if err != nil {
+		return nil, err
+	}
+	code := &Code{Code: value, UserID: userID, ExpiresAt: s.opts.Now().Add(s.opts.CodeTTL).UTC()}
+	if err := s.store.SaveCode(ctx, code); err != nil {
+		return nil, err
+	}
+	return code, nil
+}
+
+// Redeem links telegramUserID to the owner of code and returns that user ID.
+// Codes are case-insensitive and single-use.
+func (s *Service) Redeem(ctx context.Context, code string, telegramUserID int64) (string, error) {
+	taken, err := s.store.TakeCode(ctx, strings.ToUpper(strings.TrimSpace(code)))
+	if err != nil {
+		return "", err
+	}
+	if !s.opts.Now().Before(taken.ExpiresAt) {
+		return "", ErrInvalidCode
+	}
+	link := &Link{TelegramUserID: telegramUserID, UserID: taken.UserID, CreatedAt: s.opts.Now().UTC()}
+	if err := s.store.SaveLink(ctx, link); err != nil {
+		return "", err
+	}
+	return taken.UserID, nil
+}
+
+// UserID returns the gateway user linked to telegramUserID, or fallback when
+// there is none (or the lookup fails)
+func (s *Service) UserID(ctx context.Context, telegramUserID int64, fallback string) string {
+	if s == nil {
+		return fallback
+	}
+	link, err := s.store.GetLink(ctx, telegramUserID)
+	if err != nil {
+		return fallback
+	}
+	return link.UserID
+}
+
+// TelegramUserID returns the Telegram user most recently linked to userID,
+// or ErrNotLinked
+func (s *Service) TelegramUserID(ctx context.Context, userID string) (int64, error) {
+	if s == nil {
+		return 0, ErrNotLinked
+	}
+	link, err := s.store.GetLinkByUser(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	return link.TelegramUserID, nil
+}
+
+func randomCode() (string, error) {
+	buf := make([]byte, codeLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		buf[i] = codeAlphabet[int(b)%len(codeAlphabet)]
+	}
+	return string(buf), nil
+}
+
+type memoryStore struct {
+	mu    sync.Mutex
+	codes map[string]Code
+	links map[int64]Link
+}
+
+// NewMemoryStore returns a process-local Store (local dev and tests)
+func NewMemoryStore() Store {
+	return &memoryStore{codes: map[string]Code{}, links: map[int64]Link{}}
+}
+
+func (s *memoryStore) SaveCode(ctx context.Context, code *Code) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.codes[code.Code] = *code
+	return nil
+}
+
+func (s *memoryStore) TakeCode(ctx context.Context, code string) (*Code, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	taken, ok := s.codes[code]
+	if !ok {
+		return nil, ErrInvalidCode
+	}
+	delete(s.codes, code)
+	return &taken, nil
+}
+
+func (s *memoryStore) SaveLink(ctx context.Context, link *Link) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.links[link.TelegramUserID] = *link
+	return nil
+}
+
+func (s *memoryStore) GetLink(ctx context.Context, telegramUserID int64) (*Link, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	link, ok := s.links[telegramUserID]
+	if !ok {
+		return nil, ErrNotLinked
+	}
+	return &link, nil
+}
+
+func (s *memoryStore) GetLinkByUser(ctx context.Context, userID string) (*Link, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var latest *Link
+	for _, link := range s.links {
+		if link.UserID == userID && (latest == nil || link.CreatedAt.After(latest.CreatedAt)) {
+			link := link
+			latest = &link
+		}
+	}
+	if latest == nil {
+		return nil, ErrNotLinked
+	}
+	return latest, nil
+}
+
+func (s *memoryStore) DeleteUser(ctx context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, link := range s.links {
+		if link.UserID == userID {
+			delete(s.links, id)
+		}
+	}
+	for code, pending := range s.codes {
+		if pending.UserID == userID {
+			delete(s.codes, code)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package telegramlink_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/telegramlink"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_LinkFlow(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	links := telegramlink.NewService(telegramlink.NewMemoryStore(), telegramlink.Options{})
+	const telegramUser = int64(4242)
+	assert.Equal(t, "telegram_user", links.UserID(ctx, telegramUser, "telegram_user"), "Unlinked users keep the fallback")
+
+	// Act
+	code, err := links.NewCode(ctx, "user_1")
+	require.NoError(t, err)
+	userID, err := links.Redeem(ctx, " "+strings.ToLower(code.Code)+" ", telegramUser)
+
+	// Assert: the sender is now attributed to user_1, and the code is spent
+	require.NoError(t, err)
+	assert.Equal(t, "user_1", userID)
+	assert.Len(t, code.Code, 8)
+	assert.Equal(t, "user_1", links.UserID(ctx, telegramUser, "telegram_user"))
+	assert.Equal(t, "telegram_user", links.UserID(ctx, 1, "telegram_user"), "Other Telegram users stay unlinked")
+	_, err = links.Redeem(ctx, code.Code, 1)
+	assert.ErrorIs(t, err, telegramlink.ErrInvalidCode)
+}
+
+func TestService_ExpiredCode(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	links := telegramlink.NewService(telegramlink.NewMemoryStore(), telegramlink.Options{
+		CodeTTL: time.Minute,
+		Now:     func() time.Time { return now },
+	})
+	code, err := links.NewCode(ctx, "user_1")
+	require.NoError(t, err)
+
+	// Act
+	now = now.Add(time.Minute)
+	_, err = links.Redeem(ctx, code.Code, 4242)
+
+	// Assert
+	assert.ErrorIs(t, err, telegramlink.ErrInvalidCode)
+	assert.Equal(t, "telegram_user", links.UserID(ctx, 4242, "telegram_user"))
+}
+
+func TestService_NilKeepsFallback(t *testing.T) {
+	var links *telegramlink.Service
+	assert.Equal(t, "telegram_user", links.UserID(context.Background(), 4242, "telegram_user"))
+}
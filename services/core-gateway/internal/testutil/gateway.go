@@ -0,0 +1,203 @@
+// Package testutil starts the fully wired gateway for integration tests:
+// every middleware and route from internal/gateway, in-memory or SQLite
+// storage, an echo agent by default and a fake clock.
+package testutil
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/config"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/agent"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/auth"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/clock"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/gateway"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/infrastructure/database"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/user"
+)
+
+// Secret signs the test gateway's tokens
+const Secret = "test-secret"
+
+// Start is where the fake clock starts unless Options.Clock is set
+var Start = time.Date(2026, time.January, 5, 9, 0, 0, 0, time.UTC)
+
+// Options configure NewTestGateway; the zero value is ready to use
+type Options struct {
+	// Agent answers asks (default agent.NewEchoService)
+	Agent agent.Service
+	// Driver is database.DriverMemory (default) or database.DriverSQLite in a temp dir
+	Driver string
+	// Clock drives JWT times, usage days and idempotency TTLs (default stopped at Start)
+	Clock *clock.Fake
+	// Configure adjusts the config before the gateway is built
+	Configure func(cfg *config.Config)
+}
+
+// TestGateway is a running gateway; requests go to its httptest server
+type TestGateway struct {
+	*httptest.Server
+	Clock   *clock.Fake
+	Storage *database.Backend
+	JWT     auth.Service
+}
+
+// NewTestGateway starts the gateway and stops it when t ends. The gateway
+// reads config.Current(), which is process-wide: tests using it must not
+// run in parallel.
+func NewTestGateway(t testing.TB, opts Options) *TestGateway {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	if opts.Agent == nil {
+		opts.Agent = agent.NewEchoService()
+	}
+	if opts.Driver == "" {
+		opts.Driver = database.DriverMemory
+	}
+	if opts.Clock == nil {
+		opts.Clock = clock.NewFake(Start)
+	}
+
+	cfg := &config.Config{}
+	cfg.Server.Mode = gin.TestMode
+	cfg.JWT.Secret = Secret
+	cfg.JWT.ExpiryMinutes = 60
+	cfg.DB.Driver = opts.Driver
+	if opts.Driver == database.DriverSQLite {
+		cfg.DB.Path = filepath.Join(t.TempDir(), "gateway.db")
+	}
+	if opts.Configure != nil {
+		opts.Configure(cfg)
+	}
+
+	previous := config.Current()
+	config.Store(cfg)
+	t.Cleanup(func() {
+		if previous != nil {
+			config.Store(previous)
+		}
+	})
+
+	storage, err := database.Open(*cfg)
+	if err != nil {
+		t.Fatalf("testutil: open %s storage: %v", opts.Driver, err)
+	}
+	jwtService := auth.NewJWTServiceWithKeys(auth.Keys{
+		Secret:        cfg.JWT.Secret,
+		MaxExpiry:     time.Duration(cfg.JWT.MaxExpiryMinutes) * time.Minute,
+		RefreshExpiry: time.Duration(cfg.JWT.RefreshExpiryHours) * time.Hour,
+		Clock:         opts.Clock,
+	}, time.Duration(cfg.JWT.ExpiryMinutes)*time.Minute)
+
+	gw, err := gateway.New(gateway.Deps{
+		Env:     "test",
+		Config:  cfg,
+		Storage: storage,
+		JWT:     jwtService,
+		Agent:   opts.Agent,
+		Clock:   opts.Clock,
+	})
+	if err != nil {
+		t.Fatalf("testutil: build gateway: %v", err)
+	}
+
+	server := httptest.NewServer(gw.Router)
+	t.Cleanup(func() {
+		// Open streams end first, or Close would wait on them
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = gw.Drainer.Shutdown(ctx, 0)
+		server.Close()
+		// Runs before the config is restored, so the restore reaches no stale gateway
+		gw.Close()
+	})
+	return &TestGateway{Server: server, Clock: opts.Clock, Storage: storage, JWT: jwtService}
+}
+
+// Token signs an access token for userID without going through login
+func (g *TestGateway) Token(t testing.TB, userID, role string) string {
+	t.Helper()
+	token, err := g.JWT.GenerateToken(userID, role)
+	if err != nil {
+		t.Fatalf("testutil: sign token: %v", err)
+	}
+	return token
+}
+
+// CreateUser stores an account that can log in with password
+func (g *TestGateway) CreateUser(t testing.TB, username, password, role string) *user.User {
+	t.Helper()
+	return g.CreateTenantUser(t, username, password, role, "")
+}
+
+// CreateTenantUser stores an account of tenantID that can log in with password
+func (g *TestGateway) CreateTenantUser(t testing.TB, username, password, role, tenantID string) *user.User {
+	t.Helper()
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		t.Fatalf("testutil: hash password: %v", err)
+	}
+	u := &user.User{ID: username, Username: username, PasswordHash: hash, Role: role, TenantID: tenantID}
+	if err := g.Storage.Users.Create(context.Background(), u); err != nil {
+		t.Fatalf("testutil: create user %s: %v", username, err)
+	}
+	return u
+}
+
+// Login exchanges username/password at POST /auth/login and returns the token
+func (g *TestGateway) Login(t testing.TB, username, password string) string {
+	t.Helper()
+	var body struct {
+		Token string `json:"token"`
+	}
+	resp := g.Do(t, http.MethodPost, "/auth/login", "", gin.H{"username": username, "password": password}, &body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("testutil: login %s: status %d", username, resp.StatusCode)
+	}
+	return body.Token
+}
+
+// Do sends a request with an optional bearer token and JSON body. When out
+// is non-nil the response body is decoded into it; the body is always closed.
+func (g *TestGateway) Do(t testing.TB, method, path, token string, body any, out any) *http.Response {
+	t.Helper()
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("testutil: encode body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, g.URL+path, reader)
+	if err != nil {
+		t.Fatalf("testutil: build request: %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := g.Client().Do(req)
+	if err != nil {
+		t.Fatalf("testutil: %s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("testutil: decode %s %s response: %v", method, path, err)
+		}
+	}
+	return resp
+}
@@ -0,0 +1,154 @@
+package testutil_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/config"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/infrastructure/database"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGateway_LoginAskAndHistory(t *testing.T) {
+	for _, driver := range []string{database.DriverMemory, database.DriverSQLite} {
+		t.Run(driver, func(t *testing.T) {
+			// Arrange
+			gw := testutil.NewTestGateway(t, testutil.Options{Driver: driver})
+			gw.CreateUser(t, "alice", "s3cret-pass", "user")
+			token := gw.Login(t, "alice", "s3cret-pass")
+
+			// Act
+			var reply struct {
+				Reply    string `json:"reply"`
+				ThreadID string `json:"thread_id"`
+			}
+			askResp := gw.Do(t, http.MethodPost, "/api/v1/ask", token, gin.H{"message": "hello", "thread_id": "thread-1"}, &reply)
+			var history struct {
+				Messages []struct {
+					Role    string `json:"role"`
+					Content string `json:"content"`
+				} `json:"messages"`
+			}
+			historyResp := gw.Do(t, http.MethodGet, "/api/v1/conversations/thread-1/messages", token, nil, &history)
+
+			// Assert
+			require.Equal(t, http.StatusOK, askResp.StatusCode)
+			assert.Equal(t, "echo: hello", reply.Reply)
+			assert.Equal(t, "thread-1", reply.ThreadID)
+			require.Equal(t, http.StatusOK, historyResp.StatusCode)
+			require.Len(t, history.Messages, 2)
+			assert.Equal(t, "hello", history.Messages[0].Content)
+			assert.Equal(t, "echo: hello", history.Messages[1].Content)
+		})
+	}
+}
+
+func TestGateway_TokenExpiresWithFakeClock(t *testing.T) {
+	// Arrange
+	gw := testutil.NewTestGateway(t, testutil.Options{})
+	token := gw.Token(t, "alice", "user")
+	require.Equal(t, http.StatusOK, gw.Do(t, http.MethodGet, "/api/v1/me", token, nil, nil).StatusCode)
+
+	// Act
+	gw.Clock.Advance(61 * time.Minute)
+	resp := gw.Do(t, http.MethodGet, "/api/v1/me", token, nil, nil)
+
+	// Assert
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestGateway_DailyBudgetResetsAtMidnight(t *testing.T) {
+	// Arrange
+	gw := testutil.NewTestGateway(t, testutil.Options{Configure: func(cfg *config.Config) {
+		cfg.Usage.DailyChars = 10
+	}})
+	ask := func() int {
+		// A fresh token per call, as the day advances past its expiry
+		token := gw.Token(t, "alice", "user")
+		return gw.Do(t, http.MethodPost, "/api/v1/ask", token, gin.H{"message": "0123456789"}, nil).StatusCode
+	}
+	require.Equal(t, http.StatusOK, ask())
+	require.Equal(t, http.StatusTooManyRequests, ask())
+
+	// Act
+	gw.Clock.Advance(24 * time.Hour)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, ask())
+}
+
+func TestGateway_AdminRoutesRequireAdminRole(t *testing.T) {
+	// Arrange
+	gw := testutil.NewTestGateway(t, testutil.Options{})
+
+	// Act
+	asUser := gw.Do(t, http.MethodGet, "/api/v1/admin/config", gw.Token(t, "alice", "user"), nil, nil)
+	asAdmin := gw.Do(t, http.MethodGet, "/api/v1/admin/config", gw.Token(t, "root", "admin"), nil, nil)
+
+	// Assert
+	assert.Equal(t, http.StatusForbidden, asUser.StatusCode)
+	assert.Equal(t, http.StatusOK, asAdmin.StatusCode)
+}
+
+func TestGateway_TenancyLoginRefreshAndImpersonation(t *testing.T) {
+	// Arrange
+	gw := testutil.NewTestGateway(t, testutil.Options{Configure: func(cfg *config.Config) {
+		cfg.Tenancy.Enabled = true
+	}})
+	gw.CreateTenantUser(t, "alice", "s3cret-pass", "user", "acme")
+	gw.CreateTenantUser(t, "root", "s3cret-pass", "admin", "acme")
+	gw.CreateUser(t, "bob", "s3cret-pass", "user")
+
+	var login struct {
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	require.Equal(t, http.StatusOK, gw.Do(t, http.MethodPost, "/auth/login", "", gin.H{"username": "alice", "password": "s3cret-pass"}, &login).StatusCode)
+	var refreshed struct {
+		AccessToken string `json:"access_token"`
+	}
+	require.Equal(t, http.StatusOK, gw.Do(t, http.MethodPost, "/auth/refresh", "", gin.H{"refresh_token": login.RefreshToken}, &refreshed).StatusCode)
+	var impersonation struct {
+		Token string `json:"token"`
+	}
+	adminToken := gw.Login(t, "root", "s3cret-pass")
+	require.Equal(t, http.StatusOK, gw.Do(t, http.MethodPost, "/api/v1/admin/impersonate/alice", adminToken, nil, &impersonation).StatusCode)
+
+	// Act & Assert: every issued token carries the user's tenant
+	ask := func(token string) int {
+		return gw.Do(t, http.MethodPost, "/api/v1/ask", token, gin.H{"message": "hello"}, nil).StatusCode
+	}
+	assert.Equal(t, http.StatusOK, ask(login.Token))
+	assert.Equal(t, http.StatusOK, ask(refreshed.AccessToken))
+	assert.Equal(t, http.StatusOK, ask(impersonation.Token))
+	assert.Equal(t, http.StatusOK, gw.Do(t, http.MethodGet, "/api/v1/admin/config", adminToken, nil, nil).StatusCode)
+	// A user without a tenant still can't use the API
+	assert.Equal(t, http.StatusForbidden, ask(gw.Login(t, "bob", "s3cret-pass")))
+}
+
+func TestGateway_SpoofedForwardedForSharesRateLimit(t *testing.T) {
+	// Arrange: one request a minute per client IP, and no trusted proxy
+	gw := testutil.NewTestGateway(t, testutil.Options{Configure: func(cfg *config.Config) {
+		cfg.Server.Limits.Routes = map[string]config.RouteLimit{"/health": {RequestsPerMinute: 1}}
+	}})
+	get := func(forwardedFor string) int {
+		req, err := http.NewRequest(http.MethodGet, gw.URL+"/health", nil)
+		require.NoError(t, err)
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	require.Equal(t, http.StatusOK, get("203.0.113.1"))
+
+	// Act: the same peer claims to be someone else
+	status := get("203.0.113.2")
+
+	// Assert
+	assert.Equal(t, http.StatusTooManyRequests, status)
+}
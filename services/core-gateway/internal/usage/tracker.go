@@ -0,0 +1,181 @@
+// Package usage tracks per-user prompt/response sizes and enforces character budgets.
+package usage
+
+import (
+	"errors"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/clock"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/metrics"
+)
+
+var (
+	ErrRequestTooLarge = errors.New("request exceeds the per-request character budget")
+	ErrDailyBudget     = errors.New("daily character budget exhausted")
+)
+
+// Budget limits characters per request and per UTC day. Zero means unlimited.
+type Budget struct {
+	MaxRequestChars int
+	DailyChars      int
+}
+
+type dailyUsage struct {
+	day       string
+	chars     int
+	prompts   int
+	responses int
+}
+
+// Tracker keeps per-user daily usage in memory
+type Tracker struct {
+	now func() time.Time
+
+	mu       sync.Mutex
+	defaults Budget
+	roles    map[string]Budget
+	usage    map[string]*dailyUsage
+}
+
+// NewTracker creates a tracker with a default budget and optional per-role overrides
+func NewTracker(defaults Budget, roles map[string]Budget) *Tracker {
+	return NewTrackerWithClock(defaults, roles, nil)
+}
+
+// NewTrackerWithClock is NewTracker with days counted on c (nil = wall clock)
+func NewTrackerWithClock(defaults Budget, roles map[string]Budget, c clock.Clock) *Tracker {
+	return &Tracker{
+		defaults: defaults,
+		roles:    roles,
+		now:      clock.Or(c).Now,
+		usage:    make(map[string]*dailyUsage),
+	}
+}
+
+// SetBudgets replaces the budgets (config reload). Accumulated usage is kept.
+func (t *Tracker) SetBudgets(defaults Budget, roles map[string]Budget) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.defaults = defaults
+	t.roles = roles
+}
+
+// BudgetFor returns the effective budget for a role
+func (t *Tracker) BudgetFor(role string) Budget {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if b, ok := t.roles[role]; ok {
+		return b
+	}
+	return t.defaults
+}
+
+// Check validates a prompt against the budget before it is sent to the agent
+func (t *Tracker) Check(userID, role, prompt string) error {
+	return t.CheckBudget(userID, t.BudgetFor(role), prompt)
+}
+
+// CheckBudget is Check against an explicit budget (e.g. a per-user override)
+func (t *Tracker) CheckBudget(userID string, budget Budget, prompt string) error {
+	size := utf8.RuneCountInString(prompt)
+
+	if budget.MaxRequestChars > 0 && size > budget.MaxRequestChars {
+		metrics.BudgetRejections.WithLabelValues("request").Inc()
+		return ErrRequestTooLarge
+	}
+
+	if budget.DailyChars > 0 {
+		t.mu.Lock()
+		used := t.current(userID).chars
+		t.mu.Unlock()
+
+		if used+size > budget.DailyChars {
+			metrics.BudgetRejections.WithLabelValues("daily").Inc()
+			return ErrDailyBudget
+		}
+	}
+	return nil
+}
+
+// Record adds a completed exchange to the user's daily usage and metrics
+func (t *Tracker) Record(userID, role, prompt, response string) {
+	promptChars := utf8.RuneCountInString(prompt)
+	responseChars := utf8.RuneCountInString(response)
+
+	metrics.AgentChars.WithLabelValues("prompt", role).Add(float64(promptChars))
+	metrics.AgentChars.WithLabelValues("response", role).Add(float64(responseChars))
+	metrics.AgentMessageChars.WithLabelValues("prompt").Observe(float64(promptChars))
+	metrics.AgentMessageChars.WithLabelValues("response").Observe(float64(responseChars))
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	u := t.current(userID)
+	u.chars += promptChars + responseChars
+	u.prompts += promptChars
+	u.responses += responseChars
+}
+
+// Used returns the characters consumed by userID today
+func (t *Tracker) Used(userID string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.current(userID).chars
+}
+
+// Snapshot is a user's usage for the current UTC day
+type Snapshot struct {
+	Chars         int
+	PromptChars   int
+	ResponseChars int
+	// ResetsAt is the start of the next UTC day
+	ResetsAt time.Time
+}
+
+// Usage returns userID's usage so far today
+func (t *Tracker) Usage(userID string) Snapshot {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	u := t.current(userID)
+	day, _ := time.Parse("2006-01-02", u.day)
+	return Snapshot{
+		Chars:         u.chars,
+		PromptChars:   u.prompts,
+		ResponseChars: u.responses,
+		ResetsAt:      day.AddDate(0, 0, 1),
+	}
+}
+
+// Len returns the number of users with tracked usage
+func (t *Tracker) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.usage)
+}
+
+// Forget drops userID's usage counters (account deletion)
+func (t *Tracker) Forget(userID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.usage, userID)
+}
+
+// Key namespaces a user's usage by tenant so quotas never bleed across tenants
+func Key(tenantID, userID string) string {
+	if tenantID != "" {
+		return tenantID + "/" + userID
+	}
+	return userID
+}
+
+// current returns today's usage bucket, resetting it on a new UTC day. Caller holds mu.
+func (t *Tracker) current(userID string) *dailyUsage {
+	day := t.now().UTC().Format("2006-01-02")
+	u, ok := t.usage[userID]
+	if !ok || u.day != day {
+		u = &dailyUsage{day: day}
+		t.usage[userID] = u
+	}
+	return u
+}
@@ -0,0 +1,56 @@
+package usage_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/clock"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/usage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTracker_ExhaustsDailyBudget(t *testing.T) {
+	// Arrange: 100 chars per day
+	tracker := usage.NewTracker(usage.Budget{DailyChars: 100}, nil)
+
+	// Act & Assert: 40-char prompt + 40-char reply = 80 used
+	assert.NoError(t, tracker.Check("user_1", "user", strings.Repeat("a", 40)))
+	tracker.Record("user_1", "user", strings.Repeat("a", 40), strings.Repeat("b", 40))
+	assert.Equal(t, 80, tracker.Used("user_1"))
+
+	// 20 more fits exactly, 21 does not
+	assert.NoError(t, tracker.Check("user_1", "user", strings.Repeat("a", 20)))
+	assert.ErrorIs(t, tracker.Check("user_1", "user", strings.Repeat("a", 21)), usage.ErrDailyBudget)
+
+	// Other users are unaffected
+	assert.NoError(t, tracker.Check("user_2", "user", strings.Repeat("a", 50)))
+}
+
+func TestTracker_PerRequestAndRoleOverrides(t *testing.T) {
+	tracker := usage.NewTracker(
+		usage.Budget{MaxRequestChars: 10, DailyChars: 100},
+		map[string]usage.Budget{"admin": {}},
+	)
+
+	assert.ErrorIs(t, tracker.Check("user_1", "user", strings.Repeat("a", 11)), usage.ErrRequestTooLarge)
+	// Characters, not bytes
+	assert.NoError(t, tracker.Check("user_1", "user", strings.Repeat("우", 10)))
+	// Admin override is unlimited
+	assert.NoError(t, tracker.Check("admin_1", "admin", strings.Repeat("a", 10000)))
+}
+
+func TestTracker_ResetsAtMidnightUTC(t *testing.T) {
+	// Arrange
+	fake := clock.NewFake(time.Date(2026, 10, 16, 23, 59, 0, 0, time.UTC))
+	tracker := usage.NewTrackerWithClock(usage.Budget{DailyChars: 10}, nil, fake)
+	tracker.Record("user_1", "user", "hello", "world")
+	assert.Error(t, tracker.Check("user_1", "user", "again"))
+
+	// Act
+	fake.Advance(time.Minute)
+
+	// Assert
+	assert.NoError(t, tracker.Check("user_1", "user", "again"))
+	assert.Equal(t, 0, tracker.Used("user_1"))
+}
@@ -0,0 +1,181 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/metrics"
+)
+
+// DefaultCacheTTL bounds how long a change made on another replica goes unseen
+const DefaultCacheTTL = 30 * time.Second
+
+// DefaultCacheSize caps the cached users; expired entries are dropped first
+const DefaultCacheSize = 10_000
+
+// ErrNotSupported is returned by Cache for writes the wrapped repository lacks
+var ErrNotSupported = errors.New("user repository does not support this change")
+
+// Invalidator is implemented by repositories that cache users, so code that
+// changes accounts behind their back (e.g. an erasing transaction) can drop them
+type Invalidator interface {
+	Invalidate(id string)
+}
+
+// CacheOptions configures a Cache
+type CacheOptions struct {
+	// TTL of a cached lookup (default DefaultCacheTTL)
+	TTL time.Duration
+	// Size caps the cached users (default DefaultCacheSize)
+	Size int
+	Now  func() time.Time
+}
+
+type cacheEntry struct {
+	user      *User // nil caches ErrNotFound
+	expiresAt time.Time
+}
+
+// Cache is a Repository that keeps FindByID results (including "not found")
+// for TTL, for the per-request account checks. Writes through the cache
+// invalidate the user at once, so disabling an account takes effect on the
+// next request here and within TTL on other replicas. FindByUsername (login)
+// always reads the repository.
+type Cache struct {
+	Repository
+	opts CacheOptions
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	// lookups tracks ids being read from the repository, so a read that raced
+	// an invalidation is not cached
+	lookups map[string]*lookup
+}
+
+// lookup counts the repository reads of one id in flight and the
+// invalidations of that id since the oldest of them started
+type lookup struct {
+	readers    int
+	generation int
+}
+
+func NewCache(repo Repository, opts CacheOptions) *Cache {
+	if opts.TTL <= 0 {
+		opts.TTL = DefaultCacheTTL
+	}
+	if opts.Size <= 0 {
+		opts.Size = DefaultCacheSize
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &Cache{Repository: repo, opts: opts, entries: map[string]cacheEntry{}, lookups: map[string]*lookup{}}
+}
+
+// FindByID returns a copy of the cached user, reading the repository on a miss.
+// Lookup errors other than ErrNotFound are not cached, and neither is a read
+// the user was invalidated during: it may predate the change.
+func (c *Cache) FindByID(ctx context.Context, id string) (*User, error) {
+	now := c.opts.Now()
+	c.mu.Lock()
+	entry, ok := c.entries[id]
+	if ok && now.Before(entry.expiresAt) {
+		c.mu.Unlock()
+		metrics.UserCacheLookups.WithLabelValues("hit").Inc()
+		if entry.user == nil {
+			return nil, ErrNotFound
+		}
+		u := *entry.user
+		return &u, nil
+	}
+	l := c.lookups[id]
+	if l == nil {
+		l = &lookup{}
+		c.lookups[id] = l
+	}
+	l.readers++
+	generation := l.generation
+	c.mu.Unlock()
+
+	metrics.UserCacheLookups.WithLabelValues("miss").Inc()
+	u, err := c.Repository.FindByID(ctx, id)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if l.readers--; l.readers == 0 {
+		delete(c.lookups, id)
+	}
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	if l.generation == generation {
+		entry = cacheEntry{expiresAt: now.Add(c.opts.TTL)}
+		if u != nil {
+			cached := *u
+			entry.user = &cached
+		}
+		c.store(id, entry, now)
+	}
+	return u, err
+}
+
+func (c *Cache) Create(ctx context.Context, u *User) error {
+	err := c.Repository.Create(ctx, u)
+	c.Invalidate(u.ID)
+	return err
+}
+
+// Update changes the account and drops it from the cache
+func (c *Cache) Update(ctx context.Context, u *User) error {
+	repo, ok := c.Repository.(Updatable)
+	if !ok {
+		return ErrNotSupported
+	}
+	defer c.Invalidate(u.ID)
+	return repo.Update(ctx, u)
+}
+
+// Erase tombstones the account and drops it from the cache
+func (c *Cache) Erase(ctx context.Context, id string) error {
+	repo, ok := c.Repository.(Erasable)
+	if !ok {
+		return ErrNotSupported
+	}
+	defer c.Invalidate(id)
+	return repo.Erase(ctx, id)
+}
+
+// Invalidate drops id, so its next lookup reads the repository. Reads of id
+// already in flight are not cached.
+func (c *Cache) Invalidate(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, id)
+	if l := c.lookups[id]; l != nil {
+		l.generation++
+	}
+}
+
+// Len returns the number of cached users
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// store adds entry, making room when the cache is full. Caller holds mu.
+func (c *Cache) store(id string, entry cacheEntry, now time.Time) {
+	if _, ok := c.entries[id]; !ok && len(c.entries) >= c.opts.Size {
+		for key, e := range c.entries {
+			if !now.Before(e.expiresAt) {
+				delete(c.entries, key)
+			}
+		}
+		if len(c.entries) >= c.opts.Size {
+			clear(c.entries)
+		}
+	}
+	c.entries[id] = entry
+}
@@ -0,0 +1,152 @@
+package user_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/metrics"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/user"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingRepo counts the lookups that reach the repository
+type countingRepo struct {
+	user.Repository
+	finds int
+}
+
+func (r *countingRepo) FindByID(ctx context.Context, id string) (*user.User, error) {
+	r.finds++
+	return r.Repository.FindByID(ctx, id)
+}
+
+func (r *countingRepo) Update(ctx context.Context, u *user.User) error {
+	return r.Repository.(user.Updatable).Update(ctx, u)
+}
+
+func newCache(t *testing.T, now *time.Time) (*user.Cache, *countingRepo) {
+	t.Helper()
+	repo := &countingRepo{Repository: user.NewMemoryRepository()}
+	require.NoError(t, repo.Create(context.Background(), &user.User{ID: "alice", Username: "alice", Role: "user"}))
+	return user.NewCache(repo, user.CacheOptions{TTL: time.Minute, Now: func() time.Time { return *now }}), repo
+}
+
+func TestCache_HitsAndMisses(t *testing.T) {
+	// Arrange
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	cache, repo := newCache(t, &now)
+	ctx := context.Background()
+	hits := testutil.ToFloat64(metrics.UserCacheLookups.WithLabelValues("hit"))
+	misses := testutil.ToFloat64(metrics.UserCacheLookups.WithLabelValues("miss"))
+
+	// Act
+	first, err := cache.FindByID(ctx, "alice")
+	require.NoError(t, err)
+	first.Role = "mutated by the caller"
+	second, err := cache.FindByID(ctx, "alice")
+	require.NoError(t, err)
+	_, missing := cache.FindByID(ctx, "nobody")
+	_, missingAgain := cache.FindByID(ctx, "nobody")
+	now = now.Add(2 * time.Minute)
+	_, err = cache.FindByID(ctx, "alice")
+	require.NoError(t, err)
+
+	// Assert: one read per user until the TTL passes; "not found" is cached too
+	assert.Equal(t, "user", second.Role, "callers get copies")
+	assert.ErrorIs(t, missing, user.ErrNotFound)
+	assert.ErrorIs(t, missingAgain, user.ErrNotFound)
+	assert.Equal(t, 3, repo.finds)
+	assert.Equal(t, 2.0, testutil.ToFloat64(metrics.UserCacheLookups.WithLabelValues("hit"))-hits)
+	assert.Equal(t, 3.0, testutil.ToFloat64(metrics.UserCacheLookups.WithLabelValues("miss"))-misses)
+}
+
+func TestCache_UpdateInvalidates(t *testing.T) {
+	// Arrange: alice is cached as enabled
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	cache, _ := newCache(t, &now)
+	ctx := context.Background()
+	u, err := cache.FindByID(ctx, "alice")
+	require.NoError(t, err)
+
+	// Act: disable and promote her well within the TTL
+	u.Disabled, u.Role = true, "admin"
+	require.NoError(t, cache.Update(ctx, u))
+	updated, err := cache.FindByID(ctx, "alice")
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, updated.Disabled)
+	assert.Equal(t, "admin", updated.Role)
+}
+
+func TestCache_CreateAndInvalidateDropCachedEntries(t *testing.T) {
+	// Arrange: bob is cached as missing
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	cache, repo := newCache(t, &now)
+	ctx := context.Background()
+	_, err := cache.FindByID(ctx, "bob")
+	require.ErrorIs(t, err, user.ErrNotFound)
+
+	// Act
+	require.NoError(t, cache.Create(ctx, &user.User{ID: "bob", Username: "bob"}))
+	bob, err := cache.FindByID(ctx, "bob")
+	require.NoError(t, err)
+	cache.Invalidate("bob")
+	_, err = cache.FindByID(ctx, "bob")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "bob", bob.Username)
+	assert.Equal(t, 3, repo.finds)
+}
+
+// slowRepo holds a lookup after reading the user until released, like a
+// database read that is still in flight
+type slowRepo struct {
+	user.Repository
+	read    chan struct{}
+	release chan struct{}
+}
+
+func (r *slowRepo) FindByID(ctx context.Context, id string) (*user.User, error) {
+	u, err := r.Repository.FindByID(ctx, id)
+	if r.read != nil {
+		close(r.read)
+		<-r.release
+		r.read = nil
+	}
+	return u, err
+}
+
+func (r *slowRepo) Update(ctx context.Context, u *user.User) error {
+	return r.Repository.(user.Updatable).Update(ctx, u)
+}
+
+func TestCache_LookupRacingUpdateIsNotCached(t *testing.T) {
+	// Arrange: a lookup has read alice as enabled but not cached her yet
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+	repo := &slowRepo{Repository: user.NewMemoryRepository(), read: make(chan struct{}), release: make(chan struct{})}
+	require.NoError(t, repo.Repository.Create(ctx, &user.User{ID: "alice", Username: "alice", Role: "user"}))
+	cache := user.NewCache(repo, user.CacheOptions{TTL: time.Minute, Now: func() time.Time { return now }})
+	stale := make(chan *user.User, 1)
+	go func() {
+		u, _ := cache.FindByID(ctx, "alice")
+		stale <- u
+	}()
+	<-repo.read
+
+	// Act: she is disabled before that lookup finishes
+	require.NoError(t, cache.Update(ctx, &user.User{ID: "alice", Username: "alice", Role: "user", Disabled: true}))
+	close(repo.release)
+	require.False(t, (<-stale).Disabled, "the racing lookup still answers with what it read")
+	got, err := cache.FindByID(ctx, "alice")
+
+	// Assert: the stale read was not cached
+	require.NoError(t, err)
+	assert.True(t, got.Disabled)
+	assert.Equal(t, 1, cache.Len(), "only the fresh read is cached")
+}
@@ -0,0 +1,96 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrUsernameTaken is returned when creating a user whose username exists
+var ErrUsernameTaken = errors.New("username already exists")
+
+type memoryRepository struct {
+	mu    sync.RWMutex
+	users map[string]User
+}
+
+// NewMemoryRepository returns a process-local Repository (tests and DB-less local runs)
+func NewMemoryRepository() Repository {
+	return &memoryRepository{users: map[string]User{}}
+}
+
+func (r *memoryRepository) FindByID(ctx context.Context, id string) (*User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &u, nil
+}
+
+func (r *memoryRepository) FindByUsername(ctx context.Context, username string) (*User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, u := range r.users {
+		if u.Username == username {
+			return &u, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryRepository) Create(ctx context.Context, u *User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.users {
+		if existing.Username == u.Username {
+			return ErrUsernameTaken
+		}
+	}
+	if u.ID == "" {
+		u.ID = uuid.NewString()
+	}
+	if u.Role == "" {
+		u.Role = "user"
+	}
+	now := time.Now()
+	u.CreatedAt, u.UpdatedAt = now, now
+	r.users[u.ID] = *u
+	return nil
+}
+
+func (r *memoryRepository) Update(ctx context.Context, u *User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.users[u.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	for id, other := range r.users {
+		if id != u.ID && other.Username == u.Username {
+			return ErrUsernameTaken
+		}
+	}
+	u.CreatedAt, u.UpdatedAt = existing.CreatedAt, time.Now()
+	r.users[u.ID] = *u
+	return nil
+}
+
+func (r *memoryRepository) Erase(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tomb := Tombstone(id)
+	tomb.CreatedAt = r.users[id].CreatedAt
+	tomb.UpdatedAt = time.Now()
+	r.users[id] = tomb
+	return nil
+}
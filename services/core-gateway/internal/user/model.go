@@ -0,0 +1,46 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrNotFound = errors.New("user not found")
+
+type User struct {
+	ID           string `gorm:"primaryKey"`
+	Username     string `gorm:"uniqueIndex;not null"`
+	PasswordHash string `gorm:"not null"`
+	Role         string `gorm:"not null;default:user"`
+	Disabled     bool   `gorm:"not null;default:false"`
+	// TenantID is stamped on the user's tokens; required when tenancy is enabled
+	TenantID  string `gorm:"index"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type Repository interface {
+	FindByID(ctx context.Context, id string) (*User, error)
+	FindByUsername(ctx context.Context, username string) (*User, error)
+	Create(ctx context.Context, u *User) error
+}
+
+// Updatable is implemented by repositories that can change an account
+type Updatable interface {
+	// Update saves u's username, password hash, role, disabled flag and tenant.
+	// It returns ErrNotFound when u.ID does not exist.
+	Update(ctx context.Context, u *User) error
+}
+
+// Erasable is implemented by repositories that can erase an account
+type Erasable interface {
+	// Erase replaces the account with its Tombstone, creating it if missing
+	Erase(ctx context.Context, id string) error
+}
+
+// Tombstone is what remains of an erased account: no personal data, and
+// disabled so its tokens and logins are refused
+func Tombstone(id string) User {
+	return User{ID: id, Username: "deleted-" + id, Role: "user", Disabled: true}
+}
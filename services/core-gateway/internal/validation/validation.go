@@ -0,0 +1,107 @@
+// Package validation binds request DTOs and reports binding-tag failures
+// field by field, e.g. {"errors": {"message": "required"}}. Legacy (API
+// version 1) clients get them joined into one {"error": "..."} message.
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/apiversion"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/httperr"
+)
+
+var registerOnce sync.Once
+
+// useJSONNames makes validator report fields by their json tag ("thread_id", not "ThreadID")
+func useJSONNames() {
+	registerOnce.Do(func() {
+		v, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			return
+		}
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "" || name == "-" {
+				return f.Name
+			}
+			return name
+		})
+	})
+}
+
+// BindJSON decodes and validates the body into obj. On failure it writes a
+// 400 (field errors for validation, a plain error for malformed JSON) or a 413
+// (body past the route's size limit) and returns false; handlers should just return.
+func BindJSON(c *gin.Context, obj any) bool {
+	useJSONNames()
+
+	err := c.ShouldBindJSON(obj)
+	if err == nil {
+		return true
+	}
+
+	var fieldErrs validator.ValidationErrors
+	if errors.As(err, &fieldErrs) {
+		if apiversion.Legacy(c) {
+			httperr.Respond(c, http.StatusBadRequest, legacyMessage(Fields(fieldErrs)))
+			return false
+		}
+		httperr.RespondFields(c, http.StatusBadRequest, Fields(fieldErrs))
+		return false
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		httperr.Respond(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body too large: limited to %d bytes", tooLarge.Limit))
+		return false
+	}
+	httperr.Respond(c, http.StatusBadRequest, "Invalid request body: "+err.Error())
+	return false
+}
+
+// Fields maps each failed field to a short, client-facing message
+func Fields(errs validator.ValidationErrors) map[string]string {
+	fields := make(map[string]string, len(errs))
+	for _, fe := range errs {
+		fields[fe.Field()] = message(fe)
+	}
+	return fields
+}
+
+// legacyMessage joins field errors as "field: msg; field: msg", sorted by field
+func legacyMessage(fields map[string]string) string {
+	parts := make([]string, 0, len(fields))
+	for name, msg := range fields {
+		parts = append(parts, name+": "+msg)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, "; ")
+}
+
+func message(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "required"
+	case "min":
+		return fmt.Sprintf("must be at least %s characters", fe.Param())
+	case "max":
+		return fmt.Sprintf("must be at most %s characters", fe.Param())
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "email":
+		return "must be a valid email address"
+	case "gte":
+		return "must be at least " + fe.Param()
+	case "lte":
+		return "must be at most " + fe.Param()
+	}
+	return "failed " + fe.Tag() + " validation"
+}
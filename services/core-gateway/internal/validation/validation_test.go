@@ -0,0 +1,94 @@
+package validation_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/auth"
+	"github.com/nookcoder/woorung-gaksi/services/core-gateway/internal/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type feedbackRequest struct {
+	ThreadID string `json:"thread_id" binding:"required"`
+	Rating   string `json:"rating" binding:"required,oneof=up down"`
+	Comment  string `json:"comment" binding:"max=10"`
+}
+
+func bind[T any](body, accept string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/", func(c *gin.Context) {
+		var req T
+		if !validation.BindJSON(c, &req) {
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+	req, _ := http.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func fieldErrors(t *testing.T, w *httptest.ResponseRecorder) map[string]string {
+	t.Helper()
+	var body struct {
+		Errors map[string]string `json:"errors"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	return body.Errors
+}
+
+func TestBindJSON_ReportsEveryInvalidField(t *testing.T) {
+	// Act
+	w := bind[feedbackRequest](`{"rating":"meh","comment":"far too long a comment"}`, "")
+
+	// Assert: fields are named by their json tags
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, map[string]string{
+		"thread_id": "required",
+		"rating":    "must be one of: up, down",
+		"comment":   "must be at most 10 characters",
+	}, fieldErrors(t, w))
+}
+
+func TestBindJSON_LoginRequest(t *testing.T) {
+	// Act
+	w := bind[auth.LoginRequest](`{}`, "")
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, map[string]string{"username": "required", "password": "required"}, fieldErrors(t, w))
+}
+
+func TestBindJSON_PlainText(t *testing.T) {
+	// Act
+	w := bind[auth.LoginRequest](`{"username":"kim"}`, "text/plain")
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "password: required\n", w.Body.String())
+}
+
+func TestBindJSON_MalformedBody(t *testing.T) {
+	// Act
+	w := bind[auth.LoginRequest](`{"username":`, "")
+
+	// Assert: not a field problem, so the plain error envelope is used
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"error":"Invalid request body`)
+}
+
+func TestBindJSON_Valid(t *testing.T) {
+	w := bind[feedbackRequest](`{"thread_id":"t1","rating":"up"}`, "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}